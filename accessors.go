@@ -0,0 +1,46 @@
+// go-multikeypair/accessors.go
+//
+// Zero-copy accessors for reading a single field out of a Multikeypair
+// without a full Decode, for hot paths that only need e.g. the public key.
+
+package multikeypair
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Code returns the cipher code recorded in the Multikeypair.
+func (m Multikeypair) Code() (uint64, error) {
+	code, _, _, _, err := splitKeypair(m)
+	if err != nil {
+		return 0, err
+	}
+	numCode, err := UnpackCode(code)
+	if err != nil {
+		return 0, err
+	}
+	if err := validCode(numCode); err != nil {
+		return 0, err
+	}
+	return numCode, nil
+}
+
+// PublicBytes returns the raw public key. The result aliases m, so
+// callers that modify it or keep it beyond the lifetime of m should copy
+// it first.
+func (m Multikeypair) PublicBytes() ([]byte, error) {
+	_, _, public, _, err := splitKeypair(m)
+	if err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+// PrivateBytes returns the raw private key. The result aliases m, so
+// wiping m also wipes the returned slice.
+func (m Multikeypair) PrivateBytes() ([]byte, error) {
+	_, private, _, _, err := splitKeypair(m)
+	if err != nil {
+		return nil, err
+	}
+	return private, nil
+}
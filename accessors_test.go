@@ -0,0 +1,81 @@
+// go-multikeypair/accessors_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Accessors return the same fields as a full Decode, aliasing the input.
+func TestAccessors(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := mk.Code()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != ED_25519 {
+		t.Errorf("code mismatch: %x", code)
+	}
+
+	public, err := mk.PublicBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(public, kp.Public) {
+		t.Error("public key mismatch")
+	}
+
+	private, err := mk.PrivateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(private, kp.Private) {
+		t.Error("private key mismatch")
+	}
+
+	// Writes through the accessor are visible in the encoding.
+	public[0] ^= 0xff
+	again, _ := mk.PublicBytes()
+	if again[0] != public[0] {
+		t.Error("public key doesn't alias the multikeypair")
+	}
+}
+
+// Accessors read the wide (v2) layout.
+func TestAccessorsLarge(t *testing.T) {
+	public := bytes.Repeat([]byte{0xab}, MAX_V1_KEY_LENGTH+1)
+	mk, err := Encode([]byte{0x01}, public, RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mk.PublicBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, public) {
+		t.Error("public key mismatch")
+	}
+	code, err := mk.Code()
+	if err != nil || code != RSA {
+		t.Errorf("code = %x, %v", code, err)
+	}
+}
+
+// Accessors reject malformed input.
+func TestAccessorsInvalid(t *testing.T) {
+	mk := Multikeypair{0x00, 0x00, 0x05, 0x00}
+	if _, err := mk.PublicBytes(); !errors.Is(err, ErrInvalidMultikeypair) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := mk.Code(); !errors.Is(err, ErrInvalidMultikeypair) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
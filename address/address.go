@@ -0,0 +1,156 @@
+// go-multikeypair/address/address.go
+//
+// Blockchain addresses of multikeypair public keys, so that displaying
+// an address doesn't need a separate library per chain. The address
+// format is selected by a Chain, and the key's cipher must be one that
+// chain uses:
+//
+//	ETHEREUM        secp256k1  0x + EIP-55 hex of keccak256(X || Y)[12:]
+//	BITCOIN         secp256k1  Base58Check P2PKH of hash160(compressed)
+//	BITCOIN_SEGWIT  secp256k1  bc1 Bech32 P2WPKH of hash160(compressed)
+//	COSMOS          secp256k1  cosmos1 Bech32 of hash160(compressed)
+//	SOLANA          ed25519    base58 of the public key
+//
+// hash160 is RIPEMD-160 of SHA-256. Bitcoin addresses are for mainnet.
+
+package address
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	b58 "github.com/mr-tron/base58/base58"
+	multikeypair "github.com/proofzero/go-multikeypair"
+	ripemd160 "golang.org/x/crypto/ripemd160"
+	sha3 "golang.org/x/crypto/sha3"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Address errors this package exports.
+var (
+	ErrUnsupportedChain = errors.New("unsupported chain")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Chain selects an address format.
+type Chain string
+
+// Supported chains.
+const (
+	ETHEREUM       = Chain("ethereum")
+	BITCOIN        = Chain("bitcoin")
+	BITCOIN_SEGWIT = Chain("bitcoin-segwit")
+	COSMOS         = Chain("cosmos")
+	SOLANA         = Chain("solana")
+)
+
+// Version byte of mainnet P2PKH addresses, and human-readable parts of
+// Bech32 addresses.
+const (
+	bitcoinP2PKH = byte(0x00)
+	bitcoinHRP   = "bc"
+	cosmosHRP    = "cosmos"
+)
+
+// Ciphers maps each chain to the cipher of the keys it uses.
+var Ciphers = map[Chain]uint64{
+	ETHEREUM:       multikeypair.SECP_256K1,
+	BITCOIN:        multikeypair.SECP_256K1,
+	BITCOIN_SEGWIT: multikeypair.SECP_256K1,
+	COSMOS:         multikeypair.SECP_256K1,
+	SOLANA:         multikeypair.ED_25519,
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// FromKeypair returns the address of a Keypair's public key on chain.
+func FromKeypair(k multikeypair.Keypair, chain Chain) (string, error) {
+	return Encode(k.Code, k.Public, chain)
+}
+
+// Encode returns the address on chain of a public key with the given
+// cipher code. Secp256k1 keys may be compressed or uncompressed.
+func Encode(code uint64, public []byte, chain Chain) (string, error) {
+	want, ok := Ciphers[chain]
+	if !ok {
+		return "", ErrUnsupportedChain
+	}
+	if code != want {
+		return "", multikeypair.ErrUnsupportedCipher
+	}
+
+	if code == multikeypair.ED_25519 {
+		if len(public) != ed25519.PublicKeySize {
+			return "", multikeypair.ErrInvalidPublicKey
+		}
+		return b58.Encode(public), nil
+	}
+
+	pk, err := secp256k1.ParsePubKey(public)
+	if err != nil {
+		return "", multikeypair.ErrInvalidPublicKey
+	}
+	switch chain {
+	case ETHEREUM:
+		return ethereum(pk), nil
+	case BITCOIN:
+		return bitcoinP2PKHAddress(hash160(pk.SerializeCompressed())), nil
+	case BITCOIN_SEGWIT:
+		return bech32Encode(bitcoinHRP, 0, hash160(pk.SerializeCompressed())), nil
+	case COSMOS:
+		return bech32Encode(cosmosHRP, -1, hash160(pk.SerializeCompressed())), nil
+	}
+	return "", ErrUnsupportedChain
+}
+
+// An EIP-55 checksummed Ethereum address: hex digits are upper-cased
+// where the corresponding nibble of the hash of the lower-case address
+// is 8 or more.
+func ethereum(pk *secp256k1.PublicKey) string {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(pk.SerializeUncompressed()[1:])
+	lower := hex.EncodeToString(h.Sum(nil)[12:])
+
+	h.Reset()
+	h.Write([]byte(lower))
+	sum := h.Sum(nil)
+
+	var sb strings.Builder
+	sb.WriteString("0x")
+	for i, c := range []byte(lower) {
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			c -= 'a' - 'A'
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// A mainnet pay-to-public-key-hash address.
+func bitcoinP2PKHAddress(hash []byte) string {
+	buf := append([]byte{bitcoinP2PKH}, hash...)
+	first := sha256.Sum256(buf)
+	second := sha256.Sum256(first[:])
+	return b58.Encode(append(buf, second[:4]...))
+}
+
+// RIPEMD-160 of SHA-256.
+func hash160(b []byte) []byte {
+	first := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(first[:])
+	return h.Sum(nil)
+}
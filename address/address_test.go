@@ -0,0 +1,99 @@
+// go-multikeypair/address/address_test.go
+
+package address
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// The secp256k1 keypair whose private key is 1, whose public key is the
+// generator point; its addresses are well known.
+func testSecp256k1() multikeypair.Keypair {
+	private := make([]byte, 32)
+	private[31] = 1
+	public := secp256k1.PrivKeyFromBytes(private).PubKey().SerializeCompressed()
+	return multikeypair.Keypair{
+		Code:          multikeypair.SECP_256K1,
+		Name:          multikeypair.Codes[multikeypair.SECP_256K1],
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}
+}
+
+// Each chain gives the expected address.
+func TestAddresses(t *testing.T) {
+	kp := testSecp256k1()
+	seed := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x42}, 32))
+	solana := multikeypair.Keypair{
+		Code:   multikeypair.ED_25519,
+		Public: seed.Public().(ed25519.PublicKey),
+	}
+
+	for _, v := range []struct {
+		kp    multikeypair.Keypair
+		chain Chain
+		want  string
+	}{
+		{kp, ETHEREUM, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{kp, BITCOIN, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{kp, BITCOIN_SEGWIT, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{kp, COSMOS, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c"},
+		{solana, SOLANA, "3F5qRPtKg8GhGNnbd3qCj6nVJxWsGxq7pvH84okYLAqf"},
+	} {
+		got, err := FromKeypair(v.kp, v.chain)
+		if err != nil {
+			t.Fatalf("%s: %v", v.chain, err)
+		}
+		if got != v.want {
+			t.Errorf("%s: expected %s, got %s", v.chain, v.want, got)
+		}
+	}
+}
+
+// Uncompressed secp256k1 public keys give the same addresses.
+func TestUncompressed(t *testing.T) {
+	kp := testSecp256k1()
+	pk, err := secp256k1.ParsePubKey(kp.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chain := range Ciphers {
+		if chain == SOLANA {
+			continue
+		}
+		compressed, err := Encode(kp.Code, kp.Public, chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uncompressed, err := Encode(kp.Code, pk.SerializeUncompressed(), chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compressed != uncompressed {
+			t.Errorf("%s: %s != %s", chain, compressed, uncompressed)
+		}
+	}
+}
+
+// Unknown chains, ciphers a chain doesn't use, and malformed keys are
+// refused.
+func TestInvalid(t *testing.T) {
+	kp := testSecp256k1()
+	if _, err := FromKeypair(kp, Chain("dogecoin")); !errors.Is(err, ErrUnsupportedChain) {
+		t.Errorf("expected ErrUnsupportedChain, got %v", err)
+	}
+	if _, err := FromKeypair(kp, SOLANA); !errors.Is(err, multikeypair.ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	if _, err := Encode(multikeypair.SECP_256K1, make([]byte, 33), ETHEREUM); !errors.Is(err, multikeypair.ErrInvalidPublicKey) {
+		t.Errorf("expected ErrInvalidPublicKey, got %v", err)
+	}
+}
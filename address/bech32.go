@@ -0,0 +1,46 @@
+// go-multikeypair/address/bech32.go
+//
+// Bech32 (BIP 173) encoding of address payloads. Segwit addresses start
+// with a witness version as a single 5-bit group, which Cosmos addresses
+// don't have, so this lays out its own data part around the checksum
+// shared with the multikeypair package. Only the original Bech32
+// constant is needed: version 0 witness programs and Cosmos addresses
+// both use it.
+
+package address
+
+import (
+	"strings"
+
+	"github.com/proofzero/go-multikeypair/internal/bech32"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Encode data with human-readable part hrp, preceded by a witness
+// version unless version is negative.
+func bech32Encode(hrp string, version int, data []byte) string {
+	var values []byte
+	if version >= 0 {
+		values = append(values, byte(version))
+	}
+	converted, _ := bech32.ConvertBits(data, 8, 5, true)
+	values = append(values, converted...)
+
+	check := bech32.HRPExpand(hrp)
+	check = append(check, values...)
+	check = append(check, 0, 0, 0, 0, 0, 0)
+	mod := bech32.Polymod(check) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32.CHARSET[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32.CHARSET[(mod>>(5*(5-i)))&31])
+	}
+	return sb.String()
+}
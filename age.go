@@ -0,0 +1,114 @@
+// go-multikeypair/age.go
+//
+// Conversion between X25519 multikeypairs and age (age-encryption.org)
+// identities and recipients, so multikeypairs can be used directly with
+// age-encrypted files. Both are Bech32 (not Bech32m) strings:
+//
+//	AGE-SECRET-KEY-1... (the 32-byte scalar, upper case)
+//	age1...             (the 32-byte public key)
+
+package multikeypair
+
+import (
+	"crypto/ecdh"
+	"errors"
+	"strings"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// age-specific errors this module exports.
+var (
+	ErrInvalidAgeKey = errors.New("input isn't valid age identity or recipient")
+)
+
+// Human-readable parts of age identities and recipients.
+const (
+	ageIdentityHRP  = "age-secret-key-"
+	ageRecipientHRP = "age"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// AgeIdentity returns the age identity (AGE-SECRET-KEY-1...) for an
+// X25519 Keypair.
+func (k Keypair) AgeIdentity() (string, error) {
+	if k.Code != X_25519 {
+		return "", ErrUnsupportedCipher
+	}
+	if _, err := ecdh.X25519().NewPrivateKey(k.Private); err != nil {
+		return "", ErrInvalidPrivateKey
+	}
+	s, err := bech32Encode(ageIdentityHRP, k.Private, bech32Const)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(s), nil
+}
+
+// AgeRecipient returns the age recipient (age1...) for an X25519
+// Keypair.
+func (k Keypair) AgeRecipient() (string, error) {
+	if k.Code != X_25519 {
+		return "", ErrUnsupportedCipher
+	}
+	if _, err := ecdh.X25519().NewPublicKey(k.Public); err != nil {
+		return "", ErrInvalidPublicKey
+	}
+	return bech32Encode(ageRecipientHRP, k.Public, bech32Const)
+}
+
+// KeypairFromAgeIdentity parses an age identity into an X25519 Keypair,
+// deriving the public key.
+func KeypairFromAgeIdentity(s string) (Keypair, error) {
+	private, err := decodeAge(s, ageIdentityHRP)
+	if err != nil {
+		return Keypair{}, err
+	}
+	sk, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return Keypair{}, ErrInvalidAgeKey
+	}
+	public := sk.PublicKey().Bytes()
+	return Keypair{
+		Code:          X_25519,
+		Name:          codeName(X_25519),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// KeypairFromAgeRecipient parses an age recipient into a public-only
+// X25519 Keypair.
+func KeypairFromAgeRecipient(s string) (Keypair, error) {
+	public, err := decodeAge(s, ageRecipientHRP)
+	if err != nil {
+		return Keypair{}, err
+	}
+	if _, err := ecdh.X25519().NewPublicKey(public); err != nil {
+		return Keypair{}, ErrInvalidAgeKey
+	}
+	return Keypair{
+		Code:         X_25519,
+		Name:         codeName(X_25519),
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
+
+// Decode a Bech32 age key with the expected human-readable part. age
+// doesn't accept Bech32m, so neither do we.
+func decodeAge(s string, hrp string) ([]byte, error) {
+	got, data, constant, err := bech32Decode(s)
+	if err != nil {
+		return nil, ErrInvalidAgeKey
+	}
+	if got != hrp || constant != bech32Const || len(data) != 32 {
+		return nil, ErrInvalidAgeKey
+	}
+	return data, nil
+}
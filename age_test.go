@@ -0,0 +1,91 @@
+// go-multikeypair/age_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// An identity and its recipient, as produced by the age tool.
+const (
+	testAgeIdentity  = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	testAgeRecipient = "age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj"
+)
+
+// age identities parse into X25519 keypairs with the matching recipient.
+func TestAgeIdentity(t *testing.T) {
+	kp, err := KeypairFromAgeIdentity(testAgeIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.Code != X_25519 || !bytes.Equal(kp.Private, bytes.Repeat([]byte{0x42}, 32)) {
+		t.Errorf("unexpected keypair %+v", kp)
+	}
+
+	identity, err := kp.AgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity != testAgeIdentity {
+		t.Errorf("expected %s, got %s", testAgeIdentity, identity)
+	}
+	recipient, err := kp.AgeRecipient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipient != testAgeRecipient {
+		t.Errorf("expected %s, got %s", testAgeRecipient, recipient)
+	}
+
+	public, err := KeypairFromAgeRecipient(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(public.Public, kp.Public) || len(public.Private) != 0 {
+		t.Error("recipient doesn't match identity")
+	}
+}
+
+// Generated X25519 keypairs round trip through age identities.
+func TestAgeGenerated(t *testing.T) {
+	kp, err := Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := kp.AgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := KeypairFromAgeIdentity(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(kp) {
+		t.Error("round trip changed keypair")
+	}
+}
+
+// Only X25519 keys convert, and only well-formed Bech32 age strings parse.
+func TestAgeErrors(t *testing.T) {
+	if _, err := generateEd25519(t).AgeIdentity(); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+
+	bech32m, err := bech32Encode(ageRecipientHRP, bytes.Repeat([]byte{9}, 32), bech32mConst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{
+		testAgeRecipient[:len(testAgeRecipient)-1] + "q",
+		strings.Replace(testAgeRecipient, "age1", "ag1", 1),
+		testAgeIdentity,
+		bech32m,
+	} {
+		if _, err := KeypairFromAgeRecipient(bad); err != ErrInvalidAgeKey {
+			t.Errorf("%q: expected ErrInvalidAgeKey, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// go-multikeypair/airgap.go
+//
+// A compact request/response format for signing with a key that never
+// leaves an offline (air-gapped) machine. The online side builds a
+// SigningRequest and transfers it, e.g. as a base58 QR code; the
+// offline side fulfills it with the private Keypair and transfers the
+// SigningResponse back for verification.
+
+package multikeypair
+
+import (
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Air-gap specific errors this module exports.
+var (
+	ErrInvalidSigningRequest  = errors.New("input isn't valid signing request")
+	ErrInvalidSigningResponse = errors.New("input isn't valid signing response")
+	ErrFingerprintMismatch    = errors.New("key fingerprint doesn't match request")
+	ErrRequestMismatch        = errors.New("signing response doesn't match request")
+)
+
+// Domain separation prefix for signatures over signing requests, so that
+// they can't be confused with signatures over anything else.
+const signingRequestDomain = "multikeypair signing request v1\x00"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// SigningRequest asks the holder of an offline key to sign a payload.
+type SigningRequest struct {
+	// Digest of the payload to be signed.
+	Digest Multihash
+	// Fingerprint of the public key expected to fulfill the request.
+	Fingerprint Multihash
+	// Application-defined context, bound into the signature.
+	Context []byte
+}
+
+// SigningResponse is the offline side's answer to a SigningRequest.
+type SigningResponse struct {
+	// Digest of the encoded request being answered.
+	Request Multihash
+	// Signature over the encoded request.
+	Signature []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewSigningRequest prepares a request for the holder of the private half
+// of public to sign payload. Only the digest of the payload is included,
+// so large payloads never need to cross the air gap.
+func NewSigningRequest(payload []byte, public Keypair, context []byte) (SigningRequest, error) {
+	digest, err := Sum(payload, DEFAULT_HASH)
+	if err != nil {
+		return SigningRequest{}, err
+	}
+	fingerprint, err := public.Fingerprint(DEFAULT_HASH)
+	if err != nil {
+		return SigningRequest{}, err
+	}
+	return SigningRequest{
+		Digest:      digest,
+		Fingerprint: fingerprint,
+		Context:     context,
+	}, nil
+}
+
+// Fulfill signs a SigningRequest with the private key. The request is
+// refused if it was addressed to a different key.
+func (k Keypair) Fulfill(r SigningRequest) (SigningResponse, error) {
+	if !k.HasFingerprint(r.Fingerprint) {
+		return SigningResponse{}, ErrFingerprintMismatch
+	}
+	encoded, err := r.Encode()
+	if err != nil {
+		return SigningResponse{}, err
+	}
+	id, err := Sum(encoded, DEFAULT_HASH)
+	if err != nil {
+		return SigningResponse{}, err
+	}
+	signature, err := k.Sign(signingRequestMessage(encoded))
+	if err != nil {
+		return SigningResponse{}, err
+	}
+	return SigningResponse{
+		Request:   id,
+		Signature: signature,
+	}, nil
+}
+
+// Verify checks that a SigningResponse answers this request, that it was
+// produced by the key the request was addressed to, and that payload is
+// the data that was requested to be signed.
+func (r SigningRequest) Verify(public Keypair, resp SigningResponse, payload []byte) error {
+	if err := r.Digest.Verify(payload); err != nil {
+		return err
+	}
+	if !public.HasFingerprint(r.Fingerprint) {
+		return ErrFingerprintMismatch
+	}
+	encoded, err := r.Encode()
+	if err != nil {
+		return err
+	}
+	if err := resp.Request.Verify(encoded); err != nil {
+		return ErrRequestMismatch
+	}
+	return public.Verify(signingRequestMessage(encoded), resp.Signature)
+}
+
+// The message actually signed for an encoded request.
+func signingRequestMessage(encoded []byte) []byte {
+	message := make([]byte, 0, len(signingRequestDomain)+len(encoded))
+	message = append(message, signingRequestDomain...)
+	return append(message, encoded...)
+}
+
+//
+// ENCODE
+//
+
+// Encode packs a SigningRequest into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  [digest length]<digest> (16-bit length prefix)
+//	  [fingerprint length]<fingerprint> (16-bit length prefix)
+//	  [context length]<context> (16-bit length prefix)
+func (r SigningRequest) Encode() ([]byte, error) {
+	var b cryptobyte.Builder
+
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Digest)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Fingerprint)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Context)
+		})
+	})
+
+	return b.Bytes()
+}
+
+// Encode packs a SigningResponse into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  [request digest length]<request digest> (16-bit length prefix)
+//	  [signature length]<signature> (16-bit length prefix)
+func (r SigningResponse) Encode() ([]byte, error) {
+	var b cryptobyte.Builder
+
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Request)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Signature)
+		})
+	})
+
+	return b.Bytes()
+}
+
+//
+// DECODE
+//
+
+// DecodeSigningRequest unpacks an encoded SigningRequest.
+func DecodeSigningRequest(buf []byte) (SigningRequest, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return SigningRequest{}, ErrInvalidSigningRequest
+	}
+
+	var digest, fingerprint, context cryptobyte.String
+	if !values.ReadUint16LengthPrefixed(&digest) ||
+		!values.ReadUint16LengthPrefixed(&fingerprint) ||
+		!values.ReadUint16LengthPrefixed(&context) ||
+		!values.Empty() {
+		return SigningRequest{}, ErrInvalidSigningRequest
+	}
+
+	return SigningRequest{
+		Digest:      Multihash(digest),
+		Fingerprint: Multihash(fingerprint),
+		Context:     context,
+	}, nil
+}
+
+// DecodeSigningResponse unpacks an encoded SigningResponse.
+func DecodeSigningResponse(buf []byte) (SigningResponse, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return SigningResponse{}, ErrInvalidSigningResponse
+	}
+
+	var request, signature cryptobyte.String
+	if !values.ReadUint16LengthPrefixed(&request) ||
+		!values.ReadUint16LengthPrefixed(&signature) ||
+		!values.Empty() {
+		return SigningResponse{}, ErrInvalidSigningResponse
+	}
+
+	return SigningResponse{
+		Request:   Multihash(request),
+		Signature: signature,
+	}, nil
+}
+
+//
+// Base-58
+//
+
+// B58String generates a base58-encoded version of a SigningRequest,
+// suitable for rendering as a QR code.
+func (r SigningRequest) B58String() (string, error) {
+	b, err := r.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(b), nil
+}
+
+// SigningRequestFromB58 parses a base58-encoded SigningRequest.
+func SigningRequestFromB58(s string) (SigningRequest, error) {
+	b, err := b58.Decode(s)
+	if err != nil {
+		return SigningRequest{}, ErrInvalidSigningRequest
+	}
+	return DecodeSigningRequest(b)
+}
+
+// B58String generates a base58-encoded version of a SigningResponse,
+// suitable for rendering as a QR code.
+func (r SigningResponse) B58String() (string, error) {
+	b, err := r.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(b), nil
+}
+
+// SigningResponseFromB58 parses a base58-encoded SigningResponse.
+func SigningResponseFromB58(s string) (SigningResponse, error) {
+	b, err := b58.Decode(s)
+	if err != nil {
+		return SigningResponse{}, ErrInvalidSigningResponse
+	}
+	return DecodeSigningResponse(b)
+}
@@ -0,0 +1,93 @@
+// go-multikeypair/airgap_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"testing"
+)
+
+// Carry a request and response across the "air gap" as base58 strings.
+func TestSigningRequestRoundTrip(t *testing.T) {
+	kp := generateEd25519(t)
+	public := Keypair{Code: kp.Code, Public: kp.Public}
+	payload := []byte("transfer 10 tokens")
+
+	// Online: build the request knowing only the public key.
+	req, err := NewSigningRequest(payload, public, []byte("wallet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqStr, err := req.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Offline: fulfill the request with the private key.
+	offReq, err := SigningRequestFromB58(reqStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := kp.Fulfill(offReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respStr, err := resp.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Online: check the response.
+	onResp, err := SigningResponseFromB58(respStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Verify(public, onResp, payload); err != nil {
+		t.Fatalf("expected response to verify: %s", err)
+	}
+	if err := req.Verify(public, onResp, []byte("transfer 99 tokens")); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected digest mismatch, got: %v", err)
+	}
+}
+
+// A request addressed to one key can't be fulfilled by another.
+func TestSigningRequestWrongKey(t *testing.T) {
+	kp := generateEd25519(t)
+	other := generateEd25519(t)
+
+	req, err := NewSigningRequest([]byte("payload"), kp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Fulfill(req); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected fingerprint mismatch, got: %v", err)
+	}
+}
+
+// A response to one request doesn't verify against another.
+func TestSigningResponseMismatch(t *testing.T) {
+	kp := generateEd25519(t)
+
+	first, err := NewSigningRequest([]byte("first"), kp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewSigningRequest([]byte("second"), kp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := kp.Fulfill(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Verify(kp, resp, []byte("second")); !errors.Is(err, ErrRequestMismatch) {
+		t.Fatalf("expected request mismatch, got: %v", err)
+	}
+}
+
+// Garbage input is rejected.
+func TestDecodeSigningRequestInvalid(t *testing.T) {
+	if _, err := DecodeSigningRequest([]byte{0x00, 0x00, 0x01, 0xff}); !errors.Is(err, ErrInvalidSigningRequest) {
+		t.Fatalf("expected invalid signing request, got: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// go-multikeypair/append.go
+//
+// Allocation-conscious encoding: AppendEncode writes into a buffer the
+// caller owns, EncodeTo streams straight to an io.Writer, and EncodedLen
+// says how much room either will need. All of them produce exactly the
+// bytes Encode does.
+
+package multikeypair
+
+import (
+	"encoding/binary"
+	"io"
+
+	varint "github.com/multiformats/go-varint"
+)
+
+// Size in bytes of the fixed parts of each layout, excluding the code,
+// private key, and public key themselves.
+const (
+	// 24-bit total length plus three 16-bit field lengths.
+	v1Overhead = 3 + 2 + 2 + 2
+	// Escape, one-byte version, 32-bit total length, 16-bit code length,
+	// and two 32-bit key lengths.
+	v2Overhead = 3 + 1 + 4 + 2 + 4 + 4
+)
+
+// EncodedLen returns the length in bytes of the encoding of a keypair.
+func EncodedLen(private []byte, public []byte, code uint64) int {
+	return encodedLen(private, public, code, isWide(private, public))
+}
+
+// AppendEncode appends the encoding of a keypair to dst and returns the
+// extended buffer. If dst has at least EncodedLen bytes of spare
+// capacity, nothing is allocated.
+func AppendEncode(dst []byte, private []byte, public []byte, code uint64) ([]byte, error) {
+	if err := checkEncode(private, public, code); err != nil {
+		return dst, err
+	}
+	return appendKeypair(dst, private, public, code, isWide(private, public)), nil
+}
+
+// EncodeTo writes the encoding of a keypair to w, returning the number of
+// bytes written. The key material is written directly from the caller's
+// slices rather than being copied into an intermediate buffer.
+func EncodeTo(w io.Writer, private []byte, public []byte, code uint64) (int, error) {
+	if err := checkEncode(private, public, code); err != nil {
+		return 0, err
+	}
+
+	// Large enough for either layout's header including a 10-byte code.
+	var header [v2Overhead + binary.MaxVarintLen64]byte
+	var publicLength [4]byte
+
+	wide := isWide(private, public)
+	var written int
+	for _, chunk := range [][]byte{
+		appendHeader(header[:0], private, public, code, wide),
+		private,
+		appendPublicLength(publicLength[:0], public, wide),
+		public,
+	} {
+		n, err := w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Check that a keypair can be encoded at all.
+func checkEncode(private []byte, public []byte, code uint64) error {
+	if err := validCode(code); err != nil {
+		return err
+	}
+	if len(private) > MAX_KEY_LENGTH || len(public) > MAX_KEY_LENGTH {
+		return ErrTooLong
+	}
+	return nil
+}
+
+// Whether a keypair needs the wide (v2) layout.
+func isWide(private []byte, public []byte) bool {
+	return len(private) > MAX_V1_KEY_LENGTH || len(public) > MAX_V1_KEY_LENGTH
+}
+
+// Length in bytes of the encoding of a keypair in the v1 or wide (v2)
+// layout.
+func encodedLen(private []byte, public []byte, code uint64, wide bool) int {
+	n := varint.UvarintSize(code) + len(private) + len(public)
+	if wide {
+		return n + v2Overhead
+	}
+	return n + v1Overhead
+}
+
+// Append the encoding of a keypair in the v1 or wide (v2) layout. The
+// caller has checked that the keys fit the layout.
+func appendKeypair(dst []byte, private []byte, public []byte, code uint64, wide bool) []byte {
+	dst = appendHeader(dst, private, public, code, wide)
+	dst = append(dst, private...)
+	dst = appendPublicLength(dst, public, wide)
+	return append(dst, public...)
+}
+
+// Append everything that precedes the private key bytes: the total
+// length, the code, and the private key length.
+func appendHeader(dst []byte, private []byte, public []byte, code uint64, wide bool) []byte {
+	codeLen := varint.UvarintSize(code)
+	if wide {
+		total := 2 + codeLen + 4 + len(private) + 4 + len(public)
+		dst = append(dst, versionEscape...)
+		dst = binary.AppendUvarint(dst, V2)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(total))
+		dst = binary.BigEndian.AppendUint16(dst, uint16(codeLen))
+		dst = binary.AppendUvarint(dst, code)
+		return binary.BigEndian.AppendUint32(dst, uint32(len(private)))
+	}
+
+	total := 2 + codeLen + 2 + len(private) + 2 + len(public)
+	dst = append(dst, byte(total>>16), byte(total>>8), byte(total))
+	dst = binary.BigEndian.AppendUint16(dst, uint16(codeLen))
+	dst = binary.AppendUvarint(dst, code)
+	return binary.BigEndian.AppendUint16(dst, uint16(len(private)))
+}
+
+// Append the public key length prefix.
+func appendPublicLength(dst []byte, public []byte, wide bool) []byte {
+	if wide {
+		return binary.BigEndian.AppendUint32(dst, uint32(len(public)))
+	}
+	return binary.BigEndian.AppendUint16(dst, uint16(len(public)))
+}
@@ -0,0 +1,90 @@
+// go-multikeypair/append_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"testing"
+)
+
+// AppendEncode and EncodeTo produce the same bytes as Encode, for both
+// layouts, and EncodedLen predicts their length.
+func TestAppendEncode(t *testing.T) {
+	kp := generateEd25519(t)
+	for _, c := range []struct {
+		private []byte
+		public  []byte
+		code    uint64
+	}{
+		{kp.Private, kp.Public, kp.Code},
+		{nil, kp.Public, kp.Code},
+		{bytes.Repeat([]byte{0xaa}, MAX_V1_KEY_LENGTH+1), []byte("public"), RSA},
+	} {
+		want, err := Encode(c.private, c.public, c.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := EncodedLen(c.private, c.public, c.code); n != len(want) {
+			t.Errorf("encoded length mismatch: %d != %d", len(want), n)
+		}
+
+		prefix := []byte("prefix")
+		got, err := AppendEncode(prefix, c.private, c.public, c.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got[:len(prefix)], prefix) || !bytes.Equal(got[len(prefix):], want) {
+			t.Error("appended encoding mismatch")
+		}
+
+		var buf bytes.Buffer
+		n, err := EncodeTo(&buf, c.private, c.public, c.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(want) || !bytes.Equal(buf.Bytes(), want) {
+			t.Error("streamed encoding mismatch")
+		}
+	}
+}
+
+// AppendEncode into a buffer with room to spare doesn't allocate.
+func TestAppendEncodeAllocs(t *testing.T) {
+	kp := generateEd25519(t)
+	buf := make([]byte, 0, EncodedLen(kp.Private, kp.Public, kp.Code))
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := AppendEncode(buf[:0], kp.Private, kp.Public, kp.Code); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+// Invalid input is rejected before anything is written.
+func TestAppendEncodeInvalid(t *testing.T) {
+	dst := []byte("prefix")
+	out, err := AppendEncode(dst, nil, nil, 0xdead)
+	if err != ErrUnknownCode || !bytes.Equal(out, dst) {
+		t.Errorf("expected unknown code error and untouched buffer, got: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := EncodeTo(&buf, make([]byte, MAX_KEY_LENGTH+1), nil, RSA); err != ErrTooLong || buf.Len() != 0 {
+		t.Errorf("expected too long error and nothing written, got: %v", err)
+	}
+}
+
+func BenchmarkAppendEncode(b *testing.B) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, 0, EncodedLen(kp.Private, kp.Public, kp.Code))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := AppendEncode(buf[:0], kp.Private, kp.Public, kp.Code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
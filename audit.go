@@ -0,0 +1,160 @@
+// go-multikeypair/audit.go
+//
+// Detection of weak keys, for scanning fleets of stored keys. Audit
+// looks for material that decodes and may even work, but shouldn't be
+// trusted:
+//
+//	deprecated-cipher   DSA, withdrawn for signing by FIPS 186-5
+//	short-rsa-key       RSA moduli under 2048 bits
+//	small-order-point   Ed25519 public keys of small order, which admit
+//	                    signatures that verify for any message
+//	zero-key            public or private keys that are all zeros
+//	invalid-public-key  public keys that don't parse for their cipher
+//	key-mismatch        public keys that don't belong to the private key
+//
+// Hybrid keys are audited component by component.
+
+package multikeypair
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// Kinds of finding.
+const (
+	FINDING_DEPRECATED_CIPHER  = "deprecated-cipher"
+	FINDING_SHORT_RSA_KEY      = "short-rsa-key"
+	FINDING_SMALL_ORDER_POINT  = "small-order-point"
+	FINDING_ZERO_KEY           = "zero-key"
+	FINDING_INVALID_PUBLIC_KEY = "invalid-public-key"
+	FINDING_KEY_MISMATCH       = "key-mismatch"
+)
+
+// Severities of finding.
+const (
+	// The key is weaker than it should be, but not broken.
+	SEVERITY_WARNING = Severity(1)
+	// The key offers little or no security and should be replaced.
+	SEVERITY_CRITICAL = Severity(2)
+)
+
+// RSA moduli shorter than this many bits are reported.
+const MIN_RSA_BITS = 2048
+
+// RSA moduli shorter than this many bits are critical rather than weak.
+const minSafeRSABits = 1024
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Severity grades a Finding.
+type Severity uint8
+
+// Finding is a weakness found in a keypair.
+type Finding struct {
+	// Kind of weakness, one of the FINDING_ constants.
+	Kind string
+	// How serious it is.
+	Severity Severity
+	// Cipher code of the key at fault; for hybrid keys, the component's.
+	Code uint64
+	// Human-readable description.
+	Detail string
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// String names the severity, e.g. "critical".
+func (s Severity) String() string {
+	switch s {
+	case SEVERITY_WARNING:
+		return "warning"
+	case SEVERITY_CRITICAL:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// String describes the finding, e.g. "critical: short-rsa-key: 512-bit
+// modulus".
+func (f Finding) String() string {
+	return fmt.Sprintf("%v: %s: %s", f.Severity, f.Kind, f.Detail)
+}
+
+// Audit reports known weaknesses in the keypair, or nothing if it finds
+// none. Keys of ciphers it knows nothing about only get the generic
+// checks.
+func (k Keypair) Audit() []Finding {
+	if _, ok := Hybrids[k.Code]; ok {
+		classical, postQuantum, err := k.Components()
+		if err != nil {
+			return []Finding{{FINDING_INVALID_PUBLIC_KEY, SEVERITY_CRITICAL, k.Code, err.Error()}}
+		}
+		return append(classical.Audit(), postQuantum.Audit()...)
+	}
+
+	var findings []Finding
+	add := func(kind string, severity Severity, format string, args ...any) {
+		findings = append(findings, Finding{kind, severity, k.Code, fmt.Sprintf(format, args...)})
+	}
+
+	if isZero(k.Public) {
+		add(FINDING_ZERO_KEY, SEVERITY_CRITICAL, "public key is all zeros")
+	}
+	if isZero(k.Private) {
+		add(FINDING_ZERO_KEY, SEVERITY_CRITICAL, "private key is all zeros")
+	}
+
+	switch k.Code {
+	case DSA:
+		add(FINDING_DEPRECATED_CIPHER, SEVERITY_WARNING, "dsa is deprecated")
+	case RSA:
+		pk, err := x509.ParsePKCS1PublicKey(k.Public)
+		if err != nil {
+			add(FINDING_INVALID_PUBLIC_KEY, SEVERITY_CRITICAL, "public key isn't a pkcs#1 rsa key")
+			break
+		}
+		if bits := pk.N.BitLen(); bits < minSafeRSABits {
+			add(FINDING_SHORT_RSA_KEY, SEVERITY_CRITICAL, "%d-bit modulus", bits)
+		} else if bits < MIN_RSA_BITS {
+			add(FINDING_SHORT_RSA_KEY, SEVERITY_WARNING, "%d-bit modulus", bits)
+		}
+	case ED_25519:
+		if len(k.Public) != ed25519.PublicKeySize {
+			add(FINDING_INVALID_PUBLIC_KEY, SEVERITY_CRITICAL, "public key isn't %d bytes", ed25519.PublicKeySize)
+			break
+		}
+		p, err := new(edwards25519.Point).SetBytes(k.Public)
+		if err != nil {
+			add(FINDING_INVALID_PUBLIC_KEY, SEVERITY_CRITICAL, "public key isn't a curve point")
+			break
+		}
+		if new(edwards25519.Point).MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1 {
+			add(FINDING_SMALL_ORDER_POINT, SEVERITY_CRITICAL, "public key has small order")
+		}
+	}
+
+	if err := k.Validate(); errors.Is(err, ErrKeyMismatch) {
+		add(FINDING_KEY_MISMATCH, SEVERITY_CRITICAL, "public key doesn't belong to private key")
+	}
+	return findings
+}
+
+// Report whether a non-empty key is all zeros.
+func isZero(key []byte) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, b := range key {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
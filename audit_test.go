@@ -0,0 +1,83 @@
+// go-multikeypair/audit_test.go
+
+package multikeypair
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+)
+
+// Report the kinds of finding, in order.
+func findingKinds(findings []Finding) []string {
+	var kinds []string
+	for _, f := range findings {
+		kinds = append(kinds, f.Kind)
+	}
+	return kinds
+}
+
+// Freshly generated keys have no findings.
+func TestAuditClean(t *testing.T) {
+	for code := range schemes {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if findings := k.Audit(); len(findings) != 0 {
+			t.Errorf("%s: unexpected findings %v", k.Name, findings)
+		}
+	}
+}
+
+// Known weaknesses are reported.
+func TestAudit(t *testing.T) {
+	// The Ed25519 encoding of a point of order 4.
+	smallOrder := make([]byte, 32)
+	smallOrder[31] = 0x80
+	short, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed := generateEd25519(t)
+	other := generateEd25519(t)
+	hybrid, err := Generate(ED_25519_ML_DSA_65)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classical, postQuantum, err := hybrid.Components()
+	if err != nil {
+		t.Fatal(err)
+	}
+	classical.Public = smallOrder
+	badHybrid, err := NewHybrid(ED_25519_ML_DSA_65, classical.publicOnly(), postQuantum.publicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name     string
+		key      Keypair
+		kinds    []string
+		severity Severity
+	}{
+		{"dsa", Keypair{Code: DSA, Public: []byte("public")}, []string{FINDING_DEPRECATED_CIPHER}, SEVERITY_WARNING},
+		{"short rsa", Keypair{Code: RSA, Public: x509.MarshalPKCS1PublicKey(&short.PublicKey)}, []string{FINDING_SHORT_RSA_KEY}, SEVERITY_WARNING},
+		{"small order", Keypair{Code: ED_25519, Public: smallOrder}, []string{FINDING_SMALL_ORDER_POINT}, SEVERITY_CRITICAL},
+		{"zero key", Keypair{Code: X_25519, Public: make([]byte, 32)}, []string{FINDING_ZERO_KEY}, SEVERITY_CRITICAL},
+		{"invalid", Keypair{Code: ED_25519, Public: []byte("short")}, []string{FINDING_INVALID_PUBLIC_KEY}, SEVERITY_CRITICAL},
+		{"mismatch", Keypair{Code: ED_25519, Private: ed.Private, Public: other.Public}, []string{FINDING_KEY_MISMATCH}, SEVERITY_CRITICAL},
+		{"hybrid", badHybrid, []string{FINDING_SMALL_ORDER_POINT}, SEVERITY_CRITICAL},
+	} {
+		findings := c.key.Audit()
+		kinds := findingKinds(findings)
+		if len(kinds) != len(c.kinds) || (len(kinds) > 0 && kinds[0] != c.kinds[0]) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.kinds, findings)
+			continue
+		}
+		if findings[0].Severity != c.severity {
+			t.Errorf("%s: expected %v, got %v", c.name, c.severity, findings[0].Severity)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// go-multikeypair/awskms/awskms.go
+//
+// A multikeypair.RemoteBackend for keys held in AWS KMS. Ed25519
+// (ECC_NIST_EDWARDS25519), ML-DSA-65 (ML_DSA_65), P-256 (ECC_NIST_P256)
+// and RSA (RSA_2048, RSA_3072, RSA_4096) signing keys are supported; the
+// resource in a reference is the key ID, ARN, or alias. P-256 and RSA
+// keys also sign digests, so that RemoteKeypairs for them work with
+// crypto/x509 and crypto/tls.
+//
+//	backend := awskms.New(kms.NewFromConfig(cfg))
+//	multikeypair.RegisterRemoteBackend(awskms.SCHEME, backend)
+//	remote, err := multikeypair.NewRemoteKeypair(ctx, "awskms:arn:aws:kms:...")
+
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// AWS KMS specific errors this package exports.
+var (
+	ErrUnsupportedKeySpec = errors.New("unsupported aws kms key spec")
+	ErrMessageTooLarge    = errors.New("message too large for aws kms raw signing")
+)
+
+// SCHEME is the conventional reference scheme for AWS KMS keys.
+const SCHEME = "awskms"
+
+// AWS KMS signs at most this many bytes of raw message; this limits
+// Ed25519 and ML-DSA keys, which can't sign a digest instead.
+const MAX_MESSAGE_SIZE = 4096
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Client is the subset of the AWS KMS API the backend uses; *kms.Client
+// satisfies it.
+type Client interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+// Backend signs with keys held in AWS KMS. A key's cipher never changes,
+// so it is remembered after the first lookup.
+type Backend struct {
+	client Client
+
+	mu    sync.Mutex
+	codes map[string]uint64
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// New returns a backend that uses client to reach AWS KMS.
+func New(client Client) *Backend {
+	return &Backend{client: client, codes: make(map[string]uint64)}
+}
+
+// PublicKey fetches the public half of a KMS key. Keys whose KMS key
+// usage is SIGN_VERIFY are restricted to multikeypair.USAGE_SIGN.
+func (b *Backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	out, err := b.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(resource)})
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	var code uint64
+	var public []byte
+	switch out.KeySpec {
+	case types.KeySpecEccNistEdwards25519:
+		pk, err := x509.ParsePKIXPublicKey(out.PublicKey)
+		ed, ok := pk.(ed25519.PublicKey)
+		if err != nil || !ok {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		code, public = multikeypair.ED_25519, ed
+	case types.KeySpecMlDsa65:
+		pk, err := x509.ParsePKIXPublicKey(out.PublicKey)
+		ml, ok := pk.(*mldsa.PublicKey)
+		if err != nil || !ok || ml.Parameters() != mldsa.MLDSA65() {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		code, public = multikeypair.ML_DSA_65, ml.Bytes()
+	case types.KeySpecEccNistP256:
+		pk, err := x509.ParsePKIXPublicKey(out.PublicKey)
+		ec, ok := pk.(*ecdsa.PublicKey)
+		if err != nil || !ok || ec.Curve != elliptic.P256() {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		if public, err = ec.Bytes(); err != nil {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		code = multikeypair.P_256
+	case types.KeySpecRsa2048, types.KeySpecRsa3072, types.KeySpecRsa4096:
+		pk, err := x509.ParsePKIXPublicKey(out.PublicKey)
+		rsaKey, ok := pk.(*rsa.PublicKey)
+		if err != nil || !ok {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		code, public = multikeypair.RSA, x509.MarshalPKCS1PublicKey(rsaKey)
+	default:
+		return multikeypair.Keypair{}, ErrUnsupportedKeySpec
+	}
+
+	m, err := multikeypair.Encode(nil, public, code)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if out.KeyUsage == types.KeyUsageTypeSignVerify {
+		kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	}
+
+	b.mu.Lock()
+	b.codes[resource] = code
+	b.mu.Unlock()
+
+	return kp, nil
+}
+
+// Sign asks KMS to sign message. The signing algorithm is chosen from
+// the key spec so that the signature verifies as an ordinary one for the
+// cipher. Ed25519 and ML-DSA keys sign the message itself, which KMS
+// limits to MAX_MESSAGE_SIZE bytes, so larger ones are refused rather
+// than silently prehashed; P-256 and RSA keys sign its SHA-256 digest.
+func (b *Backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	code, err := b.code(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var algorithm types.SigningAlgorithmSpec
+	switch code {
+	case multikeypair.ED_25519:
+		algorithm = types.SigningAlgorithmSpecEd25519Sha512
+	case multikeypair.ML_DSA_65:
+		algorithm = types.SigningAlgorithmSpecMlDsaShake256
+	default:
+		digest := sha256.Sum256(message)
+		return b.SignDigest(ctx, resource, digest[:], crypto.SHA256)
+	}
+	if len(message) > MAX_MESSAGE_SIZE {
+		return nil, ErrMessageTooLarge
+	}
+	return b.sign(ctx, resource, message, types.MessageTypeRaw, algorithm)
+}
+
+// SignDigest asks KMS to sign a digest with a P-256 key (SHA-256 only)
+// or an RSA key (SHA-256, SHA-384 or SHA-512), implementing
+// multikeypair.RemoteDigestSigner.
+func (b *Backend) SignDigest(ctx context.Context, resource string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	code, err := b.code(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var algorithm types.SigningAlgorithmSpec
+	switch {
+	case code == multikeypair.P_256 && hash == crypto.SHA256:
+		algorithm = types.SigningAlgorithmSpecEcdsaSha256
+	case code == multikeypair.RSA && hash == crypto.SHA256:
+		algorithm = types.SigningAlgorithmSpecRsassaPkcs1V15Sha256
+	case code == multikeypair.RSA && hash == crypto.SHA384:
+		algorithm = types.SigningAlgorithmSpecRsassaPkcs1V15Sha384
+	case code == multikeypair.RSA && hash == crypto.SHA512:
+		algorithm = types.SigningAlgorithmSpecRsassaPkcs1V15Sha512
+	default:
+		return nil, multikeypair.ErrUnsupportedDigest
+	}
+	if len(digest) != hash.Size() {
+		return nil, multikeypair.ErrUnsupportedDigest
+	}
+	return b.sign(ctx, resource, digest, types.MessageTypeDigest, algorithm)
+}
+
+// The cipher of a key, looked up the first time it is needed.
+func (b *Backend) code(ctx context.Context, resource string) (uint64, error) {
+	b.mu.Lock()
+	code, ok := b.codes[resource]
+	b.mu.Unlock()
+	if ok {
+		return code, nil
+	}
+	kp, err := b.PublicKey(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+	return kp.Code, nil
+}
+
+// Make a KMS Sign call.
+func (b *Backend) sign(ctx context.Context, resource string, message []byte, messageType types.MessageType, algorithm types.SigningAlgorithmSpec) ([]byte, error) {
+	out, err := b.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(resource),
+		Message:          message,
+		MessageType:      messageType,
+		SigningAlgorithm: algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Signature, nil
+}
@@ -0,0 +1,153 @@
+// go-multikeypair/awskms/awskms_test.go
+
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// A Client that answers from a single in-memory key.
+type fakeClient struct {
+	signer      crypto.Signer
+	keySpec     types.KeySpec
+	publicCalls int
+	signInput   *kms.SignInput
+}
+
+func (c *fakeClient) GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	c.publicCalls++
+	der, err := x509.MarshalPKIXPublicKey(c.signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GetPublicKeyOutput{
+		KeyId:     params.KeyId,
+		KeySpec:   c.keySpec,
+		KeyUsage:  types.KeyUsageTypeSignVerify,
+		PublicKey: der,
+	}, nil
+}
+
+func (c *fakeClient) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	c.signInput = params
+	var opts crypto.SignerOpts = crypto.Hash(0)
+	if params.MessageType == types.MessageTypeDigest {
+		opts = crypto.SHA256
+	}
+	signature, err := c.signer.Sign(crypto_rand.Reader, params.Message, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: params.KeyId, Signature: signature}, nil
+}
+
+func newFake(t *testing.T) *fakeClient {
+	_, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeClient{signer: private, keySpec: types.KeySpecEccNistEdwards25519}
+}
+
+// Signatures made through KMS verify with the cached public key.
+func TestBackendSign(t *testing.T) {
+	client := newFake(t)
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, New(client)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	remote, err := multikeypair.NewRemoteKeypair(ctx, SCHEME+":arn:aws:kms:us-east-1:111122223333:key/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Key.Code != multikeypair.ED_25519 || remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+		t.Errorf("unexpected cached key %+v", remote.Key)
+	}
+
+	message := []byte("hello")
+	signature, err := remote.SignContext(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Verify(message, signature); err != nil {
+		t.Error(err)
+	}
+	if client.signInput.SigningAlgorithm != types.SigningAlgorithmSpecEd25519Sha512 ||
+		client.signInput.MessageType != types.MessageTypeRaw {
+		t.Errorf("unexpected sign request %+v", client.signInput)
+	}
+	if client.publicCalls != 1 {
+		t.Errorf("expected 1 public key fetch, got %d", client.publicCalls)
+	}
+}
+
+// Messages over the KMS raw limit are refused.
+func TestBackendMessageTooLarge(t *testing.T) {
+	b := New(newFake(t))
+	message := make([]byte, MAX_MESSAGE_SIZE+1)
+	if _, err := b.Sign(context.Background(), "key", message); err != ErrMessageTooLarge {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
+
+// P-256 and RSA keys sign SHA-256 digests, both for messages and for
+// standard library callers.
+func TestBackendSignDigest(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(crypto_rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		client    *fakeClient
+		code      uint64
+		algorithm types.SigningAlgorithmSpec
+	}{
+		{&fakeClient{signer: ecKey, keySpec: types.KeySpecEccNistP256}, multikeypair.P_256, types.SigningAlgorithmSpecEcdsaSha256},
+		{&fakeClient{signer: rsaKey, keySpec: types.KeySpecRsa2048}, multikeypair.RSA, types.SigningAlgorithmSpecRsassaPkcs1V15Sha256},
+	}
+	for _, c := range cases {
+		b := New(c.client)
+		ctx := context.Background()
+		kp, err := b.PublicKey(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kp.Code != c.code {
+			t.Errorf("%s: unexpected key %+v", c.client.keySpec, kp)
+		}
+
+		message := make([]byte, MAX_MESSAGE_SIZE+1)
+		signature, err := b.Sign(ctx, "key", message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kp.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", c.client.keySpec, err)
+		}
+		if c.client.signInput.SigningAlgorithm != c.algorithm ||
+			c.client.signInput.MessageType != types.MessageTypeDigest {
+			t.Errorf("%s: unexpected sign request %+v", c.client.keySpec, c.client.signInput)
+		}
+
+		if _, err := b.SignDigest(ctx, "key", make([]byte, 20), crypto.SHA1); err != multikeypair.ErrUnsupportedDigest {
+			t.Errorf("%s: expected ErrUnsupportedDigest, got %v", c.client.keySpec, err)
+		}
+	}
+}
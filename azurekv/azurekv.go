@@ -0,0 +1,349 @@
+// go-multikeypair/azurekv/azurekv.go
+//
+// A multikeypair.RemoteBackend for keys held in Azure Key Vault or
+// Managed HSM. P-256 (EC, ES256) and RSA (RS256) keys are supported,
+// and sign digests as well as messages, so RemoteKeypairs for them work
+// with crypto/x509 and crypto/tls; Key Vault has no Ed25519 keys. The resource in a reference is the
+// vault's host name and the key's path, version included:
+//
+//	azurekv:<vault>.vault.azure.net/keys/<name>/<version>
+//
+// The backend talks to the Key Vault REST API through an HTTP client
+// that adds a bearer token for https://vault.azure.net, and only sends
+// requests to Azure's vault domains, so a reference can't direct the
+// token elsewhere.
+//
+//	multikeypair.RegisterRemoteBackend(azurekv.SCHEME, azurekv.New(client))
+//	remote, err := multikeypair.NewRemoteKeypair(ctx,
+//		"azurekv:example.vault.azure.net/keys/signing/0123456789abcdef0123456789abcdef")
+
+package azurekv
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Key Vault specific errors this package exports.
+var (
+	ErrUnsupportedKeyType = errors.New("unsupported azure key vault key type")
+	ErrInvalidResource    = errors.New("invalid azure key vault key reference")
+	ErrRequestFailed      = errors.New("azure key vault request failed")
+	ErrInvalidSignature   = errors.New("invalid azure key vault signature")
+)
+
+// SCHEME is the conventional reference scheme for Key Vault keys.
+const SCHEME = "azurekv"
+
+// API_VERSION is the Key Vault REST API version the backend speaks.
+const API_VERSION = "7.4"
+
+// The domains of Key Vault and Managed HSM in Azure's public and
+// sovereign clouds.
+var vaultDomains = []string{
+	".vault.azure.net",
+	".managedhsm.azure.net",
+	".vault.azure.cn",
+	".managedhsm.azure.cn",
+	".vault.usgovcloudapi.net",
+	".managedhsm.usgovcloudapi.net",
+}
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Client sends authorized requests to Key Vault; an *http.Client whose
+// transport adds an Azure AD bearer token satisfies it.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Backend signs with keys held in Key Vault. A key version never
+// changes, so its cipher is remembered after the first lookup.
+type Backend struct {
+	client Client
+
+	mu    sync.Mutex
+	codes map[string]uint64
+}
+
+// Bytes as Key Vault encodes them in JSON: unpadded base64url.
+type base64url []byte
+
+// The KeyBundle resource, of which only the JSON web key is used.
+type keyResponse struct {
+	Key struct {
+		Kty    string    `json:"kty"`
+		KeyOps []string  `json:"key_ops"`
+		Crv    string    `json:"crv"`
+		X      base64url `json:"x"`
+		Y      base64url `json:"y"`
+		N      base64url `json:"n"`
+		E      base64url `json:"e"`
+	} `json:"key"`
+}
+
+// The sign operation's request and result.
+type signRequest struct {
+	Alg   string    `json:"alg"`
+	Value base64url `json:"value"`
+}
+
+type signResponse struct {
+	Value base64url `json:"value"`
+}
+
+// A Key Vault error.
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// New returns a backend that uses client to reach Key Vault.
+func New(client Client) *Backend {
+	return &Backend{client: client, codes: make(map[string]uint64)}
+}
+
+// PublicKey fetches the public half of a Key Vault key. Keys whose only
+// permitted operations are sign and verify are restricted to
+// multikeypair.USAGE_SIGN.
+func (b *Backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	if !validResource(resource) {
+		return multikeypair.Keypair{}, ErrInvalidResource
+	}
+	var out keyResponse
+	if err := b.call(ctx, http.MethodGet, resource, nil, &out); err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	var code uint64
+	var public []byte
+	var err error
+	key := out.Key
+	switch key.Kty {
+	case "EC", "EC-HSM":
+		if key.Crv != "P-256" {
+			return multikeypair.Keypair{}, ErrUnsupportedKeyType
+		}
+		pk := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(key.X),
+			Y:     new(big.Int).SetBytes(key.Y),
+		}
+		if public, err = pk.Bytes(); err != nil {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		code = multikeypair.P_256
+	case "RSA", "RSA-HSM":
+		e := new(big.Int).SetBytes(key.E)
+		if len(key.N) == 0 || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		pk := &rsa.PublicKey{N: new(big.Int).SetBytes(key.N), E: int(e.Int64())}
+		code, public = multikeypair.RSA, x509.MarshalPKCS1PublicKey(pk)
+	default:
+		return multikeypair.Keypair{}, ErrUnsupportedKeyType
+	}
+
+	m, err := multikeypair.Encode(nil, public, code)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	signOnly := len(key.KeyOps) != 0
+	for _, op := range key.KeyOps {
+		signOnly = signOnly && (op == "sign" || op == "verify")
+	}
+	if signOnly {
+		kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	}
+
+	b.mu.Lock()
+	b.codes[resource] = code
+	b.mu.Unlock()
+
+	return kp, nil
+}
+
+// Sign asks Key Vault to sign the SHA-256 digest of message, with ES256
+// for P-256 keys and RS256 for RSA keys. ES256 signatures are converted
+// to the ASN.1 DER form Keypair.Verify accepts.
+func (b *Backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	return b.SignDigest(ctx, resource, digest[:], crypto.SHA256)
+}
+
+// SignDigest asks Key Vault to sign a digest with a P-256 key (SHA-256
+// only) or an RSA key (SHA-256, SHA-384 or SHA-512), implementing
+// multikeypair.RemoteDigestSigner.
+func (b *Backend) SignDigest(ctx context.Context, resource string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	code, err := b.code(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var alg string
+	switch {
+	case code == multikeypair.P_256 && hash == crypto.SHA256:
+		alg = "ES256"
+	case code == multikeypair.RSA && hash == crypto.SHA256:
+		alg = "RS256"
+	case code == multikeypair.RSA && hash == crypto.SHA384:
+		alg = "RS384"
+	case code == multikeypair.RSA && hash == crypto.SHA512:
+		alg = "RS512"
+	default:
+		return nil, multikeypair.ErrUnsupportedDigest
+	}
+	if len(digest) != hash.Size() {
+		return nil, multikeypair.ErrUnsupportedDigest
+	}
+	var out signResponse
+	if err := b.call(ctx, http.MethodPost, resource+"/sign", signRequest{Alg: alg, Value: digest}, &out); err != nil {
+		return nil, err
+	}
+	if code == multikeypair.P_256 {
+		return p1363ToASN1(out.Value)
+	}
+	return out.Value, nil
+}
+
+// The cipher of a key, looked up the first time it is needed.
+func (b *Backend) code(ctx context.Context, resource string) (uint64, error) {
+	b.mu.Lock()
+	code, ok := b.codes[resource]
+	b.mu.Unlock()
+	if ok {
+		return code, nil
+	}
+	kp, err := b.PublicKey(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+	return kp.Code, nil
+}
+
+// Make a Key Vault API call, decoding the response into out.
+func (b *Backend) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	url := "https://" + path + "?api-version=" + API_VERSION
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("%w: %s: %s", ErrRequestFailed, e.Error.Code, e.Error.Message)
+		}
+		return fmt.Errorf("%w: %s", ErrRequestFailed, resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Convert an ECDSA signature from the fixed-width r || s form Key Vault
+// produces to ASN.1 DER.
+func p1363ToASN1(signature []byte) ([]byte, error) {
+	if len(signature) == 0 || len(signature)%2 != 0 {
+		return nil, ErrInvalidSignature
+	}
+	half := len(signature) / 2
+	r := new(big.Int).SetBytes(signature[:half])
+	s := new(big.Int).SetBytes(signature[half:])
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
+
+// Report whether a resource is a versioned key in one of Azure's vault
+// domains: <host>/keys/<name>/<version>.
+func validResource(resource string) bool {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 4 || parts[1] != "keys" {
+		return false
+	}
+	host := strings.ToLower(parts[0])
+	if !slices.ContainsFunc(vaultDomains, func(domain string) bool {
+		name, ok := strings.CutSuffix(host, domain)
+		return ok && name != "" && !strings.ContainsAny(name, ".:@")
+	}) {
+		return false
+	}
+	for _, id := range parts[2:] {
+		if id == "" || strings.ContainsAny(id, ":?#%@") {
+			return false
+		}
+	}
+	return true
+}
+
+func (b base64url) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *base64url) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
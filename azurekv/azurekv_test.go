@@ -0,0 +1,190 @@
+// go-multikeypair/azurekv/azurekv_test.go
+
+package azurekv
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+const testResource = "example.vault.azure.net/keys/signing/0123456789abcdef0123456789abcdef"
+
+// A Client that answers Key Vault calls from a single in-memory key.
+type fakeClient struct {
+	signer      crypto.Signer
+	publicCalls int
+	signInput   signRequest
+}
+
+func (c *fakeClient) Do(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	path := req.URL.Host + req.URL.Path
+	switch {
+	case req.URL.Query().Get("api-version") != API_VERSION:
+		rec.WriteHeader(http.StatusBadRequest)
+	case req.Method == http.MethodGet && path == testResource:
+		c.publicCalls++
+		var out keyResponse
+		out.Key.KeyOps = []string{"sign", "verify"}
+		switch pk := c.signer.Public().(type) {
+		case *ecdsa.PublicKey:
+			out.Key.Kty, out.Key.Crv = "EC-HSM", "P-256"
+			out.Key.X, out.Key.Y = pk.X.FillBytes(make([]byte, 32)), pk.Y.FillBytes(make([]byte, 32))
+		case *rsa.PublicKey:
+			out.Key.Kty = "RSA"
+			out.Key.N, out.Key.E = pk.N.Bytes(), big.NewInt(int64(pk.E)).Bytes()
+		}
+		json.NewEncoder(rec).Encode(out)
+	case req.Method == http.MethodPost && path == testResource+"/sign":
+		if err := json.NewDecoder(req.Body).Decode(&c.signInput); err != nil {
+			return nil, err
+		}
+		var signature []byte
+		var err error
+		switch sk := c.signer.(type) {
+		case *ecdsa.PrivateKey:
+			var r, s *big.Int
+			r, s, err = ecdsa.Sign(crypto_rand.Reader, sk, c.signInput.Value)
+			if err == nil {
+				signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+			}
+		case *rsa.PrivateKey:
+			hash := map[string]crypto.Hash{"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512}
+			signature, err = rsa.SignPKCS1v15(nil, sk, hash[c.signInput.Alg], c.signInput.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		json.NewEncoder(rec).Encode(signResponse{Value: signature})
+	default:
+		rec.WriteHeader(http.StatusNotFound)
+		rec.WriteString(`{"error": {"code": "KeyNotFound", "message": "not found"}}`)
+	}
+	return rec.Result(), nil
+}
+
+// Signatures made through Key Vault verify with the cached public key.
+func TestBackendSign(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(crypto_rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		client *fakeClient
+		code   uint64
+		alg    string
+	}{
+		{&fakeClient{signer: ecKey}, multikeypair.P_256, "ES256"},
+		{&fakeClient{signer: rsaKey}, multikeypair.RSA, "RS256"},
+	}
+	for _, c := range cases {
+		b := New(c.client)
+		ctx := context.Background()
+		kp, err := b.PublicKey(ctx, testResource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kp.Code != c.code || kp.Metadata.Usage != multikeypair.USAGE_SIGN {
+			t.Errorf("%s: unexpected key %+v", c.alg, kp)
+		}
+
+		message := []byte("hello")
+		signature, err := b.Sign(ctx, testResource, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kp.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", c.alg, err)
+		}
+		if c.client.signInput.Alg != c.alg {
+			t.Errorf("%s: unexpected sign request %+v", c.alg, c.client.signInput)
+		}
+		if c.client.publicCalls != 1 {
+			t.Errorf("%s: expected 1 public key fetch, got %d", c.alg, c.client.publicCalls)
+		}
+	}
+}
+
+// Digests sign with the algorithm for their hash, and hashes Key Vault
+// has no algorithm for are refused.
+func TestBackendSignDigest(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(crypto_rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	client := &fakeClient{signer: rsaKey}
+	b := New(client)
+	digest := sha512.Sum384([]byte("hello"))
+	signature, err := b.SignDigest(ctx, testResource, digest[:], crypto.SHA384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA384, digest[:], signature); err != nil {
+		t.Error(err)
+	}
+	if client.signInput.Alg != "RS384" {
+		t.Errorf("unexpected sign request %+v", client.signInput)
+	}
+	if _, err := b.SignDigest(ctx, testResource, digest[:32], crypto.SHA384); !errors.Is(err, multikeypair.ErrUnsupportedDigest) {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+
+	b = New(&fakeClient{signer: ecKey})
+	if _, err := b.SignDigest(ctx, testResource, digest[:], crypto.SHA384); !errors.Is(err, multikeypair.ErrUnsupportedDigest) {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+}
+
+// References outside Azure's vault domains or without a key version are
+// refused before any request is made, and API errors are reported.
+func TestBackendErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeClient{signer: ecKey}
+	b := New(client)
+	ctx := context.Background()
+	for _, bad := range []string{
+		"",
+		"example.vault.azure.net/keys/signing",
+		"example.vault.azure.net/secrets/signing/1",
+		"evil.example.com/keys/signing/1",
+		"evil.example.com?.vault.azure.net/keys/signing/1",
+		"user@example.vault.azure.net/keys/signing/1",
+		"vault.azure.net/keys/signing/1",
+	} {
+		if _, err := b.PublicKey(ctx, bad); !errors.Is(err, ErrInvalidResource) {
+			t.Errorf("%q: expected ErrInvalidResource, got %v", bad, err)
+		}
+	}
+	if client.publicCalls != 0 {
+		t.Error("request made for an invalid reference")
+	}
+	if _, err := b.PublicKey(ctx, "example.vault.azure.net/keys/other/1"); !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("expected ErrRequestFailed, got %v", err)
+	}
+}
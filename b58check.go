@@ -0,0 +1,100 @@
+// go-multikeypair/b58check.go
+//
+// Base58Check encoding of multikeypairs, following the Bitcoin
+// convention: a version byte, the payload, and the first four bytes of a
+// double SHA-256 over both. Catches typos in manually entered keys.
+
+package multikeypair
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// B58CHECK_VERSION is the version byte written by B58CheckString.
+const B58CHECK_VERSION = byte(0x4d)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Base58Check errors this module exports.
+var (
+	ErrInvalidB58Check = errors.New("input isn't valid base58check")
+	ErrB58CheckVersion = errors.New("unexpected base58check version byte")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// B58CheckString generates a Base58Check-encoded version of a Multikeypair
+// using B58CHECK_VERSION.
+func (m Multikeypair) B58CheckString() string {
+	return m.B58CheckStringVersion(B58CHECK_VERSION)
+}
+
+// B58CheckStringVersion generates a Base58Check-encoded version of a
+// Multikeypair with a caller-chosen version byte.
+func (m Multikeypair) B58CheckStringVersion(version byte) string {
+	buf := make([]byte, 0, 1+len(m)+4)
+	buf = append(buf, version)
+	buf = append(buf, m...)
+	sum := b58CheckSum(buf)
+	buf = append(buf, sum[:]...)
+	return b58.Encode(buf)
+}
+
+// MultikeypairFromB58Check parses a Base58Check-encoded Multikeypair
+// written with B58CHECK_VERSION.
+func MultikeypairFromB58Check(s string) (Multikeypair, error) {
+	version, m, err := MultikeypairFromB58CheckVersion(s)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	if version != B58CHECK_VERSION {
+		return Multikeypair{}, ErrB58CheckVersion
+	}
+	return m, nil
+}
+
+// MultikeypairFromB58CheckVersion parses a Base58Check-encoded
+// Multikeypair, returning the version byte it was written with.
+func MultikeypairFromB58CheckVersion(s string) (byte, Multikeypair, error) {
+	buf, err := b58.Decode(s)
+	if err != nil || len(buf) < 1+4 {
+		return 0, Multikeypair{}, ErrInvalidB58Check
+	}
+
+	body, sum := buf[:len(buf)-4], buf[len(buf)-4:]
+	want := b58CheckSum(body)
+	if subtle.ConstantTimeCompare(sum, want[:]) != 1 {
+		return 0, Multikeypair{}, ErrChecksumMismatch
+	}
+
+	m, err := castKeypair(body[1:])
+	if err != nil {
+		return 0, Multikeypair{}, err
+	}
+	return body[0], m, nil
+}
+
+// KeypairFromB58Check parses a Base58Check-encoded Multikeypair written
+// with B58CHECK_VERSION into a Keypair.
+func KeypairFromB58Check(s string) (Keypair, error) {
+	mk, err := MultikeypairFromB58Check(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return mk.Decode()
+}
+
+// The first four bytes of a double SHA-256.
+func b58CheckSum(b []byte) [4]byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	var sum [4]byte
+	copy(sum[:], second[:])
+	return sum
+}
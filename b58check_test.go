@@ -0,0 +1,61 @@
+// go-multikeypair/b58check_test.go
+
+package multikeypair
+
+import (
+	"testing"
+)
+
+// Base58Check encodings round trip.
+func TestB58Check(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := mk.B58CheckString()
+	decoded, err := KeypairFromB58Check(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(kp) {
+		t.Error("decoded keypair doesn't match")
+	}
+
+	version, _, err := MultikeypairFromB58CheckVersion(mk.B58CheckStringVersion(0x01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0x01 {
+		t.Errorf("unexpected version: %x", version)
+	}
+}
+
+// Typos and foreign version bytes are rejected.
+func TestB58CheckInvalid(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := []byte(mk.B58CheckString())
+	for _, i := range []int{0, len(s) / 2, len(s) - 1} {
+		typo := append([]byte{}, s...)
+		if typo[i] == '2' {
+			typo[i] = '3'
+		} else {
+			typo[i] = '2'
+		}
+		if _, err := MultikeypairFromB58Check(string(typo)); err == nil {
+			t.Errorf("typo at %d not detected", i)
+		}
+	}
+
+	if _, err := MultikeypairFromB58Check(mk.B58CheckStringVersion(0x01)); err != ErrB58CheckVersion {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := MultikeypairFromB58Check("0OIl"); err != ErrInvalidB58Check {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
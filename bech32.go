@@ -0,0 +1,148 @@
+// go-multikeypair/bech32.go
+//
+// Bech32 (BIP 173) and Bech32m (BIP 350) string forms of multikeypairs.
+// The format is case-insensitive and detects typos, which suits keys
+// that are read aloud or typed in. Multikeypairs are longer than the 90
+// characters BIP 173 allows for addresses, so that limit isn't applied.
+
+package multikeypair
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/proofzero/go-multikeypair/internal/bech32"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Bech32 errors this module exports.
+var (
+	ErrInvalidBech32 = errors.New("input isn't valid bech32")
+	ErrBech32HRP     = errors.New("unexpected bech32 human-readable part")
+)
+
+// Constants
+// -----------------------------------------------------------------------------
+
+// Checksum constants distinguishing the two variants.
+const (
+	bech32Const  = uint32(1)
+	bech32mConst = uint32(0x2bc830a3)
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Bech32String generates a Bech32m-encoded version of a Multikeypair with
+// the given human-readable part, e.g. "mkp".
+func (m Multikeypair) Bech32String(hrp string) (string, error) {
+	return bech32Encode(hrp, m, bech32mConst)
+}
+
+// PublicBech32String generates a Bech32m-encoded version of the public
+// half of a Keypair, omitting the private key.
+func (k Keypair) PublicBech32String(hrp string) (string, error) {
+	public, err := Encode(nil, k.Public, k.Code)
+	if err != nil {
+		return "", err
+	}
+	return public.Bech32String(hrp)
+}
+
+// MultikeypairFromBech32 parses a Bech32m- or Bech32-encoded Multikeypair,
+// returning its human-readable part.
+func MultikeypairFromBech32(s string) (string, Multikeypair, error) {
+	hrp, data, _, err := bech32Decode(s)
+	if err != nil {
+		return "", Multikeypair{}, err
+	}
+	m, err := castKeypair(data)
+	if err != nil {
+		return "", Multikeypair{}, err
+	}
+	return hrp, m, nil
+}
+
+// KeypairFromBech32 parses a Bech32m- or Bech32-encoded Multikeypair into
+// a Keypair, checking that its human-readable part is hrp.
+func KeypairFromBech32(s string, hrp string) (Keypair, error) {
+	got, m, err := MultikeypairFromBech32(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	if got != strings.ToLower(hrp) {
+		return Keypair{}, ErrBech32HRP
+	}
+	return m.Decode()
+}
+
+func bech32Encode(hrp string, data []byte, constant uint32) (string, error) {
+	hrp = strings.ToLower(hrp)
+	if len(hrp) == 0 || len(hrp) > 83 {
+		return "", ErrBech32HRP
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", ErrBech32HRP
+		}
+	}
+
+	values, _ := bech32.ConvertBits(data, 8, 5, true)
+	check := append(bech32.HRPExpand(hrp), values...)
+	check = append(check, 0, 0, 0, 0, 0, 0)
+	mod := bech32.Polymod(check) ^ constant
+
+	var sb strings.Builder
+	sb.Grow(len(hrp) + 1 + len(values) + 6)
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32.CHARSET[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32.CHARSET[(mod>>(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// Decode a Bech32 or Bech32m string, returning which checksum constant
+// it was made with.
+func bech32Decode(s string) (string, []byte, uint32, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, ErrInvalidBech32
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, 0, ErrInvalidBech32
+	}
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, ErrInvalidBech32
+		}
+	}
+
+	values := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32.CHARSET, s[i])
+		if v < 0 {
+			return "", nil, 0, ErrInvalidBech32
+		}
+		values = append(values, byte(v))
+	}
+
+	mod := bech32.Polymod(append(bech32.HRPExpand(hrp), values...))
+	if mod != bech32Const && mod != bech32mConst {
+		return "", nil, 0, ErrChecksumMismatch
+	}
+
+	data, ok := bech32.ConvertBits(values[:len(values)-6], 5, 8, false)
+	if !ok {
+		return "", nil, 0, ErrInvalidBech32
+	}
+	return hrp, data, mod, nil
+}
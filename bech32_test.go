@@ -0,0 +1,103 @@
+// go-multikeypair/bech32_test.go
+
+package multikeypair
+
+import (
+	"strings"
+	"testing"
+)
+
+// Bech32m encodings round trip and are case-insensitive.
+func TestBech32(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := mk.Bech32String("mkp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "mkp1") {
+		t.Errorf("unexpected prefix: %s", s)
+	}
+
+	for _, in := range []string{s, strings.ToUpper(s)} {
+		decoded, err := KeypairFromBech32(in, "mkp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(kp) {
+			t.Error("decoded keypair doesn't match")
+		}
+	}
+
+	if _, err := KeypairFromBech32(s, "other"); err != ErrBech32HRP {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Public-only rendering omits the private key.
+func TestPublicBech32(t *testing.T) {
+	kp := generateEd25519(t)
+	s, err := kp.PublicBech32String("mkpub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := KeypairFromBech32(s, "mkpub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Private) != 0 || !decoded.Equal(Keypair{Code: kp.Code, Name: kp.Name, Public: kp.Public, PublicLength: kp.PublicLength}) {
+		t.Error("unexpected public-only keypair")
+	}
+}
+
+// Typos and mixed case are rejected.
+func TestBech32Invalid(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := mk.Bech32String("mkp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typo := []byte(s)
+	if typo[10] == 'q' {
+		typo[10] = 'p'
+	} else {
+		typo[10] = 'q'
+	}
+	if _, _, err := MultikeypairFromBech32(string(typo)); err != ErrChecksumMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mixed := "MKP" + s[3:]
+	if _, _, err := MultikeypairFromBech32(mixed); err != ErrInvalidBech32 {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, _, err := MultikeypairFromBech32("mkp1b"); err != ErrInvalidBech32 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Known-answer test from BIP 350.
+func TestBech32mVector(t *testing.T) {
+	hrp, data, constant, err := bech32Decode("a1lqfn3a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hrp != "a" || len(data) != 0 || constant != bech32mConst {
+		t.Errorf("unexpected decode: %q %x", hrp, data)
+	}
+	s, err := bech32Encode("a", nil, bech32mConst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "a1lqfn3a" {
+		t.Errorf("unexpected encoding: %s", s)
+	}
+}
@@ -0,0 +1,298 @@
+// go-multikeypair/bip32.go
+//
+// BIP-32 hierarchical deterministic secp256k1 keys, and their standard
+// serialization as xprv/xpub strings, so that hierarchical keys
+// interoperate with existing wallet software. An extended key is a
+// secp256k1 Keypair plus the chain code and position needed to derive
+// its children; serialized, it is 78 bytes in Base58Check:
+//
+//	version (4, xprv or xpub) || depth (1) || parent fingerprint (4) ||
+//	child number (4, big endian) || chain code (32) ||
+//	key (33, 0x00 || scalar for xprv, compressed point for xpub)
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	b58 "github.com/mr-tron/base58/base58"
+	ripemd160 "golang.org/x/crypto/ripemd160"
+)
+
+// Version prefixes of mainnet extended private and public keys.
+const (
+	XPRV_VERSION = uint32(0x0488ade4)
+	XPUB_VERSION = uint32(0x0488b21e)
+)
+
+// BIP32_HARDENED is added to a child number to derive a hardened child,
+// which can only be derived from a private extended key.
+const BIP32_HARDENED = uint32(0x80000000)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// BIP-32 errors this module exports.
+var (
+	ErrInvalidExtendedKey = errors.New("input isn't valid BIP-32 extended key")
+	ErrInvalidSeed        = errors.New("BIP-32 seed must be 16 to 64 bytes")
+	ErrHardenedPublic     = errors.New("hardened child can't be derived from public extended key")
+	ErrInvalidChild       = errors.New("BIP-32 child key is invalid, use the next index")
+	ErrMaxDepth           = errors.New("BIP-32 extended key is at maximum depth")
+)
+
+// Length of a serialized extended key, before the checksum.
+const bip32KeySize = 78
+
+// Types
+// -----------------------------------------------------------------------------
+
+// ExtendedKey is a BIP-32 extended key: a secp256k1 Keypair with the
+// chain code and position in the hierarchy needed to derive children.
+type ExtendedKey struct {
+	// Depth in the hierarchy; 0 for a master key.
+	Depth uint8
+	// First four bytes of the parent's key identifier; zero for a master
+	// key.
+	ParentFingerprint uint32
+	// Index of this key among its parent's children, BIP32_HARDENED and
+	// above for hardened children.
+	ChildNumber uint32
+	// Chain code.
+	ChainCode [32]byte
+	// secp256k1 keypair. Public extended keys are public-only.
+	Key Keypair
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewMasterKey derives the master extended key for a seed of 16 to 64
+// bytes, such as a BIP-39 mnemonic's seed.
+func NewMasterKey(seed []byte) (ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return ExtendedKey{}, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	sk, err := secp256k1PrivateKey(sum[:32])
+	if err != nil {
+		return ExtendedKey{}, ErrInvalidSeed
+	}
+	x := ExtendedKey{Key: secp256k1Keypair(sk)}
+	copy(x.ChainCode[:], sum[32:])
+	return x, nil
+}
+
+// IsPrivate reports whether an extended key holds its private key.
+func (x ExtendedKey) IsPrivate() bool {
+	return len(x.Key.Private) != 0
+}
+
+// Public returns the public extended key for x.
+func (x ExtendedKey) Public() ExtendedKey {
+	x.Key = Keypair{
+		Code:         x.Key.Code,
+		Name:         x.Key.Name,
+		Public:       x.Key.Public,
+		PublicLength: x.Key.PublicLength,
+	}
+	return x
+}
+
+// Fingerprint returns the first four bytes of the key identifier
+// (RIPEMD-160 of SHA-256 of the compressed public key), which children
+// record as their ParentFingerprint.
+func (x ExtendedKey) Fingerprint() uint32 {
+	first := sha256.Sum256(x.Key.Public)
+	h := ripemd160.New()
+	h.Write(first[:])
+	return binary.BigEndian.Uint32(h.Sum(nil))
+}
+
+// Child derives the child extended key with index i. Hardened children
+// (i >= BIP32_HARDENED) need a private extended key; the child of a
+// public extended key is public. In the astronomically unlikely case
+// that index i gives an invalid key, ErrInvalidChild is returned and
+// callers should move on to i+1.
+func (x ExtendedKey) Child(i uint32) (ExtendedKey, error) {
+	if x.Depth == 0xff {
+		return ExtendedKey{}, ErrMaxDepth
+	}
+	pk, err := secp256k1.ParsePubKey(x.Key.Public)
+	if err != nil {
+		return ExtendedKey{}, ErrInvalidPublicKey
+	}
+
+	mac := hmac.New(sha512.New, x.ChainCode[:])
+	if i >= BIP32_HARDENED {
+		if !x.IsPrivate() {
+			return ExtendedKey{}, ErrHardenedPublic
+		}
+		mac.Write([]byte{0})
+		mac.Write(x.Key.Private)
+	} else {
+		mac.Write(pk.SerializeCompressed())
+	}
+	mac.Write(binary.BigEndian.AppendUint32(nil, i))
+	sum := mac.Sum(nil)
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(sum[:32]); overflow {
+		return ExtendedKey{}, ErrInvalidChild
+	}
+
+	child := ExtendedKey{
+		Depth:             x.Depth + 1,
+		ParentFingerprint: x.Fingerprint(),
+		ChildNumber:       i,
+	}
+	copy(child.ChainCode[:], sum[32:])
+
+	if x.IsPrivate() {
+		sk, err := secp256k1PrivateKey(x.Key.Private)
+		if err != nil {
+			return ExtendedKey{}, err
+		}
+		tweak.Add(&sk.Key)
+		if tweak.IsZero() {
+			return ExtendedKey{}, ErrInvalidChild
+		}
+		child.Key = secp256k1Keypair(secp256k1.NewPrivateKey(&tweak))
+		return child, nil
+	}
+
+	var point, parent, result secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&tweak, &point)
+	pk.AsJacobian(&parent)
+	secp256k1.AddNonConst(&point, &parent, &result)
+	if (result.X.IsZero() && result.Y.IsZero()) || result.Z.IsZero() {
+		return ExtendedKey{}, ErrInvalidChild
+	}
+	result.ToAffine()
+	public := secp256k1.NewPublicKey(&result.X, &result.Y).SerializeCompressed()
+	child.Key = Keypair{
+		Code:         SECP_256K1,
+		Name:         codeName(SECP_256K1),
+		Public:       public,
+		PublicLength: len(public),
+	}
+	return child, nil
+}
+
+// Derive follows a path of child numbers from x, e.g. for m/44'/0'/0'
+// the path BIP32_HARDENED+44, BIP32_HARDENED, BIP32_HARDENED.
+func (x ExtendedKey) Derive(path ...uint32) (ExtendedKey, error) {
+	var err error
+	for _, i := range path {
+		if x, err = x.Child(i); err != nil {
+			return ExtendedKey{}, err
+		}
+	}
+	return x, nil
+}
+
+//
+// ENCODE
+//
+
+// String returns the xprv string of a private extended key, or the xpub
+// string of a public one.
+func (x ExtendedKey) String() string {
+	buf := make([]byte, 0, bip32KeySize+4)
+	if x.IsPrivate() {
+		buf = binary.BigEndian.AppendUint32(buf, XPRV_VERSION)
+	} else {
+		buf = binary.BigEndian.AppendUint32(buf, XPUB_VERSION)
+	}
+	buf = append(buf, x.Depth)
+	buf = binary.BigEndian.AppendUint32(buf, x.ParentFingerprint)
+	buf = binary.BigEndian.AppendUint32(buf, x.ChildNumber)
+	buf = append(buf, x.ChainCode[:]...)
+	if x.IsPrivate() {
+		buf = append(buf, 0)
+		buf = append(buf, x.Key.Private...)
+	} else {
+		buf = append(buf, x.Key.Public...)
+	}
+	sum := b58CheckSum(buf)
+	buf = append(buf, sum[:]...)
+	return b58.Encode(buf)
+}
+
+//
+// DECODE
+//
+
+// ParseExtendedKey parses an xprv or xpub string.
+func ParseExtendedKey(s string) (ExtendedKey, error) {
+	buf, err := b58.Decode(s)
+	if err != nil || len(buf) != bip32KeySize+4 {
+		return ExtendedKey{}, ErrInvalidExtendedKey
+	}
+	body, sum := buf[:bip32KeySize], buf[bip32KeySize:]
+	want := b58CheckSum(body)
+	if subtle.ConstantTimeCompare(sum, want[:]) != 1 {
+		return ExtendedKey{}, ErrChecksumMismatch
+	}
+
+	x := ExtendedKey{
+		Depth:             body[4],
+		ParentFingerprint: binary.BigEndian.Uint32(body[5:]),
+		ChildNumber:       binary.BigEndian.Uint32(body[9:]),
+	}
+	copy(x.ChainCode[:], body[13:45])
+	if x.Depth == 0 && (x.ParentFingerprint != 0 || x.ChildNumber != 0) {
+		return ExtendedKey{}, ErrInvalidExtendedKey
+	}
+
+	key := body[45:]
+	switch binary.BigEndian.Uint32(body) {
+	case XPRV_VERSION:
+		if key[0] != 0 {
+			return ExtendedKey{}, ErrInvalidExtendedKey
+		}
+		sk, err := secp256k1PrivateKey(key[1:])
+		if err != nil {
+			return ExtendedKey{}, ErrInvalidExtendedKey
+		}
+		x.Key = secp256k1Keypair(sk)
+	case XPUB_VERSION:
+		pk, err := secp256k1.ParsePubKey(key)
+		if err != nil || !bytes.Equal(pk.SerializeCompressed(), key) {
+			return ExtendedKey{}, ErrInvalidExtendedKey
+		}
+		x.Key = Keypair{
+			Code:         SECP_256K1,
+			Name:         codeName(SECP_256K1),
+			Public:       bytes.Clone(key),
+			PublicLength: len(key),
+		}
+	default:
+		return ExtendedKey{}, ErrInvalidExtendedKey
+	}
+	return x, nil
+}
+
+// Build a secp256k1 Keypair from a private key.
+func secp256k1Keypair(sk *secp256k1.PrivateKey) Keypair {
+	private := sk.Serialize()
+	public := sk.PubKey().SerializeCompressed()
+	return Keypair{
+		Code:          SECP_256K1,
+		Name:          codeName(SECP_256K1),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}
+}
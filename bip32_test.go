@@ -0,0 +1,128 @@
+// go-multikeypair/bip32_test.go
+
+package multikeypair
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Test vector 1 from BIP-32.
+var testBIP32Vector = []struct {
+	path []uint32
+	xpub string
+	xprv string
+}{
+	{
+		nil,
+		"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+	},
+	{
+		[]uint32{BIP32_HARDENED},
+		"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+		"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+	},
+	{
+		[]uint32{BIP32_HARDENED, 1},
+		"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+		"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+	},
+	{
+		[]uint32{BIP32_HARDENED, 1, BIP32_HARDENED + 2, 2, 1000000000},
+		"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+		"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76",
+	},
+}
+
+// Keys derived from the test vector's seed serialize as expected, and
+// parse back.
+func TestBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range testBIP32Vector {
+		x, err := master.Derive(v.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := x.String(); got != v.xprv {
+			t.Errorf("%v: expected %s, got %s", v.path, v.xprv, got)
+		}
+		if got := x.Public().String(); got != v.xpub {
+			t.Errorf("%v: expected %s, got %s", v.path, v.xpub, got)
+		}
+
+		parsed, err := ParseExtendedKey(v.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != v.xprv || !parsed.Key.Equal(x.Key) {
+			t.Errorf("%v: xprv didn't round trip", v.path)
+		}
+		parsed, err = ParseExtendedKey(v.xpub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != v.xpub || parsed.IsPrivate() {
+			t.Errorf("%v: xpub didn't round trip", v.path)
+		}
+	}
+}
+
+// Non-hardened children of a public extended key match the public halves
+// of the private key's children, but hardened ones can't be derived.
+func TestBIP32PublicDerivation(t *testing.T) {
+	x, err := ParseExtendedKey(testBIP32Vector[2].xprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := x.Derive(2, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x.Public().Derive(2, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.String() != private.Public().String() {
+		t.Errorf("expected %s, got %s", private.Public(), public)
+	}
+
+	if _, err := x.Public().Child(BIP32_HARDENED); !errors.Is(err, ErrHardenedPublic) {
+		t.Errorf("expected ErrHardenedPublic, got %v", err)
+	}
+}
+
+// Derived keys sign like any other secp256k1 keypair.
+func TestBIP32Sign(t *testing.T) {
+	x, err := ParseExtendedKey(testBIP32Vector[3].xprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := x.Key.Sign([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Public().Key.Verify([]byte("hello"), signature); err != nil {
+		t.Error(err)
+	}
+}
+
+// Malformed strings and seeds are refused.
+func TestBIP32Invalid(t *testing.T) {
+	if _, err := NewMasterKey(make([]byte, 8)); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("expected ErrInvalidSeed, got %v", err)
+	}
+	if _, err := ParseExtendedKey("xpub"); !errors.Is(err, ErrInvalidExtendedKey) {
+		t.Errorf("expected ErrInvalidExtendedKey, got %v", err)
+	}
+	// The last character changes the checksum.
+	s := testBIP32Vector[0].xpub
+	if _, err := ParseExtendedKey(s[:len(s)-1] + "9"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
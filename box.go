@@ -0,0 +1,189 @@
+// go-multikeypair/box.go
+//
+// Authenticated encryption between two keypairs, using NaCl's box
+// (crypto_box: X25519, XSalsa20 and Poly1305). Unlike Encrypt, the
+// recipient learns who sent the message, and either party can compute
+// the shared key. Output is the random nonce followed by the box, as
+// most NaCl bindings pass them around:
+//
+//	nonce (24) || crypto_box(message)
+//
+// Ed25519 keypairs can take part too: their keys are converted to
+// X25519 the way libsodium's crypto_sign_ed25519_{sk,pk}_to_curve25519
+// do, the private key being the clamped SHA-512 of the seed and the
+// public key the Montgomery form of the Edwards point.
+
+package multikeypair
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"crypto/sha512"
+
+	"filippo.io/edwards25519"
+	box "golang.org/x/crypto/nacl/box"
+)
+
+// Size of the nonce prefixed to a box.
+const boxNonceSize = 24
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Seal encrypts and authenticates message from sender, whose private key
+// is needed, to recipient, whose public key is needed. Both keypairs must
+// be X25519 or Ed25519 and permit USAGE_ENCRYPT.
+func Seal(sender Keypair, recipient Keypair, message []byte) ([]byte, error) {
+	shared, err := boxKey(sender, recipient)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [boxNonceSize]byte
+	if _, err := crypto_rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return box.SealAfterPrecomputation(nonce[:], message, &nonce, shared), nil
+}
+
+// Open checks and decrypts a box made by Seal, using the recipient's
+// private key and the sender's public key.
+func Open(recipient Keypair, sender Keypair, sealed []byte) ([]byte, error) {
+	shared, err := boxKey(recipient, sender)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < boxNonceSize+box.Overhead {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce := (*[boxNonceSize]byte)(sealed[:boxNonceSize])
+	message, ok := box.OpenAfterPrecomputation(nil, sealed[boxNonceSize:], nonce, shared)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return message, nil
+}
+
+// Compute the box key shared between our private key and their public
+// key.
+func boxKey(ours Keypair, theirs Keypair) (*[32]byte, error) {
+	if err := ours.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	if err := theirs.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	private, err := x25519Private(ours)
+	if err != nil {
+		return nil, err
+	}
+	public, err := x25519Public(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse low-order public keys, which would give an all-zero secret
+	// that box itself doesn't check for.
+	sk, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	pk, err := ecdh.X25519().NewPublicKey(public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if _, err := sk.ECDH(pk); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	var shared [32]byte
+	box.Precompute(&shared, (*[32]byte)(public), (*[32]byte)(private))
+	return &shared, nil
+}
+
+//
+// ED25519 TO X25519
+//
+
+// ToX25519 converts an Ed25519 keypair to the X25519 keypair for the
+// same identity, for key agreement: the private key if there is one, and
+// the public key. The metadata is kept, so the converted key is bound by
+// the same usage and validity. X25519 keypairs are returned unchanged.
+func (k Keypair) ToX25519() (Keypair, error) {
+	if k.Code == X_25519 {
+		return k, nil
+	}
+	public, err := x25519Public(k)
+	if err != nil {
+		return Keypair{}, err
+	}
+	var private []byte
+	if len(k.Private) != 0 {
+		if private, err = x25519Private(k); err != nil {
+			return Keypair{}, err
+		}
+	}
+	return Keypair{
+		Code:          X_25519,
+		Name:          codeName(X_25519),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+		Metadata:      k.Metadata,
+	}, nil
+}
+
+// The X25519 private key of an X25519 or Ed25519 keypair.
+func x25519Private(k Keypair) ([]byte, error) {
+	switch k.Code {
+	case X_25519:
+		if len(k.Private) != 32 {
+			return nil, ErrInvalidPrivateKey
+		}
+		return k.Private, nil
+	case ED_25519:
+		if len(k.Private) != ed25519.PrivateKeySize {
+			return nil, ErrInvalidPrivateKey
+		}
+		return ed25519ToX25519Private(ed25519.PrivateKey(k.Private).Seed()), nil
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// The X25519 public key of an X25519 or Ed25519 keypair.
+func x25519Public(k Keypair) ([]byte, error) {
+	switch k.Code {
+	case X_25519:
+		if len(k.Public) != 32 {
+			return nil, ErrInvalidPublicKey
+		}
+		return k.Public, nil
+	case ED_25519:
+		return ed25519ToX25519Public(k.Public)
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// The X25519 scalar Ed25519 itself uses for a seed: the clamped first
+// half of its SHA-512.
+func ed25519ToX25519Private(seed []byte) []byte {
+	digest := sha512.Sum512(seed)
+	secret := digest[:32]
+	secret[0] &= 248
+	secret[31] &= 127
+	secret[31] |= 64
+	return secret
+}
+
+// The Montgomery u-coordinate of an Ed25519 public key.
+func ed25519ToX25519Public(public []byte) ([]byte, error) {
+	if len(public) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	p, err := new(edwards25519.Point).SetBytes(public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return p.BytesMontgomery(), nil
+}
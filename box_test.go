@@ -0,0 +1,165 @@
+// go-multikeypair/box_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Ed25519 keys convert to the X25519 keys libsodium's test suite expects.
+func TestEd25519ToX25519(t *testing.T) {
+	seed, _ := hex.DecodeString("421151a459faeade3d247115f94aedae42318124095afabe4d1451a559faedee")
+	private := ed25519.NewKeyFromSeed(seed)
+	kp := Keypair{
+		Code:    ED_25519,
+		Private: private,
+		Public:  private.Public().(ed25519.PublicKey),
+	}
+
+	sk, err := x25519Private(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(sk); got != "8052030376d47112be7f73ed7a019293dd12ad910b654455798b4667d73de166" {
+		t.Errorf("unexpected private key %s", got)
+	}
+	pk, err := x25519Public(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(pk); got != "f1814f0e8ff1043d8a44d25babff3cedcae6c22c3edaa48f857ae70de2baae50" {
+		t.Errorf("unexpected public key %s", got)
+	}
+}
+
+// ToX25519 converts Ed25519 keypairs, and public keys alone, to X25519
+// keypairs that agree on a box with the originals.
+func TestToX25519(t *testing.T) {
+	sender, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := sender.ToX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Code != X_25519 || converted.Name != Codes[X_25519] {
+		t.Errorf("unexpected cipher %s", converted.Name)
+	}
+	public, err := recipient.publicOnly().ToX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public.Private) != 0 || public.PublicLength != 32 {
+		t.Errorf("unexpected public-only conversion %+v", public)
+	}
+
+	sealed, err := Seal(converted, public, []byte("attack at dawn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(recipient, sender, sealed); err != nil {
+		t.Error(err)
+	}
+
+	same, err := converted.ToX25519()
+	if err != nil || !same.Equal(converted) {
+		t.Errorf("X25519 keypair changed by conversion: %v", err)
+	}
+
+	// Conversion doesn't shed usage restrictions.
+	sender.Metadata.Usage = USAGE_SIGN
+	restricted, err := sender.ToX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Seal(restricted, public, []byte("attack at dawn")); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+
+	p256, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p256.ToX25519(); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+}
+
+// Boxes open with the recipient's private key and the sender's public
+// key, whichever mix of X25519 and Ed25519 keys is used.
+func TestSealOpen(t *testing.T) {
+	message := []byte("attack at dawn")
+	for _, codes := range [][2]uint64{{X_25519, X_25519}, {ED_25519, X_25519}, {ED_25519, ED_25519}} {
+		sender, err := Generate(codes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipient, err := Generate(codes[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sealed, err := Seal(sender, recipient, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opened, err := Open(recipient, sender, sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(opened, message) {
+			t.Errorf("expected %q, got %q", message, opened)
+		}
+
+		// The sender can open its own box too, since the key is shared.
+		if _, err := Open(sender, recipient, sealed); err != nil {
+			t.Error(err)
+		}
+
+		other, err := Generate(codes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(recipient, other, sealed); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("expected ErrDecryptionFailed for wrong sender, got %v", err)
+		}
+		if _, err := Open(recipient, sender, sealed[:20]); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+		}
+	}
+}
+
+// Low-order public keys, other ciphers and restricted keys are refused.
+func TestSealRefused(t *testing.T) {
+	sender, err := Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := Keypair{Code: X_25519, Public: make([]byte, 32)}
+	if _, err := Seal(sender, zero, nil); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("expected ErrInvalidPublicKey, got %v", err)
+	}
+
+	p256, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Seal(sender, p256, nil); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+
+	sender.Metadata.Usage = USAGE_SIGN
+	if _, err := Seal(sender, sender, nil); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
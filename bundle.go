@@ -0,0 +1,215 @@
+// go-multikeypair/bundle.go
+//
+// A flat container packing unrelated, labelled keypairs (e.g. a signing
+// key, an encryption key, and a TLS key) into a single blob.
+
+package multikeypair
+
+import (
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Bundle-specific errors this module exports.
+var (
+	ErrInvalidKeybundle = errors.New("input isn't valid keybundle")
+	ErrDuplicateLabel   = errors.New("keybundle label already in use")
+	ErrInvalidLabel     = errors.New("keybundle label must be 1-255 bytes")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Keybundle is an ordered list of labelled Keypairs with no relationship
+// between them.
+type Keybundle struct {
+	Entries []BundleEntry
+}
+
+// BundleEntry is a single labelled Keypair in a Keybundle.
+type BundleEntry struct {
+	// Label unique within the bundle, e.g. "signing".
+	Label string
+	// The keypair itself.
+	Keypair Keypair
+	// The encoding of a wrapped keypair, which Encode writes in place of
+	// Keypair. Decoding a wrapped key leaves its private half out of the
+	// Keypair, so re-encoding that would lose it.
+	Wrapped Multikeypair
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Add appends a Keypair to the bundle under a new label.
+func (b *Keybundle) Add(label string, kp Keypair) error {
+	if len(label) == 0 || len(label) > 0xff {
+		return ErrInvalidLabel
+	}
+	if _, ok := b.Get(label); ok {
+		return ErrDuplicateLabel
+	}
+	b.Entries = append(b.Entries, BundleEntry{Label: label, Keypair: kp})
+	return nil
+}
+
+// AddWrapped appends a Multikeypair with a wrapped private key to the
+// bundle under a new label. Its Keypair is public-only; the wrapped key
+// is kept as it is.
+func (b *Keybundle) AddWrapped(label string, m Multikeypair) error {
+	if !m.IsWrapped() {
+		return ErrNotWrapped
+	}
+	kp, err := m.Decode()
+	if err != nil {
+		return err
+	}
+	if err := b.Add(label, kp); err != nil {
+		return err
+	}
+	b.Entries[len(b.Entries)-1].Wrapped = m
+	return nil
+}
+
+// Get returns the Keypair stored under a label.
+func (b Keybundle) Get(label string) (Keypair, bool) {
+	for _, e := range b.Entries {
+		if e.Label == label {
+			return e.Keypair, true
+		}
+	}
+	return Keypair{}, false
+}
+
+// Labels returns the labels in the bundle, in order.
+func (b Keybundle) Labels() []string {
+	labels := make([]string, len(b.Entries))
+	for i, e := range b.Entries {
+		labels[i] = e.Label
+	}
+	return labels
+}
+
+//
+// ENCODE
+//
+
+// Encode packs a Keybundle into bytes with the following form:
+//
+//	[length] (32-bit length prefix)
+//	  [entry length]<entry> (32-bit length prefix)
+//	  ...
+//
+// where each entry is:
+//
+//	[label length]<label> (8-bit length prefix)
+//	<multikeypair>
+func (b Keybundle) Encode() ([]byte, error) {
+	entries := make([]Multikeypair, len(b.Entries))
+	seen := make(map[string]bool, len(b.Entries))
+	for i, e := range b.Entries {
+		if len(e.Label) == 0 || len(e.Label) > 0xff {
+			return nil, ErrInvalidLabel
+		}
+		if seen[e.Label] {
+			return nil, ErrDuplicateLabel
+		}
+		seen[e.Label] = true
+
+		if len(e.Wrapped) != 0 {
+			entries[i] = e.Wrapped
+			continue
+		}
+		mk, err := e.Keypair.Encode()
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = mk
+	}
+
+	var builder cryptobyte.Builder
+
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		for i, e := range b.Entries {
+			builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+				builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+					builder.AddBytes([]byte(e.Label))
+				})
+				builder.AddBytes(entries[i])
+			})
+		}
+	})
+
+	return builder.Bytes()
+}
+
+//
+// DECODE
+//
+
+// DecodeKeybundle unpacks an encoded Keybundle. As with Decode, key
+// slices in the result alias buf, and so do the encodings of wrapped
+// keys.
+func DecodeKeybundle(buf []byte) (Keybundle, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !readUint32LengthPrefixed(&input, &values) || !input.Empty() {
+		return Keybundle{}, ErrInvalidKeybundle
+	}
+
+	var b Keybundle
+	seen := make(map[string]bool)
+	for !values.Empty() {
+		var entry, label cryptobyte.String
+		if !readUint32LengthPrefixed(&values, &entry) ||
+			!entry.ReadUint8LengthPrefixed(&label) ||
+			len(label) == 0 {
+			return Keybundle{}, ErrInvalidKeybundle
+		}
+		if seen[string(label)] {
+			return Keybundle{}, ErrDuplicateLabel
+		}
+		seen[string(label)] = true
+
+		mk := Multikeypair(entry)
+		kp, err := Decode(mk)
+		if err != nil {
+			return Keybundle{}, err
+		}
+		e := BundleEntry{Label: string(label), Keypair: kp}
+		if mk.IsWrapped() {
+			e.Wrapped = mk
+		}
+		b.Entries = append(b.Entries, e)
+	}
+
+	return b, nil
+}
+
+//
+// Base-58
+//
+
+// B58String generates a base58-encoded version of a Keybundle.
+func (b Keybundle) B58String() (string, error) {
+	buf, err := b.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(buf), nil
+}
+
+// KeybundleFromB58 parses a base58-encoded Keybundle.
+func KeybundleFromB58(s string) (Keybundle, error) {
+	buf, err := b58.Decode(s)
+	if err != nil {
+		return Keybundle{}, ErrInvalidKeybundle
+	}
+	return DecodeKeybundle(buf)
+}
@@ -0,0 +1,120 @@
+// go-multikeypair/bundle_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Bundles of unrelated keys round trip with their labels.
+func TestKeybundle(t *testing.T) {
+	signing := generateEd25519(t)
+	pq := generateMLDSA65(t)
+
+	var b Keybundle
+	if err := b.Add("signing", signing); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add("post-quantum", pq); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add("signing", pq); err != ErrDuplicateLabel {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := b.Add("", pq); err != ErrInvalidLabel {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	s, err := b.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := KeybundleFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := decoded.Labels()
+	if len(labels) != 2 || labels[0] != "signing" || labels[1] != "post-quantum" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+	if kp, ok := decoded.Get("signing"); !ok || !kp.Equal(signing) {
+		t.Error("signing key mismatch")
+	}
+	if kp, ok := decoded.Get("post-quantum"); !ok || !kp.Equal(pq) {
+		t.Error("post-quantum key mismatch")
+	}
+	if _, ok := decoded.Get("tls"); ok {
+		t.Error("unexpected entry")
+	}
+}
+
+// Wrapped keys survive a bundle round trip and unwrap afterwards.
+func TestKeybundleWrapped(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x42}, 32)
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := mk.Wrap(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b Keybundle
+	if err := b.AddWrapped("signing", mk); err != ErrNotWrapped {
+		t.Errorf("expected ErrNotWrapped, got %v", err)
+	}
+	if err := b.AddWrapped("signing", wrapped); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := b.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeKeybundle(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Re-encode the decoded bundle, as an application updating it would.
+	if buf, err = decoded.Encode(); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err = DecodeKeybundle(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	e := decoded.Entries[0]
+	if len(e.Keypair.Private) != 0 || !e.Wrapped.IsWrapped() {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	unwrapped, err := e.Wrapped.Unwrap(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, mk) {
+		t.Error("wrapped key didn't round trip")
+	}
+}
+
+// An empty bundle is valid; malformed ones are not.
+func TestKeybundleInvalid(t *testing.T) {
+	buf, err := Keybundle{}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := DecodeKeybundle(buf); err != nil || len(b.Entries) != 0 {
+		t.Errorf("unexpected result: %v, %v", b, err)
+	}
+
+	for _, in := range [][]byte{
+		nil,
+		{0x00, 0x00, 0x00, 0x05},
+		{0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00},
+	} {
+		if _, err := DecodeKeybundle(in); err != ErrInvalidKeybundle {
+			t.Errorf("%x: unexpected error: %v", in, err)
+		}
+	}
+}
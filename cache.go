@@ -0,0 +1,259 @@
+// go-multikeypair/cache.go
+//
+// Caching of public keys and verification results for keys that live in
+// a remote backend (KMS, HSM, remote signer), so that verification-heavy
+// services don't need a round trip per signature. A RemoteBackend is a
+// PublicKeySource for its resources as it stands; RemotePublicKeys
+// resolves full references, and keystore.PublicKeys serves a Keyring.
+
+package multikeypair
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Once the verification cache grows past this many entries, expired
+// entries are swept out on insert.
+const verifiedSweepSize = 1024
+
+// Types
+// -----------------------------------------------------------------------------
+
+// PublicKeySource fetches public keys by identifier from a backend.
+type PublicKeySource interface {
+	// PublicKey returns a Keypair holding (at least) the public half of
+	// the key with the given identifier.
+	PublicKey(ctx context.Context, id string) (Keypair, error)
+}
+
+var _ PublicKeySource = RemoteBackend(nil)
+
+// KeyCache caches the public keys fetched from a PublicKeySource, and
+// the outcome of successful signature verifications made with them, for
+// a fixed time-to-live. A KeyCache is itself a PublicKeySource, and is
+// safe for concurrent use.
+type KeyCache struct {
+	source PublicKeySource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	keys     map[string]cachedKey
+	verified map[verification]time.Time
+
+	// Invalidations, numbered from counter: the last one per identifier,
+	// and the last Purge. A fetch whose identifier was invalidated while
+	// it ran doesn't store its result.
+	counter     uint64
+	invalidated map[string]uint64
+	purged      uint64
+}
+
+// A cached public key and its expiry time.
+type cachedKey struct {
+	keypair Keypair
+	expires time.Time
+}
+
+// A PublicKeySource for remote key references, of the form
+// <scheme>:<resource>.
+type remoteSource struct{}
+
+// A successful verification: key identifier plus digest of the message
+// and signature that verified.
+type verification struct {
+	id     string
+	digest [sha256.Size]byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewKeyCache wraps source with a cache whose entries live for ttl.
+func NewKeyCache(source PublicKeySource, ttl time.Duration) *KeyCache {
+	return &KeyCache{
+		source:      source,
+		ttl:         ttl,
+		now:         time.Now,
+		keys:        make(map[string]cachedKey),
+		verified:    make(map[verification]time.Time),
+		invalidated: make(map[string]uint64),
+	}
+}
+
+// RemotePublicKeys returns a PublicKeySource that fetches keys by their
+// remote reference from the backend registered for its scheme.
+func RemotePublicKeys() PublicKeySource {
+	return remoteSource{}
+}
+
+func (remoteSource) PublicKey(ctx context.Context, reference string) (Keypair, error) {
+	backend, resource, err := lookupRemote(reference)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return backend.PublicKey(ctx, resource)
+}
+
+// PublicKey returns the public key for id, fetching it from the
+// underlying source if it isn't cached or has expired. Errors from the
+// source are not cached, nor are keys fetched while id was invalidated.
+func (c *KeyCache) PublicKey(ctx context.Context, id string) (Keypair, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.keys[id]
+	generation := c.generation(id)
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.keypair, nil
+	}
+
+	kp, err := c.source.PublicKey(ctx, id)
+	if err != nil {
+		return Keypair{}, err
+	}
+
+	c.mu.Lock()
+	if c.generation(id) == generation {
+		c.keys[id] = cachedKey{keypair: kp, expires: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+
+	return kp, nil
+}
+
+// Verify checks a signature over message using the public key for id.
+// Successful verifications are remembered, so repeating one within the
+// time-to-live is answered from the cache; failures are never cached.
+func (c *KeyCache) Verify(ctx context.Context, id string, message []byte, signature []byte) error {
+	now := c.now()
+	v := verification{id: id, digest: verificationDigest(message, signature)}
+
+	c.mu.Lock()
+	expires, ok := c.verified[v]
+	generation := c.generation(id)
+	c.mu.Unlock()
+	if ok && now.Before(expires) {
+		return nil
+	}
+
+	kp, err := c.PublicKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := kp.Verify(message, signature); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation(id) != generation {
+		return nil
+	}
+	if len(c.verified) >= verifiedSweepSize {
+		for k, exp := range c.verified {
+			if !now.Before(exp) {
+				delete(c.verified, k)
+			}
+		}
+	}
+	c.verified[v] = now.Add(c.ttl)
+
+	return nil
+}
+
+// Rotate replaces the cached key for id with the successor named by a
+// verified Rotation, dropping verifications made with the old key. The
+// rotation must retire the key currently known for id; if the source
+// already serves the successor, the rotation only clears the cache.
+func (c *KeyCache) Rotate(ctx context.Context, id string, r Rotation) error {
+	if err := r.Verify(); err != nil {
+		return err
+	}
+	current, err := c.PublicKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	next := r.Next
+	switch {
+	case sameKey(current, r.Previous):
+	case sameKey(current, r.Next):
+		next = current
+	default:
+		return ErrBrokenRotation
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(id)
+	c.keys[id] = cachedKey{keypair: next, expires: c.now().Add(c.ttl)}
+	return nil
+}
+
+// Invalidate drops the cached public key for id along with any
+// verification results made with it. Call this when the key is rotated
+// or revoked in the backend.
+func (c *KeyCache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(id)
+}
+
+// Drop a key and its verifications, and number the invalidation. The
+// caller holds c.mu.
+func (c *KeyCache) invalidate(id string) {
+	c.counter++
+	c.invalidated[id] = c.counter
+	delete(c.keys, id)
+	for k := range c.verified {
+		if k.id == id {
+			delete(c.verified, k)
+		}
+	}
+}
+
+// Purge drops everything in the cache.
+func (c *KeyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = make(map[string]cachedKey)
+	c.verified = make(map[verification]time.Time)
+	c.counter++
+	c.purged = c.counter
+	c.invalidated = make(map[string]uint64)
+}
+
+// The number of the last invalidation affecting id, which changes
+// whenever the cache forgets it. The caller holds c.mu.
+func (c *KeyCache) generation(id string) uint64 {
+	return max(c.invalidated[id], c.purged)
+}
+
+// Report whether two keypairs hold the same public key.
+func sameKey(a, b Keypair) bool {
+	return a.Code == b.Code && bytes.Equal(a.Public, b.Public)
+}
+
+// Digest a message and signature for use as a cache key. Both are length
+// prefixed so that moving bytes between them changes the digest.
+func verificationDigest(message []byte, signature []byte) [sha256.Size]byte {
+	h := sha256.New()
+	var n [8]byte
+	for _, b := range [][]byte{message, signature} {
+		binary.BigEndian.PutUint64(n[:], uint64(len(b)))
+		h.Write(n[:])
+		h.Write(b)
+	}
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+	return digest
+}
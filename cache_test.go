@@ -0,0 +1,209 @@
+// go-multikeypair/cache_test.go
+
+package multikeypair
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// A PublicKeySource that counts how often it is asked for a key.
+type countingSource struct {
+	keys  map[string]Keypair
+	calls int
+}
+
+func (s *countingSource) PublicKey(ctx context.Context, id string) (Keypair, error) {
+	s.calls++
+	kp, ok := s.keys[id]
+	if !ok {
+		return Keypair{}, ErrUnknownCode
+	}
+	return kp, nil
+}
+
+// Public keys are served from the cache until they expire.
+func TestKeyCachePublicKey(t *testing.T) {
+	kp := generateEd25519(t)
+	source := &countingSource{keys: map[string]Keypair{"key": kp}}
+	now := time.Unix(0, 0)
+	cache := NewKeyCache(source, time.Minute)
+	ctx := context.Background()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.PublicKey(ctx, "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("expected 1 fetch, got %d", source.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.PublicKey(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 2 {
+		t.Errorf("expected refetch after expiry, got %d fetches", source.calls)
+	}
+
+	if _, err := cache.PublicKey(ctx, "missing"); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("expected source error, got: %v", err)
+	}
+}
+
+// Verifications are cached, failures aren't, and invalidation forces a
+// refetch.
+func TestKeyCacheVerify(t *testing.T) {
+	kp := generateEd25519(t)
+	source := &countingSource{keys: map[string]Keypair{"key": kp}}
+	cache := NewKeyCache(source, time.Minute)
+	ctx := context.Background()
+
+	message := []byte("message")
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := cache.Verify(ctx, "key", message, sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.Verify(ctx, "key", []byte("other"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+	if source.calls != 1 {
+		t.Errorf("expected 1 fetch, got %d", source.calls)
+	}
+
+	// Rotate the key in the backend; the old signature no longer verifies.
+	source.keys["key"] = generateEd25519(t)
+	cache.Invalidate("key")
+	if err := cache.Verify(ctx, "key", message, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature after rotation, got: %v", err)
+	}
+	if source.calls != 2 {
+		t.Errorf("expected refetch after invalidation, got %d fetches", source.calls)
+	}
+}
+
+// A PublicKeySource that waits for a signal before answering.
+type blockingSource struct {
+	key     Keypair
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSource) PublicKey(ctx context.Context, id string) (Keypair, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return s.key, nil
+}
+
+// A key fetched while it was invalidated is returned but not cached, so
+// the invalidation isn't undone.
+func TestKeyCacheInvalidateDuringFetch(t *testing.T) {
+	source := &blockingSource{
+		key:     generateEd25519(t),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	cache := NewKeyCache(source, time.Minute)
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := cache.PublicKey(ctx, "key")
+		done <- err
+	}()
+	<-source.started
+	cache.Invalidate("key")
+	close(source.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_, err := cache.PublicKey(ctx, "key")
+		done <- err
+	}()
+	select {
+	case <-source.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale key was cached")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A rotation replaces the cached key with its successor, but only if it
+// retires the key the cache knows.
+func TestKeyCacheRotate(t *testing.T) {
+	old := generateEd25519(t)
+	source := &countingSource{keys: map[string]Keypair{"key": old}}
+	cache := NewKeyCache(source, time.Minute)
+	ctx := context.Background()
+
+	message := []byte("message")
+	sig, err := old.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Verify(ctx, "key", message, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	next, r, err := Rotate(old, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger := generateEd25519(t)
+	_, forged, err := Rotate(stranger, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Rotate(ctx, "key", forged); !errors.Is(err, ErrBrokenRotation) {
+		t.Errorf("expected ErrBrokenRotation, got: %v", err)
+	}
+	if err := cache.Rotate(ctx, "key", r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Verify(ctx, "key", message, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature after rotation, got: %v", err)
+	}
+	sig, err = next.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Verify(ctx, "key", message, sig); err != nil {
+		t.Error(err)
+	}
+	if source.calls != 1 {
+		t.Errorf("expected 1 fetch, got %d", source.calls)
+	}
+}
+
+// Remote references are resolved through their registered backend.
+func TestKeyCacheRemote(t *testing.T) {
+	kp := generateEd25519(t)
+	_, scheme := registerMemory(t, map[string]Keypair{"key/1": kp})
+	cache := NewKeyCache(RemotePublicKeys(), time.Minute)
+	ctx := context.Background()
+
+	got, err := cache.PublicKey(ctx, scheme+":key/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKey(got, kp) {
+		t.Errorf("unexpected key %v", got)
+	}
+	if _, err := cache.PublicKey(ctx, "unregistered:key/1"); !errors.Is(err, ErrUnknownRemoteBackend) {
+		t.Errorf("expected ErrUnknownRemoteBackend, got: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// go-multikeypair/canonical.go
+//
+// Canonical encodings. Decoding is lenient, so one keypair can be
+// encoded in several ways that all decode to it; the canonical encoding
+// is the single one of them that Encode produces, so that encodings can
+// be compared, hashed and used as map keys. An encoding is canonical
+// when:
+//
+//   - it uses the v1 layout if both keys fit it, and v2 otherwise;
+//   - the cipher code is a minimal varint, with nothing after it;
+//   - optional fields are in ascending tag order, application tags in
+//     name order, except a checksum, which comes last.
+//
+// Canonical re-encodes a Multikeypair in this form, and decoding with
+// DecodeOptions.Strict refuses anything else, including the v2 layout
+// for keys that would fit v1 that EncodeVersion and Migrate write on
+// request.
+
+package multikeypair
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"slices"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Canonical encoding errors this module exports.
+var (
+	ErrNonCanonical = errors.New("multikeypair isn't canonically encoded")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Canonical returns the canonical encoding of the Multikeypair, keeping
+// its optional fields and recomputing its checksum if it had one. The
+// checksum must be valid to begin with. The result never aliases m.
+func (m Multikeypair) Canonical() (Multikeypair, error) {
+	return canonicalize(m)
+}
+
+// Re-encode canonically.
+func canonicalize(m Multikeypair) (Multikeypair, error) {
+	code, private, public, rest, err := splitKeypair(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	numCode, err := UnpackCode(code)
+	if err != nil {
+		return Multikeypair{}, decodeError(FIELD_CODE, offsetIn(m, code), err)
+	}
+	if err := validCode(numCode); err != nil {
+		return Multikeypair{}, decodeError(FIELD_CODE, offsetIn(m, code), err)
+	}
+	fields, ok := splitExtensions(rest)
+	if !ok {
+		return Multikeypair{}, decodeError(FIELD_OPTIONAL, offsetIn(m, rest), ErrTrailingBytes)
+	}
+	if err := verifyChecksum(m, rest, false); err != nil {
+		return Multikeypair{}, err
+	}
+
+	out := encodeKeypairLayout(private, public, numCode, isWide(private, public))
+	var kept []extension
+	checksummed := false
+	for _, f := range fields {
+		if f.tag == TAG_CHECKSUM {
+			checksummed = true
+			continue
+		}
+		kept = append(kept, f)
+	}
+	slices.SortStableFunc(kept, compareExtensions)
+	if len(kept) > 0 {
+		if out, err = appendExtensions(out, kept...); err != nil {
+			return Multikeypair{}, err
+		}
+	}
+	if checksummed {
+		return Multikeypair(out).WithChecksum()
+	}
+	return Multikeypair(out), nil
+}
+
+// IsCanonical reports whether the Multikeypair is canonically encoded.
+func (m Multikeypair) IsCanonical() bool {
+	return checkCanonical(m) == nil
+}
+
+// Check that an encoding is canonical, reporting where it first departs
+// from the canonical encoding if not.
+func checkCanonical(buf []byte) error {
+	canonical, err := canonicalize(buf)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(canonical, buf) {
+		return nil
+	}
+	if bytes.HasPrefix(buf, versionEscape) != bytes.HasPrefix(canonical, versionEscape) {
+		return decodeError(FIELD_VERSION, 0, ErrNonCanonical)
+	}
+	i := 0
+	for i < len(buf) && i < len(canonical) && buf[i] == canonical[i] {
+		i++
+	}
+	return decodeError(FIELD_OPTIONAL, i, ErrNonCanonical)
+}
+
+// Order optional fields canonically: by tag, and application tags by
+// name. Checksums are placed separately.
+func compareExtensions(a extension, b extension) int {
+	if a.tag != b.tag || a.tag != TAG_APP {
+		return cmp.Compare(a.tag, b.tag)
+	}
+	return bytes.Compare(appTagName(a.value), appTagName(b.value))
+}
+
+// The name of an application tag field's value.
+func appTagName(value []byte) []byte {
+	if len(value) == 0 || len(value) < 1+int(value[0]) {
+		return value
+	}
+	return value[1 : 1+value[0]]
+}
@@ -0,0 +1,128 @@
+// go-multikeypair/canonical_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Encode produces canonical encodings, which Canonical leaves alone.
+func TestEncodeIsCanonical(t *testing.T) {
+	k := generateEd25519(t)
+	k.Metadata = Metadata{
+		Label:    "release signing",
+		Usage:    USAGE_SIGN,
+		NotAfter: time.Unix(1900000000, 0),
+		Tags:     map[string][]byte{"b": []byte("2"), "a": []byte("1")},
+	}
+	mk, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summed, err := mk.WithChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []Multikeypair{mk, summed} {
+		if !m.IsCanonical() {
+			t.Error("encoding isn't canonical")
+		}
+		canonical, err := m.Canonical()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(canonical, m) {
+			t.Error("canonical encoding differs")
+		}
+		if _, err := DecodeWithOptions(m, DecodeOptions{Strict: true}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Other encodings of the same keypair are normalized by Canonical and
+// refused by strict decoding, which only lets a v2 layout through.
+func TestCanonical(t *testing.T) {
+	private, public := []byte("private"), []byte("public")
+	want, err := Encode(private, public, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wide, err := EncodeVersion(private, public, ED_25519, V2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The code 0x11 as a two-byte varint.
+	padded := Multikeypair{0x00, 0x00, 0x15, 0x00, 0x02, 0x91, 0x00, 0x00, 0x07}
+	padded = append(append(padded, private...), 0x00, 0x06)
+	padded = append(padded, public...)
+
+	for name, m := range map[string]Multikeypair{"v2": wide, "padded code": padded} {
+		if m.IsCanonical() {
+			t.Errorf("%s: reported canonical", name)
+		}
+		canonical, err := m.Canonical()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(canonical, want) {
+			t.Errorf("%s: unexpected canonical encoding %x", name, canonical)
+		}
+	}
+	_, err = DecodeWithOptions(wide, DecodeOptions{Strict: true})
+	var de *DecodeError
+	if !errors.Is(err, ErrNonCanonical) || !errors.As(err, &de) || de.Field != FIELD_VERSION {
+		t.Errorf("v2: unexpected error %v", err)
+	}
+	if _, err := DecodeWithOptions(padded, DecodeOptions{Strict: true}); err == nil {
+		t.Error("padded code: strict decoding accepted it")
+	}
+}
+
+// Optional fields out of order are sorted, with the checksum moved last
+// and recomputed.
+func TestCanonicalFieldOrder(t *testing.T) {
+	k := generateEd25519(t)
+	k.Metadata = Metadata{Label: "label", Usage: USAGE_SIGN}
+	want, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, err = want.WithChecksum(); err != nil {
+		t.Fatal(err)
+	}
+
+	k.Metadata = Metadata{Usage: USAGE_SIGN}
+	mk, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := appendExtensions(mk, extension{TAG_LABEL, []byte("label")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mk, err = Multikeypair(b).WithChecksum(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = DecodeWithOptions(mk, DecodeOptions{Strict: true})
+	var de *DecodeError
+	if !errors.Is(err, ErrNonCanonical) || !errors.As(err, &de) || de.Field != FIELD_OPTIONAL {
+		t.Errorf("unexpected error %v", err)
+	}
+	canonical, err := mk.Canonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(canonical, want) {
+		t.Error("fields weren't sorted")
+	}
+
+	mk[len(mk)-1] ^= 0xff
+	if _, err := mk.Canonical(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
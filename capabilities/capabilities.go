@@ -0,0 +1,357 @@
+// go-multikeypair/capabilities/capabilities.go
+//
+// Capability delegation between multikeypairs. A parent key signs a
+// Delegation granting a child key a set of capabilities, optionally
+// restricted by caveats and a validity window; the child can in turn
+// delegate a subset of what it holds. A chain of delegations is verified
+// from a trusted root key down to the key presenting it.
+
+package capabilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Delegation-specific errors this package exports.
+var (
+	ErrInvalidDelegation = errors.New("input isn't valid delegation")
+	ErrEmptyChain        = errors.New("delegation chain is empty")
+	ErrBrokenChain       = errors.New("delegation chain is broken")
+	ErrEscalation        = errors.New("delegation grants capability issuer doesn't hold")
+	ErrNotYetValid       = errors.New("delegation not yet valid")
+	ErrExpired           = errors.New("delegation expired")
+)
+
+// Wildcard capability, covering every capability.
+const ANY = "*"
+
+// Domain separation prefix for delegation signatures.
+const delegationDomain = "multikeypair delegation v1\x00"
+
+// Delegation
+// -----------------------------------------------------------------------------
+
+// Delegation is a grant of capabilities from an issuer key to an audience
+// key, signed by the issuer.
+type Delegation struct {
+	// Public key of the delegating (parent) key.
+	Issuer multikeypair.Keypair
+	// Public key of the key receiving the capabilities.
+	Audience multikeypair.Keypair
+	// Capabilities granted, e.g. "storage/write". A trailing "/*" grants
+	// everything beneath a prefix, and "*" grants everything.
+	Capabilities []string
+	// Caveats restricting how the capabilities may be used. These are
+	// opaque to this package and accumulate down a chain.
+	Caveats []string
+	// Start of the validity window. The zero value means no start.
+	NotBefore time.Time
+	// End of the validity window. The zero value means no expiry.
+	Expiry time.Time
+	// Signature by the issuer over the encoded delegation.
+	Signature []byte
+}
+
+// Grant is the effective authority conferred by a verified chain.
+type Grant struct {
+	// Capabilities held by the final audience.
+	Capabilities []string
+	// Caveats accumulated along the chain.
+	Caveats []string
+	// Earliest expiry along the chain, or zero if none expire.
+	Expiry time.Time
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Delegate has parent grant capabilities to child, subject to caveats,
+// until expiry. Only the public half of child is recorded.
+func Delegate(
+	parent multikeypair.Keypair,
+	child multikeypair.Keypair,
+	capabilities []string,
+	caveats []string,
+	expiry time.Time,
+) (Delegation, error) {
+	d := Delegation{
+		Issuer:       publicOnly(parent),
+		Audience:     publicOnly(child),
+		Capabilities: capabilities,
+		Caveats:      caveats,
+		Expiry:       expiry,
+	}
+	payload, err := d.payload()
+	if err != nil {
+		return Delegation{}, err
+	}
+	d.Signature, err = parent.Sign(payload)
+	if err != nil {
+		return Delegation{}, err
+	}
+	return d, nil
+}
+
+// Verify checks the issuer's signature and that the delegation is within
+// its validity window at the given time.
+func (d Delegation) Verify(at time.Time) error {
+	if !d.NotBefore.IsZero() && at.Before(d.NotBefore) {
+		return ErrNotYetValid
+	}
+	if !d.Expiry.IsZero() && !at.Before(d.Expiry) {
+		return ErrExpired
+	}
+	payload, err := d.payload()
+	if err != nil {
+		return err
+	}
+	return d.Issuer.Verify(payload, d.Signature)
+}
+
+// VerifyChain verifies a chain of delegations starting at root, where
+// each delegation is issued by the audience of the one before it and
+// grants no more than its issuer holds. It returns the authority held by
+// the audience of the final delegation.
+func VerifyChain(root multikeypair.Keypair, chain []Delegation, at time.Time) (Grant, error) {
+	if len(chain) == 0 {
+		return Grant{}, ErrEmptyChain
+	}
+
+	grant := Grant{Capabilities: []string{ANY}}
+	holder := root
+	for _, d := range chain {
+		if !samePublic(holder, d.Issuer) {
+			return Grant{}, ErrBrokenChain
+		}
+		if err := d.Verify(at); err != nil {
+			return Grant{}, err
+		}
+		for _, c := range d.Capabilities {
+			if !covered(grant.Capabilities, c) {
+				return Grant{}, ErrEscalation
+			}
+		}
+		grant.Capabilities = d.Capabilities
+		grant.Caveats = append(grant.Caveats, d.Caveats...)
+		if !d.Expiry.IsZero() && (grant.Expiry.IsZero() || d.Expiry.Before(grant.Expiry)) {
+			grant.Expiry = d.Expiry
+		}
+		holder = d.Audience
+	}
+
+	return grant, nil
+}
+
+// Allows reports whether the grant includes a capability.
+func (g Grant) Allows(capability string) bool {
+	return covered(g.Capabilities, capability)
+}
+
+// Check whether a capability is covered by any of a set of held ones.
+func covered(held []string, capability string) bool {
+	for _, h := range held {
+		if h == ANY || h == capability {
+			return true
+		}
+		if strings.HasSuffix(h, "/*") && strings.HasPrefix(capability, h[:len(h)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Strip the private key from a Keypair.
+func publicOnly(k multikeypair.Keypair) multikeypair.Keypair {
+	return multikeypair.Keypair{
+		Code:         k.Code,
+		Name:         k.Name,
+		Public:       k.Public,
+		PublicLength: k.PublicLength,
+	}
+}
+
+// Check whether two Keypairs have the same public key.
+func samePublic(a multikeypair.Keypair, b multikeypair.Keypair) bool {
+	return a.Code == b.Code && bytes.Equal(a.Public, b.Public)
+}
+
+//
+// ENCODE
+//
+
+// The bytes signed by the issuer: the encoded delegation without its
+// signature, behind a domain separation prefix.
+func (d Delegation) payload() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes([]byte(delegationDomain))
+	if err := d.addFields(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes()
+}
+
+// Add the signed fields of a delegation to a builder.
+func (d Delegation) addFields(b *cryptobyte.Builder) error {
+	issuer, err := d.Issuer.Encode()
+	if err != nil {
+		return err
+	}
+	audience, err := d.Audience.Encode()
+	if err != nil {
+		return err
+	}
+
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(issuer)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(audience)
+	})
+	addStrings(b, d.Capabilities)
+	addStrings(b, d.Caveats)
+	addTime(b, d.NotBefore)
+	addTime(b, d.Expiry)
+	return nil
+}
+
+// Add a length-prefixed list of length-prefixed strings to a builder.
+func addStrings(b *cryptobyte.Builder, ss []string) {
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, s := range ss {
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(s))
+			})
+		}
+	})
+}
+
+// Add a time to a builder as 64-bit unix seconds, keeping the zero time
+// as zero.
+func addTime(b *cryptobyte.Builder, t time.Time) {
+	var buf [8]byte
+	if !t.IsZero() {
+		binary.BigEndian.PutUint64(buf[:], uint64(t.Unix()))
+	}
+	b.AddBytes(buf[:])
+}
+
+// Encode packs a Delegation into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  [issuer length]<issuer multikeypair> (16-bit length prefix)
+//	  [audience length]<audience multikeypair> (16-bit length prefix)
+//	  [capabilities length]<capabilities> (16-bit length prefix)
+//	  [caveats length]<caveats> (16-bit length prefix)
+//	  <not before> (64-bit unix seconds)
+//	  <expiry> (64-bit unix seconds)
+//	  [signature length]<signature> (16-bit length prefix)
+func (d Delegation) Encode() ([]byte, error) {
+	var b cryptobyte.Builder
+	var err error
+
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		err = d.addFields(b)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(d.Signature)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes()
+}
+
+//
+// DECODE
+//
+
+// Decode unpacks an encoded Delegation.
+func Decode(buf []byte) (Delegation, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return Delegation{}, ErrInvalidDelegation
+	}
+
+	var issuer, audience, signature cryptobyte.String
+	var notBefore, expiry time.Time
+	if !values.ReadUint16LengthPrefixed(&issuer) || !values.ReadUint16LengthPrefixed(&audience) {
+		return Delegation{}, ErrInvalidDelegation
+	}
+	capabilities, ok := readStrings(&values)
+	if !ok {
+		return Delegation{}, ErrInvalidDelegation
+	}
+	caveats, ok := readStrings(&values)
+	if !ok {
+		return Delegation{}, ErrInvalidDelegation
+	}
+	if !readTime(&values, &notBefore) ||
+		!readTime(&values, &expiry) ||
+		!values.ReadUint16LengthPrefixed(&signature) ||
+		!values.Empty() {
+		return Delegation{}, ErrInvalidDelegation
+	}
+
+	issuerKp, err := multikeypair.Decode(multikeypair.Multikeypair(issuer))
+	if err != nil {
+		return Delegation{}, err
+	}
+	audienceKp, err := multikeypair.Decode(multikeypair.Multikeypair(audience))
+	if err != nil {
+		return Delegation{}, err
+	}
+
+	return Delegation{
+		Issuer:       issuerKp,
+		Audience:     audienceKp,
+		Capabilities: capabilities,
+		Caveats:      caveats,
+		NotBefore:    notBefore,
+		Expiry:       expiry,
+		Signature:    signature,
+	}, nil
+}
+
+// Read a length-prefixed list of length-prefixed strings.
+func readStrings(s *cryptobyte.String) ([]string, bool) {
+	var list cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&list) {
+		return nil, false
+	}
+	var out []string
+	for !list.Empty() {
+		var item cryptobyte.String
+		if !list.ReadUint16LengthPrefixed(&item) {
+			return nil, false
+		}
+		out = append(out, string(item))
+	}
+	return out, true
+}
+
+// Read a time written by addTime.
+func readTime(s *cryptobyte.String, t *time.Time) bool {
+	var buf []byte
+	if !s.ReadBytes(&buf, 8) {
+		return false
+	}
+	if secs := binary.BigEndian.Uint64(buf); secs != 0 {
+		*t = time.Unix(int64(secs), 0)
+	} else {
+		*t = time.Time{}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+// go-multikeypair/capabilities/capabilities_test.go
+
+package capabilities
+
+import (
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Generate a fresh ed25519 Keypair for testing.
+func generate(t *testing.T) multikeypair.Keypair {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := multikeypair.Encode(private, public, multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := multikeypair.Decode(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// Delegate from root to child to grandchild, attenuating as we go.
+func TestVerifyChain(t *testing.T) {
+	root, child, grandchild := generate(t), generate(t), generate(t)
+	now := time.Now()
+
+	first, err := Delegate(root, child, []string{"storage/*"}, []string{"bucket=photos"}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Delegate(child, grandchild, []string{"storage/read"}, nil, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grant, err := VerifyChain(root, []Delegation{first, second}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grant.Allows("storage/read") || grant.Allows("storage/write") {
+		t.Errorf("unexpected capabilities: %v", grant.Capabilities)
+	}
+	if len(grant.Caveats) != 1 || grant.Caveats[0] != "bucket=photos" {
+		t.Errorf("unexpected caveats: %v", grant.Caveats)
+	}
+	if grant.Expiry.Unix() != now.Add(time.Minute).Unix() {
+		t.Errorf("expected earliest expiry, got: %s", grant.Expiry)
+	}
+
+	if _, err := VerifyChain(root, []Delegation{first, second}, now.Add(2*time.Minute)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected expired chain, got: %v", err)
+	}
+	if _, err := VerifyChain(child, []Delegation{first, second}, now); !errors.Is(err, ErrBrokenChain) {
+		t.Errorf("expected broken chain from wrong root, got: %v", err)
+	}
+}
+
+// A child can't delegate more than it was given.
+func TestEscalation(t *testing.T) {
+	root, child, grandchild := generate(t), generate(t), generate(t)
+
+	first, err := Delegate(root, child, []string{"storage/read"}, nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Delegate(child, grandchild, []string{"storage/write"}, nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyChain(root, []Delegation{first, second}, time.Now()); !errors.Is(err, ErrEscalation) {
+		t.Fatalf("expected escalation error, got: %v", err)
+	}
+}
+
+// A delegation survives an encode/decode round trip, and tampering with
+// it invalidates the signature.
+func TestEncodeDecode(t *testing.T) {
+	root, child := generate(t), generate(t)
+
+	d, err := Delegate(root, child, []string{"storage/*"}, []string{"bucket=photos"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(time.Now()); err != nil {
+		t.Fatalf("expected decoded delegation to verify: %s", err)
+	}
+
+	decoded.Capabilities = []string{ANY}
+	if err := decoded.Verify(time.Now()); !errors.Is(err, multikeypair.ErrInvalidSignature) {
+		t.Fatalf("expected tampered delegation to fail, got: %v", err)
+	}
+}
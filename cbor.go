@@ -0,0 +1,276 @@
+// go-multikeypair/cbor.go
+//
+// Canonical CBOR for Keypair, suitable for storing keypairs natively in
+// IPLD blocks (DAG-CBOR) and other CBOR-based protocols. The IPLD schema
+// of the representation is:
+//
+//	type Keypair struct {
+//	  code Int
+//	  public Bytes
+//	  private optional Bytes
+//	  metadata optional Bytes
+//	} representation map
+//
+// The metadata is held as the optional fields that follow the keys in a
+// multikeypair, in canonical order. Map keys are sorted length-first as
+// DAG-CBOR requires, integers and lengths use their shortest encoding,
+// and an empty private key or metadata is omitted. The decoder rejects anything that isn't in this exact form,
+// so every Keypair has exactly one CBOR encoding.
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// CBOR-specific errors this module exports.
+var (
+	ErrInvalidCBOR = errors.New("input isn't valid canonical multikeypair CBOR")
+)
+
+// CBOR major types used by the representation.
+const (
+	cborUint  = byte(0x00)
+	cborBytes = byte(0x40)
+	cborText  = byte(0x60)
+	cborMap   = byte(0xa0)
+)
+
+// Map keys, in canonical (length-first) order.
+const (
+	cborKeyCode     = "code"
+	cborKeyPublic   = "public"
+	cborKeyPrivate  = "private"
+	cborKeyMetadata = "metadata"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// ENCODE
+//
+
+// MarshalCBOR encodes a Keypair as canonical (DAG-)CBOR, private key
+// and metadata included.
+func (k Keypair) MarshalCBOR() ([]byte, error) {
+	if err := validCode(k.Code); err != nil {
+		return nil, err
+	}
+	metadata, err := encodeMetadata(k.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := 2
+	if len(k.Private) != 0 {
+		fields++
+	}
+	if len(metadata) != 0 {
+		fields++
+	}
+
+	buf := cborHeader(nil, cborMap, uint64(fields))
+	buf = cborString(buf, cborText, []byte(cborKeyCode))
+	buf = cborHeader(buf, cborUint, k.Code)
+	buf = cborString(buf, cborText, []byte(cborKeyPublic))
+	buf = cborString(buf, cborBytes, k.Public)
+	if len(k.Private) != 0 {
+		buf = cborString(buf, cborText, []byte(cborKeyPrivate))
+		buf = cborString(buf, cborBytes, k.Private)
+	}
+	if len(metadata) != 0 {
+		buf = cborString(buf, cborText, []byte(cborKeyMetadata))
+		buf = cborString(buf, cborBytes, metadata)
+	}
+
+	return buf, nil
+}
+
+// The optional fields encoding Metadata, in canonical order.
+func encodeMetadata(m Metadata) ([]byte, error) {
+	fields, err := m.fields()
+	if err != nil {
+		return nil, err
+	}
+	size, err := extensionsLen(fields)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, size)
+	for _, f := range fields {
+		buf = append(buf, f.tag, byte(len(f.value)>>8), byte(len(f.value)))
+		buf = append(buf, f.value...)
+	}
+	return buf, nil
+}
+
+// Append a CBOR header: major type plus shortest-form argument.
+func cborHeader(buf []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(buf, major|byte(arg))
+	case arg <= 0xff:
+		return append(buf, major|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), arg)
+	}
+}
+
+// Append a CBOR byte or text string.
+func cborString(buf []byte, major byte, s []byte) []byte {
+	return append(cborHeader(buf, major, uint64(len(s))), s...)
+}
+
+//
+// DECODE
+//
+
+// UnmarshalCBOR decodes a Keypair from canonical (DAG-)CBOR.
+func (k *Keypair) UnmarshalCBOR(data []byte) error {
+	d := cborDecoder{buf: data}
+
+	fields, ok := d.header(cborMap)
+	if !ok || fields < 2 || fields > 4 {
+		return ErrInvalidCBOR
+	}
+
+	var code uint64
+	var public, private []byte
+	if !d.key(cborKeyCode) {
+		return ErrInvalidCBOR
+	}
+	if code, ok = d.header(cborUint); !ok {
+		return ErrInvalidCBOR
+	}
+	if !d.key(cborKeyPublic) {
+		return ErrInvalidCBOR
+	}
+	if public, ok = d.string(cborBytes); !ok {
+		return ErrInvalidCBOR
+	}
+	// Of the optional keys, private sorts before metadata.
+	if fields == 4 || (fields == 3 && !d.peekKey(cborKeyMetadata)) {
+		if !d.key(cborKeyPrivate) {
+			return ErrInvalidCBOR
+		}
+		// An empty private key is omitted rather than encoded.
+		if private, ok = d.string(cborBytes); !ok || len(private) == 0 {
+			return ErrInvalidCBOR
+		}
+	}
+	var metadata Metadata
+	if fields == 4 || (fields == 3 && private == nil) {
+		if !d.key(cborKeyMetadata) {
+			return ErrInvalidCBOR
+		}
+		raw, ok := d.string(cborBytes)
+		if !ok {
+			return ErrInvalidCBOR
+		}
+		extensions, ok := splitExtensions(raw)
+		if !ok {
+			return ErrInvalidCBOR
+		}
+		parsed, err := parseMetadata(extensions)
+		if err != nil {
+			return ErrInvalidCBOR
+		}
+		// Empty metadata is omitted, and the rest must be in canonical
+		// order.
+		canonical, err := encodeMetadata(parsed)
+		if err != nil || len(raw) == 0 || !bytes.Equal(canonical, raw) {
+			return ErrInvalidCBOR
+		}
+		metadata = parsed
+	}
+	if len(d.buf) != 0 {
+		return ErrInvalidCBOR
+	}
+	if err := validCode(code); err != nil {
+		return err
+	}
+
+	*k = Keypair{
+		Code:          code,
+		Name:          codeName(code),
+		Public:        public,
+		PublicLength:  len(public),
+		Private:       private,
+		PrivateLength: len(private),
+		Metadata:      metadata,
+	}
+	return nil
+}
+
+// A strict decoder for the subset of CBOR the representation uses.
+type cborDecoder struct {
+	buf []byte
+}
+
+// Read a header of the given major type, insisting on shortest form.
+func (d *cborDecoder) header(major byte) (uint64, bool) {
+	if len(d.buf) < 1 || d.buf[0]&0xe0 != major {
+		return 0, false
+	}
+	info := d.buf[0] & 0x1f
+	d.buf = d.buf[1:]
+
+	var arg uint64
+	var min uint64
+	switch {
+	case info < 24:
+		return uint64(info), true
+	case info == 24 && len(d.buf) >= 1:
+		arg, min = uint64(d.buf[0]), 24
+		d.buf = d.buf[1:]
+	case info == 25 && len(d.buf) >= 2:
+		arg, min = uint64(binary.BigEndian.Uint16(d.buf)), 0x100
+		d.buf = d.buf[2:]
+	case info == 26 && len(d.buf) >= 4:
+		arg, min = uint64(binary.BigEndian.Uint32(d.buf)), 0x10000
+		d.buf = d.buf[4:]
+	case info == 27 && len(d.buf) >= 8:
+		arg, min = binary.BigEndian.Uint64(d.buf), 0x100000000
+		d.buf = d.buf[8:]
+	default:
+		return 0, false
+	}
+	if arg < min {
+		return 0, false
+	}
+	return arg, true
+}
+
+// Read a byte or text string, copying it out of the input.
+func (d *cborDecoder) string(major byte) ([]byte, bool) {
+	n, ok := d.header(major)
+	if !ok || n > uint64(len(d.buf)) {
+		return nil, false
+	}
+	s := make([]byte, n)
+	copy(s, d.buf)
+	d.buf = d.buf[n:]
+	return s, true
+}
+
+// Report whether the next map key is the given one, without reading it.
+func (d *cborDecoder) peekKey(want string) bool {
+	peek := cborDecoder{buf: d.buf}
+	return peek.key(want)
+}
+
+// Read a map key and check that it's the expected one.
+func (d *cborDecoder) key(want string) bool {
+	got, ok := d.string(cborText)
+	return ok && string(got) == want
+}
@@ -0,0 +1,95 @@
+// go-multikeypair/cbor_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Round trip a keypair through CBOR.
+func TestKeypairCBOR(t *testing.T) {
+	kp := generateEd25519(t)
+
+	data, err := kp.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Keypair
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, decoded, kp.Code, kp.Name, kp.Public, kp.Private)
+}
+
+// The encoding of a known keypair is fixed.
+func TestKeypairCBORCanonical(t *testing.T) {
+	kp := Keypair{Code: ED_25519, Public: []byte{0x01, 0x02}}
+
+	data, err := kp.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {"code": 17, "public": h'0102'}
+	want, _ := hex.DecodeString("a264636f646511667075626c6963420102")
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected encoding: %x", data)
+	}
+}
+
+// Non-canonical encodings are rejected.
+func TestKeypairCBORStrict(t *testing.T) {
+	for name, input := range map[string]string{
+		// code as a two-byte integer.
+		"long integer": "a264636f64651811667075626c6963420102",
+		// keys in the wrong order.
+		"key order": "a2667075626c696342010264636f646511",
+		// trailing garbage.
+		"trailing": "a264636f646511667075626c696342010200",
+		// explicit empty private key.
+		"empty private": "a364636f646511667075626c6963420102677072697661746540",
+	} {
+		data, _ := hex.DecodeString(input)
+		var kp Keypair
+		if err := kp.UnmarshalCBOR(data); !errors.Is(err, ErrInvalidCBOR) {
+			t.Errorf("%s: expected invalid CBOR, got: %v", name, err)
+		}
+	}
+}
+
+// Metadata round trips through CBOR, and must be in canonical order.
+func TestKeypairCBORMetadata(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{Label: "encryption", Usage: USAGE_ENCRYPT}
+	for _, private := range [][]byte{kp.Private, nil} {
+		kp.Private = private
+		data, err := kp.MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Keypair
+		if err := decoded.UnmarshalCBOR(data); err != nil {
+			t.Fatal(err)
+		}
+		validate(t, decoded, kp.Code, kp.Name, kp.Public, private)
+		if decoded.Metadata.Label != "encryption" || decoded.Metadata.Usage != USAGE_ENCRYPT {
+			t.Errorf("unexpected metadata %+v", decoded.Metadata)
+		}
+	}
+
+	for name, input := range map[string]string{
+		// {"code": 17, "public": h'0102', "metadata": h''}
+		"empty metadata": "a364636f646511667075626c6963420102686d6574616461746140",
+		// usage (tag 4) before label (tag 2).
+		"field order": "a364636f646511667075626c696342010268" + "6d65746164617461" + "4c" +
+			"04000400000002" + "020002" + "6869",
+	} {
+		data, _ := hex.DecodeString(input)
+		var kp Keypair
+		if err := kp.UnmarshalCBOR(data); !errors.Is(err, ErrInvalidCBOR) {
+			t.Errorf("%s: expected invalid CBOR, got: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// go-multikeypair/checksum.go
+//
+// An optional integrity checksum, so that a corrupted multikeypair (e.g.
+// a mistyped base58 string) is reported as corrupt rather than decoded
+// into garbage key bytes.
+
+package multikeypair
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// Length in bytes of the checksum: a truncated SHA-256 digest.
+const CHECKSUM_LENGTH = 4
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Checksum errors this module exports.
+var (
+	ErrChecksumMismatch = errors.New("multikeypair checksum mismatch")
+	ErrMissingChecksum  = errors.New("multikeypair has no checksum")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// EncodeWithChecksum encodes a keypair like Encode, followed by a
+// checksum over the whole encoding. Decode verifies the checksum when it
+// is present; decoders that predate it ignore it. Corruption can also
+// destroy the checksum itself, so callers that always write checksums
+// should decode with DecodeOptions.RequireChecksum.
+func EncodeWithChecksum(private []byte, public []byte, code uint64) (Multikeypair, error) {
+	m, err := Encode(private, public, code)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	return m.WithChecksum()
+}
+
+// WithChecksum returns a copy of the Multikeypair with a checksum
+// appended. The checksum must be the last optional field, so adding one
+// to a multikeypair that already has one is an error.
+func (m Multikeypair) WithChecksum() (Multikeypair, error) {
+	_, _, _, rest, err := splitKeypair(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	fields, ok := splitExtensions(rest)
+	if !ok {
+		return Multikeypair{}, ErrTrailingBytes
+	}
+	for _, f := range fields {
+		if f.tag == TAG_CHECKSUM {
+			return Multikeypair{}, ErrInvalidMultikeypair
+		}
+	}
+
+	out, err := appendExtensions(m, extension{TAG_CHECKSUM, make([]byte, CHECKSUM_LENGTH)})
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	sum := checksum(out[:len(out)-CHECKSUM_LENGTH])
+	copy(out[len(out)-CHECKSUM_LENGTH:], sum[:])
+	return Multikeypair(out), nil
+}
+
+// HasChecksum reports whether the Multikeypair carries a checksum.
+func (m Multikeypair) HasChecksum() bool {
+	_, _, _, rest, err := splitKeypair(m)
+	if err != nil {
+		return false
+	}
+	fields, ok := splitExtensions(rest)
+	return ok && len(fields) > 0 && fields[len(fields)-1].tag == TAG_CHECKSUM
+}
+
+// Check the checksum field, if any, of an encoding whose optional fields
+// are rest. A checksum covers every byte of the encoding before its own
+// value, and must be the last field.
+func verifyChecksum(buf []byte, rest []byte, required bool) error {
+	var buffer [8]extension
+	fields, ok := appendExtensionFields(buffer[:0], rest)
+	if !ok || len(fields) == 0 {
+		if required {
+			return decodeError(FIELD_CHECKSUM, len(buf), ErrMissingChecksum)
+		}
+		return nil
+	}
+
+	for i, f := range fields {
+		if f.tag != TAG_CHECKSUM {
+			continue
+		}
+		if i != len(fields)-1 || len(f.value) != CHECKSUM_LENGTH {
+			return decodeError(FIELD_CHECKSUM, offsetIn(buf, f.value), ErrInvalidMultikeypair)
+		}
+		sum := checksum(buf[:len(buf)-CHECKSUM_LENGTH])
+		if subtle.ConstantTimeCompare(sum[:], f.value) != 1 {
+			return decodeError(FIELD_CHECKSUM, offsetIn(buf, f.value), ErrChecksumMismatch)
+		}
+		return nil
+	}
+
+	if required {
+		return decodeError(FIELD_CHECKSUM, len(buf), ErrMissingChecksum)
+	}
+	return nil
+}
+
+// Compute the checksum of the encoded bytes.
+func checksum(b []byte) [CHECKSUM_LENGTH]byte {
+	digest := sha256.Sum256(b)
+	var sum [CHECKSUM_LENGTH]byte
+	copy(sum[:], digest[:])
+	return sum
+}
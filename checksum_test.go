@@ -0,0 +1,107 @@
+// go-multikeypair/checksum_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// Checksummed encodings round trip, including in strict mode.
+func TestChecksum(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := EncodeWithChecksum(kp.Private, kp.Public, kp.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mk.HasChecksum() {
+		t.Fatal("checksum missing")
+	}
+
+	opts := DecodeOptions{Strict: true, RequireChecksum: true}
+	decoded, err := DecodeWithOptions(mk, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(kp) {
+		t.Error("decoded keypair doesn't match")
+	}
+
+	// Accessors are unaffected by the trailing checksum.
+	public, err := mk.PublicBytes()
+	if err != nil || !bytes.Equal(public, kp.Public) {
+		t.Errorf("public key mismatch: %v", err)
+	}
+}
+
+// Corruption anywhere in the keys is detected.
+func TestChecksumCorrupt(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := EncodeWithChecksum(kp.Private, kp.Public, kp.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range []int{10, 40, len(mk) - 10, len(mk) - 1} {
+		corrupt := bytes.Clone(mk)
+		corrupt[i] ^= 0x01
+		if _, err := Decode(corrupt); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("byte %d: unexpected error: %v", i, err)
+		}
+	}
+
+	// A mistyped base58 string decodes, but is rejected. Here the typo
+	// also hits the checksum field, so only requiring one catches it.
+	s := []byte(mk.B58String())
+	i := len(s) - 20
+	if s[i] == 'x' {
+		s[i] = 'y'
+	} else {
+		s[i] = 'x'
+	}
+	raw, err := b58.Decode(string(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWithOptions(raw, DecodeOptions{RequireChecksum: true}); err == nil {
+		t.Error("expected corrupt base58 to be rejected")
+	}
+}
+
+// Checksums work with the wide layout too.
+func TestChecksumLarge(t *testing.T) {
+	public := bytes.Repeat([]byte{0x01}, MAX_V1_KEY_LENGTH+1)
+	mk, err := EncodeWithChecksum(nil, public, RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWithOptions(mk, DecodeOptions{RequireChecksum: true}); err != nil {
+		t.Fatal(err)
+	}
+	mk[len(mk)-100] ^= 0xff
+	if _, err := Decode(mk); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Checksums can be required, and can't be added twice.
+func TestChecksumRequired(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWithOptions(mk, DecodeOptions{RequireChecksum: true}); !errors.Is(err, ErrMissingChecksum) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	withSum, err := mk.WithChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := withSum.WithChecksum(); err == nil {
+		t.Error("expected second checksum to be rejected")
+	}
+}
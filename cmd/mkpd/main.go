@@ -0,0 +1,103 @@
+// go-multikeypair/cmd/mkpd/main.go
+//
+// mkpd is a key daemon: it keeps multikeypairs in a keystore and lets
+// other processes generate, sign with, derive and inspect them over gRPC
+// (see multikeypairpb/service.proto), without private keys ever leaving
+// it. It is meant to run as a sidecar, listening on a unix socket that
+// only its owner can use:
+//
+//	mkpd -keystore /var/lib/mkpd/keys -listen unix:/run/mkpd/mkpd.sock
+//
+// TCP listeners (-listen tcp:127.0.0.1:7443) have no authentication of
+// their own, so only bind them where every client may use every key.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/proofzero/go-multikeypair/keystore"
+	"github.com/proofzero/go-multikeypair/multikeypairpb"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	listen := flag.String("listen", "unix:mkpd.sock", "address to serve on, unix:<path> or tcp:<host:port>")
+	dir := flag.String("keystore", "", "keystore directory")
+	keyfile := flag.String("keyfile", "", "keyfile holding the passphrase of an encrypted keystore")
+	flag.Parse()
+
+	if err := run(*listen, *dir, *keyfile); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Open the keystore and serve it until interrupted.
+func run(listen string, dir string, keyfile string) error {
+	if dir == "" {
+		return errors.New("mkpd: -keystore is required")
+	}
+	var store *keystore.Store
+	var err error
+	if keyfile != "" {
+		store, err = keystore.OpenEncrypted(dir, keystore.EncryptionOptions{
+			Passphrase: keystore.KeyfilePassphrase(keyfile),
+		})
+	} else {
+		store, err = keystore.Open(dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	lis, err := listenOn(listen)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	multikeypairpb.RegisterKeyServiceServer(srv, newServer(store))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		srv.GracefulStop()
+	}()
+
+	log.Printf("mkpd: serving %s on %s", dir, listen)
+	return srv.Serve(lis)
+}
+
+// Listen on a unix:<path> or tcp:<host:port> address. Unix sockets are
+// made accessible to their owner only, replacing any stale socket.
+func listenOn(address string) (net.Listener, error) {
+	network, addr, ok := strings.Cut(address, ":")
+	if !ok || (network != "unix" && network != "tcp") {
+		return nil, fmt.Errorf("mkpd: invalid listen address %q", address)
+	}
+	if network == "tcp" {
+		return net.Listen(network, addr)
+	}
+
+	if err := os.Remove(addr); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	lis, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(addr, 0o600); err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return lis, nil
+}
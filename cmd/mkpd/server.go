@@ -0,0 +1,138 @@
+// go-multikeypair/cmd/mkpd/server.go
+//
+// The KeyService implementation, over any keystore.Keyring. Errors are
+// mapped onto gRPC status codes so that clients in other languages can
+// tell a missing key from a bad request.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+	"github.com/proofzero/go-multikeypair/multikeypairpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// KeyService backed by a keyring.
+type server struct {
+	multikeypairpb.UnimplementedKeyServiceServer
+	keys keystore.Keyring
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Make a KeyService serving the keys in a keyring.
+func newServer(keys keystore.Keyring) *server {
+	return &server{keys: keys}
+}
+
+// Generate creates and stores a new random key.
+func (s *server) Generate(ctx context.Context, req *multikeypairpb.GenerateRequest) (*multikeypairpb.Key, error) {
+	kp, err := multikeypair.Generate(req.GetCode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	kp.Metadata = multikeypair.Metadata{Label: req.GetLabel(), Created: time.Now()}
+	return s.store(kp)
+}
+
+// Sign signs a message with a stored key.
+func (s *server) Sign(ctx context.Context, req *multikeypairpb.SignRequest) (*multikeypairpb.SignResponse, error) {
+	signature, err := s.keys.Sign(req.GetId(), req.GetMessage())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &multikeypairpb.SignResponse{Signature: signature}, nil
+}
+
+// Derive derives and stores a subkey of a stored key.
+func (s *server) Derive(ctx context.Context, req *multikeypairpb.DeriveRequest) (*multikeypairpb.Key, error) {
+	m, err := s.keys.Get(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	defer m.Wipe()
+	master, err := m.Decode()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	subkey, err := master.DeriveSubkey(req.GetInfo(), req.GetCode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	subkey.Metadata.Created = time.Now()
+	return s.store(subkey)
+}
+
+// Inspect describes a stored key, or a multikeypair supplied by the
+// caller.
+func (s *server) Inspect(ctx context.Context, req *multikeypairpb.InspectRequest) (*multikeypairpb.Info, error) {
+	m := multikeypair.Multikeypair(req.GetMultikeypair().GetData())
+	if req.GetMultikeypair() == nil {
+		var err error
+		if m, err = s.keys.Get(req.GetId()); err != nil {
+			return nil, toStatus(err)
+		}
+		defer m.Wipe()
+	}
+	info, err := m.Inspect()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return multikeypairpb.InfoToProto(info), nil
+}
+
+// Store a keypair, wiping it, and describe it for the caller.
+func (s *server) store(kp multikeypair.Keypair) (*multikeypairpb.Key, error) {
+	defer kp.Wipe()
+	m, err := kp.Encode()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	defer m.Wipe()
+	id, err := s.keys.Put(m)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	public := multikeypair.Keypair{
+		Code:         kp.Code,
+		Name:         kp.Name,
+		Public:       kp.Public,
+		PublicLength: kp.PublicLength,
+	}
+	return &multikeypairpb.Key{Id: id, Public: multikeypairpb.KeypairToProto(public)}, nil
+}
+
+// Map an error onto a gRPC status.
+func toStatus(err error) error {
+	var code codes.Code
+	var de *multikeypair.DecodeError
+	switch {
+	case errors.Is(err, keystore.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, multikeypair.ErrUsageNotPermitted), errors.Is(err, multikeypair.ErrPolicyViolation):
+		code = codes.PermissionDenied
+	case errors.Is(err, multikeypair.ErrKeyExpired), errors.Is(err, multikeypair.ErrKeyNotYetValid):
+		code = codes.FailedPrecondition
+	case errors.Is(err, keystore.ErrWrongPassphrase):
+		code = codes.Unavailable
+	case errors.As(err, &de),
+		errors.Is(err, keystore.ErrInvalidID),
+		errors.Is(err, multikeypair.ErrUnknownCode),
+		errors.Is(err, multikeypair.ErrUnsupportedCipher),
+		errors.Is(err, multikeypair.ErrInvalidMultikeypair):
+		code = codes.InvalidArgument
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
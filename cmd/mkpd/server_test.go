@@ -0,0 +1,140 @@
+// go-multikeypair/cmd/mkpd/server_test.go
+
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+	"github.com/proofzero/go-multikeypair/multikeypairpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Serve a memory keystore over an in-process connection.
+func dial(t *testing.T) multikeypairpb.KeyServiceClient {
+	return dialKeyring(t, keystore.NewMemory(0))
+}
+
+// Serve a keyring over an in-process connection.
+func dialKeyring(t *testing.T, keys keystore.Keyring) multikeypairpb.KeyServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	multikeypairpb.RegisterKeyServiceServer(srv, newServer(keys))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return multikeypairpb.NewKeyServiceClient(conn)
+}
+
+// Keys generated and derived through the service sign messages that
+// verify against the public keys it returns.
+func TestKeyService(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t)
+
+	key, err := client.Generate(ctx, &multikeypairpb.GenerateRequest{Code: multikeypair.ED_25519, Label: "service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.GetPublic().GetPrivate()) != 0 {
+		t.Fatal("service returned a private key")
+	}
+	subkey, err := client.Derive(ctx, &multikeypairpb.DeriveRequest{Id: key.GetId(), Info: []byte("signing"), Code: multikeypair.P_256})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []*multikeypairpb.Key{key, subkey} {
+		resp, err := client.Sign(ctx, &multikeypairpb.SignRequest{Id: k.GetId(), Message: []byte("message")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := multikeypairpb.KeypairFromProto(k.GetPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := public.Verify([]byte("message"), resp.GetSignature()); err != nil {
+			t.Errorf("%s: %v", public.Name, err)
+		}
+	}
+
+	info, err := client.Inspect(ctx, &multikeypairpb.InspectRequest{Key: &multikeypairpb.InspectRequest_Id{Id: key.GetId()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GetName() != "ed25519" || info.GetLabel() != "service" || info.GetCreated() == 0 || info.GetPrivateLength() == 0 {
+		t.Errorf("unexpected info %v", info)
+	}
+}
+
+// Failures come back with status codes describing them.
+func TestKeyServiceErrors(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t)
+
+	_, err := client.Sign(ctx, &multikeypairpb.SignRequest{Id: "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown key: expected NotFound, got %v", err)
+	}
+	_, err = client.Generate(ctx, &multikeypairpb.GenerateRequest{Code: 0x7e})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown cipher: expected InvalidArgument, got %v", err)
+	}
+	_, err = client.Inspect(ctx, &multikeypairpb.InspectRequest{
+		Key: &multikeypairpb.InspectRequest_Multikeypair{Multikeypair: &multikeypairpb.Multikeypair{Data: []byte("junk")}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed multikeypair: expected InvalidArgument, got %v", err)
+	}
+}
+
+// Validity windows are reported by Inspect, and expired keys don't sign.
+func TestKeyServiceExpired(t *testing.T) {
+	ctx := context.Background()
+	keys := keystore.NewMemory(0)
+	client := dialKeyring(t, keys)
+
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter := time.Now().Add(-time.Hour).Truncate(time.Second)
+	kp.Metadata.NotAfter = notAfter
+	m, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := keys.Put(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Inspect(ctx, &multikeypairpb.InspectRequest{Key: &multikeypairpb.InspectRequest_Id{Id: id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GetNotAfter() != notAfter.Unix() || info.GetNotBefore() != 0 {
+		t.Errorf("unexpected info %v", info)
+	}
+	_, err = client.Sign(ctx, &multikeypairpb.SignRequest{Id: id, Message: []byte("message")})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expired key: expected FailedPrecondition, got %v", err)
+	}
+}
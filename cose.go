@@ -0,0 +1,521 @@
+// go-multikeypair/cose.go
+//
+// CBOR Object Signing and Encryption (RFC 9052, RFC 9053): conversion
+// between Keypairs and COSE_Key, and COSE_Sign1 messages, as used by
+// CWTs, WebAuthn and C2PA. Keys map to COSE key types as:
+//
+//	ed25519    OKP  Ed25519    EdDSA   (-8)
+//	x25519     OKP  X25519     (no signing algorithm)
+//	p256       EC2  P-256      ES256   (-7)
+//	secp256k1  EC2  secp256k1  ES256K  (-47, RFC 8812)
+//	rsa        RSA             RS256   (-257, RFC 8812)
+//
+// A COSE_Sign1 message is the tagged array
+//
+//	18([protected: bstr .cbor {1: alg}, unprotected: {}, payload: bstr, signature: bstr])
+//
+// signed over the Sig_structure ["Signature1", protected, external_aad,
+// payload]. ECDSA signatures are in the fixed-size form COSE requires.
+//
+// Keys and messages are encoded deterministically (RFC 8949 section
+// 4.2.1). The decoders accept any map order, skip labels they don't
+// know, and insist on shortest-form integers and lengths.
+
+package multikeypair
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"math/big"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// COSE-specific errors this module exports.
+var (
+	ErrInvalidCOSEKey   = errors.New("input isn't a valid COSE_Key")
+	ErrInvalidCOSESign1 = errors.New("input isn't a valid COSE_Sign1 message")
+	ErrCOSEAlgorithm    = errors.New("COSE algorithm doesn't match key")
+)
+
+// COSE key types (RFC 9053 section 7).
+const (
+	COSE_KTY_OKP = int64(1)
+	COSE_KTY_EC2 = int64(2)
+	COSE_KTY_RSA = int64(3)
+)
+
+// COSE elliptic curves (RFC 9053 section 7.1, RFC 8812).
+const (
+	COSE_CRV_P256      = int64(1)
+	COSE_CRV_X25519    = int64(4)
+	COSE_CRV_ED25519   = int64(6)
+	COSE_CRV_SECP256K1 = int64(8)
+)
+
+// COSE signature algorithms (RFC 9053, RFC 8812).
+const (
+	COSE_ALG_EDDSA  = int64(-8)
+	COSE_ALG_ES256  = int64(-7)
+	COSE_ALG_ES256K = int64(-47)
+	COSE_ALG_RS256  = int64(-257)
+)
+
+// COSEAlgorithms maps cipher codes to the COSE signature algorithm used
+// for them.
+var COSEAlgorithms = map[uint64]int64{
+	ED_25519:   COSE_ALG_EDDSA,
+	P_256:      COSE_ALG_ES256,
+	SECP_256K1: COSE_ALG_ES256K,
+	RSA:        COSE_ALG_RS256,
+}
+
+// COSE_Key labels. The key-type specific ones (negative) are shared:
+// -1 is crv for OKP and EC2 but n for RSA, and so on.
+const (
+	coseKeyKty = int64(1)
+	coseKeyAlg = int64(3)
+	coseKeyCrv = int64(-1)
+	coseKeyX   = int64(-2)
+	coseKeyY   = int64(-3)
+	coseKeyD   = int64(-4)
+
+	coseKeyN    = int64(-1)
+	coseKeyE    = int64(-2)
+	coseKeyP    = int64(-5)
+	coseKeyQ    = int64(-6)
+	coseKeyDP   = int64(-7)
+	coseKeyDQ   = int64(-8)
+	coseKeyQInv = int64(-9)
+)
+
+// Header label for the algorithm, and the COSE_Sign1 tag.
+const (
+	coseHeaderAlg = int64(1)
+	coseSign1Tag  = uint64(18)
+)
+
+// CBOR major types COSE needs beyond those of the Keypair representation.
+const (
+	cborNegative = byte(0x20)
+	cborArray    = byte(0x80)
+	cborTag      = byte(0xc0)
+	cborSimple   = byte(0xe0)
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// COSE_KEY
+//
+
+// A COSE_Key parameter; values are byte strings except for kty, alg
+// and crv.
+type coseParam struct {
+	label int64
+	value any
+}
+
+// COSEKey returns the keypair as a COSE_Key, with its private key if it
+// has one. Signing keys carry their algorithm.
+func (k Keypair) COSEKey() ([]byte, error) {
+	var params []coseParam
+	switch k.Code {
+	case ED_25519:
+		if len(k.Public) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+		params = []coseParam{{coseKeyKty, COSE_KTY_OKP}, {coseKeyCrv, COSE_CRV_ED25519}, {coseKeyX, k.Public}}
+		if len(k.Private) != 0 {
+			if len(k.Private) != ed25519.PrivateKeySize {
+				return nil, ErrInvalidPrivateKey
+			}
+			params = append(params, coseParam{coseKeyD, ed25519.PrivateKey(k.Private).Seed()})
+		}
+	case X_25519:
+		if len(k.Public) != 32 {
+			return nil, ErrInvalidPublicKey
+		}
+		params = []coseParam{{coseKeyKty, COSE_KTY_OKP}, {coseKeyCrv, COSE_CRV_X25519}, {coseKeyX, k.Public}}
+		if len(k.Private) != 0 {
+			params = append(params, coseParam{coseKeyD, k.Private})
+		}
+	case P_256, SECP_256K1:
+		point, err := uncompressedPoint(k)
+		if err != nil {
+			return nil, err
+		}
+		crv := COSE_CRV_P256
+		if k.Code == SECP_256K1 {
+			crv = COSE_CRV_SECP256K1
+		}
+		params = []coseParam{{coseKeyKty, COSE_KTY_EC2}, {coseKeyCrv, crv}, {coseKeyX, point[1:33]}, {coseKeyY, point[33:]}}
+		if len(k.Private) != 0 {
+			params = append(params, coseParam{coseKeyD, k.Private})
+		}
+	case RSA:
+		pk, err := x509.ParsePKCS1PublicKey(k.Public)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		params = []coseParam{{coseKeyKty, COSE_KTY_RSA}, {coseKeyN, pk.N.Bytes()}, {coseKeyE, big.NewInt(int64(pk.E)).Bytes()}}
+		if len(k.Private) != 0 {
+			sk, err := x509.ParsePKCS1PrivateKey(k.Private)
+			if err != nil || len(sk.Primes) != 2 {
+				return nil, ErrInvalidPrivateKey
+			}
+			params = append(params,
+				coseParam{coseKeyD, sk.D.Bytes()},
+				coseParam{coseKeyP, sk.Primes[0].Bytes()},
+				coseParam{coseKeyQ, sk.Primes[1].Bytes()},
+				coseParam{coseKeyDP, sk.Precomputed.Dp.Bytes()},
+				coseParam{coseKeyDQ, sk.Precomputed.Dq.Bytes()},
+				coseParam{coseKeyQInv, sk.Precomputed.Qinv.Bytes()},
+			)
+		}
+	default:
+		return nil, ErrUnsupportedCipher
+	}
+	if alg, ok := COSEAlgorithms[k.Code]; ok {
+		params = append(params, coseParam{coseKeyAlg, alg})
+	}
+
+	// Deterministic order sorts the encoded labels bytewise, which puts
+	// 1 and 3 first and the negative labels after them in order -1, -2,
+	// and so on.
+	buf := cborHeader(nil, cborMap, uint64(len(params)))
+	for _, labels := range [][]int64{{coseKeyKty, coseKeyAlg}, {-1, -2, -3, -4, -5, -6, -7, -8, -9}} {
+		for _, label := range labels {
+			for _, p := range params {
+				if p.label != label {
+					continue
+				}
+				buf = cborInt(buf, p.label)
+				switch v := p.value.(type) {
+				case int64:
+					buf = cborInt(buf, v)
+				case []byte:
+					buf = cborString(buf, cborBytes, v)
+				}
+			}
+		}
+	}
+	return buf, nil
+}
+
+// KeypairFromCOSEKey decodes a COSE_Key into a Keypair, public-only if
+// the key has no private part. A private key must match the public key
+// given with it.
+func KeypairFromCOSEKey(data []byte) (Keypair, error) {
+	d := cborDecoder{buf: data}
+	ints, bstrs, ok := d.coseMap()
+	if !ok || len(d.buf) != 0 {
+		return Keypair{}, ErrInvalidCOSEKey
+	}
+
+	var k Keypair
+	switch kty, crv := ints[coseKeyKty], ints[coseKeyCrv]; {
+	case kty == COSE_KTY_OKP && crv == COSE_CRV_ED25519:
+		k.Code, k.Public = ED_25519, bstrs[coseKeyX]
+		if len(k.Public) != ed25519.PublicKeySize {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+		if seed, ok := bstrs[coseKeyD]; ok {
+			if len(seed) != ed25519.SeedSize {
+				return Keypair{}, ErrInvalidPrivateKey
+			}
+			k.Private = ed25519.NewKeyFromSeed(seed)
+		}
+	case kty == COSE_KTY_OKP && crv == COSE_CRV_X25519:
+		k.Code, k.Public, k.Private = X_25519, bstrs[coseKeyX], bstrs[coseKeyD]
+		if _, err := ecdh.X25519().NewPublicKey(k.Public); err != nil {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+	case kty == COSE_KTY_EC2 && (crv == COSE_CRV_P256 || crv == COSE_CRV_SECP256K1):
+		x, y := bstrs[coseKeyX], bstrs[coseKeyY]
+		if len(x) != 32 || len(y) != 32 {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+		point := append(append([]byte{0x04}, x...), y...)
+		k.Code, k.Public, k.Private = P_256, point, bstrs[coseKeyD]
+		if crv == COSE_CRV_SECP256K1 {
+			pk, err := secp256k1.ParsePubKey(point)
+			if err != nil {
+				return Keypair{}, ErrInvalidPublicKey
+			}
+			k.Code, k.Public = SECP_256K1, pk.SerializeCompressed()
+		} else if _, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point); err != nil {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+	case kty == COSE_KTY_RSA:
+		n, e := bstrs[coseKeyN], bstrs[coseKeyE]
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+		pk := rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		k.Code, k.Public = RSA, x509.MarshalPKCS1PublicKey(&pk)
+		if d, ok := bstrs[coseKeyD]; ok {
+			sk := rsa.PrivateKey{
+				PublicKey: pk,
+				D:         new(big.Int).SetBytes(d),
+				Primes:    []*big.Int{new(big.Int).SetBytes(bstrs[coseKeyP]), new(big.Int).SetBytes(bstrs[coseKeyQ])},
+			}
+			if err := sk.Validate(); err != nil {
+				return Keypair{}, ErrInvalidPrivateKey
+			}
+			sk.Precompute()
+			k.Private = x509.MarshalPKCS1PrivateKey(&sk)
+		}
+	default:
+		return Keypair{}, ErrUnsupportedCipher
+	}
+	if alg, ok := ints[coseKeyAlg]; ok && alg != COSEAlgorithms[k.Code] {
+		return Keypair{}, ErrCOSEAlgorithm
+	}
+
+	k.Name = codeName(k.Code)
+	k.PublicLength = len(k.Public)
+	k.PrivateLength = len(k.Private)
+	if err := k.Validate(); err != nil {
+		return Keypair{}, ErrInvalidPrivateKey
+	}
+	return k, nil
+}
+
+// The uncompressed point of a P-256 or secp256k1 public key.
+func uncompressedPoint(k Keypair) ([]byte, error) {
+	if k.Code == SECP_256K1 {
+		pk, err := secp256k1.ParsePubKey(k.Public)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		return pk.SerializeUncompressed(), nil
+	}
+	if _, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), k.Public); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return k.Public, nil
+}
+
+//
+// COSE_SIGN1
+//
+
+// COSESign1 signs payload as a tagged COSE_Sign1 message, with the
+// algorithm in the protected header. external is the optional
+// externally supplied data bound into the signature.
+func (k Keypair) COSESign1(payload []byte, external []byte) ([]byte, error) {
+	alg, ok := COSEAlgorithms[k.Code]
+	if !ok {
+		return nil, ErrUnsupportedCipher
+	}
+	protected := cborInt(cborHeader(nil, cborMap, 1), coseHeaderAlg)
+	protected = cborInt(protected, alg)
+
+	signature, err := k.Sign(sigStructure(protected, external, payload))
+	if err != nil {
+		return nil, err
+	}
+	if signature, err = k.RawSignature(signature); err != nil {
+		return nil, err
+	}
+
+	buf := cborHeader(nil, cborTag, coseSign1Tag)
+	buf = cborHeader(buf, cborArray, 4)
+	buf = cborString(buf, cborBytes, protected)
+	buf = cborHeader(buf, cborMap, 0)
+	buf = cborString(buf, cborBytes, payload)
+	buf = cborString(buf, cborBytes, signature)
+	return buf, nil
+}
+
+// VerifyCOSESign1 checks a COSE_Sign1 message, tagged or not, against
+// the public key and returns its payload. The protected header's
+// algorithm must be the one for the key. Messages with a detached
+// payload aren't supported.
+func (k Keypair) VerifyCOSESign1(message []byte, external []byte) ([]byte, error) {
+	alg, ok := COSEAlgorithms[k.Code]
+	if !ok {
+		return nil, ErrUnsupportedCipher
+	}
+
+	d := cborDecoder{buf: message}
+	if len(d.buf) > 0 && d.buf[0]&0xe0 == cborTag {
+		if tag, ok := d.header(cborTag); !ok || tag != coseSign1Tag {
+			return nil, ErrInvalidCOSESign1
+		}
+	}
+	var protected, payload, signature []byte
+	if n, ok := d.header(cborArray); !ok || n != 4 {
+		return nil, ErrInvalidCOSESign1
+	}
+	if protected, ok = d.string(cborBytes); !ok {
+		return nil, ErrInvalidCOSESign1
+	}
+	if _, _, ok = d.coseMap(); !ok {
+		return nil, ErrInvalidCOSESign1
+	}
+	if payload, ok = d.string(cborBytes); !ok {
+		return nil, ErrInvalidCOSESign1
+	}
+	if signature, ok = d.string(cborBytes); !ok || len(d.buf) != 0 {
+		return nil, ErrInvalidCOSESign1
+	}
+
+	header := cborDecoder{buf: protected}
+	ints, _, ok := header.coseMap()
+	if !ok || len(header.buf) != 0 {
+		return nil, ErrInvalidCOSESign1
+	}
+	if got, ok := ints[coseHeaderAlg]; !ok || got != alg {
+		return nil, ErrCOSEAlgorithm
+	}
+
+	signature, err := k.DERSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.Verify(sigStructure(protected, external, payload), signature); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// The Sig_structure signed for a COSE_Sign1 message.
+func sigStructure(protected []byte, external []byte, payload []byte) []byte {
+	buf := cborHeader(nil, cborArray, 4)
+	buf = cborString(buf, cborText, []byte("Signature1"))
+	buf = cborString(buf, cborBytes, protected)
+	buf = cborString(buf, cborBytes, external)
+	return cborString(buf, cborBytes, payload)
+}
+
+//
+// CBOR
+//
+
+// Append a CBOR integer, positive or negative.
+func cborInt(buf []byte, v int64) []byte {
+	if v < 0 {
+		return cborHeader(buf, cborNegative, uint64(-1-v))
+	}
+	return cborHeader(buf, cborUint, uint64(v))
+}
+
+// Read a CBOR integer, positive or negative.
+func (d *cborDecoder) integer() (int64, bool) {
+	if len(d.buf) == 0 {
+		return 0, false
+	}
+	major := d.buf[0] & 0xe0
+	if major != cborUint && major != cborNegative {
+		return 0, false
+	}
+	arg, ok := d.header(major)
+	if !ok || arg > 1<<63-1 {
+		return 0, false
+	}
+	if major == cborNegative {
+		return -1 - int64(arg), true
+	}
+	return int64(arg), true
+}
+
+// Read a COSE map (a COSE_Key or header map), returning its integer
+// and byte string values by integer label. Text labels and values of
+// other types are skipped; a repeated label is an error.
+func (d *cborDecoder) coseMap() (map[int64]int64, map[int64][]byte, bool) {
+	n, ok := d.header(cborMap)
+	if !ok || n > uint64(len(d.buf)) {
+		return nil, nil, false
+	}
+	ints := make(map[int64]int64)
+	bstrs := make(map[int64][]byte)
+	seen := make(map[int64]bool)
+	for i := uint64(0); i < n; i++ {
+		if len(d.buf) == 0 {
+			return nil, nil, false
+		}
+		if d.buf[0]&0xe0 == cborText {
+			if _, ok := d.string(cborText); !ok || !d.skip(0) {
+				return nil, nil, false
+			}
+			continue
+		}
+		label, ok := d.integer()
+		if !ok || seen[label] || len(d.buf) == 0 {
+			return nil, nil, false
+		}
+		seen[label] = true
+		switch d.buf[0] & 0xe0 {
+		case cborUint, cborNegative:
+			if ints[label], ok = d.integer(); !ok {
+				return nil, nil, false
+			}
+		case cborBytes:
+			if bstrs[label], ok = d.string(cborBytes); !ok {
+				return nil, nil, false
+			}
+		default:
+			if !d.skip(0) {
+				return nil, nil, false
+			}
+		}
+	}
+	return ints, bstrs, true
+}
+
+// Skip one data item of any type, nested no deeper than the limit.
+func (d *cborDecoder) skip(depth int) bool {
+	if len(d.buf) == 0 || depth > 16 {
+		return false
+	}
+	major := d.buf[0] & 0xe0
+	if major == cborSimple {
+		// Simple values and floats: the argument is the value.
+		info := d.buf[0] & 0x1f
+		sizes := map[byte]int{24: 1, 25: 2, 26: 4, 27: 8}
+		size := sizes[info]
+		if info > 27 || len(d.buf) < 1+size {
+			return false
+		}
+		d.buf = d.buf[1+size:]
+		return true
+	}
+	arg, ok := d.header(major)
+	if !ok {
+		return false
+	}
+	switch major {
+	case cborBytes, cborText:
+		if arg > uint64(len(d.buf)) {
+			return false
+		}
+		d.buf = d.buf[arg:]
+	case cborArray, cborMap:
+		items := arg
+		if major == cborMap {
+			items *= 2
+		}
+		if arg > uint64(len(d.buf)) {
+			return false
+		}
+		for i := uint64(0); i < items; i++ {
+			if !d.skip(depth + 1) {
+				return false
+			}
+		}
+	case cborTag:
+		return d.skip(depth + 1)
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+// go-multikeypair/cose_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// The P-256 key "11" of the COSE examples (RFC 9052 appendix C).
+func testCOSEKey(t *testing.T) Keypair {
+	x, _ := hex.DecodeString("bac5b11cad8f99f9c72b05cf4b9e26d244dc189f745228255a219a86d6a09eff")
+	y, _ := hex.DecodeString("20138bf82dc1b6d562be0fa54ab7804a3a64b6d72ccfed6b6fb6ed28bbfc117e")
+	d, _ := hex.DecodeString("57c92077664146e876760c9520d054aa93c3afb04e306705db6090308507b4d3")
+	public := append(append([]byte{0x04}, x...), y...)
+	return Keypair{
+		Code:          P_256,
+		Name:          Codes[P_256],
+		Private:       d,
+		PrivateLength: len(d),
+		Public:        public,
+		PublicLength:  len(public),
+	}
+}
+
+// Keypairs of each supported cipher round trip through COSE_Key, with
+// and without their private keys.
+func TestCOSEKey(t *testing.T) {
+	for _, code := range []uint64{ED_25519, X_25519, P_256, SECP_256K1, RSA} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []Keypair{kp, kp.publicOnly()} {
+			data, err := k.COSEKey()
+			if err != nil {
+				t.Fatalf("%s: %v", k.Name, err)
+			}
+			decoded, err := KeypairFromCOSEKey(data)
+			if err != nil {
+				t.Fatalf("%s: %v", k.Name, err)
+			}
+			if !decoded.Equal(k) {
+				t.Errorf("%s: round trip changed the keypair", k.Name)
+			}
+		}
+	}
+}
+
+// The encoding of a known public key is the deterministic one.
+func TestCOSEKeyEncoding(t *testing.T) {
+	data, err := testCOSEKey(t).publicOnly().COSEKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {1: 2, 3: -7, -1: 1, -2: h'bac5...', -3: h'2013...'}
+	want, _ := hex.DecodeString("a5010203262001215820bac5b11cad8f99f9c72b05cf4b9e26d244dc189f745228255a219a86d6a09eff22582020138bf82dc1b6d562be0fa54ab7804a3a64b6d72ccfed6b6fb6ed28bbfc117e")
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected encoding: %x", data)
+	}
+}
+
+// A private key that doesn't match the public key is refused.
+func TestCOSEKeyMismatch(t *testing.T) {
+	k := testCOSEKey(t)
+	other, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Private = other.Private
+	data, err := k.COSEKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := KeypairFromCOSEKey(data); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := KeypairFromCOSEKey([]byte{0xa1, 0x01}); !errors.Is(err, ErrInvalidCOSEKey) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// The COSE_Sign1 example of RFC 9052 appendix C.2.1 verifies.
+func TestCOSESign1Vector(t *testing.T) {
+	// 18([h'a10126', {4: h'3131'}, h'546869...', h'8eb33e...'])
+	message, _ := hex.DecodeString("d28443a10126a10442313154546869732069732074686520636f6e74656e742e58408eb33e4ca31d1c465ab05aac34cc6b23d58fef5c083106c4d25a91aef0b0117e2af9a291aa32e14ab834dc56ed2a223444547e01f11d3b0916e5a4c345cacb36")
+	payload, err := testCOSEKey(t).publicOnly().VerifyCOSESign1(message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "This is the content." {
+		t.Errorf("payload %q", payload)
+	}
+}
+
+// Messages signed with each supported cipher verify against the public
+// key with the same external data, and not otherwise.
+func TestCOSESign1(t *testing.T) {
+	for _, code := range []uint64{ED_25519, P_256, SECP_256K1, RSA} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message, err := kp.COSESign1([]byte("hello"), []byte("aad"))
+		if err != nil {
+			t.Fatalf("%s: %v", kp.Name, err)
+		}
+		payload, err := kp.publicOnly().VerifyCOSESign1(message, []byte("aad"))
+		if err != nil {
+			t.Fatalf("%s: %v", kp.Name, err)
+		}
+		if string(payload) != "hello" {
+			t.Errorf("%s: payload %q", kp.Name, payload)
+		}
+		if _, err := kp.VerifyCOSESign1(message, nil); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: wrong external data: %v", kp.Name, err)
+		}
+	}
+}
+
+// A message whose algorithm isn't the key's is refused.
+func TestCOSESign1Algorithm(t *testing.T) {
+	kp := generateEd25519(t)
+	message, err := kp.COSESign1([]byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testCOSEKey(t).VerifyCOSESign1(message, nil); !errors.Is(err, ErrCOSEAlgorithm) {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := kp.VerifyCOSESign1(message[:len(message)-1], nil); !errors.Is(err, ErrInvalidCOSESign1) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// go-multikeypair/csr.go
+//
+// PKCS#10 certificate signing requests for Ed25519, P-256 and RSA
+// keypairs, so that keys managed as multikeypairs can be enrolled with
+// ACME or an internal CA without extracting the raw key bytes.
+
+package multikeypair
+
+import (
+	crypto_rand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// CSR-specific errors this module exports.
+var (
+	ErrInvalidSAN = errors.New("invalid subject alternative name")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// CreateCSR returns a PEM certificate signing request for the keypair's
+// public key with the given subject, signed with its private key. Each
+// subject alternative name is added as an IP address, a URI (if it has a
+// scheme), an email address (if it contains "@") or otherwise a DNS
+// name. Keys whose usage doesn't include USAGE_SIGN, or that are outside
+// their validity window, are refused.
+func (k Keypair) CreateCSR(subject pkix.Name, sans []string) ([]byte, error) {
+	if err := k.checkUsage(USAGE_SIGN); err != nil {
+		return nil, err
+	}
+	if err := k.checkValid(); err != nil {
+		return nil, err
+	}
+	sk, err := k.cryptoPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.CertificateRequest{Subject: subject}
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if strings.Contains(san, "://") {
+			u, err := url.Parse(san)
+			if err != nil {
+				return nil, ErrInvalidSAN
+			}
+			template.URIs = append(template.URIs, u)
+		} else if strings.Contains(san, "@") {
+			if _, err := mail.ParseAddress(san); err != nil {
+				return nil, ErrInvalidSAN
+			}
+			template.EmailAddresses = append(template.EmailAddresses, san)
+		} else if san != "" {
+			template.DNSNames = append(template.DNSNames, san)
+		} else {
+			return nil, ErrInvalidSAN
+		}
+	}
+
+	der, err := x509.CreateCertificateRequest(crypto_rand.Reader, template, sk)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
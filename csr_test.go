@@ -0,0 +1,92 @@
+// go-multikeypair/csr_test.go
+
+package multikeypair
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// CSRs for each supported cipher parse, carry the keypair's public key
+// and SANs, and are validly signed.
+func TestCreateCSR(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKeypair, err := keypairFromCryptoPrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keypairs := []Keypair{rsaKeypair}
+	for _, code := range []uint64{ED_25519, P_256} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keypairs = append(keypairs, kp)
+	}
+
+	sans := []string{"example.com", "192.0.2.1", "spiffe://example.com/service", "admin@example.com"}
+	for _, kp := range keypairs {
+		b, err := kp.CreateCSR(pkix.Name{CommonName: "example.com", Organization: []string{"Example"}}, sans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(b)
+		if block == nil || block.Type != "CERTIFICATE REQUEST" {
+			t.Fatalf("%s: expected a PEM certificate request", kp.Name)
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := csr.CheckSignature(); err != nil {
+			t.Errorf("%s: %v", kp.Name, err)
+		}
+
+		public, err := keypairFromCryptoPublicKey(csr.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !public.Equal(kp.publicOnly()) {
+			t.Errorf("%s: CSR has the wrong public key", kp.Name)
+		}
+		if csr.Subject.CommonName != "example.com" ||
+			!slices.Equal(csr.DNSNames, []string{"example.com"}) ||
+			len(csr.IPAddresses) != 1 || csr.IPAddresses[0].String() != "192.0.2.1" ||
+			len(csr.URIs) != 1 || csr.URIs[0].String() != "spiffe://example.com/service" ||
+			!slices.Equal(csr.EmailAddresses, []string{"admin@example.com"}) {
+			t.Errorf("%s: unexpected CSR contents %+v", kp.Name, csr)
+		}
+	}
+}
+
+// Unsupported ciphers, restricted keys and bad SANs are refused.
+func TestCreateCSRRefused(t *testing.T) {
+	kp, err := Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.CreateCSR(pkix.Name{}, nil); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+
+	kp, err = Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.CreateCSR(pkix.Name{}, []string{"not@an@address"}); !errors.Is(err, ErrInvalidSAN) {
+		t.Errorf("expected ErrInvalidSAN, got %v", err)
+	}
+	kp.Metadata.Usage = USAGE_ENCRYPT
+	if _, err := kp.CreateCSR(pkix.Name{}, nil); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
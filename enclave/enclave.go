@@ -0,0 +1,158 @@
+// go-multikeypair/enclave/enclave.go
+//
+// Non-exportable, device-bound P-256 keys created in the Secure Enclave
+// of a Mac. The private key never leaves the enclave; the keychain holds
+// a persistent reference to it under an application tag, and that tag is
+// the RemoteKeypair reference:
+//
+//	secure-enclave:multikeypair.<32 hex digits>
+//
+// Only darwin builds with cgo can reach the enclave. Elsewhere every
+// operation fails with ErrUnsupported. Binaries must be code signed with
+// a keychain-access-groups entitlement for the keychain to accept
+// permanent enclave keys.
+
+package enclave
+
+import (
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Enclave-specific errors this package exports.
+var (
+	ErrUnsupported      = errors.New("secure enclave not available on this platform")
+	ErrInvalidReference = errors.New("invalid secure enclave key reference")
+)
+
+// SCHEME is the reference scheme for Secure Enclave keys.
+const SCHEME = "secure-enclave"
+
+// Application tags of keys created by this package start with this.
+const tagPrefix = "multikeypair."
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Options control how an enclave key may be used.
+type Options struct {
+	// UserPresence requires Touch ID, Apple Watch, or the login password
+	// before each signature.
+	UserPresence bool
+}
+
+// The operations the platform provides on keys identified by their
+// application tag. Public keys are 65-byte uncompressed points and
+// signatures ASN.1 DER over the SHA-256 digest of the message.
+type platformEnclave interface {
+	create(tag string, opts Options) ([]byte, error)
+	publicKey(tag string) ([]byte, error)
+	sign(tag string, message []byte) ([]byte, error)
+	remove(tag string) error
+}
+
+// The backend registered under SCHEME.
+type backend struct{}
+
+func init() {
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, backend{}); err != nil {
+		panic(err)
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// GenerateInEnclave creates a new P-256 key in the Secure Enclave and
+// returns a RemoteKeypair for it. The key is restricted to
+// multikeypair.USAGE_SIGN.
+func GenerateInEnclave(ctx context.Context, opts Options) (multikeypair.RemoteKeypair, error) {
+	if platform == nil {
+		return multikeypair.RemoteKeypair{}, ErrUnsupported
+	}
+	random := make([]byte, 16)
+	if _, err := crypto_rand.Read(random); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	tag := tagPrefix + hex.EncodeToString(random)
+	if _, err := platform.create(tag, opts); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	return multikeypair.NewRemoteKeypair(ctx, SCHEME+":"+tag)
+}
+
+// Delete removes an enclave key from the keychain. The key is destroyed:
+// nothing signed with it can be signed again.
+func Delete(r multikeypair.RemoteKeypair) error {
+	tag, err := parseReference(r.Reference)
+	if err != nil {
+		return err
+	}
+	if platform == nil {
+		return ErrUnsupported
+	}
+	return platform.remove(tag)
+}
+
+// PublicKey exports the public half of an enclave key.
+func (backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	if !validTag(resource) {
+		return multikeypair.Keypair{}, ErrInvalidReference
+	}
+	if platform == nil {
+		return multikeypair.Keypair{}, ErrUnsupported
+	}
+	public, err := platform.publicKey(resource)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	m, err := multikeypair.Encode(nil, public, multikeypair.P_256)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	return kp, nil
+}
+
+// Sign signs message in the enclave, prompting the user if the key
+// requires their presence.
+func (backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	if !validTag(resource) {
+		return nil, ErrInvalidReference
+	}
+	if platform == nil {
+		return nil, ErrUnsupported
+	}
+	return platform.sign(resource, message)
+}
+
+// Extract the application tag from a reference.
+func parseReference(reference string) (string, error) {
+	tag, ok := strings.CutPrefix(reference, SCHEME+":")
+	if !ok || !validTag(tag) {
+		return "", ErrInvalidReference
+	}
+	return tag, nil
+}
+
+// Report whether a tag is one this package could have created.
+func validTag(tag string) bool {
+	suffix, ok := strings.CutPrefix(tag, tagPrefix)
+	if !ok || len(suffix) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(suffix)
+	return err == nil && strings.ToLower(suffix) == suffix
+}
@@ -0,0 +1,231 @@
+//go:build darwin && cgo
+
+// go-multikeypair/enclave/enclave_darwin.go
+//
+// Secure Enclave access through the Security framework. Keys are
+// permanent keychain items of type kSecAttrKeyTypeECSECPrimeRandom on
+// the kSecAttrTokenIDSecureEnclave token, found by application tag.
+
+package enclave
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation -framework Security
+#include <stdlib.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <Security/Security.h>
+
+static CFDictionaryRef mkp_dict(const void **keys, const void **values, CFIndex n) {
+	return CFDictionaryCreate(NULL, keys, values, n,
+		&kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+}
+
+static OSStatus mkp_status(CFErrorRef error) {
+	OSStatus status = errSecInternalComponent;
+	if (error != NULL) {
+		status = (OSStatus)CFErrorGetCode(error);
+		CFRelease(error);
+	}
+	return status;
+}
+
+static SecKeyRef mkp_copy_key(const UInt8 *tag, CFIndex tagLen, OSStatus *status) {
+	CFDataRef tagData = CFDataCreate(NULL, tag, tagLen);
+	const void *keys[] = {kSecClass, kSecAttrApplicationTag, kSecAttrKeyType, kSecAttrTokenID, kSecReturnRef};
+	const void *values[] = {kSecClassKey, tagData, kSecAttrKeyTypeECSECPrimeRandom, kSecAttrTokenIDSecureEnclave, kCFBooleanTrue};
+	CFDictionaryRef query = mkp_dict(keys, values, 5);
+	SecKeyRef key = NULL;
+	*status = SecItemCopyMatching(query, (CFTypeRef *)&key);
+	CFRelease(query);
+	CFRelease(tagData);
+	return key;
+}
+
+static OSStatus mkp_export(SecKeyRef private, UInt8 *out) {
+	SecKeyRef public = SecKeyCopyPublicKey(private);
+	if (public == NULL) {
+		return errSecInternalComponent;
+	}
+	CFErrorRef error = NULL;
+	CFDataRef data = SecKeyCopyExternalRepresentation(public, &error);
+	CFRelease(public);
+	if (data == NULL) {
+		return mkp_status(error);
+	}
+	OSStatus status = errSecSuccess;
+	if (CFDataGetLength(data) != 65) {
+		status = errSecInternalComponent;
+	} else {
+		CFDataGetBytes(data, CFRangeMake(0, 65), out);
+	}
+	CFRelease(data);
+	return status;
+}
+
+static OSStatus mkp_create(const UInt8 *tag, CFIndex tagLen, int userPresence, UInt8 *public) {
+	SecAccessControlCreateFlags flags = kSecAccessControlPrivateKeyUsage;
+	if (userPresence) {
+		flags |= kSecAccessControlUserPresence;
+	}
+	SecAccessControlRef access = SecAccessControlCreateWithFlags(NULL,
+		kSecAttrAccessibleWhenUnlockedThisDeviceOnly, flags, NULL);
+	if (access == NULL) {
+		return errSecParam;
+	}
+
+	CFDataRef tagData = CFDataCreate(NULL, tag, tagLen);
+	int bits = 256;
+	CFNumberRef size = CFNumberCreate(NULL, kCFNumberIntType, &bits);
+	const void *privateKeys[] = {kSecAttrIsPermanent, kSecAttrApplicationTag, kSecAttrAccessControl};
+	const void *privateValues[] = {kCFBooleanTrue, tagData, access};
+	CFDictionaryRef privateAttrs = mkp_dict(privateKeys, privateValues, 3);
+	const void *keys[] = {kSecAttrKeyType, kSecAttrKeySizeInBits, kSecAttrTokenID, kSecPrivateKeyAttrs};
+	const void *values[] = {kSecAttrKeyTypeECSECPrimeRandom, size, kSecAttrTokenIDSecureEnclave, privateAttrs};
+	CFDictionaryRef attrs = mkp_dict(keys, values, 4);
+
+	CFErrorRef error = NULL;
+	SecKeyRef key = SecKeyCreateRandomKey(attrs, &error);
+	OSStatus status;
+	if (key == NULL) {
+		status = mkp_status(error);
+	} else {
+		status = mkp_export(key, public);
+		CFRelease(key);
+	}
+
+	CFRelease(attrs);
+	CFRelease(privateAttrs);
+	CFRelease(size);
+	CFRelease(tagData);
+	CFRelease(access);
+	return status;
+}
+
+static OSStatus mkp_public(const UInt8 *tag, CFIndex tagLen, UInt8 *public) {
+	OSStatus status;
+	SecKeyRef key = mkp_copy_key(tag, tagLen, &status);
+	if (key == NULL) {
+		return status;
+	}
+	status = mkp_export(key, public);
+	CFRelease(key);
+	return status;
+}
+
+static OSStatus mkp_sign(const UInt8 *tag, CFIndex tagLen, const UInt8 *msg, CFIndex msgLen, UInt8 *sig, CFIndex *sigLen) {
+	OSStatus status;
+	SecKeyRef key = mkp_copy_key(tag, tagLen, &status);
+	if (key == NULL) {
+		return status;
+	}
+	CFDataRef message = CFDataCreate(NULL, msg, msgLen);
+	CFErrorRef error = NULL;
+	CFDataRef signature = SecKeyCreateSignature(key,
+		kSecKeyAlgorithmECDSASignatureMessageX962SHA256, message, &error);
+	CFRelease(message);
+	CFRelease(key);
+	if (signature == NULL) {
+		return mkp_status(error);
+	}
+	CFIndex n = CFDataGetLength(signature);
+	if (n > *sigLen) {
+		status = errSecParam;
+	} else {
+		CFDataGetBytes(signature, CFRangeMake(0, n), sig);
+		*sigLen = n;
+		status = errSecSuccess;
+	}
+	CFRelease(signature);
+	return status;
+}
+
+static OSStatus mkp_remove(const UInt8 *tag, CFIndex tagLen) {
+	CFDataRef tagData = CFDataCreate(NULL, tag, tagLen);
+	const void *keys[] = {kSecClass, kSecAttrApplicationTag, kSecAttrTokenID};
+	const void *values[] = {kSecClassKey, tagData, kSecAttrTokenIDSecureEnclave};
+	CFDictionaryRef query = mkp_dict(keys, values, 3);
+	OSStatus status = SecItemDelete(query);
+	CFRelease(query);
+	CFRelease(tagData);
+	return status;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// The largest ASN.1 DER P-256 signature.
+const maxSignatureSize = 72
+
+// OSStatus is an error code returned by the Security framework.
+type OSStatus int32
+
+func (s OSStatus) Error() string {
+	return fmt.Sprintf("secure enclave: OSStatus %d", int32(s))
+}
+
+// The Security framework keychain.
+type keychain struct{}
+
+var platform platformEnclave = keychain{}
+
+func (keychain) create(tag string, opts Options) ([]byte, error) {
+	ctag := C.CBytes([]byte(tag))
+	defer C.free(ctag)
+	presence := C.int(0)
+	if opts.UserPresence {
+		presence = 1
+	}
+	public := make([]byte, 65)
+	status := C.mkp_create((*C.UInt8)(ctag), C.CFIndex(len(tag)), presence, (*C.UInt8)(unsafe.Pointer(&public[0])))
+	if err := check(status); err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+func (keychain) publicKey(tag string) ([]byte, error) {
+	ctag := C.CBytes([]byte(tag))
+	defer C.free(ctag)
+	public := make([]byte, 65)
+	status := C.mkp_public((*C.UInt8)(ctag), C.CFIndex(len(tag)), (*C.UInt8)(unsafe.Pointer(&public[0])))
+	if err := check(status); err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+func (keychain) sign(tag string, message []byte) ([]byte, error) {
+	ctag := C.CBytes([]byte(tag))
+	defer C.free(ctag)
+	var cmsg unsafe.Pointer
+	if len(message) > 0 {
+		cmsg = C.CBytes(message)
+		defer C.free(cmsg)
+	}
+	signature := make([]byte, maxSignatureSize)
+	n := C.CFIndex(len(signature))
+	status := C.mkp_sign((*C.UInt8)(ctag), C.CFIndex(len(tag)),
+		(*C.UInt8)(cmsg), C.CFIndex(len(message)),
+		(*C.UInt8)(unsafe.Pointer(&signature[0])), &n)
+	if err := check(status); err != nil {
+		return nil, err
+	}
+	return signature[:n], nil
+}
+
+func (keychain) remove(tag string) error {
+	ctag := C.CBytes([]byte(tag))
+	defer C.free(ctag)
+	return check(C.mkp_remove((*C.UInt8)(ctag), C.CFIndex(len(tag))))
+}
+
+// Convert an OSStatus into an error.
+func check(status C.OSStatus) error {
+	if status == C.errSecSuccess {
+		return nil
+	}
+	return OSStatus(status)
+}
@@ -0,0 +1,9 @@
+//go:build !darwin || !cgo
+
+// go-multikeypair/enclave/enclave_other.go
+//
+// There is no Secure Enclave outside darwin, and reaching it needs cgo.
+
+package enclave
+
+var platform platformEnclave
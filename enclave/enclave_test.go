@@ -0,0 +1,134 @@
+// go-multikeypair/enclave/enclave_test.go
+
+package enclave
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// An enclave that keeps keys in memory.
+type fakeEnclave struct {
+	keys map[string]*ecdsa.PrivateKey
+}
+
+func (e *fakeEnclave) create(tag string, opts Options) ([]byte, error) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	e.keys[tag] = sk
+	return sk.PublicKey.Bytes()
+}
+
+func (e *fakeEnclave) publicKey(tag string) ([]byte, error) {
+	sk, ok := e.keys[tag]
+	if !ok {
+		return nil, errors.New("item not found")
+	}
+	return sk.PublicKey.Bytes()
+}
+
+func (e *fakeEnclave) sign(tag string, message []byte) ([]byte, error) {
+	sk, ok := e.keys[tag]
+	if !ok {
+		return nil, errors.New("item not found")
+	}
+	digest := sha256.Sum256(message)
+	return ecdsa.SignASN1(crypto_rand.Reader, sk, digest[:])
+}
+
+func (e *fakeEnclave) remove(tag string) error {
+	delete(e.keys, tag)
+	return nil
+}
+
+// Substitute a fake enclave for the duration of a test.
+func useFake(t *testing.T) *fakeEnclave {
+	fake := &fakeEnclave{keys: make(map[string]*ecdsa.PrivateKey)}
+	saved := platform
+	platform = fake
+	t.Cleanup(func() { platform = saved })
+	return fake
+}
+
+// Enclave keys sign in the enclave and verify as ordinary P-256 keys.
+func TestGenerateInEnclave(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+
+	remote, err := GenerateInEnclave(ctx, Options{UserPresence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Key.Code != multikeypair.P_256 || remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+		t.Errorf("unexpected cached key %+v", remote.Key)
+	}
+
+	message := []byte("hello")
+	signature, err := remote.SignContext(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Verify(message, signature); err != nil {
+		t.Error(err)
+	}
+
+	s, err := remote.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := multikeypair.RemoteKeypairFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Refresh(ctx); err != nil {
+		t.Error(err)
+	}
+
+	if err := Delete(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.keys) != 0 {
+		t.Error("key not deleted")
+	}
+	if _, err := decoded.SignContext(ctx, message); err == nil {
+		t.Error("expected deleted key to fail signing")
+	}
+}
+
+// Without an enclave every operation reports ErrUnsupported.
+func TestUnsupported(t *testing.T) {
+	saved := platform
+	platform = nil
+	defer func() { platform = saved }()
+
+	if _, err := GenerateInEnclave(context.Background(), Options{}); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+// Only tags this package could have created are accepted.
+func TestParseReference(t *testing.T) {
+	good := SCHEME + ":multikeypair.0123456789abcdef0123456789abcdef"
+	if _, err := parseReference(good); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []string{
+		SCHEME + ":multikeypair.0123",
+		SCHEME + ":multikeypair.0123456789ABCDEF0123456789ABCDEF",
+		SCHEME + ":other.0123456789abcdef0123456789abcdef",
+		"tpm:multikeypair.0123456789abcdef0123456789abcdef",
+	} {
+		if _, err := parseReference(bad); err != ErrInvalidReference {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// go-multikeypair/encrypt.go
+//
+// Public key encryption to a keypair. Anyone holding the public key can
+// encrypt; only the holder of the private key can decrypt. Each message
+// uses a fresh ephemeral key, so encryptions of the same plaintext
+// differ, and the sender is anonymous.
+//
+// X25519 keys use libsodium's sealed boxes (crypto_box_seal), so that
+// messages interoperate with libsodium and its bindings:
+//
+//	ephemeral public key (32) || crypto_box(plaintext)
+//
+// P-256 and secp256k1 keys use ECIES with AES-256-GCM. The ephemeral
+// public key is encoded the same way as the recipient's (uncompressed
+// for P-256, compressed for secp256k1):
+//
+//	ephemeral public key || nonce (12) || AES-256-GCM ciphertext and tag
+//
+// where the AES key is HKDF-SHA256 of the shared x-coordinate, with no
+// salt and the ephemeral and recipient public keys as info.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	box "golang.org/x/crypto/nacl/box"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Encryption-specific errors this module exports.
+var (
+	ErrInvalidCiphertext = errors.New("ciphertext is malformed")
+	ErrDecryptionFailed  = errors.New("decryption failed (wrong key or tampered ciphertext?)")
+)
+
+// Sizes of the ECIES nonce and AES key.
+const (
+	eciesNonceSize = 12
+	eciesKeySize   = 32
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Encrypt encrypts plaintext to the public key of a Multikeypair.
+func Encrypt(recipient Multikeypair, plaintext []byte) ([]byte, error) {
+	kp, err := recipient.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return kp.Encrypt(plaintext)
+}
+
+// Decrypt decrypts a ciphertext made by Encrypt with the private key of
+// a Multikeypair.
+func Decrypt(recipient Multikeypair, ciphertext []byte) ([]byte, error) {
+	kp, err := recipient.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return kp.Decrypt(ciphertext)
+}
+
+// Encrypt encrypts plaintext to the public key. Keys whose usage doesn't
+// include USAGE_ENCRYPT are refused.
+func (k Keypair) Encrypt(plaintext []byte) ([]byte, error) {
+	if err := k.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	s, err := lookupScheme(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	if s.encrypt == nil {
+		return nil, ErrUnsupportedCipher
+	}
+	return s.encrypt(k.Public, plaintext)
+}
+
+// Decrypt decrypts a ciphertext made by Encrypt using the private key.
+// Keys whose usage doesn't include USAGE_ENCRYPT are refused.
+func (k Keypair) Decrypt(ciphertext []byte) ([]byte, error) {
+	if err := k.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	s, err := lookupScheme(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	if s.decrypt == nil {
+		return nil, ErrUnsupportedCipher
+	}
+	private, release := k.privateKey()
+	defer release()
+	return s.decrypt(private, ciphertext)
+}
+
+//
+// X25519
+//
+
+func x25519Encrypt(public []byte, plaintext []byte) ([]byte, error) {
+	if _, err := ecdh.X25519().NewPublicKey(public); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return box.SealAnonymous(nil, plaintext, (*[32]byte)(public), crypto_rand.Reader)
+}
+
+func x25519Decrypt(private []byte, ciphertext []byte) ([]byte, error) {
+	sk, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	if len(ciphertext) < box.AnonymousOverhead {
+		return nil, ErrInvalidCiphertext
+	}
+	public := sk.PublicKey().Bytes()
+	plaintext, ok := box.OpenAnonymous(nil, ciphertext, (*[32]byte)(public), (*[32]byte)(private))
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+//
+// P-256
+//
+
+func p256Encrypt(public []byte, plaintext []byte) ([]byte, error) {
+	pk, err := ecdh.P256().NewPublicKey(public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	ephemeral, err := ecdh.P256().GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(pk)
+	if err != nil {
+		return nil, err
+	}
+	return eciesSeal(ephemeral.PublicKey().Bytes(), public, shared, plaintext)
+}
+
+func p256Decrypt(private []byte, ciphertext []byte) ([]byte, error) {
+	sk, err := ecdh.P256().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	size := len(sk.PublicKey().Bytes())
+	if len(ciphertext) < size {
+		return nil, ErrInvalidCiphertext
+	}
+	ephemeral, err := ecdh.P256().NewPublicKey(ciphertext[:size])
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	shared, err := sk.ECDH(ephemeral)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return eciesOpen(ciphertext[:size], sk.PublicKey().Bytes(), shared, ciphertext[size:])
+}
+
+//
+// SECP256K1
+//
+
+func secp256k1Encrypt(public []byte, plaintext []byte) ([]byte, error) {
+	if len(public) != secp256k1.PubKeyBytesLenCompressed {
+		return nil, ErrInvalidPublicKey
+	}
+	pk, err := secp256k1.ParsePubKey(public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	ephemeral, err := secp256k1.GeneratePrivateKeyFromRand(crypto_rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared := secp256k1.GenerateSharedSecret(ephemeral, pk)
+	return eciesSeal(ephemeral.PubKey().SerializeCompressed(), public, shared, plaintext)
+}
+
+func secp256k1Decrypt(private []byte, ciphertext []byte) ([]byte, error) {
+	sk, err := secp256k1PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	size := secp256k1.PubKeyBytesLenCompressed
+	if len(ciphertext) < size {
+		return nil, ErrInvalidCiphertext
+	}
+	ephemeral, err := secp256k1.ParsePubKey(ciphertext[:size])
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	shared := secp256k1.GenerateSharedSecret(sk, ephemeral)
+	return eciesOpen(ciphertext[:size], sk.PubKey().SerializeCompressed(), shared, ciphertext[size:])
+}
+
+//
+// ECIES
+//
+
+// Encrypt plaintext under the key derived from a shared secret, prefixing
+// the ephemeral public key and nonce.
+func eciesSeal(ephemeral []byte, recipient []byte, shared []byte, plaintext []byte) ([]byte, error) {
+	aead, err := eciesAEAD(ephemeral, recipient, shared)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(ephemeral)+eciesNonceSize, len(ephemeral)+eciesNonceSize+len(plaintext)+aead.Overhead())
+	copy(out, ephemeral)
+	nonce := out[len(ephemeral):]
+	if _, err := crypto_rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// Decrypt the nonce-prefixed remainder of a ciphertext after its
+// ephemeral public key.
+func eciesOpen(ephemeral []byte, recipient []byte, shared []byte, sealed []byte) ([]byte, error) {
+	aead, err := eciesAEAD(ephemeral, recipient, shared)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < eciesNonceSize+aead.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, sealed[:eciesNonceSize], sealed[eciesNonceSize:], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// Derive the AES-256-GCM cipher for a message.
+func eciesAEAD(ephemeral []byte, recipient []byte, shared []byte) (cipher.AEAD, error) {
+	info := bytes.Join([][]byte{ephemeral, recipient}, nil)
+	key, err := hkdf.Key(sha256.New, shared, nil, string(info), eciesKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,83 @@
+// go-multikeypair/encrypt_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Messages encrypted to each supported cipher decrypt with the private
+// key, and only with it.
+func TestEncryptDecrypt(t *testing.T) {
+	message := []byte("attack at dawn")
+	for _, code := range []uint64{X_25519, P_256, SECP_256K1} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mk, err := kp.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ciphertext, err := Encrypt(mk, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := kp.Encrypt(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(ciphertext, again) {
+			t.Errorf("%s: encryption isn't randomized", Codes[code])
+		}
+
+		plaintext, err := Decrypt(mk, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(plaintext, message) {
+			t.Errorf("%s: expected %q, got %q", Codes[code], message, plaintext)
+		}
+
+		other, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Decrypt(ciphertext); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("%s: expected ErrDecryptionFailed for wrong key, got %v", Codes[code], err)
+		}
+		ciphertext[len(ciphertext)-1] ^= 1
+		if _, err := kp.Decrypt(ciphertext); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("%s: expected ErrDecryptionFailed for tampered ciphertext, got %v", Codes[code], err)
+		}
+		if _, err := kp.Decrypt(ciphertext[:10]); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("%s: expected ErrInvalidCiphertext for truncated ciphertext, got %v", Codes[code], err)
+		}
+	}
+}
+
+// Signing-only ciphers and keys can't be used for encryption.
+func TestEncryptRefused(t *testing.T) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.Encrypt([]byte("hello")); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+
+	kp, err = Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata.Usage = USAGE_SIGN
+	if _, err := kp.Encrypt([]byte("hello")); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+	if _, err := kp.Decrypt(make([]byte, 64)); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// go-multikeypair/equal.go
+//
+// Equality and ordering of keypairs. Private key material is compared
+// in constant time so that equality checks don't leak, through timing,
+// how much of a secret key an attacker has guessed.
+//
+// Multikeypairs are ordered by cipher code, then public key, then Key,
+// a digest of the canonical encoding that stands in for the rest of it.
+// Ordering thus never compares private keys directly, and encodings of
+// the same keypair that differ only in form compare equal.
+
+package multikeypair
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// Size in bytes of a Multikeypair's Key.
+const KEY_SIZE = sha256.Size
+
+// Equal reports whether two Keypairs hold the same cipher and key
+// material. The private keys are compared in constant time; the lengths
+// of the keys and the public keys are not treated as secret.
+func (k Keypair) Equal(o Keypair) bool {
+	codeEq := k.Code == o.Code
+	publicEq := bytes.Equal(k.Public, o.Public)
+	privateEq := subtle.ConstantTimeCompare(k.Private, o.Private) == 1
+	return codeEq && publicEq && privateEq
+}
+
+// Equal reports whether two Multikeypairs hold the same encoding. The
+// whole encoding is compared in constant time, since it contains the
+// private key.
+func (m Multikeypair) Equal(o Multikeypair) bool {
+	return subtle.ConstantTimeCompare(m, o) == 1
+}
+
+// Key returns a fixed-size digest of the Multikeypair, for use as a map
+// key: the SHA-256 of its canonical encoding, or of the encoding itself
+// if it's malformed. Encodings of the same keypair have the same Key.
+func (m Multikeypair) Key() [KEY_SIZE]byte {
+	canonical, err := m.Canonical()
+	if err != nil {
+		return sha256.Sum256(m)
+	}
+	return sha256.Sum256(canonical)
+}
+
+// Compare orders two Multikeypairs, returning -1, 0 or +1. Malformed
+// encodings order before well-formed ones. It returns 0 exactly when the
+// two have the same Key.
+func Compare(a Multikeypair, b Multikeypair) int {
+	aCode, aErr := a.Code()
+	bCode, bErr := b.Code()
+	switch {
+	case aErr != nil && bErr != nil:
+		return compareKeys(a, b)
+	case aErr != nil:
+		return -1
+	case bErr != nil:
+		return 1
+	}
+	if c := cmp.Compare(aCode, bCode); c != 0 {
+		return c
+	}
+	aPublic, _ := a.PublicBytes()
+	bPublic, _ := b.PublicBytes()
+	if c := bytes.Compare(aPublic, bPublic); c != 0 {
+		return c
+	}
+	return compareKeys(a, b)
+}
+
+// Less reports whether a orders before b, for use with sort.Slice and
+// ordered containers.
+func Less(a Multikeypair, b Multikeypair) bool {
+	return Compare(a, b) < 0
+}
+
+// Order two Multikeypairs by Key.
+func compareKeys(a Multikeypair, b Multikeypair) int {
+	aKey, bKey := a.Key(), b.Key()
+	return bytes.Compare(aKey[:], bKey[:])
+}
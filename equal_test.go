@@ -0,0 +1,119 @@
+// go-multikeypair/equal_test.go
+
+package multikeypair
+
+import (
+	"slices"
+	"testing"
+)
+
+// Keypairs and Multikeypairs are equal to themselves and their copies
+// only.
+func TestEqual(t *testing.T) {
+	kp := generateEd25519(t)
+	other := generateEd25519(t)
+
+	copied := kp
+	copied.Private = append([]byte(nil), kp.Private...)
+	if !kp.Equal(copied) {
+		t.Error("expected keypair to equal its copy")
+	}
+	if kp.Equal(other) {
+		t.Error("expected different keypairs to differ")
+	}
+
+	public := Keypair{Code: kp.Code, Public: kp.Public}
+	if kp.Equal(public) {
+		t.Error("expected public-only keypair to differ from full keypair")
+	}
+
+	sameKey := kp
+	sameKey.Code = IDENTITY
+	if kp.Equal(sameKey) {
+		t.Error("expected different ciphers to differ")
+	}
+
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mkCopy, err := copied.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherMk, err := other.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mk.Equal(mkCopy) {
+		t.Error("expected multikeypair to equal its copy")
+	}
+	if mk.Equal(otherMk) {
+		t.Error("expected different multikeypairs to differ")
+	}
+}
+
+// Multikeypairs sort by code and public key, and encodings of the same
+// keypair compare equal and share a Key.
+func TestCompare(t *testing.T) {
+	var encodings []Multikeypair
+	for _, code := range []uint64{X_25519, ED_25519, X_25519, P_256, ED_25519} {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mk, err := k.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		encodings = append(encodings, mk)
+	}
+	malformed := Multikeypair{0x00}
+	encodings = append(encodings, malformed)
+
+	slices.SortFunc(encodings, Compare)
+	if !encodings[0].Equal(malformed) {
+		t.Error("malformed encoding didn't sort first")
+	}
+	for i := 1; i < len(encodings)-1; i++ {
+		if !Less(encodings[i], encodings[i+1]) {
+			t.Errorf("encodings %d and %d out of order", i, i+1)
+		}
+		a, _ := encodings[i].Code()
+		b, _ := encodings[i+1].Code()
+		if a > b {
+			t.Errorf("codes %#x and %#x out of order", a, b)
+		}
+	}
+
+	k := generateEd25519(t)
+	mk, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wide, err := EncodeVersion(k.Private, k.Public, k.Code, V2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Compare(mk, wide) != 0 || mk.Key() != wide.Key() {
+		t.Error("encodings of the same keypair differ")
+	}
+
+	// The same public key with different metadata differs.
+	k.Metadata.Label = "labelled"
+	labelled, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Compare(mk, labelled) == 0 || mk.Key() == labelled.Key() {
+		t.Error("differently labelled keypairs compare equal")
+	}
+	if Compare(mk, labelled) != -Compare(labelled, mk) {
+		t.Error("comparison isn't antisymmetric")
+	}
+
+	keys := map[[KEY_SIZE]byte]bool{mk.Key(): true}
+	if !keys[wide.Key()] {
+		t.Error("map lookup by Key failed")
+	}
+}
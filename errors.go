@@ -0,0 +1,67 @@
+// go-multikeypair/errors.go
+//
+// Structured decoding errors. When an encoding is malformed, decoding
+// reports which field was at fault and where in the input it lies, e.g.
+//
+//	multikeypair: public key at byte 39: input isn't valid multikeypair
+//
+// The error wraps the sentinel that describes the failure, so callers
+// can keep testing for ErrInvalidMultikeypair and friends with
+// errors.Is, and get at the details with errors.As.
+
+package multikeypair
+
+import (
+	"fmt"
+)
+
+// Fields of an encoding that a DecodeError may name.
+const (
+	FIELD_LENGTH   = "length"
+	FIELD_VERSION  = "version"
+	FIELD_CODE     = "code"
+	FIELD_PRIVATE  = "private key"
+	FIELD_PUBLIC   = "public key"
+	FIELD_OPTIONAL = "optional fields"
+	FIELD_CHECKSUM = "checksum"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// DecodeError reports a malformed field of an encoded multikeypair.
+type DecodeError struct {
+	// Field at fault, one of the FIELD_ constants.
+	Field string
+	// Offset in bytes from the start of the encoding of the bytes at
+	// fault: a field's value, or its length prefix if that couldn't be
+	// read.
+	Offset int
+	// Sentinel error describing the failure.
+	Err error
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Error describes the failure, the field and its offset.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("multikeypair: %s at byte %d: %v", e.Field, e.Offset, e.Err)
+}
+
+// Unwrap returns the sentinel error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Make a DecodeError.
+func decodeError(field string, offset int, err error) error {
+	return &DecodeError{Field: field, Offset: offset, Err: err}
+}
+
+// The offset of field within buf, which it must alias. Slicing keeps a
+// slice's end of capacity, so the difference in capacities is the
+// difference in starts.
+func offsetIn(buf []byte, field []byte) int {
+	return cap(buf) - cap(field)
+}
@@ -0,0 +1,73 @@
+// go-multikeypair/errors_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"testing"
+)
+
+// Malformed encodings report the field at fault and its offset, and
+// still match the sentinel errors.
+func TestDecodeErrorFields(t *testing.T) {
+	mk, err := Encode([]byte("private"), []byte("public"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The outer length only covers the first byte of the public key's
+	// length prefix, which starts at byte 15.
+	cut := append(Multikeypair{0x00, 0x00, 13}, mk[3:16]...)
+	unknown := append(Multikeypair{}, mk...)
+	unknown[5] = 0x7e
+	summed, err := mk.WithChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summed[len(summed)-1] ^= 0xff
+
+	for _, c := range []struct {
+		name   string
+		input  Multikeypair
+		field  string
+		offset int
+		err    error
+	}{
+		{"truncated", mk[:10], FIELD_LENGTH, 0, ErrInvalidMultikeypair},
+		{"cut public key", cut, FIELD_PUBLIC, 15, ErrInvalidMultikeypair},
+		{"unknown code", unknown, FIELD_CODE, 5, ErrUnknownCode},
+		{"bad checksum", summed, FIELD_CHECKSUM, len(summed) - CHECKSUM_LENGTH, ErrChecksumMismatch},
+		{"unknown version", Multikeypair{0x00, 0x00, 0x00, 0x03}, FIELD_VERSION, 3, ErrUnknownVersion},
+	} {
+		_, err := Decode(c.input)
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Fatalf("%s: expected DecodeError, got %T", c.name, err)
+		}
+		if de.Field != c.field || de.Offset != c.offset {
+			t.Errorf("%s: expected %s at %d, got %s at %d", c.name, c.field, c.offset, de.Field, de.Offset)
+		}
+	}
+}
+
+// Offsets count from the start of the encoding, not of the slice's
+// backing array.
+func TestDecodeErrorOffsetInSlice(t *testing.T) {
+	mk, err := Encode([]byte("private"), []byte("public"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := append([]byte("prefix"), mk...)
+	buf[len("prefix")+5] = 0x7e
+
+	_, err = Decode(buf[len("prefix"):])
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Offset != 5 {
+		t.Errorf("expected offset 5, got %v", err)
+	}
+	if got := de.Error(); got != "multikeypair: code at byte 5: unknown multikeypair code" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
@@ -0,0 +1,274 @@
+// go-multikeypair/ethereum.go
+//
+// Conversion between secp256k1 multikeypairs and Ethereum JSON key files
+// (Web3 Secret Storage, version 3), as written by geth and MetaMask:
+//
+//	{"address": "<hex address>",
+//	 "crypto": {"cipher": "aes-128-ctr",
+//	            "cipherparams": {"iv": "<hex>"},
+//	            "ciphertext": "<hex>",
+//	            "kdf": "scrypt" | "pbkdf2",
+//	            "kdfparams": {"dklen": 32, "salt": "<hex>",
+//	                          "n": ..., "r": ..., "p": ... | "c": ..., "prf": "hmac-sha256"},
+//	            "mac": "<hex keccak256(derived[16:32] || ciphertext)>"},
+//	 "id": "<uuid>",
+//	 "version": 3}
+//
+// The ciphertext is the 32-byte private scalar encrypted with
+// derived[0:16].
+
+package multikeypair
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	scrypt "golang.org/x/crypto/scrypt"
+	sha3 "golang.org/x/crypto/sha3"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Ethereum-specific errors this module exports.
+var (
+	ErrInvalidEthereumKeystore = errors.New("input isn't valid Ethereum keystore")
+	ErrEthereumPassword        = errors.New("Ethereum keystore MAC mismatch (wrong password?)")
+)
+
+// Algorithm names and sizes used by version 3 key files.
+const (
+	ethereumVersion = 3
+	ethereumCipher  = "aes-128-ctr"
+	ethereumScrypt  = "scrypt"
+	ethereumPBKDF2  = "pbkdf2"
+	ethereumPRF     = "hmac-sha256"
+	ethereumDKLen   = 32
+	ethereumMaxN    = 1 << 20
+)
+
+// scrypt cost parameters for newly written key files. These are geth's
+// "standard" parameters.
+var (
+	ethereumScryptN = 1 << 18
+	ethereumScryptR = 8
+	ethereumScryptP = 1
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// A version 3 key file.
+type ethereumKeystore struct {
+	Address string         `json:"address"`
+	Crypto  ethereumCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+// The encrypted key and how to decrypt it.
+type ethereumCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string            `json:"kdf"`
+	KDFParams ethereumKDFParams `json:"kdfparams"`
+	MAC       string            `json:"mac"`
+}
+
+// Parameters for either KDF; those of the other are left zero.
+type ethereumKDFParams struct {
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	N     int    `json:"n,omitempty"`
+	R     int    `json:"r,omitempty"`
+	P     int    `json:"p,omitempty"`
+	C     int    `json:"c,omitempty"`
+	PRF   string `json:"prf,omitempty"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// EthereumKeystore returns a version 3 Ethereum key file for a secp256k1
+// Keypair, encrypted with password using scrypt.
+func (k Keypair) EthereumKeystore(password []byte) ([]byte, error) {
+	if k.Code != SECP_256K1 {
+		return nil, ErrUnsupportedCipher
+	}
+	if _, err := secp256k1PrivateKey(k.Private); err != nil {
+		return nil, err
+	}
+	address, err := ethereumAddress(k.Public)
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 32+aes.BlockSize+16)
+	if _, err := crypto_rand.Read(random); err != nil {
+		return nil, err
+	}
+	salt, iv, uuid := random[:32], random[32:48], random[48:]
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	params := ethereumKDFParams{
+		DKLen: ethereumDKLen,
+		Salt:  hex.EncodeToString(salt),
+		N:     ethereumScryptN,
+		R:     ethereumScryptR,
+		P:     ethereumScryptP,
+	}
+	derived, err := params.derive(ethereumScrypt, password)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := ethereumXOR(derived[:16], iv, k.Private)
+	if err != nil {
+		return nil, err
+	}
+
+	var ks ethereumKeystore
+	ks.Address = hex.EncodeToString(address)
+	ks.Crypto.Cipher = ethereumCipher
+	ks.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	ks.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	ks.Crypto.KDF = ethereumScrypt
+	ks.Crypto.KDFParams = params
+	ks.Crypto.MAC = hex.EncodeToString(ethereumMAC(derived, ciphertext))
+	ks.ID = fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+	ks.Version = ethereumVersion
+	return json.Marshal(ks)
+}
+
+// KeypairFromEthereumKeystore decrypts a version 3 Ethereum key file,
+// protected with either scrypt or PBKDF2, into a secp256k1 Keypair.
+func KeypairFromEthereumKeystore(b []byte, password []byte) (Keypair, error) {
+	var ks ethereumKeystore
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+	c := ks.Crypto
+	if ks.Version != ethereumVersion || c.Cipher != ethereumCipher {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+	ciphertext, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+	iv, err := hex.DecodeString(c.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+	mac, err := hex.DecodeString(c.MAC)
+	if err != nil {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+
+	derived, err := c.KDFParams.derive(c.KDF, password)
+	if err != nil {
+		return Keypair{}, err
+	}
+	if !hmac.Equal(ethereumMAC(derived, ciphertext), mac) {
+		return Keypair{}, ErrEthereumPassword
+	}
+	private, err := ethereumXOR(derived[:16], iv, ciphertext)
+	if err != nil {
+		return Keypair{}, err
+	}
+	sk, err := secp256k1PrivateKey(private)
+	if err != nil {
+		return Keypair{}, ErrInvalidEthereumKeystore
+	}
+	public := sk.PubKey().SerializeCompressed()
+
+	if ks.Address != "" {
+		address, err := ethereumAddress(public)
+		if err != nil {
+			return Keypair{}, err
+		}
+		if !strings.EqualFold(strings.TrimPrefix(ks.Address, "0x"), hex.EncodeToString(address)) {
+			return Keypair{}, ErrInvalidEthereumKeystore
+		}
+	}
+
+	return Keypair{
+		Code:          SECP_256K1,
+		Name:          codeName(SECP_256K1),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// Derive the key-encryption and MAC keys from password.
+func (p ethereumKDFParams) derive(kdf string, password []byte) ([]byte, error) {
+	if p.DKLen != ethereumDKLen {
+		return nil, ErrInvalidEthereumKeystore
+	}
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, ErrInvalidEthereumKeystore
+	}
+	switch kdf {
+	case ethereumScrypt:
+		if p.N > ethereumMaxN {
+			return nil, ErrInvalidEthereumKeystore
+		}
+		derived, err := scrypt.Key(password, salt, p.N, p.R, p.P, p.DKLen)
+		if err != nil {
+			return nil, ErrInvalidEthereumKeystore
+		}
+		return derived, nil
+	case ethereumPBKDF2:
+		if p.PRF != ethereumPRF || p.C <= 0 {
+			return nil, ErrInvalidEthereumKeystore
+		}
+		return pbkdf2.Key(sha256.New, string(password), salt, p.C, p.DKLen)
+	}
+	return nil, ErrInvalidEthereumKeystore
+}
+
+// The MAC over a ciphertext.
+func ethereumMAC(derived []byte, ciphertext []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(derived[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+// Encrypt or decrypt with AES-128-CTR.
+func ethereumXOR(key []byte, iv []byte, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// The 20-byte Ethereum address of a secp256k1 public key: the last 20
+// bytes of the Keccak-256 of the uncompressed point without its prefix.
+func ethereumAddress(public []byte) ([]byte, error) {
+	pk, err := secp256k1.ParsePubKey(public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(pk.SerializeUncompressed()[1:])
+	return h.Sum(nil)[12:], nil
+}
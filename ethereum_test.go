@@ -0,0 +1,105 @@
+// go-multikeypair/ethereum_test.go
+
+package multikeypair
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The PBKDF2 and scrypt test vectors from the Web3 Secret Storage
+// definition, both holding testEthereumPrivate under "testpassword".
+const (
+	testEthereumPBKDF2 = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+	testEthereumScrypt = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"83dbcc02d8ccb40e466191a123791e0e"},"ciphertext":"d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c","kdf":"scrypt","kdfparams":{"dklen":32,"n":262144,"p":8,"r":1,"salt":"ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},"mac":"2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+
+	testEthereumPrivate = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+	testEthereumAddress = "008aeeda4d805471df9b2a5b0f38a0c3bcba786b"
+)
+
+// Both test vectors decrypt to the expected key.
+func TestEthereumKeystoreVectors(t *testing.T) {
+	for _, ks := range []string{testEthereumPBKDF2, testEthereumScrypt} {
+		kp, err := KeypairFromEthereumKeystore([]byte(ks), []byte("testpassword"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kp.Code != SECP_256K1 || hex.EncodeToString(kp.Private) != testEthereumPrivate {
+			t.Errorf("unexpected keypair %+v", kp)
+		}
+		address, err := ethereumAddress(kp.Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(address) != testEthereumAddress {
+			t.Errorf("expected address %s, got %x", testEthereumAddress, address)
+		}
+	}
+
+	if _, err := KeypairFromEthereumKeystore([]byte(testEthereumPBKDF2), []byte("wrong")); !errors.Is(err, ErrEthereumPassword) {
+		t.Errorf("expected ErrEthereumPassword, got %v", err)
+	}
+}
+
+// Key files we write decrypt back to the same key, and carry its address.
+func TestEthereumKeystoreRoundTrip(t *testing.T) {
+	n := ethereumScryptN
+	ethereumScryptN = 1 << 10
+	t.Cleanup(func() { ethereumScryptN = n })
+
+	kp, err := Generate(SECP_256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := kp.EthereumKeystore([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ks ethereumKeystore
+	if err := json.Unmarshal(b, &ks); err != nil {
+		t.Fatal(err)
+	}
+	address, err := ethereumAddress(kp.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.Version != 3 || ks.Address != hex.EncodeToString(address) || len(ks.ID) != 36 || ks.ID[14] != '4' {
+		t.Errorf("unexpected key file %s", b)
+	}
+
+	decoded, err := KeypairFromEthereumKeystore(b, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(kp) {
+		t.Error("keypair didn't round trip")
+	}
+
+	// A key file whose address doesn't match its key is refused.
+	tampered := strings.Replace(string(b), ks.Address, strings.Repeat("0", 40), 1)
+	if _, err := KeypairFromEthereumKeystore([]byte(tampered), []byte("password")); !errors.Is(err, ErrInvalidEthereumKeystore) {
+		t.Errorf("expected ErrInvalidEthereumKeystore, got %v", err)
+	}
+}
+
+// Only secp256k1 keys can be written, and unknown algorithms are refused.
+func TestEthereumKeystoreInvalid(t *testing.T) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.EthereumKeystore([]byte("password")); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	unknown := strings.Replace(testEthereumPBKDF2, `"kdf":"pbkdf2"`, `"kdf":"argon2"`, 1)
+	if _, err := KeypairFromEthereumKeystore([]byte(unknown), []byte("testpassword")); !errors.Is(err, ErrInvalidEthereumKeystore) {
+		t.Errorf("expected ErrInvalidEthereumKeystore, got %v", err)
+	}
+	if _, err := KeypairFromEthereumKeystore([]byte("{"), nil); !errors.Is(err, ErrInvalidEthereumKeystore) {
+		t.Errorf("expected ErrInvalidEthereumKeystore, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// go-multikeypair/extension.go
+//
+// Optional fields that follow the public key inside the outer length
+// prefix. Decoders that predate a field skip over it, so fields can be
+// added without a new wire format version. Each field has the form:
+//
+//	<tag> (8-bit)
+//	[value length]<value> (16-bit length prefix)
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Tags for the optional fields we know about.
+const (
+	TAG_CHECKSUM   = byte(0x01)
+	TAG_LABEL      = byte(0x02)
+	TAG_CREATED    = byte(0x03)
+	TAG_USAGE      = byte(0x04)
+	TAG_APP        = byte(0x05)
+	TAG_NOT_BEFORE = byte(0x06)
+	TAG_NOT_AFTER  = byte(0x07)
+	TAG_REFERENCE  = byte(0x08)
+	TAG_WRAPPED    = byte(0x09)
+)
+
+// An optional field read from an encoding.
+type extension struct {
+	tag   byte
+	value []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Split whatever follows the public key into optional fields. Reports
+// false if the bytes aren't a well-formed sequence of fields.
+func splitExtensions(rest []byte) ([]extension, bool) {
+	return appendExtensionFields(nil, rest)
+}
+
+// Append the optional fields in rest to fields, as splitExtensions does,
+// so that callers can supply a buffer.
+func appendExtensionFields(fields []extension, rest []byte) ([]extension, bool) {
+	input := cryptobyte.String(rest)
+	for !input.Empty() {
+		var tag uint8
+		var value cryptobyte.String
+		if !input.ReadUint8(&tag) || !input.ReadUint16LengthPrefixed(&value) {
+			return nil, false
+		}
+		fields = append(fields, extension{tag: tag, value: value})
+	}
+	return fields, true
+}
+
+// Report whether an optional field tag is one we know about.
+func knownExtension(tag byte) bool {
+	switch tag {
+	case TAG_CHECKSUM, TAG_LABEL, TAG_CREATED, TAG_USAGE, TAG_APP,
+		TAG_NOT_BEFORE, TAG_NOT_AFTER, TAG_REFERENCE, TAG_WRAPPED:
+		return true
+	default:
+		return false
+	}
+}
+
+// Append optional fields to an encoded multikeypair, fixing up the
+// outer length prefix. The result doesn't alias buf.
+func appendExtensions(buf []byte, fields ...extension) ([]byte, error) {
+	if _, _, _, _, err := splitKeypair(buf); err != nil {
+		return nil, err
+	}
+	size, err := extensionsLen(fields)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(buf), len(buf)+size)
+	copy(out, buf)
+	return addExtensions(out, fields...)
+}
+
+// Length in bytes of a set of optional fields.
+func extensionsLen(fields []extension) (int, error) {
+	size := 0
+	for _, f := range fields {
+		if len(f.value) > 0xffff {
+			return 0, ErrTooLong
+		}
+		size += 3 + len(f.value)
+	}
+	return size, nil
+}
+
+// Append optional fields to a well-formed multikeypair the caller owns,
+// in place, fixing up the outer length prefix. The fields have been
+// checked by extensionsLen.
+func addExtensions(out []byte, fields ...extension) ([]byte, error) {
+	// Locate the outer length prefix.
+	offset, width := 0, 3
+	if bytes.HasPrefix(out, versionEscape) {
+		_, n := binary.Uvarint(out[len(versionEscape):])
+		offset, width = len(versionEscape)+n, 4
+	}
+
+	for _, f := range fields {
+		out = append(out, f.tag, byte(len(f.value)>>8), byte(len(f.value)))
+		out = append(out, f.value...)
+	}
+
+	length := uint64(len(out) - offset - width)
+	if length >= 1<<(8*width) {
+		return nil, ErrTooLong
+	}
+	for i := width - 1; i >= 0; i-- {
+		out[offset+i] = byte(length)
+		length >>= 8
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+// go-multikeypair/fingerprint.go
+//
+// Fingerprints identify a keypair by a digest of its public key, so that
+// keys can be compared and referred to succinctly without exposing (or
+// needing) the private half.
+
+package multikeypair
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Short labels for fingerprint strings, matching the form OpenSSH uses
+// for the common hashes. Other hashes are labelled with their upper-cased
+// multihash name.
+var fingerprintLabels = map[uint64]string{
+	SHA2_256: "SHA256",
+	SHA2_512: "SHA512",
+}
+
+// Fingerprint digests the public key with the hash function registered
+// under hashCode.
+func (k Keypair) Fingerprint(hashCode uint64) (Multihash, error) {
+	return Sum(k.Public, hashCode)
+}
+
+// HasFingerprint reports whether the public key matches a fingerprint,
+// using whichever hash function the fingerprint records.
+func (k Keypair) HasFingerprint(fp Multihash) bool {
+	return fp.Verify(k.Public) == nil
+}
+
+// FingerprintString renders a short, human-friendly fingerprint of the
+// public key in the style of OpenSSH, e.g. "SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU".
+func (k Keypair) FingerprintString(hashCode uint64) (string, error) {
+	fp, err := k.Fingerprint(hashCode)
+	if err != nil {
+		return "", err
+	}
+	digest, err := fp.Digest()
+	if err != nil {
+		return "", err
+	}
+	label, ok := fingerprintLabels[hashCode]
+	if !ok {
+		name, err := HashName(hashCode)
+		if err != nil {
+			return "", err
+		}
+		label = strings.ToUpper(name)
+	}
+	return label + ":" + base64.RawStdEncoding.EncodeToString(digest), nil
+}
@@ -0,0 +1,54 @@
+// go-multikeypair/fingerprint_test.go
+
+package multikeypair
+
+import (
+	"strings"
+	"testing"
+)
+
+// Fingerprints depend only on the public key.
+func TestFingerprint(t *testing.T) {
+	kp := generateEd25519(t)
+	public := Keypair{Code: kp.Code, Public: kp.Public}
+
+	fp, err := kp.Fingerprint(DEFAULT_HASH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicFp, err := public.Fingerprint(DEFAULT_HASH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fp.Equal(publicFp) {
+		t.Error("expected fingerprint to ignore private key")
+	}
+	if !public.HasFingerprint(fp) {
+		t.Error("expected public key to match its fingerprint")
+	}
+	if generateEd25519(t).HasFingerprint(fp) {
+		t.Error("expected other key not to match fingerprint")
+	}
+}
+
+// Fingerprint strings use the OpenSSH form for SHA-256 and a name-derived
+// label otherwise.
+func TestFingerprintString(t *testing.T) {
+	// The SHA-256 of the empty string, as printed by OpenSSH.
+	empty := Keypair{Code: ED_25519}
+	s, err := empty.FingerprintString(SHA2_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU" {
+		t.Errorf("unexpected fingerprint string: %s", s)
+	}
+
+	s, err = generateEd25519(t).FingerprintString(SHA3_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "SHA3-256:") {
+		t.Errorf("unexpected fingerprint label: %s", s)
+	}
+}
@@ -0,0 +1,163 @@
+// go-multikeypair/flag.go
+//
+// Loading multikeypairs from command-line flags and environment
+// variables. Both accept a plain base58 string (as produced by
+// B58String) or a multibase string in one of the common bases.
+
+package multikeypair
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Parsing-specific errors this module exports.
+var (
+	ErrEmptyInput = errors.New("empty multikeypair string")
+	ErrEnvNotSet  = errors.New("environment variable not set")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Flag is a Multikeypair that can be set from a command-line flag. It
+// implements flag.Value, and pflag.Value via Type. When printed (e.g. as
+// a flag default in usage output) only the public key is shown.
+//
+//	var key multikeypair.Flag
+//	flag.Var(&key, "key", "signing key (base58 or multibase)")
+type Flag struct {
+	Multikeypair
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// String implements flag.Value, showing the redacted (public only) form.
+func (f *Flag) String() string {
+	if f == nil || len(f.Multikeypair) == 0 {
+		return ""
+	}
+	kp, err := f.Multikeypair.Decode()
+	if err != nil {
+		return ""
+	}
+	public, err := Encode(nil, kp.Public, kp.Code)
+	if err != nil {
+		return ""
+	}
+	return public.B58String()
+}
+
+// Set implements flag.Value.
+func (f *Flag) Set(s string) error {
+	mk, err := ParseMultikeypair(s)
+	if err != nil {
+		return err
+	}
+	f.Multikeypair = mk
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f *Flag) Type() string {
+	return "multikeypair"
+}
+
+// FromEnv parses the Multikeypair held in the named environment variable.
+func FromEnv(name string) (Multikeypair, error) {
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return Multikeypair{}, fmt.Errorf("multikeypair: %w: %s", ErrEnvNotSet, name)
+	}
+	mk, err := ParseMultikeypair(s)
+	if err != nil {
+		return Multikeypair{}, fmt.Errorf("%w (from $%s)", err, name)
+	}
+	return mk, nil
+}
+
+// ParseMultikeypair parses a base58 or multibase string into a
+// Multikeypair. Surrounding whitespace is ignored. Error messages
+// describe the input's shape but never echo its content, since it may be
+// a private key.
+func ParseMultikeypair(s string) (Multikeypair, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Multikeypair{}, fmt.Errorf("multikeypair: %w", ErrEmptyInput)
+	}
+
+	b, encoding, err := decodeMultibase(s)
+	if err != nil {
+		return Multikeypair{}, fmt.Errorf(
+			"multikeypair: %w: %d character string isn't valid %s",
+			ErrInvalidMultikeypair,
+			len(s),
+			encoding,
+		)
+	}
+	mk, err := castKeypair(b)
+	if err != nil {
+		return Multikeypair{}, fmt.Errorf(
+			"multikeypair: %s string decoded to %d bytes that aren't a multikeypair: %w",
+			encoding,
+			len(b),
+			err,
+		)
+	}
+	return mk, nil
+}
+
+// Decode a plain base58 or multibase string, naming the encoding used.
+func decodeMultibase(s string) ([]byte, string, error) {
+	// Plain base58 is tried first. Its leading character comes from the
+	// encoding's length prefix, which for keypairs of 64 KiB or more can
+	// be any base58 digit, including a multibase prefix, so it is only
+	// taken here if it decodes to a multikeypair.
+	plain, plainErr := b58.Decode(s)
+	if plainErr == nil {
+		if _, err := castKeypair(plain); err == nil {
+			return plain, "base58", nil
+		}
+	}
+
+	payload := s[1:]
+	switch s[0] {
+	case 'z':
+		b, err := b58.Decode(payload)
+		return b, "multibase base58btc", err
+	case 'f', 'F':
+		b, err := hex.DecodeString(payload)
+		return b, "multibase base16", err
+	case 'b':
+		b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(payload))
+		return b, "multibase base32", err
+	case 'm':
+		b, err := base64.RawStdEncoding.DecodeString(payload)
+		return b, "multibase base64", err
+	case 'M':
+		b, err := base64.StdEncoding.DecodeString(payload)
+		return b, "multibase base64pad", err
+	case 'u':
+		b, err := base64.RawURLEncoding.DecodeString(payload)
+		return b, "multibase base64url", err
+	case 'U':
+		b, err := base64.URLEncoding.DecodeString(payload)
+		return b, "multibase base64urlpad", err
+	default:
+		if plainErr == nil {
+			return plain, "base58", nil
+		}
+		return nil, "base58 or a supported multibase", ErrInvalidMultikeypair
+	}
+}
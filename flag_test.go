@@ -0,0 +1,101 @@
+// go-multikeypair/flag_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// A Flag can be set from base58 and multibase strings.
+func TestFlagSet(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		mk.B58String(),
+		"z" + b58.Encode(mk),
+		"f" + hex.EncodeToString(mk),
+		"u" + base64.RawURLEncoding.EncodeToString(mk),
+		" " + mk.B58String() + "\n",
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var key Flag
+		fs.Var(&key, "key", "signing key")
+		if err := fs.Parse([]string{"-key", s}); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key.Multikeypair, mk) {
+			t.Errorf("multikeypair mismatch after parsing %q", s)
+		}
+		if strings.Contains(key.String(), b58.Encode(kp.Private)) {
+			t.Error("flag string leaked the private key")
+		}
+	}
+}
+
+// Plain base58 is recognized for keypairs whose encoding is long enough
+// that it starts with a multibase prefix rather than '1'.
+// Base58 is quadratic, so this takes several seconds.
+func TestParseMultikeypairLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large base58 round trip in short mode")
+	}
+	mk := Multikeypair(encodeKeypairV1(make([]byte, MAX_V1_KEY_LENGTH), nil, RSA))
+	s := mk.B58String()
+	if s[0] == '1' {
+		t.Fatalf("expected a large keypair not to start with '1'")
+	}
+	for _, s := range []string{s, "f" + hex.EncodeToString(mk)} {
+		parsed, err := ParseMultikeypair(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed, mk) {
+			t.Errorf("multikeypair mismatch after parsing %.8q...", s)
+		}
+	}
+}
+
+// Parse errors describe the input without echoing it.
+func TestParseMultikeypairErrors(t *testing.T) {
+	for _, s := range []string{"", "!!!", "1" + strings.Repeat("0", 10), "f0102"} {
+		_, err := ParseMultikeypair(s)
+		if err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+		if len(s) > 3 && strings.Contains(err.Error(), s) {
+			t.Errorf("error echoed its input: %s", err)
+		}
+	}
+}
+
+// FromEnv reads and parses an environment variable.
+func TestFromEnv(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MULTIKEYPAIR_TEST_KEY", mk.B58String())
+
+	got, err := FromEnv("MULTIKEYPAIR_TEST_KEY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, mk) {
+		t.Error("multikeypair mismatch after loading from environment")
+	}
+	if _, err := FromEnv("MULTIKEYPAIR_TEST_MISSING"); !errors.Is(err, ErrEnvNotSet) {
+		t.Errorf("expected unset variable error, got: %v", err)
+	}
+}
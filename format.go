@@ -0,0 +1,68 @@
+// go-multikeypair/format.go
+//
+// Printing and logging of keypairs. Each form shows the cipher, a
+// fingerprint of the public key, and the key lengths, but never any
+// private key bytes, so an accidental fmt.Println(kp) or log line is safe.
+
+package multikeypair
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// String implements fmt.Stringer, e.g.
+// "ed25519 SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU (public 32 bytes, private 64 bytes)".
+func (k Keypair) String() string {
+	return fmt.Sprintf(
+		"%s %s (public %d bytes, private %d bytes)",
+		k.cipherName(),
+		k.fingerprintOrUnknown(),
+		len(k.Public),
+		len(k.Private),
+	)
+}
+
+// GoString implements fmt.GoStringer for the %#v verb.
+func (k Keypair) GoString() string {
+	return fmt.Sprintf(
+		"multikeypair.Keypair{Code:0x%x, Name:%q, Fingerprint:%q, PublicLength:%d, PrivateLength:%d}",
+		k.Code,
+		k.cipherName(),
+		k.fingerprintOrUnknown(),
+		len(k.Public),
+		len(k.Private),
+	)
+}
+
+// LogValue implements slog.LogValuer.
+func (k Keypair) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("cipher", k.cipherName()),
+		slog.String("fingerprint", k.fingerprintOrUnknown()),
+		slog.Int("public_length", len(k.Public)),
+		slog.Int("private_length", len(k.Private)),
+	)
+}
+
+// The cipher name, falling back to the registry when the Keypair was
+// built by hand without one.
+func (k Keypair) cipherName() string {
+	if k.Name != "" {
+		return k.Name
+	}
+	if name := codeName(k.Code); name != "" {
+		return name
+	}
+	return fmt.Sprintf("unknown(0x%x)", k.Code)
+}
+
+// The default fingerprint string, or a placeholder if it can't be
+// computed.
+func (k Keypair) fingerprintOrUnknown() string {
+	s, err := k.FingerprintString(DEFAULT_HASH)
+	if err != nil {
+		return "unknown"
+	}
+	return s
+}
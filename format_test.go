@@ -0,0 +1,55 @@
+// go-multikeypair/format_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// None of the printed or logged forms of a Keypair contain its private
+// key, in any of the encodings fmt might use.
+func TestFormatRedacted(t *testing.T) {
+	kp := generateEd25519(t)
+	fp, err := kp.FingerprintString(DEFAULT_HASH)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	logger.Info("loaded key", "key", kp)
+
+	outputs := []string{
+		fmt.Sprint(kp),
+		fmt.Sprintf("%v", kp),
+		fmt.Sprintf("%+v", kp),
+		fmt.Sprintf("%#v", kp),
+		fmt.Sprintf("%s", kp),
+		fmt.Sprint([]Keypair{kp}),
+		logs.String(),
+	}
+	for _, out := range outputs {
+		for _, secret := range []string{
+			string(kp.Private),
+			fmt.Sprintf("%x", kp.Private),
+			fmt.Sprint(kp.Private),
+			b58.Encode(kp.Private),
+		} {
+			if strings.Contains(out, secret) {
+				t.Errorf("private key leaked into output: %s", out)
+			}
+		}
+		if !strings.Contains(out, fp) {
+			t.Errorf("expected fingerprint in output: %s", out)
+		}
+	}
+	if !strings.Contains(logs.String(), "key.cipher=ed25519") {
+		t.Errorf("expected cipher in structured log: %s", logs.String())
+	}
+}
@@ -0,0 +1,232 @@
+// go-multikeypair/frost/encode.go
+//
+// Wire encodings of FROST key shares and protocol messages. All of them
+// sit behind a 24-bit length prefix like a Multikeypair, and points and
+// scalars are always 32 bytes.
+
+package frost
+
+import (
+	b58 "github.com/mr-tron/base58/base58"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Size in bytes of an encoded point or scalar.
+const elementLength = 32
+
+//
+// ENCODE
+//
+
+// Encode packs a KeyShare into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  <identifier> (16-bit)
+//	  <threshold> (16-bit)
+//	  <secret> (32 bytes)
+//	  <verifying share> (32 bytes)
+//	  <group public key> (32 bytes)
+func (s KeyShare) Encode() ([]byte, error) {
+	if len(s.Secret) != elementLength || len(s.VerifyingShare) != elementLength || len(s.GroupPublic) != elementLength {
+		return nil, ErrInvalidKeyShare
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16(s.Identifier)
+		b.AddUint16(s.Threshold)
+		b.AddBytes(s.Secret)
+		b.AddBytes(s.VerifyingShare)
+		b.AddBytes(s.GroupPublic)
+	})
+	return b.Bytes()
+}
+
+// Encode packs a Commitment into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  <identifier> (16-bit)
+//	  <hiding commitment> (32 bytes)
+//	  <binding commitment> (32 bytes)
+func (c Commitment) Encode() ([]byte, error) {
+	if len(c.Hiding) != elementLength || len(c.Binding) != elementLength {
+		return nil, ErrInvalidCommitment
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		addCommitment(b, c)
+	})
+	return b.Bytes()
+}
+
+// Add the fields of a commitment to a builder.
+func addCommitment(b *cryptobyte.Builder, c Commitment) {
+	b.AddUint16(c.Identifier)
+	b.AddBytes(c.Hiding)
+	b.AddBytes(c.Binding)
+}
+
+// Encode packs a SigningPackage into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  [message length]<message> (24-bit length prefix)
+//	  [commitments length]<commitments> (16-bit length prefix)
+//
+// where each commitment is encoded as in Commitment.Encode but without
+// the outer length prefix.
+func (p SigningPackage) Encode() ([]byte, error) {
+	for _, c := range p.Commitments {
+		if len(c.Hiding) != elementLength || len(c.Binding) != elementLength {
+			return nil, ErrInvalidCommitment
+		}
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(p.Message)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			for _, c := range p.Commitments {
+				addCommitment(b, c)
+			}
+		})
+	})
+	return b.Bytes()
+}
+
+// Encode packs a SignatureShare into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  <identifier> (16-bit)
+//	  <share> (32 bytes)
+func (s SignatureShare) Encode() ([]byte, error) {
+	if len(s.Share) != elementLength {
+		return nil, ErrInvalidShare
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16(s.Identifier)
+		b.AddBytes(s.Share)
+	})
+	return b.Bytes()
+}
+
+//
+// DECODE
+//
+
+// DecodeKeyShare unpacks an encoded KeyShare.
+func DecodeKeyShare(buf []byte) (KeyShare, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+
+	var s KeyShare
+	if !values.ReadUint16(&s.Identifier) ||
+		!values.ReadUint16(&s.Threshold) ||
+		!values.ReadBytes(&s.Secret, elementLength) ||
+		!values.ReadBytes(&s.VerifyingShare, elementLength) ||
+		!values.ReadBytes(&s.GroupPublic, elementLength) ||
+		!values.Empty() {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+	return s, nil
+}
+
+// DecodeCommitment unpacks an encoded Commitment.
+func DecodeCommitment(buf []byte) (Commitment, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return Commitment{}, ErrInvalidCommitment
+	}
+
+	c, ok := readCommitment(&values)
+	if !ok || !values.Empty() {
+		return Commitment{}, ErrInvalidCommitment
+	}
+	return c, nil
+}
+
+// Read the fields of a commitment.
+func readCommitment(s *cryptobyte.String) (Commitment, bool) {
+	var c Commitment
+	ok := s.ReadUint16(&c.Identifier) &&
+		s.ReadBytes(&c.Hiding, elementLength) &&
+		s.ReadBytes(&c.Binding, elementLength)
+	return c, ok
+}
+
+// DecodeSigningPackage unpacks an encoded SigningPackage.
+func DecodeSigningPackage(buf []byte) (SigningPackage, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return SigningPackage{}, ErrInvalidPackage
+	}
+
+	var message, list cryptobyte.String
+	if !values.ReadUint24LengthPrefixed(&message) ||
+		!values.ReadUint16LengthPrefixed(&list) ||
+		!values.Empty() {
+		return SigningPackage{}, ErrInvalidPackage
+	}
+
+	p := SigningPackage{Message: message}
+	for !list.Empty() {
+		c, ok := readCommitment(&list)
+		if !ok {
+			return SigningPackage{}, ErrInvalidPackage
+		}
+		p.Commitments = append(p.Commitments, c)
+	}
+	return p, nil
+}
+
+// DecodeSignatureShare unpacks an encoded SignatureShare.
+func DecodeSignatureShare(buf []byte) (SignatureShare, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return SignatureShare{}, ErrInvalidShare
+	}
+
+	var s SignatureShare
+	if !values.ReadUint16(&s.Identifier) ||
+		!values.ReadBytes(&s.Share, elementLength) ||
+		!values.Empty() {
+		return SignatureShare{}, ErrInvalidShare
+	}
+	return s, nil
+}
+
+//
+// Base-58
+//
+
+// B58String generates a base58-encoded version of a KeyShare.
+func (s KeyShare) B58String() (string, error) {
+	b, err := s.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(b), nil
+}
+
+// KeyShareFromB58 parses a base58-encoded KeyShare.
+func KeyShareFromB58(s string) (KeyShare, error) {
+	b, err := b58.Decode(s)
+	if err != nil {
+		return KeyShare{}, ErrInvalidKeyShare
+	}
+	return DecodeKeyShare(b)
+}
@@ -0,0 +1,489 @@
+// go-multikeypair/frost/frost.go
+//
+// FROST threshold signing (RFC 9591) for ed25519 multikeypairs, using the
+// FROST(Ed25519, SHA-512) ciphersuite. A trusted dealer splits an ed25519
+// Keypair into key shares; any threshold of the share holders can then
+// jointly produce an ordinary ed25519 signature that verifies against
+// the original public key, without the private key ever being rebuilt.
+//
+// Signing takes two rounds. Each signer calls Commit and sends the
+// Commitment to a coordinator, keeping the Nonces secret. The coordinator
+// assembles a SigningPackage from the message and the commitments; each
+// signer answers it with a SignatureShare from Sign; and the coordinator
+// combines the shares with Aggregate.
+
+package frost
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"filippo.io/edwards25519"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// FROST-specific errors this package exports.
+var (
+	ErrThreshold         = errors.New("threshold must be between 2 and share count")
+	ErrUnsupportedCipher = errors.New("frost requires an ed25519 keypair")
+	ErrInvalidKeyShare   = errors.New("input isn't valid frost key share")
+	ErrInvalidCommitment = errors.New("input isn't valid frost commitment")
+	ErrInvalidPackage    = errors.New("input isn't valid frost signing package")
+	ErrInvalidShare      = errors.New("input isn't valid frost signature share")
+	ErrNotParticipant    = errors.New("signer has no commitment in signing package")
+	ErrTooFewSigners     = errors.New("signing package has fewer commitments than the threshold")
+	ErrNoncesUsed        = errors.New("frost nonces already used")
+	ErrShareMismatch     = errors.New("signature shares don't match signing package")
+	ErrInvalidSignature  = errors.New("frost signature share verification failed")
+)
+
+// Context string for the FROST(Ed25519, SHA-512) ciphersuite.
+const contextString = "FROST-ED25519-SHA512-v1"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// KeyShare is one participant's share of a group signing key.
+type KeyShare struct {
+	// Participant identifier, from 1.
+	Identifier uint16
+	// Number of participants needed to sign.
+	Threshold uint16
+	// Secret signing share (32-byte scalar).
+	Secret []byte
+	// Public verifying share (32-byte point), used to check this
+	// participant's signature shares.
+	VerifyingShare []byte
+	// Group ed25519 public key that signatures verify against.
+	GroupPublic []byte
+}
+
+// Nonces are a signer's secret, single-use values from round one. They
+// are wiped when used by Sign.
+type Nonces struct {
+	hiding  *edwards25519.Scalar
+	binding *edwards25519.Scalar
+	used    bool
+}
+
+// Commitment is a signer's public round one message.
+type Commitment struct {
+	// Participant identifier.
+	Identifier uint16
+	// Commitment to the hiding nonce (32-byte point).
+	Hiding []byte
+	// Commitment to the binding nonce (32-byte point).
+	Binding []byte
+}
+
+// SigningPackage is the coordinator's round two message: the message to
+// sign and the commitments of the participating signers.
+type SigningPackage struct {
+	// Message to be signed.
+	Message []byte
+	// One commitment per participating signer.
+	Commitments []Commitment
+}
+
+// SignatureShare is a signer's round two message.
+type SignatureShare struct {
+	// Participant identifier.
+	Identifier uint16
+	// Share of the signature (32-byte scalar).
+	Share []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// KEY GENERATION
+//
+
+// Split divides an ed25519 Keypair into n key shares, any k of which can
+// sign on its behalf, so the Keypair must permit USAGE_SIGN. The dealer
+// running Split must be trusted, and the original private key should be
+// destroyed once the shares are handed out.
+func Split(kp multikeypair.Keypair, n int, k int) ([]KeyShare, error) {
+	if k < 2 || k > n || n > 0xffff {
+		return nil, ErrThreshold
+	}
+	if !kp.Metadata.Usage.Allows(multikeypair.USAGE_SIGN) {
+		return nil, multikeypair.ErrUsageNotPermitted
+	}
+	if kp.Code != multikeypair.ED_25519 || len(kp.Private) != ed25519.PrivateKeySize {
+		return nil, ErrUnsupportedCipher
+	}
+
+	// The ed25519 signing scalar, derived from the seed as in RFC 8032.
+	h := sha512.Sum512(kp.Private[:ed25519.SeedSize])
+	defer clear(h[:])
+	secret, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		return nil, err
+	}
+	group := new(edwards25519.Point).ScalarBaseMult(secret)
+	if !bytes.Equal(group.Bytes(), kp.Public) {
+		return nil, ErrUnsupportedCipher
+	}
+
+	coefficients := make([]*edwards25519.Scalar, k)
+	coefficients[0] = secret
+	for i := 1; i < k; i++ {
+		if coefficients[i], err = randomScalar(); err != nil {
+			return nil, err
+		}
+	}
+
+	shares := make([]KeyShare, n)
+	for i := range shares {
+		id := uint16(i + 1)
+		// Evaluate the polynomial at the identifier (Horner's method).
+		x := identifierScalar(id)
+		y := edwards25519.NewScalar()
+		for j := k - 1; j >= 0; j-- {
+			y.MultiplyAdd(y, x, coefficients[j])
+		}
+		shares[i] = KeyShare{
+			Identifier:     id,
+			Threshold:      uint16(k),
+			Secret:         y.Bytes(),
+			VerifyingShare: new(edwards25519.Point).ScalarBaseMult(y).Bytes(),
+			GroupPublic:    bytes.Clone(kp.Public),
+		}
+	}
+
+	return shares, nil
+}
+
+// GroupKeypair returns the public-only ed25519 Keypair that signatures by
+// this share's group verify against.
+func (s KeyShare) GroupKeypair() (multikeypair.Keypair, error) {
+	mk, err := multikeypair.Encode(nil, s.GroupPublic, multikeypair.ED_25519)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	return mk.Decode()
+}
+
+//
+// ROUND ONE
+//
+
+// Commit generates fresh nonces for one signing operation, returning
+// them along with the commitment to send to the coordinator.
+func (s KeyShare) Commit() (*Nonces, Commitment, error) {
+	secret, err := edwards25519.NewScalar().SetCanonicalBytes(s.Secret)
+	if err != nil {
+		return nil, Commitment{}, ErrInvalidKeyShare
+	}
+	hiding, err := nonceGenerate(secret)
+	if err != nil {
+		return nil, Commitment{}, err
+	}
+	binding, err := nonceGenerate(secret)
+	if err != nil {
+		return nil, Commitment{}, err
+	}
+
+	nonces := &Nonces{hiding: hiding, binding: binding}
+	commitment := Commitment{
+		Identifier: s.Identifier,
+		Hiding:     new(edwards25519.Point).ScalarBaseMult(hiding).Bytes(),
+		Binding:    new(edwards25519.Point).ScalarBaseMult(binding).Bytes(),
+	}
+	return nonces, commitment, nil
+}
+
+// Generate a nonce, hedging the randomness with the secret share.
+func nonceGenerate(secret *edwards25519.Scalar) (*edwards25519.Scalar, error) {
+	var random [32]byte
+	if _, err := crypto_rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	return hashToScalar("nonce", random[:], secret.Bytes()), nil
+}
+
+//
+// ROUND TWO
+//
+
+// Sign produces this signer's share of the signature described by pkg,
+// using (and then wiping) the nonces from its earlier Commit. Nonces must
+// never be reused, so a second Sign with the same nonces fails. The
+// package must hold at least Threshold commitments, this signer's among
+// them.
+func (s KeyShare) Sign(nonces *Nonces, pkg SigningPackage) (SignatureShare, error) {
+	if nonces == nil || nonces.used {
+		return SignatureShare{}, ErrNoncesUsed
+	}
+	if s.Threshold < 2 {
+		return SignatureShare{}, ErrInvalidKeyShare
+	}
+	if len(pkg.Commitments) < int(s.Threshold) {
+		return SignatureShare{}, ErrTooFewSigners
+	}
+	if !slices.ContainsFunc(pkg.Commitments, func(c Commitment) bool { return c.Identifier == s.Identifier }) {
+		return SignatureShare{}, ErrNotParticipant
+	}
+	secret, err := edwards25519.NewScalar().SetCanonicalBytes(s.Secret)
+	if err != nil {
+		return SignatureShare{}, ErrInvalidKeyShare
+	}
+	group, err := new(edwards25519.Point).SetBytes(s.GroupPublic)
+	if err != nil {
+		return SignatureShare{}, ErrInvalidKeyShare
+	}
+
+	c, err := newSigningContext(group, pkg)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+	rho, ok := c.bindingFactors[s.Identifier]
+	if !ok {
+		return SignatureShare{}, ErrNotParticipant
+	}
+
+	// Refuse to sign if our nonces don't match our commitment, which
+	// would otherwise leak the secret share.
+	own := c.commitments[s.Identifier]
+	if new(edwards25519.Point).ScalarBaseMult(nonces.hiding).Equal(own.hiding) != 1 ||
+		new(edwards25519.Point).ScalarBaseMult(nonces.binding).Equal(own.binding) != 1 {
+		return SignatureShare{}, ErrNotParticipant
+	}
+
+	lambda := c.lambda(s.Identifier)
+
+	// z_i = d_i + e_i * rho_i + lambda_i * s_i * c
+	z := edwards25519.NewScalar().Multiply(lambda, secret)
+	z.Multiply(z, c.challenge)
+	z.MultiplyAdd(nonces.binding, rho, z)
+	z.Add(z, nonces.hiding)
+
+	nonces.hiding.Set(edwards25519.NewScalar())
+	nonces.binding.Set(edwards25519.NewScalar())
+	nonces.used = true
+
+	return SignatureShare{Identifier: s.Identifier, Share: z.Bytes()}, nil
+}
+
+//
+// AGGREGATION
+//
+
+// Aggregate combines one signature share per commitment in pkg into an
+// ed25519 signature over pkg.Message by group. The result is checked
+// before it is returned; use VerifyShare to find a misbehaving signer if
+// the check fails.
+func Aggregate(group multikeypair.Keypair, pkg SigningPackage, shares []SignatureShare) ([]byte, error) {
+	if group.Code != multikeypair.ED_25519 {
+		return nil, ErrUnsupportedCipher
+	}
+	groupPoint, err := new(edwards25519.Point).SetBytes(group.Public)
+	if err != nil {
+		return nil, ErrUnsupportedCipher
+	}
+	c, err := newSigningContext(groupPoint, pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) != len(c.commitments) {
+		return nil, ErrShareMismatch
+	}
+
+	z := edwards25519.NewScalar()
+	seen := make(map[uint16]bool, len(shares))
+	for _, share := range shares {
+		if _, ok := c.commitments[share.Identifier]; !ok || seen[share.Identifier] {
+			return nil, ErrShareMismatch
+		}
+		seen[share.Identifier] = true
+		zi, err := edwards25519.NewScalar().SetCanonicalBytes(share.Share)
+		if err != nil {
+			return nil, ErrInvalidShare
+		}
+		z.Add(z, zi)
+	}
+
+	signature := append(c.groupCommitment.Bytes(), z.Bytes()...)
+	if !ed25519.Verify(ed25519.PublicKey(group.Public), pkg.Message, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return signature, nil
+}
+
+// VerifyShare checks a single signature share against the verifying
+// share of the participant that produced it.
+func VerifyShare(group multikeypair.Keypair, pkg SigningPackage, verifyingShare []byte, share SignatureShare) error {
+	groupPoint, err := new(edwards25519.Point).SetBytes(group.Public)
+	if err != nil {
+		return ErrUnsupportedCipher
+	}
+	public, err := new(edwards25519.Point).SetBytes(verifyingShare)
+	if err != nil {
+		return ErrInvalidKeyShare
+	}
+	z, err := edwards25519.NewScalar().SetCanonicalBytes(share.Share)
+	if err != nil {
+		return ErrInvalidShare
+	}
+	c, err := newSigningContext(groupPoint, pkg)
+	if err != nil {
+		return err
+	}
+	commitment, ok := c.commitments[share.Identifier]
+	if !ok {
+		return ErrNotParticipant
+	}
+
+	// G * z_i == D_i + E_i * rho_i + PK_i * (c * lambda_i)
+	left := new(edwards25519.Point).ScalarBaseMult(z)
+	right := new(edwards25519.Point).ScalarMult(c.bindingFactors[share.Identifier], commitment.binding)
+	right.Add(right, commitment.hiding)
+	scale := edwards25519.NewScalar().Multiply(c.challenge, c.lambda(share.Identifier))
+	right.Add(right, new(edwards25519.Point).ScalarMult(scale, public))
+	if left.Equal(right) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+//
+// SHARED COMPUTATION
+//
+
+// A decoded commitment.
+type commitmentPoints struct {
+	hiding  *edwards25519.Point
+	binding *edwards25519.Point
+}
+
+// Values derived from a signing package that every party computes
+// identically.
+type signingContext struct {
+	identifiers     []uint16
+	commitments     map[uint16]commitmentPoints
+	bindingFactors  map[uint16]*edwards25519.Scalar
+	groupCommitment *edwards25519.Point
+	challenge       *edwards25519.Scalar
+}
+
+func newSigningContext(group *edwards25519.Point, pkg SigningPackage) (*signingContext, error) {
+	list := slices.Clone(pkg.Commitments)
+	slices.SortFunc(list, func(a, b Commitment) int {
+		return int(a.Identifier) - int(b.Identifier)
+	})
+
+	c := &signingContext{
+		commitments:    make(map[uint16]commitmentPoints, len(list)),
+		bindingFactors: make(map[uint16]*edwards25519.Scalar, len(list)),
+	}
+	if len(list) < 2 {
+		return nil, ErrInvalidPackage
+	}
+
+	// encode_group_commitment_list
+	var encoded []byte
+	for i, cm := range list {
+		if cm.Identifier == 0 || (i > 0 && list[i-1].Identifier == cm.Identifier) {
+			return nil, ErrInvalidPackage
+		}
+		hiding, err := new(edwards25519.Point).SetBytes(cm.Hiding)
+		if err != nil {
+			return nil, ErrInvalidCommitment
+		}
+		binding, err := new(edwards25519.Point).SetBytes(cm.Binding)
+		if err != nil {
+			return nil, ErrInvalidCommitment
+		}
+		c.identifiers = append(c.identifiers, cm.Identifier)
+		c.commitments[cm.Identifier] = commitmentPoints{hiding: hiding, binding: binding}
+		encoded = append(encoded, identifierScalar(cm.Identifier).Bytes()...)
+		encoded = append(encoded, cm.Hiding...)
+		encoded = append(encoded, cm.Binding...)
+	}
+
+	// compute_binding_factors
+	prefix := group.Bytes()
+	prefix = append(prefix, hash("msg", pkg.Message)...)
+	prefix = append(prefix, hash("com", encoded)...)
+	for _, id := range c.identifiers {
+		c.bindingFactors[id] = hashToScalar("rho", prefix, identifierScalar(id).Bytes())
+	}
+
+	// compute_group_commitment
+	c.groupCommitment = edwards25519.NewIdentityPoint()
+	for _, id := range c.identifiers {
+		cm := c.commitments[id]
+		term := new(edwards25519.Point).ScalarMult(c.bindingFactors[id], cm.binding)
+		c.groupCommitment.Add(c.groupCommitment, cm.hiding)
+		c.groupCommitment.Add(c.groupCommitment, term)
+	}
+
+	// compute_challenge, as in plain ed25519 (no context string).
+	h := sha512.New()
+	h.Write(c.groupCommitment.Bytes())
+	h.Write(group.Bytes())
+	h.Write(pkg.Message)
+	c.challenge, _ = edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+
+	return c, nil
+}
+
+// derive_interpolating_value for a participant of this signing context.
+func (c *signingContext) lambda(id uint16) *edwards25519.Scalar {
+	xi := identifierScalar(id)
+	numerator := identifierScalar(1)
+	denominator := identifierScalar(1)
+	for _, other := range c.identifiers {
+		if other == id {
+			continue
+		}
+		xj := identifierScalar(other)
+		numerator.Multiply(numerator, xj)
+		denominator.Multiply(denominator, edwards25519.NewScalar().Subtract(xj, xi))
+	}
+	return numerator.Multiply(numerator, denominator.Invert(denominator))
+}
+
+// Serialize a participant identifier as a scalar.
+func identifierScalar(id uint16) *edwards25519.Scalar {
+	var buf [32]byte
+	binary.LittleEndian.PutUint16(buf[:], id)
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(buf[:])
+	return s
+}
+
+// The ciphersuite's domain-separated hash (H4, H5).
+func hash(tag string, parts ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(contextString))
+	h.Write([]byte(tag))
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// The ciphersuite's domain-separated hash to scalar (H1, H3).
+func hashToScalar(tag string, parts ...[]byte) *edwards25519.Scalar {
+	s, _ := edwards25519.NewScalar().SetUniformBytes(hash(tag, parts...))
+	return s
+}
+
+// A uniformly random scalar.
+func randomScalar() (*edwards25519.Scalar, error) {
+	var buf [64]byte
+	if _, err := crypto_rand.Read(buf[:]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetUniformBytes(buf[:])
+}
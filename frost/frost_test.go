@@ -0,0 +1,245 @@
+// go-multikeypair/frost/frost_test.go
+
+package frost
+
+import (
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Generate a fresh ed25519 Keypair for testing.
+func generate(t *testing.T) multikeypair.Keypair {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := multikeypair.Encode(private, public, multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// Run both signing rounds for a subset of signers.
+func sign(t *testing.T, signers []KeyShare, message []byte) (SigningPackage, []SignatureShare) {
+	nonces := make([]*Nonces, len(signers))
+	pkg := SigningPackage{Message: message}
+	for i, s := range signers {
+		n, c, err := s.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+
+	var shares []SignatureShare
+	for i, s := range signers {
+		share, err := s.Sign(nonces[i], pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, share)
+	}
+	return pkg, shares
+}
+
+// Any threshold of signers produce a standard ed25519 signature.
+func TestThresholdSign(t *testing.T) {
+	kp := generate(t)
+	shares, err := Split(kp, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := shares[0].GroupKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("jointly signed")
+	for _, pick := range [][]int{{0, 1, 2}, {4, 2, 1}, {0, 1, 2, 3, 4}} {
+		var signers []KeyShare
+		for _, i := range pick {
+			signers = append(signers, shares[i])
+		}
+		pkg, sigShares := sign(t, signers, message)
+
+		for i, s := range sigShares {
+			if err := VerifyShare(group, pkg, signers[i].VerifyingShare, s); err != nil {
+				t.Errorf("share %d: %v", s.Identifier, err)
+			}
+		}
+
+		signature, err := Aggregate(group, pkg, sigShares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The signature verifies against the original keypair.
+		if err := kp.Verify(message, signature); err != nil {
+			t.Errorf("signers %v: %v", pick, err)
+		}
+	}
+}
+
+// Signers refuse packages with fewer commitments than the threshold.
+func TestSignThreshold(t *testing.T) {
+	shares, err := Split(generate(t), 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n0, c0, _ := shares[0].Commit()
+	_, c1, _ := shares[1].Commit()
+	pkg := SigningPackage{Message: []byte("m"), Commitments: []Commitment{c0, c1}}
+	if _, err := shares[0].Sign(n0, pkg); err != ErrTooFewSigners {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Keys that may not sign can't be split.
+func TestSplitUsage(t *testing.T) {
+	kp := generate(t)
+	kp.Metadata.Usage = multikeypair.USAGE_ENCRYPT
+	if _, err := Split(kp, 3, 2); err != multikeypair.ErrUsageNotPermitted {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// A bad share is caught by Aggregate and pinned down by VerifyShare.
+func TestBadShare(t *testing.T) {
+	kp := generate(t)
+	shares, err := Split(kp, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, _ := shares[0].GroupKeypair()
+
+	pkg, sigShares := sign(t, shares[:2], []byte("message"))
+	sigShares[1].Share = sigShares[0].Share
+	if _, err := Aggregate(group, pkg, sigShares); err != ErrInvalidSignature {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := VerifyShare(group, pkg, shares[1].VerifyingShare, sigShares[1]); err != ErrInvalidSignature {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Aggregate(group, pkg, sigShares[:1]); err != ErrShareMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Nonces can't be reused.
+func TestNonceReuse(t *testing.T) {
+	shares, err := Split(generate(t), 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n0, c0, _ := shares[0].Commit()
+	_, c1, _ := shares[1].Commit()
+	pkg := SigningPackage{Message: []byte("m"), Commitments: []Commitment{c0, c1}}
+
+	if _, err := shares[0].Sign(n0, pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := shares[0].Sign(n0, pkg); err != ErrNoncesUsed {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Signers refuse packages that don't include their commitment.
+	n2, _, _ := shares[0].Commit()
+	if _, err := shares[0].Sign(n2, pkg); err != ErrNotParticipant {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Key shares and protocol messages survive encoding.
+func TestEncoding(t *testing.T) {
+	kp := generate(t)
+	shares, err := Split(kp, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decodedShares []KeyShare
+	for _, s := range shares[:2] {
+		str, err := s.B58String()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := KeyShareFromB58(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedShares = append(decodedShares, d)
+	}
+
+	nonces := make([]*Nonces, 2)
+	pkg := SigningPackage{Message: []byte("over the wire")}
+	for i, s := range decodedShares {
+		n, c, err := s.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := c.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, err = DecodeCommitment(b); err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+
+	b, err := pkg.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, err := DecodeSigningPackage(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sigShares []SignatureShare
+	for i, s := range decodedShares {
+		share, err := s.Sign(nonces[i], received)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := share.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if share, err = DecodeSignatureShare(b); err != nil {
+			t.Fatal(err)
+		}
+		sigShares = append(sigShares, share)
+	}
+
+	group, _ := shares[0].GroupKeypair()
+	signature, err := Aggregate(group, pkg, sigShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kp.Verify(pkg.Message, signature); err != nil {
+		t.Error(err)
+	}
+}
+
+// Non-ed25519 keys and bad thresholds are rejected.
+func TestSplitInvalid(t *testing.T) {
+	kp := generate(t)
+	if _, err := Split(kp, 2, 3); err != ErrThreshold {
+		t.Errorf("unexpected error: %v", err)
+	}
+	other := kp
+	other.Code = multikeypair.ML_DSA_65
+	if _, err := Split(other, 3, 2); err != ErrUnsupportedCipher {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+// go-multikeypair/gcpkms/gcpkms.go
+//
+// A multikeypair.RemoteBackend for keys held in Google Cloud KMS.
+// Ed25519 (EC_SIGN_ED25519), P-256 (EC_SIGN_P256_SHA256) and RSA
+// (RSA_SIGN_PKCS1_*_SHA256) signing keys are supported; the resource in
+// a reference is the key version's resource name. P-256 and RSA keys
+// also sign digests, so that RemoteKeypairs for them work with
+// crypto/x509 and crypto/tls. The backend talks to
+// the Cloud KMS REST API through an HTTP client that adds credentials,
+// such as one from golang.org/x/oauth2/google:
+//
+//	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloudkms")
+//	multikeypair.RegisterRemoteBackend(gcpkms.SCHEME, gcpkms.New(client))
+//	remote, err := multikeypair.NewRemoteKeypair(ctx,
+//		"gcpkms:projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")
+
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Cloud KMS specific errors this package exports.
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported gcp kms key algorithm")
+	ErrMessageTooLarge      = errors.New("message too large for gcp kms raw signing")
+	ErrInvalidResource      = errors.New("invalid gcp kms key version name")
+	ErrRequestFailed        = errors.New("gcp kms request failed")
+	ErrCorruptResponse      = errors.New("gcp kms response failed its checksum")
+)
+
+// SCHEME is the conventional reference scheme for Cloud KMS keys.
+const SCHEME = "gcpkms"
+
+// ENDPOINT is the Cloud KMS REST API.
+const ENDPOINT = "https://cloudkms.googleapis.com/v1/"
+
+// Cloud KMS signs at most this many bytes of raw message; larger
+// messages are only accepted for keys that sign a digest.
+const MAX_MESSAGE_SIZE = 65536
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Client sends authorized requests to Cloud KMS; an *http.Client whose
+// transport adds OAuth2 credentials satisfies it.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Backend signs with keys held in Cloud KMS. A key version's algorithm
+// never changes, so it is remembered after the first lookup.
+type Backend struct {
+	client Client
+
+	mu    sync.Mutex
+	codes map[string]uint64
+}
+
+// The PublicKey resource.
+type publicKeyResponse struct {
+	Pem       string `json:"pem"`
+	PemCrc32c string `json:"pemCrc32c"`
+	Algorithm string `json:"algorithm"`
+}
+
+// The AsymmetricSign request and response. Raw data and digests are
+// mutually exclusive; Cloud KMS only accepts data for algorithms that
+// sign the message itself.
+type signRequest struct {
+	Data   []byte     `json:"data,omitempty"`
+	Digest *signedSum `json:"digest,omitempty"`
+}
+
+type signedSum struct {
+	Sha256 []byte `json:"sha256"`
+}
+
+type signResponse struct {
+	Signature       []byte `json:"signature"`
+	SignatureCrc32c string `json:"signatureCrc32c"`
+}
+
+// A Google API error.
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// New returns a backend that uses client to reach Cloud KMS.
+func New(client Client) *Backend {
+	return &Backend{client: client, codes: make(map[string]uint64)}
+}
+
+// PublicKey fetches the public half of a Cloud KMS key version. Every
+// supported algorithm is a signing one, so keys are restricted to
+// multikeypair.USAGE_SIGN.
+func (b *Backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	if !validResource(resource) {
+		return multikeypair.Keypair{}, ErrInvalidResource
+	}
+	var out publicKeyResponse
+	if err := b.call(ctx, http.MethodGet, resource+"/publicKey", nil, &out); err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if !checksumMatches([]byte(out.Pem), out.PemCrc32c) {
+		return multikeypair.Keypair{}, ErrCorruptResponse
+	}
+	code, ok := algorithmCode(out.Algorithm)
+	if !ok {
+		return multikeypair.Keypair{}, ErrUnsupportedAlgorithm
+	}
+
+	block, _ := pem.Decode([]byte(out.Pem))
+	if block == nil {
+		return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+	}
+	pk, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+	}
+	var public []byte
+	switch key := pk.(type) {
+	case ed25519.PublicKey:
+		if code == multikeypair.ED_25519 {
+			public = key
+		}
+	case *ecdsa.PublicKey:
+		if code == multikeypair.P_256 {
+			public, err = key.Bytes()
+		}
+	case *rsa.PublicKey:
+		if code == multikeypair.RSA {
+			public = x509.MarshalPKCS1PublicKey(key)
+		}
+	}
+	if public == nil || err != nil {
+		return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+	}
+
+	m, err := multikeypair.Encode(nil, public, code)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Metadata.Usage = multikeypair.USAGE_SIGN
+
+	b.mu.Lock()
+	b.codes[resource] = code
+	b.mu.Unlock()
+
+	return kp, nil
+}
+
+// Sign asks Cloud KMS to sign message. Ed25519 keys sign the message
+// itself, which Cloud KMS limits to MAX_MESSAGE_SIZE bytes; P-256 and
+// RSA keys sign its SHA-256 digest, so that the signature verifies as an
+// ordinary one for the cipher.
+func (b *Backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	code, err := b.code(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	if code != multikeypair.ED_25519 {
+		digest := sha256.Sum256(message)
+		return b.SignDigest(ctx, resource, digest[:], crypto.SHA256)
+	}
+	if len(message) > MAX_MESSAGE_SIZE {
+		return nil, ErrMessageTooLarge
+	}
+	return b.sign(ctx, resource, signRequest{Data: message})
+}
+
+// SignDigest asks Cloud KMS to sign a SHA-256 digest with a P-256 or
+// RSA key, implementing multikeypair.RemoteDigestSigner. The supported
+// algorithms all fix SHA-256, so other hashes are refused.
+func (b *Backend) SignDigest(ctx context.Context, resource string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	code, err := b.code(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	if code == multikeypair.ED_25519 || hash != crypto.SHA256 || len(digest) != sha256.Size {
+		return nil, multikeypair.ErrUnsupportedDigest
+	}
+	return b.sign(ctx, resource, signRequest{Digest: &signedSum{Sha256: digest}})
+}
+
+// The cipher of a key version, looked up the first time it is needed.
+func (b *Backend) code(ctx context.Context, resource string) (uint64, error) {
+	b.mu.Lock()
+	code, ok := b.codes[resource]
+	b.mu.Unlock()
+	if ok {
+		return code, nil
+	}
+	kp, err := b.PublicKey(ctx, resource)
+	if err != nil {
+		return 0, err
+	}
+	return kp.Code, nil
+}
+
+// Make an AsymmetricSign call, checking the signature's checksum.
+func (b *Backend) sign(ctx context.Context, resource string, in signRequest) ([]byte, error) {
+	var out signResponse
+	if err := b.call(ctx, http.MethodPost, resource+":asymmetricSign", in, &out); err != nil {
+		return nil, err
+	}
+	if !checksumMatches(out.Signature, out.SignatureCrc32c) {
+		return nil, ErrCorruptResponse
+	}
+	return out.Signature, nil
+}
+
+// Make a Cloud KMS API call, decoding the response into out.
+func (b *Backend) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, ENDPOINT+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+			return fmt.Errorf("%w: %s: %s", ErrRequestFailed, e.Error.Status, e.Error.Message)
+		}
+		return fmt.Errorf("%w: %s", ErrRequestFailed, resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// The cipher code for a Cloud KMS signing algorithm.
+func algorithmCode(algorithm string) (uint64, bool) {
+	switch algorithm {
+	case "EC_SIGN_ED25519":
+		return multikeypair.ED_25519, true
+	case "EC_SIGN_P256_SHA256":
+		return multikeypair.P_256, true
+	case "RSA_SIGN_PKCS1_2048_SHA256", "RSA_SIGN_PKCS1_3072_SHA256", "RSA_SIGN_PKCS1_4096_SHA256":
+		return multikeypair.RSA, true
+	}
+	return 0, false
+}
+
+// Report whether data matches the CRC32C checksum Cloud KMS sent with
+// it, as a decimal string. Responses without one aren't checked.
+func checksumMatches(data []byte, checksum string) bool {
+	if checksum == "" {
+		return true
+	}
+	want, err := strconv.ParseUint(checksum, 10, 32)
+	if err != nil {
+		return false
+	}
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)) == uint32(want)
+}
+
+// Report whether a resource is a key version name:
+// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*.
+func validResource(resource string) bool {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 10 {
+		return false
+	}
+	for i, collection := range []string{"projects", "locations", "keyRings", "cryptoKeys", "cryptoKeyVersions"} {
+		id := parts[2*i+1]
+		if parts[2*i] != collection || id == "" || strings.ContainsAny(id, ":?#%") {
+			return false
+		}
+	}
+	return true
+}
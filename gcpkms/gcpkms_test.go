@@ -0,0 +1,189 @@
+// go-multikeypair/gcpkms/gcpkms_test.go
+
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+const testResource = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+// A Client that answers Cloud KMS calls from a single in-memory key.
+type fakeClient struct {
+	signer      crypto.Signer
+	algorithm   string
+	publicCalls int
+	signInput   signRequest
+}
+
+func (c *fakeClient) Do(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	path := strings.TrimPrefix(req.URL.String(), ENDPOINT)
+	switch {
+	case req.Method == http.MethodGet && path == testResource+"/publicKey":
+		c.publicCalls++
+		der, err := x509.MarshalPKIXPublicKey(c.signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		json.NewEncoder(rec).Encode(publicKeyResponse{
+			Pem:       pemKey,
+			PemCrc32c: checksum([]byte(pemKey)),
+			Algorithm: c.algorithm,
+		})
+	case req.Method == http.MethodPost && path == testResource+":asymmetricSign":
+		if err := json.NewDecoder(req.Body).Decode(&c.signInput); err != nil {
+			return nil, err
+		}
+		var signature []byte
+		var err error
+		if c.signInput.Digest != nil {
+			signature, err = c.signer.Sign(crypto_rand.Reader, c.signInput.Digest.Sha256, crypto.SHA256)
+		} else {
+			signature, err = c.signer.Sign(crypto_rand.Reader, c.signInput.Data, crypto.Hash(0))
+		}
+		if err != nil {
+			return nil, err
+		}
+		json.NewEncoder(rec).Encode(signResponse{
+			Signature:       signature,
+			SignatureCrc32c: checksum(signature),
+		})
+	default:
+		rec.WriteHeader(http.StatusNotFound)
+		rec.WriteString(`{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`)
+	}
+	return rec.Result(), nil
+}
+
+func checksum(data []byte) string {
+	return strconv.FormatUint(uint64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))), 10)
+}
+
+// Signatures made through Cloud KMS verify with the cached public key,
+// for keys that sign messages and keys that sign digests.
+func TestBackendSign(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		client *fakeClient
+		code   uint64
+	}{
+		{&fakeClient{signer: edKey, algorithm: "EC_SIGN_ED25519"}, multikeypair.ED_25519},
+		{&fakeClient{signer: ecKey, algorithm: "EC_SIGN_P256_SHA256"}, multikeypair.P_256},
+	}
+	for _, c := range cases {
+		b := New(c.client)
+		ctx := context.Background()
+		kp, err := b.PublicKey(ctx, testResource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kp.Code != c.code || kp.Metadata.Usage != multikeypair.USAGE_SIGN {
+			t.Errorf("%s: unexpected key %+v", c.client.algorithm, kp)
+		}
+
+		message := []byte("hello")
+		signature, err := b.Sign(ctx, testResource, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kp.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", c.client.algorithm, err)
+		}
+		if (c.client.signInput.Digest == nil) != (c.code == multikeypair.ED_25519) {
+			t.Errorf("%s: unexpected sign request %+v", c.client.algorithm, c.client.signInput)
+		}
+		if c.client.publicCalls != 1 {
+			t.Errorf("%s: expected 1 public key fetch, got %d", c.client.algorithm, c.client.publicCalls)
+		}
+	}
+}
+
+// RSA and P-256 keys sign SHA-256 digests directly; other hashes and
+// Ed25519 keys are refused.
+func TestBackendSignDigest(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(crypto_rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b := New(&fakeClient{signer: rsaKey, algorithm: "RSA_SIGN_PKCS1_2048_SHA256"})
+	digest := sha256.Sum256([]byte("hello"))
+	signature, err := b.SignDigest(ctx, testResource, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Error(err)
+	}
+	long := sha512.Sum512([]byte("hello"))
+	if _, err := b.SignDigest(ctx, testResource, long[:], crypto.SHA512); !errors.Is(err, multikeypair.ErrUnsupportedDigest) {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+
+	b = New(&fakeClient{signer: edKey, algorithm: "EC_SIGN_ED25519"})
+	if _, err := b.SignDigest(ctx, testResource, digest[:], crypto.SHA256); !errors.Is(err, multikeypair.ErrUnsupportedDigest) {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+}
+
+// Unsupported algorithms, malformed names and API errors are refused.
+func TestBackendErrors(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b := New(&fakeClient{signer: edKey, algorithm: "HMAC_SHA256"})
+	if _, err := b.PublicKey(ctx, testResource); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+
+	b = New(&fakeClient{signer: edKey, algorithm: "EC_SIGN_ED25519"})
+	for _, bad := range []string{"", "projects/p", "projects/p/locations/l/keyRings/r/cryptoKeys/k", testResource + "/x/y"} {
+		if _, err := b.PublicKey(ctx, bad); !errors.Is(err, ErrInvalidResource) {
+			t.Errorf("%q: expected ErrInvalidResource, got %v", bad, err)
+		}
+	}
+	other := strings.Replace(testResource, "cryptoKeys/k", "cryptoKeys/other", 1)
+	if _, err := b.PublicKey(ctx, other); !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("expected ErrRequestFailed, got %v", err)
+	}
+	if _, err := b.Sign(ctx, testResource, make([]byte, MAX_MESSAGE_SIZE+1)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
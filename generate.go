@@ -0,0 +1,129 @@
+// go-multikeypair/generate.go
+//
+// Generation of fresh keypairs for the ciphers we know how to operate on,
+// either at random or deterministically from a seed.
+//
+// Derivation from a seed is stable: the same seed and cipher code always
+// give the same keypair. A seed of the cipher's native seed size is used
+// as is:
+//
+//	ed25519     32-byte RFC 8032 seed (as ed25519.NewKeyFromSeed)
+//	ml-dsa-65   32-byte FIPS 204 seed
+//	ml-kem-768  64-byte FIPS 203 seed
+//	x25519      32-byte private scalar
+//	p256        32-byte private scalar
+//	secp256k1   32-byte private scalar
+//
+// Any other seed, of at least MIN_SEED_LENGTH bytes, is first expanded
+// to the native size with HKDF-SHA256:
+//
+//	HKDF(ikm = seed, salt = "", info = "multikeypair seed v1\x00" || uvarint(code) || counter)
+//
+// with counter a single byte starting at zero. Should the material not
+// be a valid scalar for p256 or secp256k1, the seed is expanded again
+// with the next counter; a native-size seed that isn't a valid scalar is
+// expanded from counter zero.
+// A hybrid derives each component from a 32-byte seed, expanded from its
+// own as above with the component's uvarint code in place of the counter.
+// RSA keys can't be derived from a seed.
+
+package multikeypair
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Generation-specific errors this module exports.
+var (
+	ErrShortSeed = errors.New("seed must be at least 32 bytes")
+)
+
+// Shortest seed GenerateFromSeed accepts.
+const MIN_SEED_LENGTH = 32
+
+// Domain separation prefix for seed expansion.
+const seedDomain = "multikeypair seed v1\x00"
+
+// Returned by a scheme's fromSeed when the material isn't a valid key.
+var errSeedRejected = errors.New("seed material rejected")
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Generate creates a new random Keypair for a cipher code, using the
+// system's secure random number generator.
+func Generate(code uint64) (Keypair, error) {
+	s, err := lookupScheme(code)
+	if err != nil {
+		return Keypair{}, err
+	}
+	if s.generate == nil {
+		return Keypair{}, ErrUnsupportedCipher
+	}
+	private, public, err := s.generate()
+	if err != nil {
+		return Keypair{}, err
+	}
+	return newKeypair(code, private, public), nil
+}
+
+// GenerateFromSeed derives a Keypair for a cipher code from a seed, as
+// described above. The seed should be secret and uniformly random; the
+// keypair is only as strong as it is.
+func GenerateFromSeed(code uint64, seed []byte) (Keypair, error) {
+	if len(seed) < MIN_SEED_LENGTH {
+		return Keypair{}, ErrShortSeed
+	}
+	s, err := lookupScheme(code)
+	if err != nil {
+		return Keypair{}, err
+	}
+	if s.fromSeed == nil {
+		return Keypair{}, ErrUnsupportedCipher
+	}
+
+	counter := 0
+	if len(seed) != s.seedSize {
+		counter = 1
+	}
+	material := seed
+	for ; counter <= 0x100; counter++ {
+		if counter > 0 {
+			if material, err = expandSeed(seed, code, []byte{byte(counter - 1)}, s.seedSize); err != nil {
+				return Keypair{}, err
+			}
+		}
+		private, public, err := s.fromSeed(material)
+		if errors.Is(err, errSeedRejected) {
+			continue
+		}
+		if err != nil {
+			return Keypair{}, err
+		}
+		return newKeypair(code, private, public), nil
+	}
+	return Keypair{}, errSeedRejected
+}
+
+// Expand a seed into size bytes of material for a cipher code.
+func expandSeed(seed []byte, code uint64, label []byte, size int) ([]byte, error) {
+	info := append([]byte(seedDomain), PackCode(code)...)
+	return hkdf.Key(sha256.New, seed, nil, string(append(info, label...)), size)
+}
+
+// Build a Keypair from its keys.
+func newKeypair(code uint64, private []byte, public []byte) Keypair {
+	return Keypair{
+		Code:          code,
+		Name:          codeName(code),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}
+}
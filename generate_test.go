@@ -0,0 +1,117 @@
+// go-multikeypair/generate_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Generated keypairs of every supported cipher can sign and verify.
+func TestGenerate(t *testing.T) {
+	for _, code := range []uint64{ED_25519, ML_DSA_65, ED_25519_ML_DSA_65, P_256, SECP_256K1} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		if kp.Code != code || kp.Name != Codes[code] {
+			t.Errorf("%x: unexpected code or name: %x %s", code, kp.Code, kp.Name)
+		}
+		sig, err := kp.Sign([]byte("message"))
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		if err := kp.Verify([]byte("message"), sig); err != nil {
+			t.Errorf("%x: %v", code, err)
+		}
+		if _, err := kp.Encode(); err != nil {
+			t.Errorf("%x: %v", code, err)
+		}
+	}
+}
+
+// Ciphers we can't operate on can't be generated.
+func TestGenerateUnsupported(t *testing.T) {
+	if _, err := Generate(DSA); err != ErrUnsupportedCipher {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Generate(0x1234); err != ErrUnknownCode {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Seeds give the same keypair every time, and different seeds or codes
+// give different keypairs.
+func TestGenerateFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 48)
+	other := bytes.Repeat([]byte{0x43}, 48)
+	for _, code := range []uint64{ED_25519, ML_DSA_65, ED_25519_ML_DSA_65, P_256, X_25519, SECP_256K1, ML_KEM_768} {
+		first, err := GenerateFromSeed(code, seed)
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		second, err := GenerateFromSeed(code, seed)
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		if !first.Equal(second) {
+			t.Errorf("%x: derivation isn't deterministic", code)
+		}
+		third, err := GenerateFromSeed(code, other)
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		if bytes.Equal(first.Public, third.Public) {
+			t.Errorf("%x: different seeds gave the same key", code)
+		}
+		if first.Code != code || first.Name != Codes[code] {
+			t.Errorf("%x: unexpected code or name", code)
+		}
+	}
+}
+
+// A 32-byte seed is the Ed25519 seed itself, and a native-size scalar
+// the private key itself.
+func TestGenerateFromSeedNative(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x01}, 32)
+	kp, err := GenerateFromSeed(ED_25519, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kp.Private, ed25519.NewKeyFromSeed(seed)) {
+		t.Error("ed25519 seed wasn't used as is")
+	}
+	kp, err = GenerateFromSeed(SECP_256K1, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kp.Private, seed) {
+		t.Error("secp256k1 scalar wasn't used as is")
+	}
+}
+
+// A known seed derives a known key, so the derivation can't drift.
+// The expected scalar was computed independently from the HKDF
+// definition.
+func TestGenerateFromSeedStable(t *testing.T) {
+	kp, err := GenerateFromSeed(P_256, bytes.Repeat([]byte{0x42}, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(kp.Private); got != "ef5b48de41d36268fb836eb434692c51d82ae9b91e58c063103300662d07330e" {
+		t.Errorf("derived %s", got)
+	}
+}
+
+// Short seeds and ciphers without seed derivation are refused.
+func TestGenerateFromSeedInvalid(t *testing.T) {
+	if _, err := GenerateFromSeed(ED_25519, make([]byte, 16)); !errors.Is(err, ErrShortSeed) {
+		t.Errorf("short seed: %v", err)
+	}
+	if _, err := GenerateFromSeed(RSA, make([]byte, 32)); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("rsa: %v", err)
+	}
+}
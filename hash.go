@@ -0,0 +1,193 @@
+// go-multikeypair/hash.go
+//
+// A registry of multihash functions used wherever this module needs
+// to digest key material: fingerprints, checksums, and commitments.
+// Digests are self-describing (multihash encoded) so that a verifier
+// always knows which function produced them.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"sync"
+
+	b58 "github.com/mr-tron/base58/base58"
+	varint "github.com/multiformats/go-varint"
+	blake2b "golang.org/x/crypto/blake2b"
+	sha3 "golang.org/x/crypto/sha3"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Hash-specific errors this module exports.
+var (
+	ErrUnknownHash      = errors.New("unknown multihash code")
+	ErrHashRegistered   = errors.New("multihash code already registered")
+	ErrInvalidMultihash = errors.New("input isn't valid multihash")
+	ErrDigestMismatch   = errors.New("multihash digest mismatch")
+)
+
+// Hashes
+// -----------------------------------------------------------------------------
+
+// Multihash codes for the hash functions we know about. The values are
+// taken from the multicodec table.
+const (
+	SHA2_256    = uint64(0x12)
+	SHA2_512    = uint64(0x13)
+	SHA3_256    = uint64(0x16)
+	BLAKE3      = uint64(0x1e)
+	BLAKE2B_256 = uint64(0xb220)
+)
+
+// DEFAULT_HASH is the hash function used when the caller doesn't ask for
+// a specific one.
+const DEFAULT_HASH = SHA2_256
+
+// hashFunc is a registered hash function.
+type hashFunc struct {
+	// Human-readable hash name.
+	name string
+	// Constructor for a fresh hash state.
+	new func() hash.Hash
+}
+
+var (
+	hashesMu sync.RWMutex
+	// BLAKE3 is not built in; callers that want it register an
+	// implementation of their choosing, e.g.
+	//   RegisterHash(BLAKE3, "blake3", func() hash.Hash { return blake3.New(32, nil) })
+	hashes = map[uint64]hashFunc{
+		SHA2_256: {"sha2-256", sha256.New},
+		SHA2_512: {"sha2-512", sha512.New},
+		SHA3_256: {"sha3-256", sha3.New256},
+		BLAKE2B_256: {"blake2b-256", func() hash.Hash {
+			// Only fails when given an over-long key.
+			h, _ := blake2b.New256(nil)
+			return h
+		}},
+	}
+)
+
+// RegisterHash makes a hash function available under a multihash code.
+// Codes that are already registered cannot be replaced.
+func RegisterHash(code uint64, name string, fn func() hash.Hash) error {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+
+	if _, ok := hashes[code]; ok {
+		return ErrHashRegistered
+	}
+	hashes[code] = hashFunc{name: name, new: fn}
+	return nil
+}
+
+// HashName returns the human-readable name of a registered hash function.
+func HashName(code uint64) (string, error) {
+	h, err := lookupHash(code)
+	if err != nil {
+		return "", err
+	}
+	return h.name, nil
+}
+
+// Look up a registered hash function by code.
+func lookupHash(code uint64) (hashFunc, error) {
+	hashesMu.RLock()
+	defer hashesMu.RUnlock()
+
+	h, ok := hashes[code]
+	if !ok {
+		return hashFunc{}, ErrUnknownHash
+	}
+	return h, nil
+}
+
+// Multihash
+// -----------------------------------------------------------------------------
+
+// Multihash is a self-describing digest with the following form:
+// <hash code><digest length><digest> (uvarint code, uvarint length)
+type Multihash []byte
+
+// Sum digests data using the hash function registered under code.
+func Sum(data []byte, code uint64) (Multihash, error) {
+	h, err := lookupHash(code)
+	if err != nil {
+		return Multihash{}, err
+	}
+	state := h.new()
+	state.Write(data)
+	return encodeMultihash(code, state.Sum(nil)), nil
+}
+
+// Pack a hash code and digest into a Multihash.
+func encodeMultihash(code uint64, digest []byte) Multihash {
+	buf := make([]byte, 0, varint.UvarintSize(code)+varint.UvarintSize(uint64(len(digest)))+len(digest))
+	buf = append(buf, varint.ToUvarint(code)...)
+	buf = append(buf, varint.ToUvarint(uint64(len(digest)))...)
+	buf = append(buf, digest...)
+	return Multihash(buf)
+}
+
+// Unpack a Multihash into its hash code and digest.
+func decodeMultihash(m Multihash) (uint64, []byte, error) {
+	code, n, err := varint.FromUvarint(m)
+	if err != nil {
+		return 0, nil, ErrInvalidMultihash
+	}
+	length, l, err := varint.FromUvarint(m[n:])
+	if err != nil {
+		return 0, nil, ErrInvalidMultihash
+	}
+	digest := m[n+l:]
+	if uint64(len(digest)) != length {
+		return 0, nil, ErrInvalidMultihash
+	}
+	return code, digest, nil
+}
+
+// Code returns the hash code recorded in the Multihash.
+func (m Multihash) Code() (uint64, error) {
+	code, _, err := decodeMultihash(m)
+	return code, err
+}
+
+// Digest returns the raw digest bytes recorded in the Multihash.
+func (m Multihash) Digest() ([]byte, error) {
+	_, digest, err := decodeMultihash(m)
+	return digest, err
+}
+
+// Verify recomputes the digest of data using the hash function recorded
+// in the Multihash and checks that it matches.
+func (m Multihash) Verify(data []byte) error {
+	code, _, err := decodeMultihash(m)
+	if err != nil {
+		return err
+	}
+	sum, err := Sum(data, code)
+	if err != nil {
+		return err
+	}
+	if !m.Equal(sum) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// Equal reports whether two Multihashes record the same function and
+// digest.
+func (m Multihash) Equal(o Multihash) bool {
+	return bytes.Equal(m, o)
+}
+
+// B58String generates a base58-encoded version of a Multihash.
+func (m Multihash) B58String() string {
+	return b58.Encode([]byte(m))
+}
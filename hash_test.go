@@ -0,0 +1,88 @@
+// go-multikeypair/hash_test.go
+
+package multikeypair
+
+import (
+	"crypto/sha1"
+	"errors"
+	"testing"
+)
+
+// Digest some data with each built-in hash function and check that the
+// result is self-describing and verifies.
+func TestSum(t *testing.T) {
+	data := []byte("cv-sB6?r*RW8vP5TuMSv_wvw#dV4nUP!@y%u@pmK!P-S2gYVLve!PfdC#kew5Q7U")
+
+	for _, code := range []uint64{SHA2_256, SHA2_512, SHA3_256, BLAKE2B_256} {
+		mh, err := Sum(data, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := mh.Code()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != code {
+			t.Errorf("hash code mismatch: %d != %d", code, c)
+		}
+		if err := mh.Verify(data); err != nil {
+			t.Errorf("expected digest to verify: %s", err)
+		}
+		if err := mh.Verify(data[1:]); !errors.Is(err, ErrDigestMismatch) {
+			t.Errorf("expected digest mismatch, got: %v", err)
+		}
+	}
+}
+
+// Unknown hash codes are rejected.
+func TestSumUnknown(t *testing.T) {
+	if _, err := Sum([]byte("data"), 0xdead); !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("expected unknown hash error, got: %v", err)
+	}
+}
+
+// Register an additional hash function and use it.
+func TestRegisterHash(t *testing.T) {
+	const SHA1 = uint64(0x11)
+	if err := RegisterHash(SHA1, "sha1", sha1.New); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unregisterHash(SHA1) })
+	if err := RegisterHash(SHA1, "sha1", sha1.New); !errors.Is(err, ErrHashRegistered) {
+		t.Fatalf("expected duplicate registration error, got: %v", err)
+	}
+	mh, err := Sum([]byte("data"), SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := mh.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest) != sha1.Size {
+		t.Errorf("digest length mismatch: %d != %d", sha1.Size, len(digest))
+	}
+	name, err := HashName(SHA1)
+	if err != nil || name != "sha1" {
+		t.Errorf("expected registered name, got: %q (%v)", name, err)
+	}
+}
+
+// Remove a hash function registered by a test, so that it doesn't leak
+// into others.
+func unregisterHash(code uint64) {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	delete(hashes, code)
+}
+
+// Truncated multihashes are rejected.
+func TestInvalidMultihash(t *testing.T) {
+	mh, err := Sum([]byte("data"), DEFAULT_HASH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mh[:len(mh)-1].Digest(); !errors.Is(err, ErrInvalidMultihash) {
+		t.Fatalf("expected invalid multihash error, got: %v", err)
+	}
+}
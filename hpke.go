@@ -0,0 +1,142 @@
+// go-multikeypair/hpke.go
+//
+// Hybrid Public Key Encryption (RFC 9180) to multikeypair KEM keys, in
+// base mode. The keypair's cipher selects the KEM:
+//
+//	x25519      DHKEM(X25519, HKDF-SHA256)
+//	p256        DHKEM(P-256, HKDF-SHA256)
+//	ml-kem-768  ML-KEM-768
+//
+// and an HPKESuite the KDF and AEAD, defaulting to HKDF-SHA256 and
+// AES-128-GCM. HPKESeal and HPKEOpen handle single messages, as the
+// encapsulated key followed by the ciphertext; HPKESender and
+// HPKERecipient set up contexts for a stream of messages.
+
+package multikeypair
+
+import (
+	"crypto/ecdh"
+	"crypto/hpke"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// HPKESuite selects the KDF and AEAD of an HPKE ciphersuite; the KEM is
+// given by the keypair. Nil fields use HKDF-SHA256 and AES-128-GCM.
+type HPKESuite struct {
+	KDF  hpke.KDF
+	AEAD hpke.AEAD
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// HPKESeal encrypts plaintext to the public key, returning the
+// encapsulated key followed by the ciphertext. Keys whose usage doesn't
+// include USAGE_ENCRYPT are refused.
+func (k Keypair) HPKESeal(suite HPKESuite, info []byte, plaintext []byte) ([]byte, error) {
+	pk, err := k.HPKEPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	kdf, aead := suite.algorithms()
+	return hpke.Seal(pk, kdf, aead, info, plaintext)
+}
+
+// HPKEOpen decrypts a message made by HPKESeal using the private key.
+func (k Keypair) HPKEOpen(suite HPKESuite, info []byte, ciphertext []byte) ([]byte, error) {
+	sk, err := k.HPKEPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	kdf, aead := suite.algorithms()
+	plaintext, err := hpke.Open(sk, kdf, aead, info, ciphertext)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// HPKESender sets up a context for sending a stream of messages to the
+// public key, returning the encapsulated key the recipient needs.
+func (k Keypair) HPKESender(suite HPKESuite, info []byte) ([]byte, *hpke.Sender, error) {
+	pk, err := k.HPKEPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	kdf, aead := suite.algorithms()
+	return hpke.NewSender(pk, kdf, aead, info)
+}
+
+// HPKERecipient sets up a context for receiving a stream of messages
+// with the private key, given the sender's encapsulated key.
+func (k Keypair) HPKERecipient(suite HPKESuite, enc []byte, info []byte) (*hpke.Recipient, error) {
+	sk, err := k.HPKEPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	kdf, aead := suite.algorithms()
+	r, err := hpke.NewRecipient(enc, sk, kdf, aead, info)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return r, nil
+}
+
+// HPKEPublicKey returns the public key as an HPKE KEM public key.
+func (k Keypair) HPKEPublicKey() (hpke.PublicKey, error) {
+	if err := k.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	kem, err := hpkeKEM(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := kem.NewPublicKey(k.Public)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return pk, nil
+}
+
+// HPKEPrivateKey returns the private key as an HPKE KEM private key.
+func (k Keypair) HPKEPrivateKey() (hpke.PrivateKey, error) {
+	if err := k.checkUsage(USAGE_ENCRYPT); err != nil {
+		return nil, err
+	}
+	kem, err := hpkeKEM(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := kem.NewPrivateKey(k.Private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk, nil
+}
+
+// The KEM for a cipher code.
+func hpkeKEM(code uint64) (hpke.KEM, error) {
+	switch code {
+	case X_25519:
+		return hpke.DHKEM(ecdh.X25519()), nil
+	case P_256:
+		return hpke.DHKEM(ecdh.P256()), nil
+	case ML_KEM_768:
+		return hpke.MLKEM768(), nil
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// The suite's KDF and AEAD, with defaults filled in.
+func (s HPKESuite) algorithms() (hpke.KDF, hpke.AEAD) {
+	kdf, aead := s.KDF, s.AEAD
+	if kdf == nil {
+		kdf = hpke.HKDFSHA256()
+	}
+	if aead == nil {
+		aead = hpke.AES128GCM()
+	}
+	return kdf, aead
+}
@@ -0,0 +1,111 @@
+// go-multikeypair/hpke_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/hpke"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// The first message of RFC 9180's test vector A.1.1:
+// DHKEM(X25519, HKDF-SHA256), HKDF-SHA256, AES-128-GCM.
+const (
+	testHPKEPrivate = "4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8"
+	testHPKEPublic  = "3948cfe0ad1ddb695d780e59077195da6c56506b027329794ab02bca80815c4d"
+	testHPKEEnc     = "37fda3567bdbd628e88668c3c8d7e97d1d1253b6d4ea6d44c150f741f1bf4431"
+	testHPKECipher  = "f938558b5d72f1a23810b4be2ab4f84331acc02fc97babc53a52ae8218a355a96d8770ac83d07bea87e13c512a"
+)
+
+// The RFC 9180 test vector decrypts with the default suite.
+func TestHPKEVector(t *testing.T) {
+	private, _ := hex.DecodeString(testHPKEPrivate)
+	public, _ := hex.DecodeString(testHPKEPublic)
+	enc, _ := hex.DecodeString(testHPKEEnc)
+	ciphertext, _ := hex.DecodeString(testHPKECipher)
+	kp := Keypair{Code: X_25519, Private: private, Public: public}
+
+	r, err := kp.HPKERecipient(HPKESuite{}, enc, []byte("Ode on a Grecian Urn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := r.Open([]byte("Count-0"), ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "Beauty is truth, truth beauty" {
+		t.Errorf("unexpected plaintext %q", plaintext)
+	}
+}
+
+// Single messages and streams round trip for each KEM.
+func TestHPKERoundTrip(t *testing.T) {
+	info := []byte("test")
+	suites := []HPKESuite{{}, {KDF: hpke.HKDFSHA512(), AEAD: hpke.ChaCha20Poly1305()}}
+	for _, code := range []uint64{X_25519, P_256, ML_KEM_768} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, suite := range suites {
+			ciphertext, err := kp.HPKESeal(suite, info, []byte("hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			plaintext, err := kp.HPKEOpen(suite, info, ciphertext)
+			if err != nil {
+				t.Fatalf("%s: %v", Codes[code], err)
+			}
+			if string(plaintext) != "hello" {
+				t.Errorf("%s: unexpected plaintext %q", Codes[code], plaintext)
+			}
+			if _, err := kp.HPKEOpen(suite, []byte("other"), ciphertext); !errors.Is(err, ErrDecryptionFailed) {
+				t.Errorf("%s: expected ErrDecryptionFailed for wrong info, got %v", Codes[code], err)
+			}
+		}
+
+		enc, s, err := kp.HPKESender(HPKESuite{}, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := kp.HPKERecipient(HPKESuite{}, enc, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, message := range [][]byte{[]byte("one"), []byte("two"), {}} {
+			ciphertext, err := s.Seal(nil, message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plaintext, err := r.Open(nil, ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(plaintext, message) {
+				t.Errorf("%s: expected %q, got %q", Codes[code], message, plaintext)
+			}
+		}
+	}
+}
+
+// Other ciphers and restricted keys are refused.
+func TestHPKERefused(t *testing.T) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.HPKESeal(HPKESuite{}, nil, nil); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+
+	kp, err = Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata.Usage = USAGE_DERIVE
+	if _, err := kp.HPKESeal(HPKESuite{}, nil, nil); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
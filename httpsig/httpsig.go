@@ -0,0 +1,525 @@
+// go-multikeypair/httpsig/httpsig.go
+//
+// HTTP Message Signatures (RFC 9421) on requests, for service-to-service
+// authentication with multikeypairs. A Signer adds a signature over
+// selected components of an outgoing request:
+//
+//	Signature-Input: sig1=("@method" "@authority" "@path" "@query");created=1618884473;keyid="SHA256:...";alg="ed25519"
+//	Signature: sig1=:<base64 signature>:
+//
+// where keyid is the SHA-256 fingerprint string of the signing key, and
+// a Verifier checks it on incoming requests, looking the key up by that
+// fingerprint. The algorithm follows from the keypair's cipher:
+//
+//	ed25519    ed25519
+//	p256       ecdsa-p256-sha256
+//	rsa        rsa-v1_5-sha256
+//	secp256k1  (unregistered; no alg parameter)
+//
+// Headers are covered by lower-cased name. The request body isn't
+// covered unless a Content-Digest (RFC 9530) header is added and
+// included among the components.
+
+package httpsig
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// HTTP signature errors this package exports.
+var (
+	ErrNoSignature       = errors.New("request has no signature")
+	ErrInvalidSignature  = errors.New("request signature is malformed")
+	ErrMissingComponent  = errors.New("request lacks signed component")
+	ErrUncovered         = errors.New("signature doesn't cover required component")
+	ErrUnknownKey        = errors.New("signature key isn't known")
+	ErrSignatureExpired  = errors.New("request signature expired")
+	ErrSignatureTooEarly = errors.New("request signature created in the future")
+)
+
+// Algorithms maps cipher codes to the RFC 9421 algorithm names recorded
+// in the alg parameter.
+var Algorithms = map[uint64]string{
+	multikeypair.ED_25519: "ed25519",
+	multikeypair.P_256:    "ecdsa-p256-sha256",
+	multikeypair.RSA:      "rsa-v1_5-sha256",
+}
+
+// Components covered when a Signer doesn't name any.
+var DefaultComponents = []string{"@method", "@authority", "@path", "@query"}
+
+// Label of the signature when a Signer doesn't give one.
+const defaultLabel = "sig1"
+
+// Allowed clock skew for signatures created in the future.
+const clockSkew = time.Minute
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Signer signs outgoing requests with a keypair.
+type Signer struct {
+	// Key signing requests.
+	Key multikeypair.Keypair
+	// Components covered: derived components such as "@method", and
+	// header names. Defaults to DefaultComponents.
+	Components []string
+	// Label of the signature. Defaults to "sig1".
+	Label string
+	// Lifetime of signatures, recorded in the expires parameter. Zero
+	// for no expiry.
+	Lifetime time.Duration
+}
+
+// Verifier checks signatures on incoming requests.
+type Verifier struct {
+	// Keys looks up a public key by its fingerprint string (keyid).
+	Keys func(keyid string) (multikeypair.Keypair, error)
+	// Components a signature must cover. Defaults to DefaultComponents.
+	Required []string
+	// Oldest signature accepted, by its created parameter. Zero accepts
+	// any age.
+	MaxAge time.Duration
+}
+
+// A signature's covered components and parameters.
+type params struct {
+	components []string
+	created    int64
+	expires    int64
+	keyid      string
+	alg        string
+	// The serialization received, which the signature base must
+	// reproduce exactly.
+	raw string
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// SIGNING
+//
+
+// Sign adds Signature-Input and Signature headers to a request.
+func (s Signer) Sign(req *http.Request) error {
+	keyid, err := s.Key.FingerprintString(multikeypair.SHA2_256)
+	if err != nil {
+		return err
+	}
+	components := s.Components
+	if components == nil {
+		components = DefaultComponents
+	}
+	label := s.Label
+	if label == "" {
+		label = defaultLabel
+	}
+
+	now := time.Now()
+	p := params{
+		components: lowered(components),
+		created:    now.Unix(),
+		keyid:      keyid,
+		alg:        Algorithms[s.Key.Code],
+	}
+	if s.Lifetime != 0 {
+		p.expires = now.Add(s.Lifetime).Unix()
+	}
+
+	base, err := signatureBase(req, p)
+	if err != nil {
+		return err
+	}
+	signature, err := s.Key.Sign(base)
+	if err != nil {
+		return err
+	}
+	if signature, err = s.Key.RawSignature(signature); err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature-Input", label+"="+p.String())
+	req.Header.Set("Signature", label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// Transport returns a RoundTripper that signs each request before
+// passing it to base, or http.DefaultTransport if base is nil. Requests
+// are cloned rather than modified.
+func (s Signer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		if err := s.Sign(req); err != nil {
+			return nil, err
+		}
+		return base.RoundTrip(req)
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+//
+// VERIFYING
+//
+
+// Verify checks the signatures on a request, returning the key of the
+// first one that is valid and covers the required components.
+func (v Verifier) Verify(req *http.Request) (multikeypair.Keypair, error) {
+	inputs, err := parseDictionary(req.Header.Values("Signature-Input"))
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	signatures, err := parseDictionary(req.Header.Values("Signature"))
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if len(inputs) == 0 {
+		return multikeypair.Keypair{}, ErrNoSignature
+	}
+
+	required := v.Required
+	if required == nil {
+		required = DefaultComponents
+	}
+	err = ErrNoSignature
+	for _, member := range inputs {
+		var key multikeypair.Keypair
+		if key, err = v.verify(req, member, signatures, lowered(required)); err == nil {
+			return key, nil
+		}
+	}
+	return multikeypair.Keypair{}, err
+}
+
+// Verify one labelled signature.
+func (v Verifier) verify(req *http.Request, input member, signatures []member, required []string) (multikeypair.Keypair, error) {
+	p, err := parseParams(input.value)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	var encoded string
+	for _, m := range signatures {
+		if m.label == input.label {
+			encoded = m.value
+		}
+	}
+	if len(encoded) < 2 || encoded[0] != ':' || encoded[len(encoded)-1] != ':' {
+		return multikeypair.Keypair{}, ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded[1 : len(encoded)-1])
+	if err != nil {
+		return multikeypair.Keypair{}, ErrInvalidSignature
+	}
+
+	for _, c := range required {
+		if !slices.Contains(p.components, c) {
+			return multikeypair.Keypair{}, ErrUncovered
+		}
+	}
+	now := time.Now()
+	if p.expires != 0 && now.Unix() >= p.expires {
+		return multikeypair.Keypair{}, ErrSignatureExpired
+	}
+	if p.created != 0 {
+		created := time.Unix(p.created, 0)
+		if created.After(now.Add(clockSkew)) {
+			return multikeypair.Keypair{}, ErrSignatureTooEarly
+		}
+		if v.MaxAge != 0 && now.Sub(created) > v.MaxAge {
+			return multikeypair.Keypair{}, ErrSignatureExpired
+		}
+	}
+
+	if v.Keys == nil {
+		return multikeypair.Keypair{}, ErrUnknownKey
+	}
+	key, err := v.Keys(p.keyid)
+	if err != nil {
+		return multikeypair.Keypair{}, ErrUnknownKey
+	}
+	if p.alg != "" && p.alg != Algorithms[key.Code] {
+		return multikeypair.Keypair{}, ErrInvalidSignature
+	}
+	base, err := signatureBase(req, p)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if signature, err = key.DERSignature(signature); err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if err := key.Verify(base, signature); err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	return key, nil
+}
+
+// Middleware returns a handler that verifies requests before passing
+// them to next, answering 401 Unauthorized when verification fails. The
+// verified key is available to next through KeyFromContext.
+func (v Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key, err := v.Verify(req)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, key)))
+	})
+}
+
+type contextKey struct{}
+
+// KeyFromContext returns the key that signed a request passed on by
+// Middleware.
+func KeyFromContext(ctx context.Context) (multikeypair.Keypair, bool) {
+	key, ok := ctx.Value(contextKey{}).(multikeypair.Keypair)
+	return key, ok
+}
+
+// Keys returns a lookup function for a Verifier over a fixed set of
+// public keys, indexed by their fingerprint strings.
+func Keys(keys ...multikeypair.Keypair) func(keyid string) (multikeypair.Keypair, error) {
+	byID := make(map[string]multikeypair.Keypair, len(keys))
+	for _, k := range keys {
+		if id, err := k.FingerprintString(multikeypair.SHA2_256); err == nil {
+			byID[id] = k
+		}
+	}
+	return func(keyid string) (multikeypair.Keypair, error) {
+		k, ok := byID[keyid]
+		if !ok {
+			return multikeypair.Keypair{}, ErrUnknownKey
+		}
+		return k, nil
+	}
+}
+
+//
+// SIGNATURE BASE
+//
+
+// Build the signature base (RFC 9421 section 2.5): a line per covered
+// component, then the signature parameters.
+func signatureBase(req *http.Request, p params) ([]byte, error) {
+	var b strings.Builder
+	for _, c := range p.components {
+		value, err := componentValue(req, c)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%q: %s\n", c, value)
+	}
+	fmt.Fprintf(&b, "\"@signature-params\": %s", p)
+	return []byte(b.String()), nil
+}
+
+// The value of a derived component or header field.
+func componentValue(req *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return scheme(req) + "://" + authority(req) + req.URL.RequestURI(), nil
+	case "@authority":
+		return authority(req), nil
+	case "@scheme":
+		return scheme(req), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if path := req.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", ErrMissingComponent
+	}
+	values := req.Header.Values(component)
+	if len(values) == 0 {
+		return "", ErrMissingComponent
+	}
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// The request's authority, lower-cased and without a default port.
+func authority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+	if s := scheme(req); s == "https" {
+		host = strings.TrimSuffix(host, ":443")
+	} else if s == "http" {
+		host = strings.TrimSuffix(host, ":80")
+	}
+	return host
+}
+
+// The request's scheme: that of the URL for outgoing requests, or from
+// the connection for incoming ones.
+func scheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Lower-case component names, which header names are matched by.
+func lowered(components []string) []string {
+	out := make([]string, len(components))
+	for i, c := range components {
+		out[i] = strings.ToLower(c)
+	}
+	return out
+}
+
+//
+// STRUCTURED FIELDS
+//
+
+// Serialize signature parameters as an inner list with parameters
+// (RFC 8941): as received, or in the order Sign writes them.
+func (p params) String() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	quoted := make([]string, len(p.components))
+	for i, c := range p.components {
+		quoted[i] = strconv.Quote(c)
+	}
+	s := "(" + strings.Join(quoted, " ") + ")"
+	if p.created != 0 {
+		s += ";created=" + strconv.FormatInt(p.created, 10)
+	}
+	if p.expires != 0 {
+		s += ";expires=" + strconv.FormatInt(p.expires, 10)
+	}
+	if p.keyid != "" {
+		s += ";keyid=" + strconv.Quote(p.keyid)
+	}
+	if p.alg != "" {
+		s += ";alg=" + strconv.Quote(p.alg)
+	}
+	return s
+}
+
+// A dictionary member: its label and unparsed value.
+type member struct {
+	label string
+	value string
+}
+
+// Split the dictionary in one or more header lines into members, at
+// commas outside strings and inner lists.
+func parseDictionary(lines []string) ([]member, error) {
+	var members []member
+	for _, line := range lines {
+		depth, quoted, start := 0, false, 0
+		for i := 0; i <= len(line); i++ {
+			if i < len(line) {
+				switch c := line[i]; {
+				case quoted && c == '\\':
+					i++
+					continue
+				case c == '"':
+					quoted = !quoted
+					continue
+				case quoted:
+					continue
+				case c == '(':
+					depth++
+					continue
+				case c == ')':
+					depth--
+					continue
+				case c != ',' || depth != 0:
+					continue
+				}
+			}
+			label, value, ok := strings.Cut(strings.TrimSpace(line[start:i]), "=")
+			if !ok || label == "" || quoted || depth != 0 {
+				return nil, ErrInvalidSignature
+			}
+			members = append(members, member{label, value})
+			start = i + 1
+		}
+	}
+	return members, nil
+}
+
+// Parse signature parameters: an inner list of quoted component names
+// followed by ;key=value parameters.
+func parseParams(s string) (params, error) {
+	var p params
+	if !strings.HasPrefix(s, "(") {
+		return p, ErrInvalidSignature
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return p, ErrInvalidSignature
+	}
+	for _, item := range strings.Fields(s[1:end]) {
+		c, err := strconv.Unquote(item)
+		if err != nil || !strings.HasPrefix(item, `"`) {
+			return p, ErrInvalidSignature
+		}
+		p.components = append(p.components, c)
+	}
+
+	for _, param := range strings.Split(s[end+1:], ";")[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return p, ErrInvalidSignature
+		}
+		var err error
+		switch key {
+		case "created":
+			p.created, err = strconv.ParseInt(value, 10, 64)
+		case "expires":
+			p.expires, err = strconv.ParseInt(value, 10, 64)
+		case "keyid":
+			p.keyid, err = strconv.Unquote(value)
+		case "alg":
+			p.alg, err = strconv.Unquote(value)
+		}
+		if err != nil {
+			return p, ErrInvalidSignature
+		}
+	}
+	if rest := s[end+1:]; rest != "" && !strings.HasPrefix(rest, ";") {
+		return p, ErrInvalidSignature
+	}
+	p.raw = s
+	return p, nil
+}
@@ -0,0 +1,177 @@
+// go-multikeypair/httpsig/httpsig_test.go
+
+package httpsig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// The request of RFC 9421 appendix B.2.
+func testRequest(t *testing.T) *http.Request {
+	req, err := http.NewRequest("POST", "http://example.com/foo?param=Value&Pet=dog", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "18")
+	return req
+}
+
+func generate(t *testing.T, code uint64) multikeypair.Keypair {
+	t.Helper()
+	k, err := multikeypair.Generate(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// The Ed25519 signature of RFC 9421 appendix B.2.6 verifies.
+func TestVerifyRFC9421(t *testing.T) {
+	x, _ := base64.RawURLEncoding.DecodeString("JrQLj5P_89iXES9-vFgrIy29clF9CC_oPPsw3c5D0bs")
+	key := multikeypair.Keypair{Code: multikeypair.ED_25519, Public: x}
+
+	req := testRequest(t)
+	req.Header.Set("Signature-Input", `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	req.Header.Set("Signature", `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`)
+
+	v := Verifier{
+		Keys: func(keyid string) (multikeypair.Keypair, error) {
+			if keyid != "test-key-ed25519" {
+				return multikeypair.Keypair{}, ErrUnknownKey
+			}
+			return key, nil
+		},
+		Required: []string{"@method", "@path", "@authority"},
+	}
+	if _, err := v.Verify(req); err != nil {
+		t.Fatal(err)
+	}
+
+	req.Method = "PUT"
+	if _, err := v.Verify(req); !errors.Is(err, multikeypair.ErrInvalidSignature) {
+		t.Fatalf("modified request: %v", err)
+	}
+}
+
+// The signature base matches RFC 9421 appendix B.2.6.
+func TestSignatureBase(t *testing.T) {
+	p, err := parseParams(`("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := signatureBase(testRequest(t), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`
+	if string(base) != want {
+		t.Fatalf("got\n%s", base)
+	}
+}
+
+// Requests signed with each supported cipher verify against the
+// public key, found by fingerprint.
+func TestSignVerify(t *testing.T) {
+	for _, code := range []uint64{
+		multikeypair.ED_25519,
+		multikeypair.P_256,
+		multikeypair.SECP_256K1,
+		multikeypair.RSA,
+	} {
+		k := generate(t, code)
+		req := testRequest(t)
+		s := Signer{Key: k, Components: []string{"@method", "@authority", "@path", "@query", "Content-Type"}, Lifetime: time.Minute}
+		if err := s.Sign(req); err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+
+		v := Verifier{Keys: Keys(generate(t, code), k), Required: []string{"@method", "content-type"}}
+		got, err := v.Verify(req)
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		if string(got.Public) != string(k.Public) {
+			t.Errorf("%s: wrong key", k.Name)
+		}
+
+		req.Header.Set("Content-Type", "text/plain")
+		if _, err := v.Verify(req); !errors.Is(err, multikeypair.ErrInvalidSignature) {
+			t.Errorf("%s: modified header: %v", k.Name, err)
+		}
+	}
+}
+
+// Unsigned, unknown-key and under-covering signatures are rejected.
+func TestVerifyRejects(t *testing.T) {
+	k := generate(t, multikeypair.ED_25519)
+	v := Verifier{Keys: Keys(k)}
+
+	if _, err := v.Verify(testRequest(t)); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("unsigned: %v", err)
+	}
+
+	req := testRequest(t)
+	if err := (Signer{Key: generate(t, multikeypair.ED_25519)}).Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(req); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: %v", err)
+	}
+
+	req = testRequest(t)
+	if err := (Signer{Key: k, Components: []string{"@method"}}).Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(req); !errors.Is(err, ErrUncovered) {
+		t.Errorf("uncovered: %v", err)
+	}
+}
+
+// Requests sent through a signing Transport pass the Middleware, which
+// hands the key on to the handler.
+func TestTransportMiddleware(t *testing.T) {
+	k := generate(t, multikeypair.ED_25519)
+	handler := Verifier{Keys: Keys(k)}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key, ok := KeyFromContext(req.Context())
+		if !ok || !ed25519.PublicKey(key.Public).Equal(ed25519.PublicKey(k.Public)) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: Signer{Key: k}.Transport(nil)}
+	resp, err := client.Get(server.URL + "/path?q=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("signed: status %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned: status %d", resp.StatusCode)
+	}
+}
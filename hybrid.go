@@ -0,0 +1,277 @@
+// go-multikeypair/hybrid.go
+//
+// Hybrid keypairs pair a classical key with a post-quantum key under a
+// single cipher code. A hybrid Keypair encodes as an ordinary
+// Multikeypair, so storage doesn't change when migrating to it; signing
+// produces a signature from both components and verification requires
+// both to be valid.
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Hybrid-specific errors this module exports.
+var (
+	ErrNotHybrid        = errors.New("cipher is not a hybrid")
+	ErrHybridComponents = errors.New("keypairs don't match hybrid components")
+	ErrInvalidHybridKey = errors.New("input isn't valid hybrid key")
+	ErrInvalidHybridSig = errors.New("input isn't valid hybrid signature")
+	ErrHybridPrivate    = errors.New("hybrid components must both have private keys or neither")
+)
+
+// Domain separation prefix for hybrid signatures, so that a component
+// signature can't be stripped out and presented on its own.
+const hybridDomain = "multikeypair hybrid v1\x00"
+
+// Hybrids maps a hybrid cipher code to its classical and post-quantum
+// component codes, in that order.
+var Hybrids = map[uint64][2]uint64{
+	ED_25519_ML_DSA_65: {ED_25519, ML_DSA_65},
+}
+
+func init() {
+	for code := range Hybrids {
+		schemes[code] = scheme{
+			sign:     hybridSigner(code),
+			verify:   hybridVerifier(code),
+			generate: hybridGenerator(code),
+			fromSeed: hybridFromSeed(code),
+			seedSize: 32,
+			public:   hybridPublicFromPrivate(code),
+		}
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewHybrid combines a classical and a post-quantum Keypair into a single
+// Keypair with the given hybrid code. Either both components carry a
+// private key or neither does.
+func NewHybrid(code uint64, classical Keypair, postQuantum Keypair) (Keypair, error) {
+	components, ok := Hybrids[code]
+	if !ok {
+		return Keypair{}, ErrNotHybrid
+	}
+	if classical.Code != components[0] || postQuantum.Code != components[1] {
+		return Keypair{}, ErrHybridComponents
+	}
+	if (len(classical.Private) == 0) != (len(postQuantum.Private) == 0) {
+		return Keypair{}, ErrHybridPrivate
+	}
+
+	var private []byte
+	if len(classical.Private) != 0 {
+		var err error
+		private, err = packPair(classical.Private, postQuantum.Private)
+		if err != nil {
+			return Keypair{}, err
+		}
+	}
+	public, err := packPair(classical.Public, postQuantum.Public)
+	if err != nil {
+		return Keypair{}, err
+	}
+
+	return Keypair{
+		Code:          code,
+		Name:          codeName(code),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// Components splits a hybrid Keypair into its classical and post-quantum
+// Keypairs. The components alias k, except that a private key in locked
+// memory is copied out, so wipe the components once done with them.
+func (k Keypair) Components() (Keypair, Keypair, error) {
+	codes, ok := Hybrids[k.Code]
+	if !ok {
+		return Keypair{}, Keypair{}, ErrNotHybrid
+	}
+
+	classicalPub, pqPub, err := unpackPair(k.Public, ErrInvalidHybridKey)
+	if err != nil {
+		return Keypair{}, Keypair{}, err
+	}
+	var classicalPriv, pqPriv []byte
+	if len(k.Private) != 0 {
+		// Locked memory can't be handed to the component ciphers.
+		private := k.Private
+		if k.locked != nil {
+			private = bytes.Clone(private)
+		}
+		classicalPriv, pqPriv, err = unpackPair(private, ErrInvalidHybridKey)
+		if err != nil {
+			return Keypair{}, Keypair{}, err
+		}
+	}
+
+	classical := Keypair{
+		Code:          codes[0],
+		Name:          codeName(codes[0]),
+		Private:       classicalPriv,
+		PrivateLength: len(classicalPriv),
+		Public:        classicalPub,
+		PublicLength:  len(classicalPub),
+	}
+	postQuantum := Keypair{
+		Code:          codes[1],
+		Name:          codeName(codes[1]),
+		Private:       pqPriv,
+		PrivateLength: len(pqPriv),
+		Public:        pqPub,
+		PublicLength:  len(pqPub),
+	}
+	return classical, postQuantum, nil
+}
+
+// Build the signing function for a hybrid code.
+func hybridSigner(code uint64) func([]byte, []byte) ([]byte, error) {
+	codes := Hybrids[code]
+	return func(private []byte, message []byte) ([]byte, error) {
+		classical, postQuantum, err := unpackPair(private, ErrInvalidPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		message = hybridMessage(code, message)
+		classicalSig, err := Keypair{Code: codes[0], Private: classical}.Sign(message)
+		if err != nil {
+			return nil, err
+		}
+		pqSig, err := Keypair{Code: codes[1], Private: postQuantum}.Sign(message)
+		if err != nil {
+			return nil, err
+		}
+		return packPair(classicalSig, pqSig)
+	}
+}
+
+// Build the verification function for a hybrid code.
+func hybridVerifier(code uint64) func([]byte, []byte, []byte) error {
+	codes := Hybrids[code]
+	return func(public []byte, message []byte, signature []byte) error {
+		classical, postQuantum, err := unpackPair(public, ErrInvalidPublicKey)
+		if err != nil {
+			return err
+		}
+		classicalSig, pqSig, err := unpackPair(signature, ErrInvalidHybridSig)
+		if err != nil {
+			return err
+		}
+		message = hybridMessage(code, message)
+		if err := (Keypair{Code: codes[0], Public: classical}).Verify(message, classicalSig); err != nil {
+			return err
+		}
+		return Keypair{Code: codes[1], Public: postQuantum}.Verify(message, pqSig)
+	}
+}
+
+// Build the key generation function for a hybrid code.
+func hybridGenerator(code uint64) func() ([]byte, []byte, error) {
+	codes := Hybrids[code]
+	return func() ([]byte, []byte, error) {
+		classical, err := Generate(codes[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		postQuantum, err := Generate(codes[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		hybrid, err := NewHybrid(code, classical, postQuantum)
+		if err != nil {
+			return nil, nil, err
+		}
+		return hybrid.Private, hybrid.Public, nil
+	}
+}
+
+// Build the seed derivation function for a hybrid code. Each component
+// is derived from its own expansion of the seed material, so the two
+// never share key material.
+func hybridFromSeed(code uint64) func([]byte) ([]byte, []byte, error) {
+	codes := Hybrids[code]
+	return func(material []byte) ([]byte, []byte, error) {
+		var components [2]Keypair
+		for i, component := range codes {
+			seed, err := expandSeed(material, code, PackCode(component), 32)
+			if err != nil {
+				return nil, nil, err
+			}
+			if components[i], err = GenerateFromSeed(component, seed); err != nil {
+				return nil, nil, err
+			}
+		}
+		hybrid, err := NewHybrid(code, components[0], components[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return hybrid.Private, hybrid.Public, nil
+	}
+}
+
+// Build the public key derivation function for a hybrid code.
+func hybridPublicFromPrivate(code uint64) func([]byte) ([]byte, error) {
+	codes := Hybrids[code]
+	return func(private []byte) ([]byte, error) {
+		classical, postQuantum, err := unpackPair(private, ErrInvalidPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		var publics [2][]byte
+		for i, component := range [2][]byte{classical, postQuantum} {
+			s, err := lookupScheme(codes[i])
+			if err != nil {
+				return nil, err
+			}
+			if publics[i], err = s.public(component); err != nil {
+				return nil, err
+			}
+		}
+		return packPair(publics[0], publics[1])
+	}
+}
+
+// The message actually signed by each component.
+func hybridMessage(code uint64, message []byte) []byte {
+	codeBuf := PackCode(code)
+	out := make([]byte, 0, len(hybridDomain)+len(codeBuf)+len(message))
+	out = append(out, hybridDomain...)
+	out = append(out, codeBuf...)
+	return append(out, message...)
+}
+
+// Pack two byte strings, each with a 16-bit length prefix.
+func packPair(first []byte, second []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(first)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(second)
+	})
+	return b.Bytes()
+}
+
+// Unpack two byte strings packed by packPair.
+func unpackPair(buf []byte, invalid error) ([]byte, []byte, error) {
+	input := cryptobyte.String(buf)
+	var first, second cryptobyte.String
+	if !input.ReadUint16LengthPrefixed(&first) ||
+		!input.ReadUint16LengthPrefixed(&second) ||
+		!input.Empty() {
+		return nil, nil, invalid
+	}
+	return first, second, nil
+}
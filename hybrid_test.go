@@ -0,0 +1,108 @@
+// go-multikeypair/hybrid_test.go
+
+package multikeypair
+
+import (
+	"crypto/mldsa"
+	"errors"
+	"testing"
+)
+
+// Generate a fresh ML-DSA-65 Keypair for testing.
+func generateMLDSA65(t *testing.T) Keypair {
+	sk, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	private := sk.Bytes()
+	public := sk.PublicKey().Bytes()
+	return Keypair{
+		Code:          ML_DSA_65,
+		Name:          Codes[ML_DSA_65],
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}
+}
+
+// Build a hybrid keypair, round trip it through a Multikeypair, and
+// sign with it.
+func TestHybridSignVerify(t *testing.T) {
+	hybrid, err := NewHybrid(ED_25519_ML_DSA_65, generateEd25519(t), generateMLDSA65(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk, err := hybrid.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := Decode(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate(t, kp, ED_25519_ML_DSA_65, Codes[ED_25519_ML_DSA_65], hybrid.Public, hybrid.Private)
+
+	message := []byte("message")
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kp.Verify(message, sig); err != nil {
+		t.Fatalf("expected hybrid signature to verify: %s", err)
+	}
+	if err := kp.Verify([]byte("other"), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+}
+
+// Both component signatures are required, and neither verifies on its
+// own against the bare message.
+func TestHybridRequiresBoth(t *testing.T) {
+	hybrid, err := NewHybrid(ED_25519_ML_DSA_65, generateEd25519(t), generateMLDSA65(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("message")
+	sig, err := hybrid.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classicalSig, pqSig, err := unpackPair(sig, ErrInvalidHybridSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classical, postQuantum, err := hybrid.Components()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := classical.Verify(message, classicalSig); err == nil {
+		t.Error("expected stripped classical signature to fail")
+	}
+	if err := postQuantum.Verify(message, pqSig); err == nil {
+		t.Error("expected stripped post-quantum signature to fail")
+	}
+
+	// Replace the post-quantum signature with one from another key.
+	forged, err := generateMLDSA65(t).Sign(hybridMessage(ED_25519_ML_DSA_65, message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad, err := packPair(classicalSig, forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hybrid.Verify(message, bad); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected forged hybrid signature to fail, got: %v", err)
+	}
+}
+
+// Components must match the hybrid code.
+func TestHybridComponents(t *testing.T) {
+	if _, err := NewHybrid(ED_25519_ML_DSA_65, generateMLDSA65(t), generateEd25519(t)); !errors.Is(err, ErrHybridComponents) {
+		t.Errorf("expected component mismatch, got: %v", err)
+	}
+	if _, err := NewHybrid(ED_25519, generateEd25519(t), generateMLDSA65(t)); !errors.Is(err, ErrNotHybrid) {
+		t.Errorf("expected not hybrid error, got: %v", err)
+	}
+}
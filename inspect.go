@@ -0,0 +1,94 @@
+// go-multikeypair/inspect.go
+//
+// Header-only inspection of a Multikeypair, for listing and indexing
+// keystores without touching key material. The metadata that
+// identifies a key is read too, since it lies outside the key fields.
+
+package multikeypair
+
+import (
+	"bytes"
+	"time"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Info describes a Multikeypair without holding any key bytes.
+type Info struct {
+	// Cipher type code.
+	Code uint64
+	// Human-readable cipher name.
+	Name string
+	// Wire format version (V1 or V2).
+	Version uint64
+	// Length in bytes of private key; zero for a public-only keypair.
+	PrivateLength int
+	// Length in bytes of public key.
+	PublicLength int
+	// Total encoded size in bytes.
+	Size int
+	// Label from the metadata, if any.
+	Label string
+	// Creation time from the metadata; the zero value if unknown.
+	Created time.Time
+	// Usage from the metadata.
+	Usage Usage
+	// Start of the validity window from the metadata; the zero value if
+	// there is none.
+	NotBefore time.Time
+	// End of the validity window from the metadata; the zero value if
+	// the key doesn't expire.
+	NotAfter time.Time
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Inspect reads the cipher, field lengths and identifying metadata of a
+// Multikeypair without copying out any key material.
+func Inspect(m Multikeypair) (Info, error) {
+	code, private, public, rest, err := splitKeypair(m)
+	if err != nil {
+		return Info{}, err
+	}
+	numCode, err := UnpackCode(code)
+	if err != nil {
+		return Info{}, err
+	}
+	if err := validCode(numCode); err != nil {
+		return Info{}, err
+	}
+
+	var metadata Metadata
+	if fields, ok := splitExtensions(rest); ok {
+		if metadata, err = parseMetadata(fields); err != nil {
+			return Info{}, decodeError(FIELD_OPTIONAL, offsetIn(m, rest), err)
+		}
+	}
+
+	version := V1
+	if bytes.HasPrefix(m, versionEscape) {
+		version = V2
+	}
+
+	return Info{
+		Code:          numCode,
+		Name:          codeName(numCode),
+		Version:       version,
+		PrivateLength: len(private),
+		PublicLength:  len(public),
+		Size:          len(m),
+		Label:         metadata.Label,
+		Created:       metadata.Created,
+		Usage:         metadata.Usage,
+		NotBefore:     metadata.NotBefore,
+		NotAfter:      metadata.NotAfter,
+	}, nil
+}
+
+// Inspect reads the cipher, field lengths and identifying metadata of a
+// Multikeypair without copying out any key material.
+func (m Multikeypair) Inspect() (Info, error) {
+	return Inspect(m)
+}
@@ -0,0 +1,78 @@
+// go-multikeypair/inspect_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Inspect reports the cipher and field lengths.
+func TestInspect(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Inspect(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Info{
+		Code:          ED_25519,
+		Name:          "ed25519",
+		Version:       V1,
+		PrivateLength: len(kp.Private),
+		PublicLength:  len(kp.Public),
+		Size:          len(mk),
+	}
+	if info != want {
+		t.Errorf("got %+v, want %+v", info, want)
+	}
+}
+
+// Inspect reports the wide layout for long keys.
+func TestInspectLarge(t *testing.T) {
+	public := bytes.Repeat([]byte{0x01}, MAX_V1_KEY_LENGTH+1)
+	mk, err := Encode(nil, public, RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := mk.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != V2 || info.PublicLength != len(public) || info.PrivateLength != 0 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+}
+
+// Inspect reports the label, creation time, usage and validity window
+// from the metadata.
+func TestInspectMetadata(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{
+		Label:     "release signing",
+		Created:   time.Unix(1700000000, 0),
+		Usage:     USAGE_SIGN,
+		NotBefore: time.Unix(1700000000, 0),
+		NotAfter:  time.Unix(1800000000, 0),
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := mk.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Label != kp.Metadata.Label || !info.Created.Equal(kp.Metadata.Created) || info.Usage != USAGE_SIGN ||
+		!info.NotBefore.Equal(kp.Metadata.NotBefore) || !info.NotAfter.Equal(kp.Metadata.NotAfter) {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if !info.Expired(time.Unix(1800000001, 0)) || info.Valid(time.Unix(1700000001, 0)) != nil {
+		t.Error("unexpected validity")
+	}
+}
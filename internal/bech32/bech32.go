@@ -0,0 +1,74 @@
+// go-multikeypair/internal/bech32/bech32.go
+//
+// The Bech32 (BIP 173) checksum and bit regrouping shared by the
+// multikeypair string form and the address package. Each caller lays
+// out its own data part and checksum constant: multikeypairs use
+// Bech32m, while addresses use the original constant and, for segwit,
+// lead with a witness version.
+
+package bech32
+
+// CHARSET maps 5-bit values to the characters of a Bech32 data part.
+const CHARSET = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Polymod computes the BCH checksum over 5-bit values.
+func Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// HRPExpand expands a human-readable part into the values that precede
+// the data part in the checksum.
+func HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// ConvertBits regroups bits, e.g. from 8-bit bytes to 5-bit groups.
+// Padding is only allowed when pad is set; otherwise leftover bits must
+// be zero.
+func ConvertBits(data []byte, from uint, to uint, pad bool) ([]byte, bool) {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, b := range data {
+		if uint32(b)>>from != 0 {
+			return nil, false
+		}
+		acc = acc<<from | uint32(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,293 @@
+// go-multikeypair/jose/jose.go
+//
+// JSON Web Signatures (RFC 7515) and JSON Web Tokens (RFC 7519) signed
+// with multikeypairs, in compact serialization:
+//
+//	base64url(header) "." base64url(payload) "." base64url(signature)
+//
+// The algorithm is selected by the keypair's cipher:
+//
+//	ed25519    EdDSA   (RFC 8037)
+//	p256       ES256
+//	secp256k1  ES256K  (RFC 8812)
+//	rsa        RS256
+//
+// and the header's "kid" is the key's JWK thumbprint (RFC 7638), so
+// that verifiers holding several public-only multikeypairs can tell
+// which one signed a token.
+
+package jose
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// JOSE-specific errors this package exports.
+var (
+	ErrInvalidToken  = errors.New("input isn't valid compact JWS")
+	ErrUnknownKey    = errors.New("no key matches token")
+	ErrTokenExpired  = errors.New("token expired")
+	ErrTokenNotValid = errors.New("token not yet valid")
+)
+
+// JWS algorithm names.
+const (
+	EDDSA  = "EdDSA"
+	ES256  = "ES256"
+	ES256K = "ES256K"
+	RS256  = "RS256"
+)
+
+// Algorithms maps cipher codes to the JWS algorithm used for them.
+var Algorithms = map[uint64]string{
+	multikeypair.ED_25519:   EDDSA,
+	multikeypair.P_256:      ES256,
+	multikeypair.SECP_256K1: ES256K,
+	multikeypair.RSA:        RS256,
+}
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Header is a JWS protected header.
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// JWK is the public part of a key as a JSON Web Key. Only the members
+// for the key's type are set.
+type JWK struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+}
+
+// Claims holds the registered claims Verify checks, for embedding in an
+// application's claims struct. Times are seconds since the epoch.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Sign returns a compact JWS over payload, signed with the keypair.
+func Sign(k multikeypair.Keypair, payload []byte) (string, error) {
+	return sign(k, "", payload)
+}
+
+// SignJWT returns a JWT whose claims are claims marshalled as JSON.
+func SignJWT(k multikeypair.Keypair, claims any) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return sign(k, "JWT", payload)
+}
+
+// Verify checks a compact JWS against a set of public keys and returns
+// its payload. If the token has a "kid", only the key with that
+// thumbprint is tried; otherwise every key whose algorithm matches is.
+func Verify(token string, keys ...multikeypair.Keypair) ([]byte, error) {
+	header, payload, signed, signature, err := split(token)
+	if err != nil {
+		return nil, err
+	}
+
+	tried := false
+	for _, k := range keys {
+		if Algorithms[k.Code] != header.Algorithm {
+			continue
+		}
+		if header.KeyID != "" {
+			if kid, err := Thumbprint(k); err != nil || kid != header.KeyID {
+				continue
+			}
+		}
+		tried = true
+		if err := verify(k, signed, signature); err == nil {
+			return payload, nil
+		}
+	}
+	if !tried {
+		return nil, ErrUnknownKey
+	}
+	return nil, multikeypair.ErrInvalidSignature
+}
+
+// VerifyJWT checks a JWT against a set of public keys, unmarshals its
+// claims into claims, and checks the "exp" and "nbf" claims against the
+// current time.
+func VerifyJWT(token string, claims any, keys ...multikeypair.Keypair) error {
+	payload, err := Verify(token, keys...)
+	if err != nil {
+		return err
+	}
+	var registered Claims
+	if err := json.Unmarshal(payload, &registered); err != nil {
+		return ErrInvalidToken
+	}
+	now := time.Now().Unix()
+	if registered.ExpiresAt != 0 && now >= registered.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if registered.NotBefore != 0 && now < registered.NotBefore {
+		return ErrTokenNotValid
+	}
+	if claims == nil {
+		return nil
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// PublicJWK returns the public key of a keypair as a JWK.
+func PublicJWK(k multikeypair.Keypair) (JWK, error) {
+	switch k.Code {
+	case multikeypair.ED_25519:
+		if len(k.Public) != 32 {
+			return JWK{}, multikeypair.ErrInvalidPublicKey
+		}
+		return JWK{KeyType: "OKP", Curve: "Ed25519", X: encode(k.Public)}, nil
+	case multikeypair.P_256:
+		pk, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), k.Public)
+		if err != nil {
+			return JWK{}, multikeypair.ErrInvalidPublicKey
+		}
+		b, _ := pk.Bytes()
+		return JWK{KeyType: "EC", Curve: "P-256", X: encode(b[1:33]), Y: encode(b[33:])}, nil
+	case multikeypair.SECP_256K1:
+		pk, err := secp256k1.ParsePubKey(k.Public)
+		if err != nil {
+			return JWK{}, multikeypair.ErrInvalidPublicKey
+		}
+		b := pk.SerializeUncompressed()
+		return JWK{KeyType: "EC", Curve: "secp256k1", X: encode(b[1:33]), Y: encode(b[33:])}, nil
+	case multikeypair.RSA:
+		pk, err := x509.ParsePKCS1PublicKey(k.Public)
+		if err != nil {
+			return JWK{}, multikeypair.ErrInvalidPublicKey
+		}
+		e := big.NewInt(int64(pk.E))
+		return JWK{KeyType: "RSA", N: encode(pk.N.Bytes()), E: encode(e.Bytes())}, nil
+	}
+	return JWK{}, multikeypair.ErrUnsupportedCipher
+}
+
+// Thumbprint returns the RFC 7638 JWK thumbprint of a keypair's public
+// key: the base64url SHA-256 of its required JWK members, in
+// lexicographic order without whitespace.
+func Thumbprint(k multikeypair.Keypair) (string, error) {
+	jwk, err := PublicJWK(k)
+	if err != nil {
+		return "", err
+	}
+	// encoding/json writes struct fields in declaration order, so build
+	// the required members as a map, whose keys it sorts.
+	members := map[string]string{"kty": jwk.KeyType}
+	switch jwk.KeyType {
+	case "OKP":
+		members["crv"], members["x"] = jwk.Curve, jwk.X
+	case "EC":
+		members["crv"], members["x"], members["y"] = jwk.Curve, jwk.X, jwk.Y
+	case "RSA":
+		members["e"], members["n"] = jwk.E, jwk.N
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return encode(sum[:]), nil
+}
+
+// Sign a payload with a header of the given type.
+func sign(k multikeypair.Keypair, typ string, payload []byte) (string, error) {
+	algorithm, ok := Algorithms[k.Code]
+	if !ok {
+		return "", multikeypair.ErrUnsupportedCipher
+	}
+	kid, err := Thumbprint(k)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(Header{Algorithm: algorithm, KeyID: kid, Type: typ})
+	if err != nil {
+		return "", err
+	}
+
+	signed := encode(header) + "." + encode(payload)
+	signature, err := k.Sign([]byte(signed))
+	if err != nil {
+		return "", err
+	}
+	if signature, err = k.RawSignature(signature); err != nil {
+		return "", err
+	}
+	return signed + "." + encode(signature), nil
+}
+
+// Verify a signature over the signing input with a key.
+func verify(k multikeypair.Keypair, signed []byte, signature []byte) error {
+	signature, err := k.DERSignature(signature)
+	if err != nil {
+		return err
+	}
+	return k.Verify(signed, signature)
+}
+
+// Split a compact JWS into its header, payload, signing input and
+// signature.
+func split(token string) (Header, []byte, []byte, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Header{}, nil, nil, nil, ErrInvalidToken
+	}
+	rawHeader, err1 := decode(parts[0])
+	payload, err2 := decode(parts[1])
+	signature, err3 := decode(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return Header{}, nil, nil, nil, ErrInvalidToken
+	}
+	var header Header
+	if err := json.Unmarshal(rawHeader, &header); err != nil || header.Algorithm == "" {
+		return Header{}, nil, nil, nil, ErrInvalidToken
+	}
+	return header, payload, []byte(parts[0] + "." + parts[1]), signature, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,197 @@
+// go-multikeypair/jose/jose_test.go
+
+package jose
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// The Ed25519 key of RFC 8037, appendix A.
+const (
+	rfc8037D     = "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"
+	rfc8037X     = "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+	rfc8037Token = "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc.hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func generate(t *testing.T, code uint64) multikeypair.Keypair {
+	t.Helper()
+	k, err := multikeypair.Generate(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func publicOnly(k multikeypair.Keypair) multikeypair.Keypair {
+	return multikeypair.Keypair{
+		Code:         k.Code,
+		Name:         k.Name,
+		Public:       k.Public,
+		PublicLength: k.PublicLength,
+	}
+}
+
+// Thumbprints match RFC 7638 section 3.1 and RFC 8037 appendix A.3.
+func TestThumbprint(t *testing.T) {
+	n := mustDecode(t, "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	rsaKey := multikeypair.Keypair{
+		Code:   multikeypair.RSA,
+		Public: x509.MarshalPKCS1PublicKey(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}),
+	}
+	edKey := multikeypair.Keypair{
+		Code:   multikeypair.ED_25519,
+		Public: mustDecode(t, rfc8037X),
+	}
+
+	for _, v := range []struct {
+		k    multikeypair.Keypair
+		want string
+	}{
+		{rsaKey, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		{edKey, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	} {
+		got, err := Thumbprint(v.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v.want {
+			t.Errorf("%s: got %s, want %s", v.k.Name, got, v.want)
+		}
+	}
+}
+
+// The RFC 8037 example token verifies, and signing its payload with the
+// same key reproduces its (deterministic) signature.
+func TestRFC8037(t *testing.T) {
+	sk := ed25519.NewKeyFromSeed(mustDecode(t, rfc8037D))
+	k := multikeypair.Keypair{
+		Code:    multikeypair.ED_25519,
+		Private: sk,
+		Public:  sk.Public().(ed25519.PublicKey),
+	}
+
+	payload, err := Verify(rfc8037Token, publicOnly(k))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != "Example of Ed25519 signing" {
+		t.Errorf("payload %q", payload)
+	}
+
+	signature, err := k.Sign([]byte("eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustDecode(t, "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"); string(signature) != string(want) {
+		t.Error("signature doesn't match RFC 8037")
+	}
+}
+
+// Tokens signed with each supported cipher verify against the public
+// key alone, and not against another key.
+func TestSignVerify(t *testing.T) {
+	for _, code := range []uint64{
+		multikeypair.ED_25519,
+		multikeypair.P_256,
+		multikeypair.SECP_256K1,
+		multikeypair.RSA,
+	} {
+		k := generate(t, code)
+		other := generate(t, code)
+
+		token, err := Sign(k, []byte("hello"))
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		header, _, _, signature, err := split(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Algorithm != Algorithms[code] {
+			t.Errorf("%s: alg %s", k.Name, header.Algorithm)
+		}
+		if (code == multikeypair.P_256 || code == multikeypair.SECP_256K1) && len(signature) != 64 {
+			t.Errorf("%s: signature is %d bytes, want 64", k.Name, len(signature))
+		}
+
+		payload, err := Verify(token, publicOnly(other), publicOnly(k))
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		if string(payload) != "hello" {
+			t.Errorf("%s: payload %q", k.Name, payload)
+		}
+		if _, err := Verify(token, publicOnly(other)); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%s: other key: %v", k.Name, err)
+		}
+	}
+}
+
+// A token with a modified payload is rejected.
+func TestVerifyTampered(t *testing.T) {
+	k := generate(t, multikeypair.P_256)
+	token, err := Sign(k, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte("jello"))
+	tampered := strings.Join(parts, ".")
+	if _, err := Verify(tampered, publicOnly(k)); !errors.Is(err, multikeypair.ErrInvalidSignature) {
+		t.Errorf("got %v", err)
+	}
+	if _, err := Verify("not.a token", publicOnly(k)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("got %v", err)
+	}
+}
+
+// JWT claims round trip, and expired or not yet valid tokens are
+// rejected.
+func TestJWT(t *testing.T) {
+	k := generate(t, multikeypair.ED_25519)
+	type claims struct {
+		Claims
+		Scope string `json:"scope"`
+	}
+
+	now := time.Now().Unix()
+	token, err := SignJWT(k, claims{Claims{Subject: "alice", ExpiresAt: now + 60}, "read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got claims
+	if err := VerifyJWT(token, &got, publicOnly(k)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != "alice" || got.Scope != "read" {
+		t.Errorf("claims %+v", got)
+	}
+
+	expired, _ := SignJWT(k, Claims{ExpiresAt: now - 60})
+	if err := VerifyJWT(expired, nil, publicOnly(k)); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expired: %v", err)
+	}
+	early, _ := SignJWT(k, Claims{NotBefore: now + 60})
+	if err := VerifyJWT(early, nil, publicOnly(k)); !errors.Is(err, ErrTokenNotValid) {
+		t.Errorf("not before: %v", err)
+	}
+}
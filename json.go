@@ -0,0 +1,243 @@
+// go-multikeypair/json.go
+//
+// JSON marshaling for Keypair and Multikeypair. The standard marshalers
+// redact private key material, so that a keypair that finds its way into
+// a log line or API response doesn't leak its secret half; use
+// MarshalSensitiveJSON to include it deliberately. Metadata isn't
+// secret, and is always included.
+
+package multikeypair
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// JSON-specific errors this module exports.
+var (
+	ErrInvalidJSON = errors.New("input isn't valid multikeypair JSON")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// The JSON form of a Keypair. Key material is base58-encoded.
+type keypairJSON struct {
+	Code     uint64        `json:"code"`
+	Name     string        `json:"name"`
+	Public   string        `json:"public"`
+	Private  string        `json:"private,omitempty"`
+	Metadata *metadataJSON `json:"metadata,omitempty"`
+}
+
+// The JSON form of Metadata. Times are RFC 3339 and tag values base64,
+// as encoding/json writes them.
+type metadataJSON struct {
+	Label     string            `json:"label,omitempty"`
+	Created   *time.Time        `json:"created,omitempty"`
+	Usage     Usage             `json:"usage,omitempty"`
+	NotBefore *time.Time        `json:"notBefore,omitempty"`
+	NotAfter  *time.Time        `json:"notAfter,omitempty"`
+	Tags      map[string][]byte `json:"tags,omitempty"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// Keypair
+//
+
+// MarshalJSON implements json.Marshaler. The private key is omitted.
+func (k Keypair) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keypairJSON{
+		Code:     k.Code,
+		Name:     k.Name,
+		Public:   b58.Encode(k.Public),
+		Metadata: metadataToJSON(k.Metadata),
+	})
+}
+
+// MarshalSensitiveJSON marshals a Keypair to JSON including its private
+// key. Only use this when the output is going somewhere secrets belong.
+func (k Keypair) MarshalSensitiveJSON() ([]byte, error) {
+	return json.Marshal(keypairJSON{
+		Code:     k.Code,
+		Name:     k.Name,
+		Public:   b58.Encode(k.Public),
+		Private:  b58.Encode(k.Private),
+		Metadata: metadataToJSON(k.Metadata),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts both redacted and
+// sensitive JSON; in the former case the private key is left empty.
+func (k *Keypair) UnmarshalJSON(data []byte) error {
+	var j keypairJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if err := validCode(j.Code); err != nil {
+		return err
+	}
+	public, err := b58.Decode(j.Public)
+	if err != nil {
+		return ErrInvalidJSON
+	}
+	var private []byte
+	if j.Private != "" {
+		private, err = b58.Decode(j.Private)
+		if err != nil {
+			return ErrInvalidJSON
+		}
+	}
+	metadata, err := metadataFromJSON(j.Metadata)
+	if err != nil {
+		return err
+	}
+
+	*k = Keypair{
+		Code:          j.Code,
+		Name:          codeName(j.Code),
+		Public:        public,
+		PublicLength:  len(public),
+		Private:       private,
+		PrivateLength: len(private),
+		Metadata:      metadata,
+	}
+	return nil
+}
+
+// The JSON form of Metadata, or nil if there is none.
+func metadataToJSON(m Metadata) *metadataJSON {
+	if m.IsZero() {
+		return nil
+	}
+	return &metadataJSON{
+		Label:     m.Label,
+		Created:   timeToJSON(m.Created),
+		Usage:     m.Usage,
+		NotBefore: timeToJSON(m.NotBefore),
+		NotAfter:  timeToJSON(m.NotAfter),
+		Tags:      m.Tags,
+	}
+}
+
+// Metadata from its JSON form, checked as it would be for encoding.
+func metadataFromJSON(j *metadataJSON) (Metadata, error) {
+	if j == nil {
+		return Metadata{}, nil
+	}
+	m := Metadata{
+		Label:     j.Label,
+		Created:   timeFromJSON(j.Created),
+		Usage:     j.Usage,
+		NotBefore: timeFromJSON(j.NotBefore),
+		NotAfter:  timeFromJSON(j.NotAfter),
+		Tags:      j.Tags,
+	}
+	if _, err := m.fields(); err != nil {
+		return Metadata{}, ErrInvalidJSON
+	}
+	return m, nil
+}
+
+// A time for JSON, omitted if zero.
+func timeToJSON(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// A time from JSON, zero if omitted.
+func timeFromJSON(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+//
+// Multikeypair
+//
+
+// MarshalJSON implements json.Marshaler, producing the base58 string of a
+// copy of the Multikeypair with its private key removed. Optional fields
+// are kept, except a wrapped private key; a checksum is recomputed. An
+// empty Multikeypair marshals to null.
+func (m Multikeypair) MarshalJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("null"), nil
+	}
+	kp, err := m.Decode()
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, rest, _ := splitKeypair(m)
+	fields, _ := splitExtensions(rest)
+	var kept []extension
+	checksummed := false
+	for _, f := range fields {
+		switch f.tag {
+		case TAG_CHECKSUM:
+			checksummed = true
+		case TAG_WRAPPED:
+		default:
+			kept = append(kept, f)
+		}
+	}
+	public := Multikeypair(encodeKeypair(nil, kp.Public, kp.Code))
+	if len(kept) > 0 {
+		b, err := appendExtensions(public, kept...)
+		if err != nil {
+			return nil, err
+		}
+		public = Multikeypair(b)
+	}
+	if checksummed {
+		if public, err = public.WithChecksum(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(public.B58String())
+}
+
+// MarshalSensitiveJSON marshals a Multikeypair to JSON as a base58 string
+// including its private key. Only use this when the output is going
+// somewhere secrets belong.
+func (m Multikeypair) MarshalSensitiveJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("null"), nil
+	}
+	return json.Marshal(m.B58String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler for a base58 string. As is
+// the convention, null leaves the Multikeypair unchanged; an empty
+// string empties it.
+func (m *Multikeypair) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*m = nil
+		return nil
+	}
+	mk, err := MultikeypairFromB58(s)
+	if err != nil {
+		return err
+	}
+	*m = mk
+	return nil
+}
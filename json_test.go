@@ -0,0 +1,166 @@
+// go-multikeypair/json_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// Plain json.Marshal never includes the private key.
+func TestKeypairJSONRedacted(t *testing.T) {
+	kp := generateEd25519(t)
+
+	data, err := json.Marshal(struct{ Key Keypair }{kp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), b58.Encode(kp.Private)) {
+		t.Fatalf("private key leaked into JSON: %s", data)
+	}
+
+	var decoded struct{ Key Keypair }
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, decoded.Key, kp.Code, kp.Name, kp.Public, nil)
+}
+
+// MarshalSensitiveJSON round trips the whole keypair.
+func TestKeypairJSONSensitive(t *testing.T) {
+	kp := generateEd25519(t)
+
+	data, err := kp.MarshalSensitiveJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Keypair
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	validate(t, decoded, kp.Code, kp.Name, kp.Public, kp.Private)
+}
+
+// A Multikeypair marshals to a public-only base58 string by default.
+func TestMultikeypairJSON(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var redacted Multikeypair
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := redacted.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate(t, decoded, kp.Code, kp.Name, kp.Public, nil)
+
+	data, err = mk.MarshalSensitiveJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full Multikeypair
+	if err := json.Unmarshal(data, &full); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(full, mk) {
+		t.Error("expected sensitive JSON to round trip the multikeypair")
+	}
+}
+
+// Metadata survives both JSON forms, so usage restrictions still hold
+// after a round trip.
+func TestJSONMetadata(t *testing.T) {
+	kp := generateEd25519(t)
+	notAfter := time.Unix(1900000000, 0)
+	kp.Metadata = Metadata{
+		Label:    "encryption",
+		Usage:    USAGE_ENCRYPT,
+		NotAfter: notAfter,
+		Tags:     map[string][]byte{"app": {0x01}},
+	}
+
+	data, err := kp.MarshalSensitiveJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Keypair
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	m := decoded.Metadata
+	if m.Label != "encryption" || m.Usage != USAGE_ENCRYPT || !m.NotAfter.Equal(notAfter) ||
+		!m.Created.IsZero() || !bytes.Equal(m.Tags["app"], []byte{0x01}) {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+	if _, err := decoded.Sign([]byte("message")); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mk, err = mk.WithChecksum(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = json.Marshal(mk); err != nil {
+		t.Fatal(err)
+	}
+	var redacted Multikeypair
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		t.Fatal(err)
+	}
+	public, err := DecodeWithOptions(redacted, DecodeOptions{RequireChecksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public.Private) != 0 || public.Metadata.Usage != USAGE_ENCRYPT || public.Metadata.Label != "encryption" {
+		t.Errorf("unexpected redacted keypair %+v", public.Metadata)
+	}
+
+	if err := json.Unmarshal([]byte(`{"code":17,"name":"ed25519","public":"","metadata":{"tags":{"":""}}}`), &decoded); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON, got %v", err)
+	}
+}
+
+// An empty Multikeypair is null in JSON, so optional fields work.
+func TestMultikeypairJSONEmpty(t *testing.T) {
+	type document struct {
+		Key Multikeypair `json:"key"`
+	}
+	data, err := json.Marshal(document{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"key":null}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+	if data, err := (Multikeypair{}).MarshalSensitiveJSON(); err != nil || string(data) != "null" {
+		t.Errorf("unexpected sensitive JSON %s, %v", data, err)
+	}
+
+	for _, input := range []string{`{"key":null}`, `{"key":""}`, `{}`} {
+		var d document
+		if err := json.Unmarshal([]byte(input), &d); err != nil {
+			t.Errorf("%s: %v", input, err)
+		}
+		if len(d.Key) != 0 {
+			t.Errorf("%s: expected an empty key", input)
+		}
+	}
+}
@@ -4,13 +4,14 @@
 // addition to the suite of multiformat project types.
 
 // TODO CLI tool for working with multikeypairs.
-// TODO: Investigate cosign/minisign for generating, encoding/decoding?
 
 package multikeypair
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"maps"
 
 	//"fmt"
 
@@ -22,20 +23,30 @@ import (
 // Errors
 // -----------------------------------------------------------------------------
 
-// Minimum and maximum key lengths in bytes.
+// Minimum and maximum key lengths in bytes. Keys longer than
+// MAX_V1_KEY_LENGTH are encoded using the wide (v2) layout.
 const (
-	MIN_KEY_LENGTH = 2
-	MAX_KEY_LENGTH = 256
+	MIN_KEY_LENGTH    = 2
+	MAX_V1_KEY_LENGTH = 0xffff
+	MAX_KEY_LENGTH    = 1 << 20
+)
+
+// Wire format versions. Version 1 is the original layout with 16-bit key
+// length prefixes and no version marker.
+const (
+	V1 = uint64(1)
+	V2 = uint64(2)
 )
 
 // Keypair-specific errors this module exports.
 var (
 	ErrUnknownCode         = errors.New("unknown multikeypair code")
 	ErrTooShort            = errors.New("multikeypair too short. must be >= 2 bytes")
-	ErrTooLong             = errors.New("multikeypair too long. must be < 129 bytes")
+	ErrTooLong             = errors.New("multikeypair too long. keys must be <= 1 MiB")
 	ErrInvalidMultikeypair = errors.New("input isn't valid multikeypair")
 	ErrVarintBufferShort   = errors.New("uvarint: buffer too small")
 	ErrVarintTooLong       = errors.New("uvarint: varint too big (max 64bit)")
+	ErrUnknownVersion      = errors.New("unknown multikeypair version")
 )
 
 // Ciphers
@@ -43,35 +54,63 @@ var (
 
 // Support ciphers. Accepting PRs for more!
 const (
-	IDENTITY = uint64(0x00)
-	ED_25519 = uint64(0x11)
-	BIP_32   = uint64(0x22)
-	DSA      = uint64(0x33)
-	RSA      = uint64(0x44)
+	IDENTITY           = uint64(0x00)
+	ED_25519           = uint64(0x11)
+	BIP_32             = uint64(0x22)
+	DSA                = uint64(0x33)
+	RSA                = uint64(0x44)
+	ML_DSA_65          = uint64(0x55)
+	ED_25519_ML_DSA_65 = uint64(0x66)
+	P_256              = uint64(0x77)
+	X_25519            = uint64(0x88)
+	SECP_256K1         = uint64(0x99)
+	ML_KEM_768         = uint64(0xaa)
 )
 
-// Names is a mapping from cipher name to code.
-var Names = map[string]uint64{
-	"identity": IDENTITY,
-	"ed25519":  ED_25519,
-	"bip32":    BIP_32,
-	"dsa":      DSA,
-	"res":      RSA,
+// Names of the built-in ciphers, by code.
+var builtinCiphers = map[uint64]string{
+	IDENTITY:           "identity",
+	ED_25519:           "ed25519",
+	BIP_32:             "bip32",
+	DSA:                "dsa",
+	RSA:                "rsa",
+	ML_DSA_65:          "ml-dsa-65",
+	ED_25519_ML_DSA_65: "ed25519+ml-dsa-65",
+	P_256:              "p256",
+	X_25519:            "x25519",
+	SECP_256K1:         "secp256k1",
+	ML_KEM_768:         "ml-kem-768",
 }
 
+// Names is a mapping from cipher name to code.
+//
+// Deprecated: Names is a copy of the built-in ciphers' names, kept for
+// compatibility. It lacks registered ciphers and aliases, and changing
+// it has no effect. Use CipherCode instead.
+var Names = func() map[string]uint64 {
+	names := make(map[string]uint64, len(builtinCiphers))
+	for code, name := range builtinCiphers {
+		names[name] = code
+	}
+	return names
+}()
+
 // Codes is a mapping from cipher code to name.
-var Codes = map[uint64]string{
-	IDENTITY: "identity",
-	ED_25519: "ed25519",
-	BIP_32:   "bip32",
-	DSA:      "dsa",
-	RSA:      "rsa",
-}
+//
+// Deprecated: Codes is a copy of the built-in ciphers' names, kept for
+// compatibility. It lacks registered ciphers, and changing it has no
+// effect. Use CipherName instead.
+var Codes = maps.Clone(builtinCiphers)
 
 // Keypair
 // -----------------------------------------------------------------------------
 
 // Keypair is a public/private keypair unpacked into a struct for easy access.
+//
+// Ownership: Encode copies key material into a fresh Multikeypair, while
+// Decode returns a Keypair whose Public and Private slices alias the
+// Multikeypair they were decoded from. Wiping either one therefore wipes
+// both; copy the slices first if they need independent lifetimes.
 type Keypair struct {
 	// Cipher identification code.
 	Code uint64
@@ -85,18 +124,38 @@ type Keypair struct {
 	Private []byte
 	// Length in bytes of private key.
 	PrivateLength int
+	// Optional metadata, carried in the encoding's extension fields.
+	Metadata Metadata
+
+	// Set while the private key is in locked memory; see LockPrivate.
+	locked *lockedMemory
 }
 
 // Multikey
 // -----------------------------------------------------------------------------
 
-// Multikeypair is a byte slice with the following form:
-// [length] (24-bit length prefix)
-//   [code length]<code> (16-bit length prefix, uvarint code)
-//   [private key length]<private key> (16-bit length prefix)
-//   [public key length]<public key> (16-bit length prefix)
+// Multikeypair is a byte slice with the following (v1) form:
+//
+//	[length] (24-bit length prefix)
+//	  [code length]<code> (16-bit length prefix, uvarint code)
+//	  [private key length]<private key> (16-bit length prefix)
+//	  [public key length]<public key> (16-bit length prefix)
+//
+// Keys too long for a 16-bit length prefix use the wide (v2) form
+// instead. A 24-bit length of zero never starts a valid v1 multikeypair,
+// so it is used to escape into a versioned layout:
+//
+//	<0x000000> (24-bit escape)
+//	<version> (uvarint, 2)
+//	[length] (32-bit length prefix)
+//	  [code length]<code> (16-bit length prefix, uvarint code)
+//	  [private key length]<private key> (32-bit length prefix)
+//	  [public key length]<public key> (32-bit length prefix)
 type Multikeypair []byte
 
+// Escape marking a versioned (v2 or later) multikeypair.
+var versionEscape = []byte{0x00, 0x00, 0x00}
+
 // Implementation
 // -----------------------------------------------------------------------------
 
@@ -110,69 +169,90 @@ func Encode(private []byte, public []byte, code uint64) (Multikeypair, error) {
 	if err := validCode(code); err != nil {
 		return Multikeypair{}, err
 	}
-	b := encodeKeypair(private, public, code)
-	return Multikeypair(b), nil
+	if len(private) > MAX_KEY_LENGTH || len(public) > MAX_KEY_LENGTH {
+		return Multikeypair{}, ErrTooLong
+	}
+	return Multikeypair(encodeKeypair(private, public, code)), nil
 }
 
 // EncodeName encodes a keypair into a Multikeypair, specifying the keypair
-// type using a string name instead of an integer code.
+// type using a string name instead of an integer code. Names and their
+// aliases are matched without regard to case; unknown names are
+// refused with ErrUnknownName.
 func EncodeName(private []byte, public []byte, name string) (Multikeypair, error) {
-	code := Names[name]
+	code, err := CipherCode(name)
+	if err != nil {
+		return Multikeypair{}, err
+	}
 	return Encode(private, public, code)
 }
 
-// Encode a Keypair struct into a Multikeypair.
+// Encode a Keypair struct into a Multikeypair. The metadata is written
+// into the same buffer as the keys, which is sized for both up front.
 func (k Keypair) Encode() (Multikeypair, error) {
-	if err := validCode(k.Code); err != nil {
+	if err := checkEncode(k.Private, k.Public, k.Code); err != nil {
+		return Multikeypair{}, err
+	}
+	if k.Metadata.IsZero() {
+		return Multikeypair(encodeKeypair(k.Private, k.Public, k.Code)), nil
+	}
+	fields, err := k.Metadata.fields()
+	if err != nil {
 		return Multikeypair{}, err
 	}
-	return Encode(k.Private, k.Public, k.Code)
+	size, err := extensionsLen(fields)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	wide := isWide(k.Private, k.Public)
+	b := make([]byte, 0, encodedLen(k.Private, k.Public, k.Code, wide)+size)
+	b = appendKeypair(b, k.Private, k.Public, k.Code, wide)
+	if b, err = addExtensions(b, fields...); err != nil {
+		return Multikeypair{}, err
+	}
+	return Multikeypair(b), nil
 }
 
-// Check that the supplied code is one we recognize.
+// Check that the supplied code is one we recognize, and that the policy
+// permits it.
 func validCode(code uint64) error {
-	for k := range Codes {
-		if k == code {
-			return nil
-		}
+	if _, err := CipherName(code); err != nil {
+		return err
 	}
-	return ErrUnknownCode
+	return checkPolicy(code)
 }
 
-// Pack key material and code type into an array of bytes.
+// Pack key material and code type into an array of bytes, using the v1
+// layout unless the keys are too long for it.
 func encodeKeypair(private []byte, public []byte, code uint64) []byte {
-	codeBuf := PackCode(code)
-
-	var b cryptobyte.Builder
-
-	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
-		// Store the code (packed as varint) with a length prefix.
-		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
-			b.AddBytes(codeBuf)
-		})
-		// Store the private key with a length prefix.
-		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
-			b.AddBytes(private)
-		})
-		// Store the public key with a length prefix.
-		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
-			b.AddBytes(public)
-		})
-	})
-
-	result, err := b.Bytes()
-	if err != nil {
-		panic(err)
-	}
+	return encodeKeypairLayout(private, public, code, isWide(private, public))
+}
+
+// Pack key material and code type into an array of bytes using the
+// original (v1) layout.
+func encodeKeypairV1(private []byte, public []byte, code uint64) []byte {
+	return encodeKeypairLayout(private, public, code, false)
+}
+
+// Pack key material and code type into an array of bytes using the wide
+// (v2) layout.
+func encodeKeypairV2(private []byte, public []byte, code uint64) []byte {
+	return encodeKeypairLayout(private, public, code, true)
+}
 
-	return result
+// Pack a keypair into a buffer of exactly the right size, so that
+// encoding allocates once.
+func encodeKeypairLayout(private []byte, public []byte, code uint64, wide bool) []byte {
+	dst := make([]byte, 0, encodedLen(private, public, code, wide))
+	return appendKeypair(dst, private, public, code, wide)
 }
 
 //
 // DECODE
 //
 
-// Decode unpacks a multikeypair into a Keypair struct.
+// Decode unpacks a multikeypair into a Keypair struct. The key slices in
+// the result alias m.
 func Decode(m Multikeypair) (Keypair, error) {
 	keypair, err := decodeKeypair([]byte(m))
 	if err != nil {
@@ -182,59 +262,189 @@ func Decode(m Multikeypair) (Keypair, error) {
 	return *keypair, nil
 }
 
+// DecodeInto unpacks a multikeypair into an existing Keypair struct. It
+// allocates nothing but the label and application tags of any metadata.
+// The key slices in the result alias m, as with Decode; see
+// DecodeIntoWithOptions to copy them instead. Whatever kp held is
+// replaced, releasing any locked memory but otherwise without wiping
+// it. On error kp is left unchanged.
+func DecodeInto(m Multikeypair, kp *Keypair) error {
+	return decodeInto(m, DecodeOptions{}, kp)
+}
+
 // Decode unpacks a multikeypair into a Keypair struct.
 func (m Multikeypair) Decode() (Keypair, error) {
 	return Decode(m)
 }
 
 func decodeKeypair(buf []byte) (*Keypair, error) {
-	input := cryptobyte.String(buf)
+	return decodeKeypairWithOptions(buf, DecodeOptions{})
+}
 
-	// Extract the overall length of the data.
-	var values cryptobyte.String
-	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
-		return nil, ErrInvalidMultikeypair
+func decodeKeypairWithOptions(buf []byte, opts DecodeOptions) (*Keypair, error) {
+	keypair := new(Keypair)
+	if err := decodeInto(buf, opts, keypair); err != nil {
+		return nil, err
 	}
+	return keypair, nil
+}
 
-	// Extract the code (packed as a varint)
-	var code cryptobyte.String
-	if !values.ReadUint16LengthPrefixed(&code) {
-		return nil, ErrInvalidMultikeypair
+// Decode into kp, which is only written once the whole encoding has
+// been checked.
+func decodeInto(buf []byte, opts DecodeOptions, kp *Keypair) error {
+	if opts.MaxSize > 0 && len(buf) > opts.MaxSize {
+		return ErrTooLong
 	}
-	// Code is a varint that needs to be unpacked into a uint64.
-	numCode, err := UnpackCode(code)
+
+	code, private, public, rest, err := splitKeypair(buf)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	var private cryptobyte.String
-	if !values.ReadUint16LengthPrefixed(&private) {
-		return nil, ErrInvalidMultikeypair
+	if err := opts.check(buf, code, private, public, rest); err != nil {
+		return err
+	}
+	if err := verifyChecksum(buf, rest, opts.RequireChecksum); err != nil {
+		return err
+	}
+	if opts.Strict {
+		if err := checkCanonical(buf); err != nil {
+			return err
+		}
 	}
 
-	var public cryptobyte.String
-	if !values.ReadUint16LengthPrefixed(&public) {
-		return nil, ErrInvalidMultikeypair
+	// Code is a varint that needs to be unpacked into a uint64.
+	numCode, err := UnpackCode(code)
+	if err != nil {
+		return decodeError(FIELD_CODE, offsetIn(buf, code), err)
 	}
 
 	// Check that the cipher type code we decoded is valid.
 	if err := validCode(numCode); err != nil {
-		return nil, err
+		return decodeError(FIELD_CODE, offsetIn(buf, code), err)
 	}
-	name := Codes[numCode]
+	name := codeName(numCode)
 	privateLength := len(private)
 	publicLength := len(public)
 
-	keypair := &Keypair{
+	// Metadata is only read from well-formed extension fields; anything
+	// else was already rejected above if the caller asked for that. The
+	// fields are split into a buffer on the stack, which is enough for
+	// most keys without allocating.
+	var metadata Metadata
+	var buffer [8]extension
+	fields, ok := appendExtensionFields(buffer[:0], rest)
+	if ok {
+		if metadata, err = parseMetadata(fields); err != nil {
+			return decodeError(FIELD_OPTIONAL, offsetIn(buf, rest), err)
+		}
+	}
+
+	// A wrapped private key is unusable until unwrapped, so leave it out.
+	if _, wrapped := findWrappedField(fields); ok && wrapped {
+		private, privateLength = nil, 0
+	}
+
+	if opts.Copy {
+		// One allocation for both keys, capped so that appending to the
+		// private key can't overwrite the public one.
+		keys := make([]byte, 0, len(private)+len(public))
+		keys = append(keys, private...)
+		keys = append(keys, public...)
+		private, public = keys[:len(private):len(private)], keys[len(private):]
+		for name, value := range metadata.Tags {
+			metadata.Tags[name] = bytes.Clone(value)
+		}
+	}
+
+	if kp.locked != nil {
+		kp.locked.destroy()
+	}
+	*kp = Keypair{
 		Code:          numCode,
 		Name:          name,
 		Private:       private,
 		PrivateLength: privateLength,
 		Public:        public,
 		PublicLength:  publicLength,
+		Metadata:      metadata,
 	}
+	return nil
+}
 
-	return keypair, nil
+// Split an encoded multikeypair into its raw fields without interpreting
+// them. The fields alias buf; rest is whatever follows the public key
+// inside the outer length prefix. Malformed encodings are reported with
+// a *DecodeError.
+func splitKeypair(buf []byte) (code, private, public, rest []byte, err error) {
+	if bytes.HasPrefix(buf, versionEscape) {
+		return splitKeypairV2(buf)
+	}
+
+	input := cryptobyte.String(buf)
+
+	// Extract the overall length of the data.
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return nil, nil, nil, nil, decodeError(FIELD_LENGTH, 0, ErrInvalidMultikeypair)
+	}
+
+	// Extract the code (packed as a varint) and the keys.
+	var codeBuf, privateBuf, publicBuf cryptobyte.String
+	if !values.ReadUint16LengthPrefixed(&codeBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_CODE, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+	if !values.ReadUint16LengthPrefixed(&privateBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_PRIVATE, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+	if !values.ReadUint16LengthPrefixed(&publicBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_PUBLIC, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+
+	return codeBuf, privateBuf, publicBuf, values, nil
+}
+
+// Split a versioned multikeypair into its raw fields.
+func splitKeypairV2(buf []byte) (code, private, public, rest []byte, err error) {
+	input := cryptobyte.String(buf[len(versionEscape):])
+
+	version, n := binary.Uvarint(input)
+	if n <= 0 {
+		return nil, nil, nil, nil, decodeError(FIELD_VERSION, len(versionEscape), ErrInvalidMultikeypair)
+	}
+	if version != V2 {
+		return nil, nil, nil, nil, decodeError(FIELD_VERSION, len(versionEscape), ErrUnknownVersion)
+	}
+	input = input[n:]
+
+	var values cryptobyte.String
+	if !readUint32LengthPrefixed(&input, &values) || !input.Empty() {
+		return nil, nil, nil, nil, decodeError(FIELD_LENGTH, len(versionEscape)+n, ErrInvalidMultikeypair)
+	}
+
+	var codeBuf, privateBuf, publicBuf cryptobyte.String
+	if !values.ReadUint16LengthPrefixed(&codeBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_CODE, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+	if !readUint32LengthPrefixed(&values, &privateBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_PRIVATE, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+	if !readUint32LengthPrefixed(&values, &publicBuf) {
+		return nil, nil, nil, nil, decodeError(FIELD_PUBLIC, offsetIn(buf, values), ErrInvalidMultikeypair)
+	}
+
+	return codeBuf, privateBuf, publicBuf, values, nil
+}
+
+// Read a 32-bit length-prefixed byte string; cryptobyte only provides
+// readers for 8, 16 and 24-bit prefixes.
+func readUint32LengthPrefixed(s *cryptobyte.String, out *cryptobyte.String) bool {
+	var length uint32
+	var v []byte
+	if !s.ReadUint32(&length) || !s.ReadBytes(&v, int(length)) {
+		return false
+	}
+	*out = v
+	return true
 }
 
 func castKeypair(buf []byte) (Multikeypair, error) {
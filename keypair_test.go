@@ -5,8 +5,10 @@ package multikeypair
 import (
 	"bytes"
 	crypto_rand "crypto/rand"
+	"encoding/hex"
 	//"fmt"
 	"testing"
+	"time"
 
 	//auth "golang.org/x/crypto/nacl/auth"
 	box "golang.org/x/crypto/nacl/box"
@@ -143,3 +145,191 @@ func validate(
 		)
 	}
 }
+
+// Keys too long for the v1 layout are encoded with the wide layout and
+// decode back to the same material.
+func TestEncodeLarge(t *testing.T) {
+	private := bytes.Repeat([]byte{0xaa}, 64*1024+1)
+	public := bytes.Repeat([]byte{0xbb}, 4096)
+	code := RSA
+	name := Codes[RSA]
+
+	mk, err := Encode(private, public, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(mk, versionEscape) {
+		t.Error("expected wide layout for large key")
+	}
+	kp, err := Decode(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validate(t, kp, code, name, public, private)
+}
+
+// Keys that fit the v1 layout keep using it, so existing readers can
+// still decode them.
+func TestEncodeSmallIsV1(t *testing.T) {
+	mk, err := Encode([]byte("private"), []byte("public"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.HasPrefix(mk, versionEscape) {
+		t.Error("expected v1 layout for small key")
+	}
+}
+
+// Keys over the maximum length are rejected.
+func TestEncodeTooLong(t *testing.T) {
+	private := make([]byte, MAX_KEY_LENGTH+1)
+	if _, err := Encode(private, nil, RSA); err != ErrTooLong {
+		t.Fatalf("expected too long error, got: %v", err)
+	}
+}
+
+// Both layouts are pinned byte for byte.
+func TestEncodeLayout(t *testing.T) {
+	public := []byte{0x00, 0x03}
+	for version, want := range map[uint64]string{
+		V1: "000009000111000000020003",
+		V2: "000000020000000d00011100000000000000020003",
+	} {
+		mk, err := EncodeVersion(nil, public, ED_25519, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(mk); got != want {
+			t.Errorf("v%d: expected %s, got %s", version, want, got)
+		}
+	}
+}
+
+// Encoding an Ed25519 keypair without metadata allocates only the
+// result.
+func TestEncodeAllocs(t *testing.T) {
+	kp := generateEd25519(t)
+	for name, encode := range map[string]func() error{
+		"Encode": func() error {
+			_, err := Encode(kp.Private, kp.Public, kp.Code)
+			return err
+		},
+		"Keypair.Encode": func() error {
+			_, err := kp.Encode()
+			return err
+		},
+	} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := encode(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 1 {
+			t.Errorf("%s: expected 1 allocation, got %v", name, allocs)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Encode(kp.Private, kp.Public, kp.Code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeypairEncodeMetadata(b *testing.B) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		b.Fatal(err)
+	}
+	kp.Metadata = Metadata{Label: "signing", Created: time.Unix(1700000000, 0), Usage: USAGE_SIGN}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := kp.Encode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// DecodeInto fills an existing struct with what Decode returns, aliasing
+// the encoding, and leaves it alone when decoding fails.
+func TestDecodeInto(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{Label: "signing", Usage: USAGE_SIGN, Tags: map[string][]byte{"app": []byte("v")}}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Decode(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Keypair{Code: SECP_256K1, Name: "stale", Private: []byte("stale")}
+	if err := DecodeInto(mk, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) || got.PrivateLength != want.PrivateLength || got.Metadata.Label != "signing" ||
+		!bytes.Equal(got.Metadata.Tags["app"], []byte("v")) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	// Both results alias the same encoding.
+	got.Public[0] ^= 0xff
+	if want.Public[0] != got.Public[0] {
+		t.Error("public key doesn't alias the encoding")
+	}
+	got.Public[0] ^= 0xff
+
+	before := got
+	if err := DecodeInto(mk[:len(mk)-1], &got); err == nil {
+		t.Fatal("expected truncated encoding to fail")
+	}
+	if got.Code != before.Code || &got.Public[0] != &before.Public[0] || got.Metadata.Label != before.Metadata.Label {
+		t.Error("keypair changed by failed decode")
+	}
+}
+
+// Decoding into a struct allocates nothing for keys without a label or
+// application tags.
+func TestDecodeIntoAllocs(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{Created: time.Unix(1700000000, 0), Usage: USAGE_SIGN}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Keypair
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := DecodeInto(mk, &out); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkDecodeInto(b *testing.B) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		b.Fatal(err)
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		b.Fatal(err)
+	}
+	var out Keypair
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := DecodeInto(mk, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
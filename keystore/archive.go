@@ -0,0 +1,172 @@
+// go-multikeypair/keystore/archive.go
+//
+// Encrypted archives of a whole keyring, for backups and for moving keys
+// between machines or backends. An archive holds every key's full
+// encoding, metadata included, sealed with XChaCha20-Poly1305 under a key
+// derived from a passphrase with Argon2id, as for encrypted keystores.
+// The archive has the following form:
+//
+//	<magic> ("mkparchive\x01")
+//	<argon2 time> (32-bit)
+//	<argon2 memory in KiB> (32-bit)
+//	<argon2 threads> (8-bit)
+//	[salt length]<salt> (8-bit length prefix)
+//	<sealed key list>
+//
+// Everything before the sealed key list is authenticated as
+// additional data. The key list is a sequence of multikeypairs, each
+// with a 32-bit length prefix.
+
+package keystore
+
+import (
+	"bytes"
+	"context"
+	crypto_rand "crypto/rand"
+	"errors"
+	"io"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	argon2 "golang.org/x/crypto/argon2"
+	chacha20poly1305 "golang.org/x/crypto/chacha20poly1305"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Archive-specific errors this package exports.
+var (
+	ErrInvalidArchive = errors.New("invalid keystore archive")
+)
+
+// Magic prefix for archives.
+var archiveMagic = []byte("mkparchive\x01")
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Export writes every key in a keyring to w as an archive encrypted
+// under passphrase.
+func Export(w io.Writer, k Keyring, passphrase []byte) error {
+	return ExportContext(context.Background(), w, k, passphrase)
+}
+
+// ExportContext is Export with a context for the access hook of an
+// Audited keyring, which is told of each exported key.
+func ExportContext(ctx context.Context, w io.Writer, k Keyring, passphrase []byte) error {
+	ids, err := k.List()
+	if err != nil {
+		return err
+	}
+	var list cryptobyte.Builder
+	for _, id := range ids {
+		m, err := getForExport(ctx, k, id)
+		if err != nil {
+			return err
+		}
+		list.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(m)
+		})
+		m.Wipe()
+	}
+	plaintext, err := list.Bytes()
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+
+	params := defaultArgon2
+	salt := make([]byte, 16)
+	if _, err := crypto_rand.Read(salt); err != nil {
+		return err
+	}
+	header, err := archiveHeader(params, salt)
+	if err != nil {
+		return err
+	}
+	key := archiveKey(passphrase, params, salt)
+	defer clear(key)
+	sealed, err := seal(key, plaintext, header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(header, sealed...))
+	return err
+}
+
+// Import reads an archive written by Export and stores its keys in a
+// keyring, replacing any with the same identifiers. It returns the
+// identifiers of the imported keys. Nothing is stored unless the whole
+// archive decrypts and every key in it decodes.
+func Import(r io.Reader, k Keyring, passphrase []byte) ([]string, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := bytes.CutPrefix(buf, archiveMagic)
+	if !ok {
+		return nil, ErrInvalidArchive
+	}
+	input := cryptobyte.String(body)
+	var params argon2Params
+	var salt cryptobyte.String
+	if !input.ReadUint32(&params.time) ||
+		!input.ReadUint32(&params.memory) ||
+		!input.ReadUint8(&params.threads) ||
+		!input.ReadUint8LengthPrefixed(&salt) ||
+		params.time == 0 || params.threads == 0 || len(salt) < 16 {
+		return nil, ErrInvalidArchive
+	}
+	header := buf[:len(buf)-len(input)]
+
+	key := archiveKey(passphrase, params, salt)
+	defer clear(key)
+	plaintext, err := open(key, input, header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	defer clear(plaintext)
+
+	var keys []multikeypair.Multikeypair
+	list := cryptobyte.String(plaintext)
+	for !list.Empty() {
+		var n uint32
+		var m []byte
+		if !list.ReadUint32(&n) || !list.ReadBytes(&m, int(n)) {
+			return nil, ErrInvalidArchive
+		}
+		if _, err := multikeypair.Decode(m); err != nil {
+			return nil, err
+		}
+		keys = append(keys, m)
+	}
+
+	ids := make([]string, 0, len(keys))
+	for _, m := range keys {
+		id, err := k.Put(m)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Encode the unsealed part of an archive.
+func archiveHeader(params argon2Params, salt []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes(archiveMagic)
+	b.AddUint32(params.time)
+	b.AddUint32(params.memory)
+	b.AddUint8(params.threads)
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(salt)
+	})
+	return b.Bytes()
+}
+
+// Derive an archive's key from the passphrase.
+func archiveKey(passphrase []byte, params argon2Params, salt []byte) []byte {
+	return argon2.IDKey(passphrase, salt, params.time, params.memory, params.threads, chacha20poly1305.KeySize)
+}
@@ -0,0 +1,99 @@
+// go-multikeypair/keystore/archive_test.go
+
+package keystore
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Keys exported from one keyring import into another intact, metadata
+// included.
+func TestArchive(t *testing.T) {
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata = multikeypair.Metadata{Label: "backup", Usage: multikeypair.USAGE_SIGN}
+	labelled, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := NewMemory(0)
+	for _, m := range []multikeypair.Multikeypair{labelled, generate(t)} {
+		if _, err := src.Put(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := Export(&archive, src, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(archive.Bytes(), labelled) {
+		t.Error("archive holds plaintext keys")
+	}
+
+	dst, err := Open(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := Import(bytes.NewReader(archive.Bytes()), dst, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := src.List()
+	if got, _ := dst.List(); !slices.Equal(got, want) || !slices.Equal(ids, want) {
+		t.Errorf("expected %v, imported %v, stored %v", want, ids, got)
+	}
+	id, _ := ID(labelled)
+	got, err := dst.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, labelled) {
+		t.Error("imported key differs")
+	}
+}
+
+// Wrong passphrases, tampering, and other data are refused without
+// storing anything.
+func TestArchiveRefused(t *testing.T) {
+	src := NewMemory(0)
+	if _, err := src.Put(generate(t)); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := Export(&archive, src, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(archive.Bytes())
+	// The first byte of the salt, after the Argon2 parameters and the
+	// salt's length.
+	tampered[len(archiveMagic)+10] ^= 0x01
+
+	dst := NewMemory(0)
+	for _, c := range []struct {
+		name       string
+		archive    []byte
+		passphrase string
+		err        error
+	}{
+		{"wrong passphrase", archive.Bytes(), "wrong", ErrWrongPassphrase},
+		{"tampered salt", tampered, "passphrase", ErrWrongPassphrase},
+		{"not an archive", []byte("mkpstore\x01"), "passphrase", ErrInvalidArchive},
+		{"truncated", archive.Bytes()[:len(archiveMagic)+4], "passphrase", ErrInvalidArchive},
+	} {
+		_, err := Import(bytes.NewReader(c.archive), dst, []byte(c.passphrase))
+		if err != c.err {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+	if ids, _ := dst.List(); len(ids) != 0 {
+		t.Errorf("keys stored: %v", ids)
+	}
+}
@@ -0,0 +1,398 @@
+// go-multikeypair/keystore/encrypt.go
+//
+// Encryption at rest for keystores. Every key file is sealed with
+// XChaCha20-Poly1305 under a master key derived from a passphrase (or the
+// contents of a keyfile) with Argon2id. The master key is derived lazily,
+// the first time a key is read or written, and forgotten again after a
+// configurable idle period. Each key's header, its encoding with the
+// private key zeroed, is kept unsealed beside it, so that the store can
+// be listed without the passphrase; public keys and metadata are
+// therefore not confidential.
+
+package keystore
+
+import (
+	"bytes"
+	crypto_rand "crypto/rand"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	argon2 "golang.org/x/crypto/argon2"
+	chacha20poly1305 "golang.org/x/crypto/chacha20poly1305"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Encryption-specific errors this package exports.
+var (
+	ErrEncrypted        = errors.New("keystore is encrypted")
+	ErrNotEncrypted     = errors.New("keystore holds unencrypted keys")
+	ErrWrongPassphrase  = errors.New("wrong keystore passphrase")
+	ErrInvalidHeader    = errors.New("invalid keystore header")
+	ErrInvalidSealedKey = errors.New("invalid sealed key file")
+	ErrNoPassphrase     = errors.New("keystore has no passphrase source")
+)
+
+// Name of the file holding the key derivation parameters.
+const headerName = ".keystore"
+
+// Magic prefixes for the header and sealed key files.
+var (
+	headerMagic = []byte("mkpstore\x01")
+	sealedMagic = []byte("mkpseal\x01")
+)
+
+// Additional data for the passphrase check in the header.
+const checkAD = "multikeypair keystore check"
+
+// Argon2id parameters used for new keystores: the second recommended
+// option from RFC 9106. Tests lower these.
+var defaultArgon2 = argon2Params{time: 3, memory: 64 * 1024, threads: 4}
+
+// The most costly Argon2id parameters a keystore header may ask for, so
+// that a tampered header can't make unlocking exhaust memory or CPU.
+// The memory limit is 4 GiB.
+var maxArgon2 = argon2Params{time: 16, memory: 4 * 1024 * 1024, threads: 64}
+
+// Types
+// -----------------------------------------------------------------------------
+
+// EncryptionOptions configures an encrypted keystore.
+type EncryptionOptions struct {
+	// Passphrase returns the master passphrase, or keyfile contents. It
+	// is called whenever the store is locked and a key needs to be read
+	// or written. The returned slice is wiped after use.
+	Passphrase func() ([]byte, error)
+	// IdleTimeout is how long the store stays unlocked after its last
+	// use. Zero means it stays unlocked until Lock is called.
+	IdleTimeout time.Duration
+}
+
+// Argon2id cost parameters, recorded in the keystore header.
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// State for an encrypted store.
+type sealer struct {
+	opts   EncryptionOptions
+	params argon2Params
+	salt   []byte
+	check  []byte
+
+	mu    sync.Mutex
+	key   []byte
+	timer *time.Timer
+	// When the master key was last used. The idle timer can fire just as
+	// the key is used, so it checks this before locking.
+	lastUsed time.Time
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// OpenEncrypted opens the encrypted keystore in dir, creating it if
+// needed. A directory that already holds unencrypted keys is refused.
+// The passphrase isn't requested until a key is first read or written,
+// except when creating a new keystore.
+func OpenEncrypted(dir string, opts EncryptionOptions) (*Store, error) {
+	if opts.Passphrase == nil {
+		return nil, ErrNoPassphrase
+	}
+	s, err := openDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sl := &sealer{opts: opts}
+	header, err := os.ReadFile(filepath.Join(dir, headerName))
+	switch {
+	case err == nil:
+		if err := sl.parseHeader(header); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		ids, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) != 0 {
+			return nil, ErrNotEncrypted
+		}
+		if err := sl.create(dir); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	s.sealer = sl
+	return s, nil
+}
+
+// KeyfilePassphrase returns a passphrase source that reads the contents
+// of a keyfile.
+func KeyfilePassphrase(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// Lock forgets the master key of an encrypted store, so the passphrase
+// is needed again for the next read or write. It does nothing for an
+// unencrypted store.
+func (s *Store) Lock() {
+	if s.sealer != nil {
+		s.sealer.lock()
+	}
+}
+
+// Locked reports whether an encrypted store currently needs its
+// passphrase. Unencrypted stores are never locked.
+func (s *Store) Locked() bool {
+	if s.sealer == nil {
+		return false
+	}
+	s.sealer.mu.Lock()
+	defer s.sealer.mu.Unlock()
+	return s.sealer.key == nil
+}
+
+// Encrypted reports whether the store seals keys at rest.
+func (s *Store) Encrypted() bool {
+	return s.sealer != nil
+}
+
+// Report whether dir holds an encrypted keystore.
+func isEncrypted(dir string) (bool, error) {
+	_, err := os.Stat(filepath.Join(dir, headerName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//
+// KEYS
+//
+
+// Create a new keystore header, asking for the passphrase immediately.
+func (sl *sealer) create(dir string) error {
+	sl.params = defaultArgon2
+	sl.salt = make([]byte, 16)
+	if _, err := crypto_rand.Read(sl.salt); err != nil {
+		return err
+	}
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	key, err := sl.derive()
+	if err != nil {
+		return err
+	}
+	sl.check, err = seal(key, nil, []byte(checkAD))
+	if err != nil {
+		clear(key)
+		return err
+	}
+	sl.setKey(key)
+
+	header, err := sl.header()
+	if err != nil {
+		return err
+	}
+	return writeAtomic(dir, headerName, header)
+}
+
+// Return the master key, unlocking if necessary, and record the use for
+// the idle timeout. Callers must hold sl.mu.
+func (sl *sealer) unlock() ([]byte, error) {
+	if sl.key == nil {
+		key, err := sl.derive()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := open(key, sl.check, []byte(checkAD)); err != nil {
+			clear(key)
+			return nil, ErrWrongPassphrase
+		}
+		sl.setKey(key)
+	}
+	sl.lastUsed = time.Now()
+	return sl.key, nil
+}
+
+// Install a freshly derived master key. Callers must hold sl.mu.
+func (sl *sealer) setKey(key []byte) {
+	sl.key = key
+	sl.lastUsed = time.Now()
+	if sl.opts.IdleTimeout > 0 && sl.timer == nil {
+		sl.timer = time.AfterFunc(sl.opts.IdleTimeout, sl.expire)
+	}
+}
+
+// Lock if the master key has gone unused for the idle timeout, or wait
+// out the rest of it if the key was used since the timer was set.
+func (sl *sealer) expire() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if sl.key == nil || sl.timer == nil {
+		return
+	}
+	if idle := time.Since(sl.lastUsed); idle < sl.opts.IdleTimeout {
+		sl.timer.Reset(sl.opts.IdleTimeout - idle)
+		return
+	}
+	sl.wipe()
+}
+
+// Wipe and forget the master key.
+func (sl *sealer) lock() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.wipe()
+}
+
+// Wipe the master key and stop the idle timer. Callers must hold sl.mu.
+func (sl *sealer) wipe() {
+	clear(sl.key)
+	sl.key = nil
+	if sl.timer != nil {
+		sl.timer.Stop()
+		sl.timer = nil
+	}
+}
+
+// Derive the master key from the passphrase.
+func (sl *sealer) derive() ([]byte, error) {
+	passphrase, err := sl.opts.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(passphrase)
+	p := sl.params
+	return argon2.IDKey(passphrase, sl.salt, p.time, p.memory, p.threads, chacha20poly1305.KeySize), nil
+}
+
+// Seal a key file's contents, bound to its identifier.
+func (sl *sealer) sealKey(id string, plaintext []byte) ([]byte, error) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	key, err := sl.unlock()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(key, plaintext, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(sealedMagic), sealed...), nil
+}
+
+// Open a sealed key file's contents, checking its identifier.
+func (sl *sealer) openKey(id string, sealed []byte) ([]byte, error) {
+	body, ok := bytes.CutPrefix(sealed, sealedMagic)
+	if !ok {
+		return nil, ErrInvalidSealedKey
+	}
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	key, err := sl.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return open(key, body, []byte(id))
+}
+
+//
+// AEAD
+//
+
+// Seal plaintext under key with a random nonce, which is prepended.
+func seal(key []byte, plaintext []byte, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := crypto_rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+// Open a ciphertext produced by seal.
+func open(key []byte, sealed []byte, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidSealedKey
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrInvalidSealedKey
+	}
+	return plaintext, nil
+}
+
+//
+// HEADER
+//
+
+// Encode the keystore header with the following form:
+//
+//	<magic> ("mkpstore\x01")
+//	<argon2 time> (32-bit)
+//	<argon2 memory in KiB> (32-bit)
+//	<argon2 threads> (8-bit)
+//	[salt length]<salt> (8-bit length prefix)
+//	[check length]<sealed passphrase check> (8-bit length prefix)
+func (sl *sealer) header() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes(headerMagic)
+	b.AddUint32(sl.params.time)
+	b.AddUint32(sl.params.memory)
+	b.AddUint8(sl.params.threads)
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sl.salt)
+	})
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sl.check)
+	})
+	return b.Bytes()
+}
+
+// Decode a keystore header written by header.
+func (sl *sealer) parseHeader(buf []byte) error {
+	body, ok := bytes.CutPrefix(buf, headerMagic)
+	if !ok {
+		return ErrInvalidHeader
+	}
+	input := cryptobyte.String(body)
+	var salt, check cryptobyte.String
+	if !input.ReadUint32(&sl.params.time) ||
+		!input.ReadUint32(&sl.params.memory) ||
+		!input.ReadUint8(&sl.params.threads) ||
+		!input.ReadUint8LengthPrefixed(&salt) ||
+		!input.ReadUint8LengthPrefixed(&check) ||
+		!input.Empty() ||
+		sl.params.time == 0 || sl.params.threads == 0 || len(salt) < 16 ||
+		sl.params.time > maxArgon2.time ||
+		sl.params.memory > maxArgon2.memory ||
+		sl.params.threads > maxArgon2.threads {
+		return ErrInvalidHeader
+	}
+	sl.salt, sl.check = salt, check
+	return nil
+}
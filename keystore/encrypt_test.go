@@ -0,0 +1,219 @@
+// go-multikeypair/keystore/encrypt_test.go
+
+package keystore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func init() {
+	// Keep key derivation cheap in tests.
+	defaultArgon2 = argon2Params{time: 1, memory: 64, threads: 1}
+}
+
+// A passphrase source that counts how often it is asked.
+type countingPassphrase struct {
+	passphrase string
+	calls      int
+}
+
+func (c *countingPassphrase) get() ([]byte, error) {
+	c.calls++
+	return []byte(c.passphrase), nil
+}
+
+// Keys are sealed on disk and readable with the right passphrase.
+func TestEncrypted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	pass := &countingPassphrase{passphrase: "correct horse"}
+	s, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: pass.get})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mk := generate(t)
+	id, err := s.Put(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, id+keyExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, mk) {
+		t.Error("key stored in the clear")
+	}
+
+	// Reopening asks for the passphrase only once a key is read.
+	pass2 := &countingPassphrase{passphrase: "correct horse"}
+	s2, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: pass2.get})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass2.calls != 0 || !s2.Locked() {
+		t.Error("expected lazy unlocking")
+	}
+	got, err := s2.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(mk) {
+		t.Error("loaded key doesn't match")
+	}
+	if _, err := s2.Get(id); err != nil {
+		t.Fatal(err)
+	}
+	if pass2.calls != 1 {
+		t.Errorf("passphrase requested %d times", pass2.calls)
+	}
+
+	// An unencrypted Open is refused.
+	if _, err := Open(dir); err != ErrEncrypted {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// A wrong passphrase is reported as such.
+func TestWrongPassphrase(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	right := &countingPassphrase{passphrase: "right"}
+	s, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: right.get})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Put(generate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := &countingPassphrase{passphrase: "wrong"}
+	s2, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: wrong.get})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s2.Get(id); err != ErrWrongPassphrase {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Headers asking for more costly key derivation than the limits allow
+// are refused.
+func TestHeaderLimits(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, 16)
+	for _, c := range []struct {
+		params argon2Params
+		ok     bool
+	}{
+		{defaultArgon2, true},
+		{maxArgon2, true},
+		{argon2Params{time: maxArgon2.time + 1, memory: 64, threads: 1}, false},
+		{argon2Params{time: 1, memory: maxArgon2.memory + 1, threads: 1}, false},
+		{argon2Params{time: 1, memory: 64, threads: maxArgon2.threads + 1}, false},
+		{argon2Params{time: 0, memory: 64, threads: 1}, false},
+	} {
+		header, err := (&sealer{params: c.params, salt: salt}).header()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = new(sealer).parseHeader(header)
+		if c.ok && err != nil {
+			t.Errorf("%+v: %v", c.params, err)
+		}
+		if !c.ok && err != ErrInvalidHeader {
+			t.Errorf("%+v: expected ErrInvalidHeader, got %v", c.params, err)
+		}
+	}
+}
+
+// The store re-locks after the idle timeout.
+func TestIdleLock(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	pass := &countingPassphrase{passphrase: "pass"}
+	s, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: pass.get, IdleTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Put(generate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.Locked() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !s.Locked() {
+		t.Fatal("store didn't re-lock")
+	}
+	if _, err := s.Get(id); err != nil {
+		t.Fatal(err)
+	}
+	if pass.calls != 2 {
+		t.Errorf("passphrase requested %d times", pass.calls)
+	}
+
+	s.Lock()
+	if !s.Locked() {
+		t.Error("Lock didn't lock")
+	}
+}
+
+// The idle timer doesn't lock a store that was used after it was set.
+func TestIdleLockRecentUse(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	pass := &countingPassphrase{passphrase: "pass"}
+	s, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: pass.get, IdleTimeout: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(generate(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// As if the timer fired while the key was being used.
+	s.sealer.expire()
+	if s.Locked() {
+		t.Fatal("store locked while in use")
+	}
+
+	s.sealer.mu.Lock()
+	s.sealer.lastUsed = time.Now().Add(-2 * time.Hour)
+	s.sealer.mu.Unlock()
+	s.sealer.expire()
+	if !s.Locked() {
+		t.Error("idle store didn't lock")
+	}
+}
+
+// Keyfiles work as passphrases, and plaintext stores can't be encrypted
+// in place.
+func TestKeyfile(t *testing.T) {
+	keyfile := filepath.Join(t.TempDir(), "keyfile")
+	if err := os.WriteFile(keyfile, []byte("random keyfile contents"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "keys")
+	s, err := OpenEncrypted(dir, EncryptionOptions{Passphrase: KeyfilePassphrase(keyfile)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(generate(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := Open(filepath.Join(t.TempDir(), "plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Put(generate(t)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenEncrypted(plain.Dir(), EncryptionOptions{Passphrase: KeyfilePassphrase(keyfile)}); err != ErrNotEncrypted {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+// go-multikeypair/keystore/hook.go
+//
+// Access auditing for services that must log every use of their private
+// keys. Wrapping a keyring with WithAccessHook reports each time a
+// private key is read from it, signed with, or exported, together with
+// the context the caller passed in, so that request identifiers and the
+// like can be attached to the log entry.
+
+package keystore
+
+import (
+	"context"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Operations reported to an AccessHook.
+const (
+	ACCESS_READ   = Operation(1)
+	ACCESS_SIGN   = Operation(2)
+	ACCESS_EXPORT = Operation(3)
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Operation is a kind of private key access.
+type Operation uint8
+
+// Access describes one access to a private key.
+type Access struct {
+	// Identifier of the key: the fingerprint of its public key.
+	ID string
+	// What was done with the key.
+	Operation Operation
+	// Error the access failed with, if any. Failed attempts are reported
+	// too.
+	Err error
+}
+
+// AccessHook is called after every private key access through an
+// audited keyring, with the context the caller supplied. It must be safe
+// for concurrent use.
+type AccessHook func(ctx context.Context, access Access)
+
+// Audited is a Keyring whose private key accesses are reported to a
+// hook. Storing, listing, reading headers and deleting keys aren't
+// reported.
+type Audited struct {
+	Keyring
+	hook AccessHook
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// String names the operation, e.g. "sign".
+func (o Operation) String() string {
+	switch o {
+	case ACCESS_READ:
+		return "read"
+	case ACCESS_SIGN:
+		return "sign"
+	case ACCESS_EXPORT:
+		return "export"
+	}
+	return "unknown"
+}
+
+// WithAccessHook wraps a keyring so that its private key accesses are
+// reported to hook.
+func WithAccessHook(k Keyring, hook AccessHook) *Audited {
+	return &Audited{Keyring: k, hook: hook}
+}
+
+// Get loads the Multikeypair stored under id, reporting a read.
+func (a *Audited) Get(id string) (multikeypair.Multikeypair, error) {
+	return a.GetContext(context.Background(), id)
+}
+
+// GetContext is Get with a context for the hook.
+func (a *Audited) GetContext(ctx context.Context, id string) (multikeypair.Multikeypair, error) {
+	return a.get(ctx, id, ACCESS_READ)
+}
+
+// Header loads the key stored under id with its private key zeroed.
+// No private key is read, so nothing is reported.
+func (a *Audited) Header(id string) (multikeypair.Multikeypair, error) {
+	return Header(a.Keyring, id)
+}
+
+// Sign signs message with the key stored under id, reporting a
+// signature.
+func (a *Audited) Sign(id string, message []byte) ([]byte, error) {
+	return a.SignContext(context.Background(), id, message)
+}
+
+// SignContext is Sign with a context for the hook. The context is also
+// passed on to keyrings that take one, such as Remote.
+func (a *Audited) SignContext(ctx context.Context, id string, message []byte) ([]byte, error) {
+	var signature []byte
+	var err error
+	if k, ok := a.Keyring.(interface {
+		SignContext(context.Context, string, []byte) ([]byte, error)
+	}); ok {
+		signature, err = k.SignContext(ctx, id, message)
+	} else {
+		signature, err = a.Keyring.Sign(id, message)
+	}
+	a.hook(ctx, Access{ID: id, Operation: ACCESS_SIGN, Err: err})
+	return signature, err
+}
+
+// Load a key and report the access.
+func (a *Audited) get(ctx context.Context, id string, op Operation) (multikeypair.Multikeypair, error) {
+	m, err := a.Keyring.Get(id)
+	a.hook(ctx, Access{ID: id, Operation: op, Err: err})
+	return m, err
+}
+
+// Load a key for export, reporting it if the keyring is audited.
+func getForExport(ctx context.Context, k Keyring, id string) (multikeypair.Multikeypair, error) {
+	if a, ok := k.(*Audited); ok {
+		return a.get(ctx, id, ACCESS_EXPORT)
+	}
+	return k.Get(id)
+}
@@ -0,0 +1,63 @@
+// go-multikeypair/keystore/hook_test.go
+
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Context key for a test request identifier.
+type requestKey struct{}
+
+// Reads, signatures and exports are reported with the caller's context,
+// failed ones included; other operations aren't.
+func TestAccessHook(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	audited := WithAccessHook(NewMemory(0), func(ctx context.Context, access Access) {
+		mu.Lock()
+		defer mu.Unlock()
+		request, _ := ctx.Value(requestKey{}).(string)
+		entry := request + " " + access.Operation.String()
+		if access.Err != nil {
+			entry += " failed"
+		}
+		got = append(got, entry)
+	})
+
+	id, err := audited.Put(generate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audited.List(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+	if _, err := audited.GetContext(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audited.SignContext(ctx, id, []byte("message")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audited.Sign(id, []byte("message")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportContext(ctx, &bytes.Buffer{}, audited, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if err := audited.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audited.Get(id); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	want := []string{"req-1 read", "req-1 sign", " sign", "req-1 export", " read failed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
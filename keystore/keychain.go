@@ -0,0 +1,165 @@
+// go-multikeypair/keystore/keychain.go
+//
+// A keystore backed by the operating system's secure storage: the macOS
+// Keychain, Windows Credential Manager, or the Linux Secret Service. Keys
+// are stored as base58 secrets under a service name, with the same
+// identifiers as a file Store.
+
+package keystore
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	b58 "github.com/mr-tron/base58/base58"
+	multikeypair "github.com/proofzero/go-multikeypair"
+	keyring "github.com/zalando/go-keyring"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Keychain-specific errors this package exports.
+var (
+	ErrKeyTooLarge = errors.New("key too large for os keychain")
+)
+
+// Account name of the entry listing the stored identifiers. It contains
+// a character outside the base58 alphabet, so it can't collide with one.
+const indexAccount = ".index"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Keychain stores multikeypairs in the operating system's keychain. OS
+// keychains can't enumerate entries, so the Keychain also maintains an
+// index entry listing its keys.
+type Keychain struct {
+	service string
+	mu      sync.Mutex
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// OpenKeychain returns a keystore that keeps keys in the OS keychain
+// under the given service name, e.g. "com.example.myapp".
+func OpenKeychain(service string) *Keychain {
+	return &Keychain{service: service}
+}
+
+// Put stores a Multikeypair, replacing any existing key with the same
+// public key, and returns its identifier. Windows limits secrets to a
+// few kilobytes, so very large keys are refused there.
+func (k *Keychain) Put(m multikeypair.Multikeypair) (string, error) {
+	id, err := ID(m)
+	if err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	err = keyring.Set(k.service, id, m.B58String())
+	if errors.Is(err, keyring.ErrSetDataTooBig) {
+		return "", ErrKeyTooLarge
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ids, err := k.index()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(ids, id) {
+		if err := k.setIndex(append(ids, id)); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Get loads the Multikeypair stored under id.
+func (k *Keychain) Get(id string) (multikeypair.Multikeypair, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	secret, err := keyring.Get(k.service, id)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return multikeypair.MultikeypairFromB58(secret)
+}
+
+// List returns the identifiers of every stored key, sorted.
+func (k *Keychain) List() ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	ids, err := k.index()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Delete removes the key stored under id.
+func (k *Keychain) Delete(id string) error {
+	if !validID(id) {
+		return ErrInvalidID
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	err := keyring.Delete(k.service, id)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	ids, err := k.index()
+	if err != nil {
+		return err
+	}
+	return k.setIndex(slices.DeleteFunc(ids, func(s string) bool { return s == id }))
+}
+
+// Read the index entry. Callers must hold k.mu.
+func (k *Keychain) index() ([]string, error) {
+	s, err := keyring.Get(k.service, indexAccount)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, id := range strings.Fields(s) {
+		if _, err := b58.Decode(id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Write the index entry. Callers must hold k.mu.
+func (k *Keychain) setIndex(ids []string) error {
+	if len(ids) == 0 {
+		err := keyring.Delete(k.service, indexAccount)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return keyring.Set(k.service, indexAccount, strings.Join(ids, " "))
+}
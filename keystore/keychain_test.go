@@ -0,0 +1,57 @@
+// go-multikeypair/keystore/keychain_test.go
+
+package keystore
+
+import (
+	"testing"
+
+	keyring "github.com/zalando/go-keyring"
+)
+
+// Keys can be stored, listed, loaded, and deleted in the OS keychain.
+func TestKeychain(t *testing.T) {
+	keyring.MockInit()
+	k := OpenKeychain("test.multikeypair")
+
+	a, b := generate(t), generate(t)
+	idA, err := k.Put(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idB, err := k.Put(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Put(a); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := k.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 keys, got %v", ids)
+	}
+
+	got, err := k.Get(idA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(a) {
+		t.Error("loaded key doesn't match")
+	}
+
+	if err := k.Delete(idB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get(idB); err != ErrNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if ids, _ := k.List(); len(ids) != 1 || ids[0] != idA {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+	if _, err := k.Get("../x"); err != ErrInvalidID {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// go-multikeypair/keystore/keyring.go
+//
+// A common interface over the places keys can be kept, so that an
+// application can move its keys between a directory, memory, the OS
+// keychain, or a KMS without touching the code that uses them. Every
+// backend identifies keys by the base58 fingerprint of their public key
+// (see ID), and can sign with a key without handing its private half
+// to the caller.
+//
+// Keys held by a KMS never leave it, so the Remote keyring stores
+// public-only multikeypairs carrying a remote key reference, as written
+// by multikeypair.RemoteKeypair.Encode, and signs through the backend
+// registered for the reference.
+
+package keystore
+
+import (
+	"context"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Keyring stores multikeypairs under their identifiers and signs with
+// them.
+type Keyring interface {
+	// Put stores a Multikeypair, replacing any existing key with the
+	// same public key, and returns its identifier.
+	Put(m multikeypair.Multikeypair) (string, error)
+	// Get loads the Multikeypair stored under id.
+	Get(id string) (multikeypair.Multikeypair, error)
+	// List returns the identifiers of every stored key, sorted.
+	List() ([]string, error)
+	// Sign signs message with the key stored under id.
+	Sign(id string, message []byte) ([]byte, error)
+	// Delete removes the key stored under id.
+	Delete(id string) error
+}
+
+// Remote is a Keyring of keys held by remote backends, such as a cloud
+// KMS. It keeps the references to them in another Keyring.
+type Remote struct {
+	refs Keyring
+}
+
+// A multikeypair.PublicKeySource over a Keyring.
+type keyringSource struct {
+	ring Keyring
+}
+
+var (
+	_ Keyring = (*Store)(nil)
+	_ Keyring = (*Memory)(nil)
+	_ Keyring = (*Keychain)(nil)
+	_ Keyring = (*Remote)(nil)
+	_ Keyring = (*Audited)(nil)
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Sign a message with a key loaded from a keyring, wiping it afterwards.
+func signStored(m multikeypair.Multikeypair, message []byte) ([]byte, error) {
+	defer m.Wipe()
+	kp, err := m.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return kp.Sign(message)
+}
+
+// Sign signs message with the key stored under id.
+func (s *Store) Sign(id string, message []byte) ([]byte, error) {
+	m, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return signStored(m, message)
+}
+
+// Sign signs message with the key stored under id.
+func (s *Memory) Sign(id string, message []byte) ([]byte, error) {
+	m, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return signStored(m, message)
+}
+
+// Sign signs message with the key stored under id.
+func (k *Keychain) Sign(id string, message []byte) ([]byte, error) {
+	m, err := k.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return signStored(m, message)
+}
+
+// PublicKeys returns a multikeypair.PublicKeySource that serves the
+// public halves of the keys in ring by identifier, e.g. to back a
+// multikeypair.KeyCache.
+func PublicKeys(ring Keyring) multikeypair.PublicKeySource {
+	return keyringSource{ring: ring}
+}
+
+func (s keyringSource) PublicKey(ctx context.Context, id string) (multikeypair.Keypair, error) {
+	if err := ctx.Err(); err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	m, err := Header(s.ring, id)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := m.Decode()
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Private, kp.PrivateLength = nil, 0
+	return kp, nil
+}
+
+//
+// REMOTE
+//
+
+// NewRemote returns a keyring of remote keys whose references are kept
+// in refs, e.g. a file Store. The backends for the references must be
+// registered with multikeypair.RegisterRemoteBackend.
+func NewRemote(refs Keyring) *Remote {
+	return &Remote{refs: refs}
+}
+
+// Add fetches the public key for a reference from its backend and
+// stores the reference, returning its identifier.
+func (r *Remote) Add(ctx context.Context, reference string) (string, error) {
+	remote, err := multikeypair.NewRemoteKeypair(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+	m, err := remote.Encode()
+	if err != nil {
+		return "", err
+	}
+	return r.refs.Put(m)
+}
+
+// Put stores an encoded remote key reference. Multikeypairs holding a
+// private key, or lacking a reference, are refused.
+func (r *Remote) Put(m multikeypair.Multikeypair) (string, error) {
+	if _, err := multikeypair.DecodeRemoteKeypair(m); err != nil {
+		return "", err
+	}
+	return r.refs.Put(m)
+}
+
+// Get loads the encoded reference stored under id.
+func (r *Remote) Get(id string) (multikeypair.Multikeypair, error) {
+	return r.refs.Get(id)
+}
+
+// Header loads the encoded reference stored under id, which holds no
+// private key.
+func (r *Remote) Header(id string) (multikeypair.Multikeypair, error) {
+	return Header(r.refs, id)
+}
+
+// List returns the identifiers of every stored reference, sorted.
+func (r *Remote) List() ([]string, error) {
+	return r.refs.List()
+}
+
+// Sign asks the backend holding the key stored under id to sign
+// message.
+func (r *Remote) Sign(id string, message []byte) ([]byte, error) {
+	return r.SignContext(context.Background(), id, message)
+}
+
+// SignContext is Sign with a context for the round trip.
+func (r *Remote) SignContext(ctx context.Context, id string, message []byte) ([]byte, error) {
+	m, err := r.refs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := multikeypair.DecodeRemoteKeypair(m)
+	if err != nil {
+		return nil, err
+	}
+	return remote.SignContext(ctx, message)
+}
+
+// Delete removes the reference stored under id. The key itself stays in
+// its backend.
+func (r *Remote) Delete(id string) error {
+	return r.refs.Delete(id)
+}
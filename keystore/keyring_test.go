@@ -0,0 +1,110 @@
+// go-multikeypair/keystore/keyring_test.go
+
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	keyring "github.com/zalando/go-keyring"
+)
+
+// Reference scheme of the test backend.
+const testScheme = "keystoretest"
+
+// A remote backend holding a single key in memory.
+type testBackend struct {
+	key multikeypair.Keypair
+}
+
+// The backend registered under testScheme. Backends can't be replaced
+// once registered, so repeated runs share it and swap its key.
+var testRemote = &testBackend{}
+
+func (b *testBackend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	return b.key, nil
+}
+
+func (b *testBackend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	return b.key.Sign(message)
+}
+
+// Every backend stores keys under the same identifier and signs with
+// them.
+func TestKeyring(t *testing.T) {
+	keyring.MockInit()
+	store, err := Open(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := Open(filepath.Join(t.TempDir(), "refs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRemote.key = kp
+	err = multikeypair.RegisterRemoteBackend(testScheme, testRemote)
+	if err != nil && err != multikeypair.ErrRemoteRegistered {
+		t.Fatal(err)
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ID(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := NewRemote(refs)
+	if _, err := remote.Put(mk); err == nil {
+		t.Error("remote keyring accepted a private key")
+	}
+	if id, err := remote.Add(context.Background(), testScheme+":key"); err != nil || id != want {
+		t.Fatalf("unexpected id %s, error %v", id, err)
+	}
+
+	for name, k := range map[string]Keyring{
+		"store":    store,
+		"memory":   NewMemory(0),
+		"keychain": OpenKeychain("test.multikeypair.keyring"),
+		"remote":   remote,
+	} {
+		if k != remote {
+			id, err := k.Put(mk)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if id != want {
+				t.Errorf("%s: unexpected id %s", name, id)
+			}
+		}
+		sig, err := k.Sign(want, []byte("message"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := kp.Verify([]byte("message"), sig); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		pub, err := PublicKeys(k).PublicKey(context.Background(), want)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if pub.Private != nil || !bytes.Equal(pub.Public, kp.Public) {
+			t.Errorf("%s: unexpected public key %v", name, pub)
+		}
+		if err := k.Delete(want); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if _, err := k.Sign(want, []byte("message")); err != ErrNotFound {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+}
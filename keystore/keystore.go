@@ -0,0 +1,305 @@
+// go-multikeypair/keystore/keystore.go
+//
+// A directory-backed store of multikeypairs. Each key lives in its own
+// file, named by the base58 fingerprint of its public key, and is written
+// atomically with permissions that only let the owner read it.
+
+package keystore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	b58 "github.com/mr-tron/base58/base58"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Keystore-specific errors this package exports.
+var (
+	ErrNotFound             = errors.New("key not found in keystore")
+	ErrInvalidID            = errors.New("invalid keystore key id")
+	ErrInsecurePermissions  = errors.New("keystore directory is accessible by other users")
+	ErrNotKeystoreDirectory = errors.New("keystore path isn't a directory")
+)
+
+// File name extension for stored keys.
+const keyExt = ".mkp"
+
+// File name extension for the unsealed headers of keys in encrypted
+// stores.
+const headerExt = ".hdr"
+
+// Permissions for the keystore directory and key files.
+const (
+	dirMode  = fs.FileMode(0o700)
+	fileMode = fs.FileMode(0o600)
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Store is a directory of multikeypairs. It is safe for concurrent use
+// by multiple goroutines and processes, in that every write replaces a
+// whole file atomically.
+type Store struct {
+	dir string
+	// Set for encrypted stores.
+	sealer *sealer
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Open opens the unencrypted keystore in dir, creating the directory if
+// needed. An existing directory that other users can access is refused,
+// as is an encrypted keystore; use OpenEncrypted for those.
+func Open(dir string) (*Store, error) {
+	s, err := openDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := isEncrypted(dir)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, ErrEncrypted
+	}
+	return s, nil
+}
+
+// Open a keystore directory, creating it if needed, and check its
+// permissions.
+func openDir(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, ErrNotKeystoreDirectory
+	}
+	// Windows doesn't report meaningful permission bits.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, ErrInsecurePermissions
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the directory backing the keystore.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// ID returns the identifier a Multikeypair is stored under: the base58
+// multihash fingerprint of its public key.
+func ID(m multikeypair.Multikeypair) (string, error) {
+	kp, err := m.Decode()
+	if err != nil {
+		return "", err
+	}
+	fp, err := kp.Fingerprint(multikeypair.DEFAULT_HASH)
+	if err != nil {
+		return "", err
+	}
+	return fp.B58String(), nil
+}
+
+// Put stores a Multikeypair, replacing any existing key with the same
+// public key, and returns its identifier.
+func (s *Store) Put(m multikeypair.Multikeypair) (string, error) {
+	id, err := ID(m)
+	if err != nil {
+		return "", err
+	}
+	data := []byte(m)
+	if s.sealer != nil {
+		if data, err = s.sealer.sealKey(id, m); err != nil {
+			return "", err
+		}
+	}
+	if err := writeAtomic(s.dir, id+keyExt, data); err != nil {
+		return "", err
+	}
+	if s.sealer != nil {
+		header := slices.Clone(m)
+		header.Wipe()
+		if err := writeAtomic(s.dir, id+headerExt, header); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Get loads the Multikeypair stored under id.
+func (s *Store) Get(id string) (multikeypair.Multikeypair, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.sealer != nil {
+		if buf, err = s.sealer.openKey(id, buf); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := multikeypair.Decode(buf); err != nil {
+		return nil, err
+	}
+	return multikeypair.Multikeypair(buf), nil
+}
+
+// Header loads the key stored under id with its private key zeroed.
+// Unencrypted key files are read as they are; encrypted stores read the
+// header kept beside the sealed key, and only unseal keys stored without
+// one.
+func (s *Store) Header(id string) (multikeypair.Multikeypair, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.sealer != nil {
+		path = strings.TrimSuffix(path, keyExt) + headerExt
+	}
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && s.sealer != nil {
+		m, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		m.Wipe()
+		return m, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := multikeypair.Multikeypair(buf)
+	defer m.Wipe()
+	if headerID, err := ID(m); err != nil || headerID != id {
+		return nil, ErrInvalidID
+	}
+	return m, nil
+}
+
+// List returns the identifiers of every stored key, sorted.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, keyExt) {
+			continue
+		}
+		id := strings.TrimSuffix(name, keyExt)
+		if validID(id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Delete removes the key stored under id.
+func (s *Store) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Remove(strings.TrimSuffix(path, keyExt) + headerExt)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// The file path for a key identifier. Identifiers are base58, so they
+// can't contain path separators; anything else is rejected.
+func (s *Store) path(id string) (string, error) {
+	if !validID(id) {
+		return "", ErrInvalidID
+	}
+	return filepath.Join(s.dir, id+keyExt), nil
+}
+
+// Check that an identifier is a base58-encoded multihash.
+func validID(id string) bool {
+	b, err := b58.Decode(id)
+	if err != nil || len(b) == 0 {
+		return false
+	}
+	_, err = multikeypair.Multihash(b).Digest()
+	return err == nil
+}
+
+// Write a file atomically: write and sync a temporary file alongside
+// it, rename it into place, and sync the directory.
+func writeAtomic(dir string, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(fileMode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Sync a directory so that renames and removals in it are durable.
+// Not every platform supports this, so failures are ignored there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
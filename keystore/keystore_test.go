@@ -0,0 +1,119 @@
+// go-multikeypair/keystore/keystore_test.go
+
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Generate a fresh encoded ed25519 keypair for testing.
+func generate(t *testing.T) multikeypair.Multikeypair {
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mk
+}
+
+// Keys can be stored, listed, loaded, and deleted.
+func TestStore(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := generate(t), generate(t)
+	idA, err := s.Put(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idB, err := s.Put(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 keys, got %v", ids)
+	}
+
+	got, err := s.Get(idA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(a) {
+		t.Error("loaded key doesn't match")
+	}
+
+	if err := s.Delete(idB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(idB); err != ErrNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := s.Delete(idB); err != ErrNotFound {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Putting the same key again replaces it under the same id.
+	again, err := s.Put(a)
+	if err != nil || again != idA {
+		t.Errorf("unexpected id %s: %v", again, err)
+	}
+}
+
+// Key files are private to the owner.
+func TestPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	dir := filepath.Join(t.TempDir(), "keys")
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Put(generate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, id+keyExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != fileMode {
+		t.Errorf("unexpected file mode: %v", info.Mode())
+	}
+
+	open := t.TempDir()
+	if err := os.Chmod(open, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(open); err != ErrInsecurePermissions {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Identifiers that could escape the directory are rejected.
+func TestInvalidID(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"", "../secret", "foo/bar", "QmNotAMultihash0"} {
+		if _, err := s.Get(id); err != ErrInvalidID {
+			t.Errorf("%q: unexpected error: %v", id, err)
+		}
+	}
+}
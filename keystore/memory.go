@@ -0,0 +1,156 @@
+// go-multikeypair/keystore/memory.go
+//
+// An in-process keystore for servers that unlock their keys once at
+// startup, from a file Store or elsewhere, and then serve them from
+// memory. Keys can be given a time to live, after which they're evicted;
+// evicted and deleted keys have their private key material wiped rather
+// than left for the garbage collector.
+
+package keystore
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Memory holds multikeypairs in memory, under the same identifiers as a
+// file Store. It is safe for concurrent use by multiple goroutines.
+type Memory struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*memoryEntry
+}
+
+// A key held by a Memory keystore, and the timer that evicts it.
+type memoryEntry struct {
+	key   multikeypair.Multikeypair
+	timer *time.Timer
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewMemory returns an empty in-memory keystore. Keys are evicted ttl
+// after they were last Put; a ttl of zero keeps them until they're
+// deleted or the keystore is closed.
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{ttl: ttl, keys: make(map[string]*memoryEntry)}
+}
+
+// Put stores a copy of a Multikeypair, replacing and wiping any existing
+// key with the same public key, and returns its identifier. The caller
+// remains responsible for wiping its own copy.
+func (s *Memory) Put(m multikeypair.Multikeypair) (string, error) {
+	id, err := ID(m)
+	if err != nil {
+		return "", err
+	}
+	e := &memoryEntry{key: slices.Clone(m)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		// Closed keystores accept keys again, like a fresh one.
+		s.keys = make(map[string]*memoryEntry)
+	}
+	s.remove(id)
+	if s.ttl > 0 {
+		e.timer = time.AfterFunc(s.ttl, func() { s.expire(id, e) })
+	}
+	s.keys[id] = e
+	return id, nil
+}
+
+// Get returns a copy of the Multikeypair stored under id, which the
+// caller should wipe once done with it.
+func (s *Memory) Get(id string) (multikeypair.Multikeypair, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(e.key), nil
+}
+
+// Header returns a copy of the Multikeypair stored under id with its
+// private key zeroed.
+func (s *Memory) Header(id string) (multikeypair.Multikeypair, error) {
+	m, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	m.Wipe()
+	return m, nil
+}
+
+// List returns the identifiers of every stored key, sorted.
+func (s *Memory) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Delete removes and wipes the key stored under id.
+func (s *Memory) Delete(id string) error {
+	if !validID(id) {
+		return ErrInvalidID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.remove(id) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close removes and wipes every stored key. It's meant for shutdown.
+func (s *Memory) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.keys {
+		s.remove(id)
+	}
+	s.keys = nil
+	return nil
+}
+
+// Remove and wipe the key stored under id, if any, reporting whether
+// there was one. The caller must hold the lock.
+func (s *Memory) remove(id string) bool {
+	e, ok := s.keys[id]
+	if !ok {
+		return false
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	e.key.Wipe()
+	delete(s.keys, id)
+	return true
+}
+
+// Evict a key whose time to live has passed, unless it has since been
+// replaced.
+func (s *Memory) expire(id string, e *memoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[id] == e {
+		s.remove(id)
+	}
+}
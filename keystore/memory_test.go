@@ -0,0 +1,131 @@
+// go-multikeypair/keystore/memory_test.go
+
+package keystore
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Report whether the private key inside an encoding is all zeros.
+func wiped(t *testing.T, m multikeypair.Multikeypair) bool {
+	kp, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return !slices.ContainsFunc(kp.Private, func(b byte) bool { return b != 0 })
+}
+
+// Keys can be stored, listed, loaded, and deleted, and are copied in
+// and out.
+func TestMemory(t *testing.T) {
+	s := NewMemory(0)
+	a, b := generate(t), generate(t)
+	idA, err := s.Put(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idB, err := s.Put(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := ID(a); idA != want {
+		t.Errorf("unexpected id %s", idA)
+	}
+
+	ids, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{idA, idB}; !slices.Equal(ids, slices.Sorted(slices.Values(want))) {
+		t.Errorf("unexpected ids %v", ids)
+	}
+
+	a.Wipe()
+	got, err := s.Get(idA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, a) || wiped(t, got) {
+		t.Error("stored key shares memory with the caller's")
+	}
+	got.Wipe()
+	if again, _ := s.Get(idA); wiped(t, again) {
+		t.Error("returned key shares memory with the stored one")
+	}
+
+	stored := s.keys[idB].key
+	if err := s.Delete(idB); err != nil {
+		t.Fatal(err)
+	}
+	if !wiped(t, stored) {
+		t.Error("deleted key wasn't wiped")
+	}
+	if _, err := s.Get(idB); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.Delete(idB); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Get("../escape"); err != ErrInvalidID {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+
+	stored = s.keys[idA].key
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !wiped(t, stored) {
+		t.Error("Close didn't wipe keys")
+	}
+	if ids, _ := s.List(); len(ids) != 0 {
+		t.Errorf("keys left after Close: %v", ids)
+	}
+}
+
+// Keys are evicted and wiped once their time to live has passed, and
+// putting a key again restarts its clock.
+func TestMemoryTTL(t *testing.T) {
+	s := NewMemory(200 * time.Millisecond)
+	m := generate(t)
+	id, err := s.Put(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	stored := s.keys[id].key
+	s.mu.Unlock()
+
+	time.Sleep(120 * time.Millisecond)
+	if _, err := s.Put(m); err != nil {
+		t.Fatal(err)
+	}
+	if !wiped(t, stored) {
+		t.Error("replaced key wasn't wiped")
+	}
+	time.Sleep(120 * time.Millisecond)
+	if _, err := s.Get(id); err != nil {
+		t.Fatalf("key evicted early: %v", err)
+	}
+
+	s.mu.Lock()
+	stored = s.keys[id].key
+	s.mu.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := s.Get(id); err == ErrNotFound {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := s.Get(id); err != ErrNotFound {
+		t.Fatalf("key wasn't evicted: %v", err)
+	}
+	if !wiped(t, stored) {
+		t.Error("evicted key wasn't wiped")
+	}
+}
@@ -0,0 +1,113 @@
+// go-multikeypair/keystore/query.go
+//
+// Searching a keyring by identifier, cipher, label, usage or expiry. Keys are
+// described from their headers: the encoding with the private key
+// zeroed, read with multikeypair.Inspect. Keyrings that can load a
+// header without the private key, such as encrypted stores, which keep
+// it unsealed, do so; for the rest the key is loaded and wiped at once.
+// Reading a header isn't a private key access, so it isn't audited.
+
+package keystore
+
+import (
+	"strings"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Filter selects keys by what's known about them. The zero value
+// matches every key.
+type Filter struct {
+	// Identifier, or a prefix of it. Empty matches every key.
+	ID string
+	// Cipher code, if HasCode is set.
+	Code uint64
+	// Only keys of the cipher Code. Unset matches every cipher.
+	HasCode bool
+	// Text the label must contain. Empty matches every key, labelled or
+	// not.
+	Label string
+	// Operations the key must allow. Zero matches every key.
+	Usage multikeypair.Usage
+	// Only keys whose validity window has ended.
+	Expired bool
+	// Only keys whose validity window hasn't ended. Setting neither this
+	// nor Expired matches every key.
+	Unexpired bool
+}
+
+// HeaderReader is implemented by keyrings that can load the header of
+// a stored key without reading its private key.
+type HeaderReader interface {
+	// Header loads the Multikeypair stored under id with its private key
+	// zeroed.
+	Header(id string) (multikeypair.Multikeypair, error)
+}
+
+// KeyInfo describes a stored key.
+type KeyInfo struct {
+	// Identifier the key is stored under: the fingerprint of its public
+	// key.
+	ID string
+	multikeypair.Info
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// List describes the keys in a keyring that match filter, sorted by
+// identifier. Expiry is judged at the time of the call.
+func List(k Keyring, filter Filter) ([]KeyInfo, error) {
+	now := time.Now()
+	ids, err := k.List()
+	if err != nil {
+		return nil, err
+	}
+	var keys []KeyInfo
+	for _, id := range ids {
+		if !strings.HasPrefix(id, filter.ID) {
+			continue
+		}
+		m, err := Header(k, id)
+		if err != nil {
+			return nil, err
+		}
+		info, err := m.Inspect()
+		if err != nil {
+			return nil, err
+		}
+		if filter.matches(info, now) {
+			keys = append(keys, KeyInfo{ID: id, Info: info})
+		}
+	}
+	return keys, nil
+}
+
+// Header loads the Multikeypair stored under id in k with its private
+// key zeroed, without reading the private key if k is a HeaderReader.
+func Header(k Keyring, id string) (multikeypair.Multikeypair, error) {
+	if h, ok := k.(HeaderReader); ok {
+		return h.Header(id)
+	}
+	m, err := k.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	m.Wipe()
+	return m, nil
+}
+
+// Report whether a key matches the filter at a time, apart from its
+// identifier.
+func (f Filter) matches(info multikeypair.Info, at time.Time) bool {
+	expired := info.Expired(at)
+	return (!f.HasCode || info.Code == f.Code) &&
+		strings.Contains(info.Label, f.Label) &&
+		info.Usage.Allows(f.Usage) &&
+		(!f.Expired || expired) &&
+		(!f.Unexpired || !expired)
+}
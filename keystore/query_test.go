@@ -0,0 +1,149 @@
+// go-multikeypair/keystore/query_test.go
+
+package keystore
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Keys are selected by identifier prefix, cipher, label, usage and
+// expiry.
+func TestList(t *testing.T) {
+	k := NewMemory(0)
+	put := func(code uint64, metadata multikeypair.Metadata) string {
+		kp, err := multikeypair.Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp.Metadata = metadata
+		mk, err := kp.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := k.Put(mk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	signing := put(multikeypair.ED_25519, multikeypair.Metadata{Label: "release signing", Usage: multikeypair.USAGE_SIGN})
+	exchange := put(multikeypair.X_25519, multikeypair.Metadata{Label: "exchange", Usage: multikeypair.USAGE_DERIVE})
+	plain := put(multikeypair.ED_25519, multikeypair.Metadata{})
+	expired := put(multikeypair.ED_25519, multikeypair.Metadata{Label: "retired", NotAfter: time.Now().Add(-time.Hour)})
+	identity, err := multikeypair.Encode([]byte("private"), []byte("public"), multikeypair.IDENTITY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := k.Put(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]struct {
+		filter Filter
+		want   []string
+	}{
+		"all":       {Filter{}, []string{signing, exchange, plain, expired, raw}},
+		"id":        {Filter{ID: exchange[:len(exchange)-2]}, []string{exchange}},
+		"cipher":    {Filter{Code: multikeypair.ED_25519, HasCode: true}, []string{signing, plain, expired}},
+		"identity":  {Filter{Code: multikeypair.IDENTITY, HasCode: true}, []string{raw}},
+		"label":     {Filter{Label: "sign"}, []string{signing}},
+		"usage":     {Filter{Usage: multikeypair.USAGE_SIGN}, []string{signing, plain, expired, raw}},
+		"expired":   {Filter{Expired: true}, []string{expired}},
+		"unexpired": {Filter{Unexpired: true}, []string{signing, exchange, plain, raw}},
+		"none":      {Filter{Code: multikeypair.X_25519, HasCode: true, Label: "release"}, nil},
+	} {
+		keys, err := List(k, c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]bool)
+		for _, info := range keys {
+			got[info.ID] = true
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: expected %d keys, got %+v", name, len(c.want), keys)
+		}
+		for _, id := range c.want {
+			if !got[id] {
+				t.Errorf("%s: missing %s", name, id)
+			}
+		}
+	}
+
+	keys, err := List(k, Filter{ID: signing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Label != "release signing" || keys[0].Name != "ed25519" {
+		t.Errorf("unexpected info %+v", keys)
+	}
+}
+
+// Listing reads headers only: it neither reports key reads to an access
+// hook nor unseals an encrypted store.
+func TestListHeaders(t *testing.T) {
+	pass := &countingPassphrase{passphrase: "correct horse"}
+	store, err := OpenEncrypted(filepath.Join(t.TempDir(), "keys"), EncryptionOptions{Passphrase: pass.get})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata.Label = "sealed"
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := store.Put(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Lock()
+	calls := pass.calls
+
+	var accesses []Access
+	audited := WithAccessHook(store, func(ctx context.Context, access Access) {
+		accesses = append(accesses, access)
+	})
+	keys, err := List(audited, Filter{Label: "sealed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != id || keys[0].PrivateLength != len(kp.Private) {
+		t.Errorf("unexpected keys %+v", keys)
+	}
+	if pass.calls != calls || !store.Locked() {
+		t.Error("listing unsealed the store")
+	}
+	if len(accesses) != 0 {
+		t.Errorf("listing reported accesses %+v", accesses)
+	}
+
+	header, err := Header(audited, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := header.PrivateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.ContainsFunc(private, func(b byte) bool { return b != 0 }) {
+		t.Error("header holds the private key")
+	}
+
+	if err := store.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Header(store, id); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// go-multikeypair/locked.go
+//
+// Locked memory for private keys. By default a Keypair's private key is
+// an ordinary heap slice, which the operating system may swap to disk and
+// the garbage collector may copy or leave behind. LockPrivate moves it
+// into a dedicated memory mapping instead:
+//
+//	[guard page][canary ... private key][guard page]
+//
+// The mapping is locked into RAM so it is never swapped, and is bracketed
+// by inaccessible guard pages so that running off either end faults
+// rather than reading or overwriting neighbouring memory. The key is
+// placed flush against the trailing guard page, and the space before it
+// is filled with a random canary that is checked when the key is wiped,
+// to catch writes that underrun it.
+//
+// Private still refers to the key, now inside the mapping. Signing and
+// decryption read it through an accessor that copies it to the heap for
+// the duration of the operation and wipes the copy afterwards, because
+// parts of the standard library (crypto/ed25519) cache keys by their
+// address and can't handle memory outside the Go heap. The standard
+// library also makes its own transient copies, which this can't
+// prevent, and a crypto.Signer obtained from a locked Keypair holds an
+// unlocked copy for as long as it lives.
+//
+// Wipe zeroes and unmaps the memory. Any copy of the Keypair, or of its
+// Private slice, taken while the key was locked must not be used
+// afterwards: reading it faults.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Locked memory errors this module exports.
+var (
+	ErrLockedMemoryUnsupported = errors.New("locked memory isn't supported on this platform")
+	ErrCanaryCorrupted         = errors.New("locked memory canary corrupted")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Locked memory holding a private key.
+type lockedMemory struct {
+	// The whole mapping, guard pages included.
+	mapping []byte
+	// The canary and the key, between the guard pages.
+	inner []byte
+	// The key, at the end of inner.
+	data []byte
+	// Expected contents of the canary.
+	canary []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// LockPrivate moves the private key into locked, guarded memory, wiping
+// the slice it was in. Keypairs without a private key, or whose key is
+// already locked, are left as they are.
+func (k *Keypair) LockPrivate() error {
+	if len(k.Private) == 0 || k.locked != nil {
+		return nil
+	}
+	m, err := allocLocked(len(k.Private))
+	if err != nil {
+		return err
+	}
+	copy(m.data, k.Private)
+	clear(k.Private)
+	k.Private = m.data
+	k.locked = m
+	return nil
+}
+
+// PrivateLocked reports whether the private key is in locked memory.
+func (k Keypair) PrivateLocked() bool {
+	return k.locked != nil
+}
+
+// Read the private key for an operation. Keys in locked memory are
+// copied to the heap; release wipes the copy.
+func (k Keypair) privateKey() (private []byte, release func()) {
+	if k.locked == nil {
+		return k.Private, func() {}
+	}
+	private = bytes.Clone(k.Private)
+	return private, func() { clear(private) }
+}
+
+// Allocate locked memory for size bytes and fill in its canary.
+func allocLocked(size int) (*lockedMemory, error) {
+	m, err := mapLocked(size)
+	if err != nil {
+		return nil, err
+	}
+	canary := m.inner[:len(m.inner)-size]
+	if _, err := rand.Read(canary); err != nil {
+		unmapLocked(m)
+		return nil, err
+	}
+	m.canary = append([]byte(nil), canary...)
+	return m, nil
+}
+
+// Zero and release locked memory. The canary is checked first; if it was
+// overwritten memory is corrupt, and carrying on isn't safe.
+func (m *lockedMemory) destroy() {
+	intact := subtle.ConstantTimeCompare(m.inner[:len(m.canary)], m.canary) == 1
+	clear(m.inner)
+	unmapLocked(m)
+	if !intact {
+		panic(ErrCanaryCorrupted)
+	}
+}
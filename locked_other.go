@@ -0,0 +1,15 @@
+//go:build !unix
+
+// go-multikeypair/locked_other.go
+//
+// Locked memory isn't implemented outside unix.
+
+package multikeypair
+
+// Map locked memory; always unsupported here.
+func mapLocked(size int) (*lockedMemory, error) {
+	return nil, ErrLockedMemoryUnsupported
+}
+
+// Unlock and unmap locked memory; there is none here.
+func unmapLocked(m *lockedMemory) {}
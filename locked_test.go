@@ -0,0 +1,144 @@
+// go-multikeypair/locked_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Generate an Ed25519 keypair with its private key in locked memory,
+// skipping the test where that isn't available.
+func generateLocked(t *testing.T) Keypair {
+	k := generateEd25519(t)
+	if err := k.LockPrivate(); errors.Is(err, ErrLockedMemoryUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// Locked keys move out of their original slice and still sign.
+func TestLockPrivate(t *testing.T) {
+	k := generateEd25519(t)
+	original := k.Private
+	want := bytes.Clone(original)
+	if err := k.LockPrivate(); errors.Is(err, ErrLockedMemoryUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if !k.PrivateLocked() || !bytes.Equal(k.Private, want) {
+		t.Fatal("private key wasn't moved into locked memory")
+	}
+	if !bytes.Equal(original, make([]byte, len(original))) {
+		t.Error("original private key wasn't wiped")
+	}
+	if err := k.LockPrivate(); err != nil {
+		t.Error(err)
+	}
+
+	sig, err := k.Sign([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify([]byte("message"), sig); err != nil {
+		t.Error(err)
+	}
+
+	k.Wipe()
+	if k.PrivateLocked() || k.Private != nil {
+		t.Error("Wipe didn't release locked memory")
+	}
+}
+
+// Writes that underrun the key are caught when it's wiped.
+func TestLockedCanary(t *testing.T) {
+	k := generateLocked(t)
+	inner := k.locked.inner
+	inner[len(inner)-len(k.Private)-1] ^= 0xff
+
+	defer func() {
+		if r := recover(); r != ErrCanaryCorrupted {
+			t.Errorf("expected ErrCanaryCorrupted panic, got %v", r)
+		}
+	}()
+	k.Wipe()
+}
+
+// Every cipher works with its private key locked.
+func TestLockedSchemes(t *testing.T) {
+	for code, s := range schemes {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := k.LockPrivate(); errors.Is(err, ErrLockedMemoryUnsupported) {
+			t.Skip(err)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if s.sign != nil {
+			sig, err := k.Sign([]byte("message"))
+			if err != nil {
+				t.Fatalf("%s: %v", k.Name, err)
+			}
+			if err := k.Verify([]byte("message"), sig); err != nil {
+				t.Errorf("%s: %v", k.Name, err)
+			}
+		}
+		if err := k.Validate(); err != nil {
+			t.Errorf("%s: %v", k.Name, err)
+		}
+		k.Wipe()
+	}
+}
+
+// Decoding over a locked keypair releases its locked memory.
+func TestLockedDecodeInto(t *testing.T) {
+	k := generateLocked(t)
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecodeInto(mk, &k); err != nil {
+		t.Fatal(err)
+	}
+	if k.PrivateLocked() || !bytes.Contains(mk, k.Private) {
+		t.Error("keypair still refers to locked memory")
+	}
+}
+
+// The components of a locked hybrid key are copied out of locked memory,
+// and sign.
+func TestLockedComponents(t *testing.T) {
+	k, err := Generate(ED_25519_ML_DSA_65)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.LockPrivate(); errors.Is(err, ErrLockedMemoryUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Wipe()
+	classical, postQuantum, err := k.Components()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []Keypair{classical, postQuantum} {
+		sig, err := c.Sign([]byte("message"))
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		if err := c.Verify([]byte("message"), sig); err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+		}
+		c.Wipe()
+	}
+	if _, err := k.Sign([]byte("message")); err != nil {
+		t.Errorf("wiping the components wiped the locked key: %v", err)
+	}
+}
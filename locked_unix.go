@@ -0,0 +1,46 @@
+//go:build unix
+
+// go-multikeypair/locked_unix.go
+//
+// Locked memory on unix: an anonymous mapping with mlock and mprotect.
+
+package multikeypair
+
+import (
+	"os"
+
+	unix "golang.org/x/sys/unix"
+)
+
+// Map locked memory with room for size bytes of key after the canary,
+// which gets at least a byte.
+func mapLocked(size int) (*lockedMemory, error) {
+	page := os.Getpagesize()
+	innerSize := (size + 1 + page - 1) / page * page
+	mapping, err := unix.Mmap(-1, 0, innerSize+2*page, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	m := &lockedMemory{mapping: mapping, inner: mapping[page : page+innerSize : page+innerSize]}
+	m.data = m.inner[innerSize-size:]
+
+	if err := unix.Mprotect(mapping[:page], unix.PROT_NONE); err != nil {
+		unix.Munmap(mapping)
+		return nil, err
+	}
+	if err := unix.Mprotect(mapping[page+innerSize:], unix.PROT_NONE); err != nil {
+		unix.Munmap(mapping)
+		return nil, err
+	}
+	if err := unix.Mlock(m.inner); err != nil {
+		unix.Munmap(mapping)
+		return nil, err
+	}
+	return m, nil
+}
+
+// Unlock and unmap locked memory.
+func unmapLocked(m *lockedMemory) {
+	unix.Munlock(m.inner)
+	unix.Munmap(m.mapping)
+}
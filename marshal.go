@@ -0,0 +1,61 @@
+// go-multikeypair/marshal.go
+//
+// Implementations of the encoding package interfaces, so that a
+// Multikeypair slots into gob, YAML, TOML, and anything else built on
+// them. The binary form is the raw encoding and the text form is base58;
+// both include the private key. JSON keeps its own, redacted, marshalers.
+// An empty Multikeypair marshals to empty output and back, so that an
+// unset field survives.
+
+package multikeypair
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (m Multikeypair) MarshalBinary() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte{}, nil
+	}
+	if _, err := decodeKeypair(m); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), m...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The input is
+// copied.
+func (m *Multikeypair) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*m = nil
+		return nil
+	}
+	mk, err := castKeypair(data)
+	if err != nil {
+		return err
+	}
+	*m = append(Multikeypair(nil), mk...)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m Multikeypair) MarshalText() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte{}, nil
+	}
+	if _, err := decodeKeypair(m); err != nil {
+		return nil, err
+	}
+	return []byte(m.B58String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Multikeypair) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*m = nil
+		return nil
+	}
+	mk, err := MultikeypairFromB58(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mk
+	return nil
+}
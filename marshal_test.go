@@ -0,0 +1,105 @@
+// go-multikeypair/marshal_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+// Round trip a Multikeypair through gob, which uses BinaryMarshaler.
+func TestMultikeypairGob(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(mk); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Multikeypair
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, mk) {
+		t.Error("multikeypair mismatch after gob round trip")
+	}
+}
+
+// Round trip a Multikeypair through its text form.
+func TestMultikeypairText(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := mk.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != mk.B58String() {
+		t.Errorf("expected base58 text, got: %s", text)
+	}
+	var decoded Multikeypair
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, mk) {
+		t.Error("multikeypair mismatch after text round trip")
+	}
+}
+
+// Invalid input is rejected by both unmarshalers.
+func TestMultikeypairUnmarshalInvalid(t *testing.T) {
+	var mk Multikeypair
+	if err := mk.UnmarshalBinary([]byte{0x00, 0x00, 0x01, 0xff}); !errors.Is(err, ErrInvalidMultikeypair) {
+		t.Errorf("expected invalid multikeypair, got: %v", err)
+	}
+	if err := mk.UnmarshalText([]byte("0OIl")); !errors.Is(err, ErrInvalidMultikeypair) {
+		t.Errorf("expected invalid multikeypair, got: %v", err)
+	}
+}
+
+// An empty Multikeypair marshals to empty output, which unmarshals back
+// to an empty Multikeypair.
+func TestMultikeypairMarshalEmpty(t *testing.T) {
+	var empty Multikeypair
+	data, err := empty.MarshalBinary()
+	if err != nil || len(data) != 0 {
+		t.Errorf("MarshalBinary = %x, %v", data, err)
+	}
+	text, err := empty.MarshalText()
+	if err != nil || len(text) != 0 {
+		t.Errorf("MarshalText = %q, %v", text, err)
+	}
+
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := mk
+	if err := decoded.UnmarshalBinary(nil); err != nil || len(decoded) != 0 {
+		t.Errorf("UnmarshalBinary = %x, %v", decoded, err)
+	}
+	decoded = mk
+	if err := decoded.UnmarshalText(nil); err != nil || len(decoded) != 0 {
+		t.Errorf("UnmarshalText = %x, %v", decoded, err)
+	}
+
+	// An unset field survives gob.
+	type document struct {
+		Name string
+		Key  Multikeypair
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(document{Name: "unset"}); err != nil {
+		t.Fatal(err)
+	}
+	var d document
+	if err := gob.NewDecoder(&buf).Decode(&d); err != nil || d.Name != "unset" || len(d.Key) != 0 {
+		t.Errorf("unexpected gob round trip %+v, %v", d, err)
+	}
+}
@@ -0,0 +1,152 @@
+// go-multikeypair/metadata.go
+//
+// Optional metadata stored alongside the keys in a multikeypair: a label,
+// creation time, key usage, and application-defined tags. Each item is a
+// separate extension field, so decoders that predate one skip it.
+
+package multikeypair
+
+import (
+	"encoding/binary"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Metadata-specific errors this module exports.
+var (
+	ErrInvalidMetadata = errors.New("invalid multikeypair metadata")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Metadata describes a keypair. The zero value means no metadata, and
+// encodes to nothing.
+type Metadata struct {
+	// Human-readable label, e.g. "release signing".
+	Label string
+	// When the key was created. The zero value means unknown.
+	Created time.Time
+	// Operations the key may be used for.
+	Usage Usage
+	// Start of the key's validity window. The zero value means no start.
+	NotBefore time.Time
+	// End of the key's validity window, inclusive. The zero value means
+	// no expiry.
+	NotAfter time.Time
+	// Application-defined tags. Names are 1-255 bytes.
+	Tags map[string][]byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// IsZero reports whether the Metadata is empty.
+func (m Metadata) IsZero() bool {
+	return m.Label == "" && m.Created.IsZero() && m.Usage == 0 &&
+		m.NotBefore.IsZero() && m.NotAfter.IsZero() && len(m.Tags) == 0
+}
+
+// The extension fields encoding the Metadata, in canonical order: by
+// tag, and tags by name.
+func (m Metadata) fields() ([]extension, error) {
+	fields := make([]extension, 0, 5+len(m.Tags))
+	if m.Label != "" {
+		fields = append(fields, extension{TAG_LABEL, []byte(m.Label)})
+	}
+	if !m.Created.IsZero() {
+		fields = append(fields, extension{TAG_CREATED, encodeTime(m.Created)})
+	}
+	if m.Usage != 0 {
+		fields = append(fields, extension{TAG_USAGE, binary.BigEndian.AppendUint32(nil, uint32(m.Usage))})
+	}
+	if !m.NotBefore.IsZero() {
+		fields = append(fields, extension{TAG_NOT_BEFORE, encodeTime(m.NotBefore)})
+	}
+	if !m.NotAfter.IsZero() {
+		fields = append(fields, extension{TAG_NOT_AFTER, encodeTime(m.NotAfter)})
+	}
+	// The fields so far were added in tag order.
+	if len(m.Tags) == 0 {
+		return fields, nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Tags)) {
+		if len(name) == 0 || len(name) > 0xff {
+			return nil, ErrInvalidMetadata
+		}
+		value := make([]byte, 0, 1+len(name)+len(m.Tags[name]))
+		value = append(value, byte(len(name)))
+		value = append(value, name...)
+		value = append(value, m.Tags[name]...)
+		fields = append(fields, extension{TAG_APP, value})
+	}
+	slices.SortStableFunc(fields, compareExtensions)
+	return fields, nil
+}
+
+// Read Metadata from extension fields. Fields that aren't metadata are
+// skipped; metadata fields that are malformed or repeated are an error.
+func parseMetadata(fields []extension) (Metadata, error) {
+	var m Metadata
+	var seen [256]bool
+	for _, f := range fields {
+		if f.tag != TAG_APP && seen[f.tag] {
+			return Metadata{}, ErrInvalidMetadata
+		}
+		seen[f.tag] = true
+
+		switch f.tag {
+		case TAG_LABEL:
+			m.Label = string(f.value)
+		case TAG_CREATED, TAG_NOT_BEFORE, TAG_NOT_AFTER:
+			t, ok := decodeTime(f.value)
+			if !ok {
+				return Metadata{}, ErrInvalidMetadata
+			}
+			switch f.tag {
+			case TAG_CREATED:
+				m.Created = t
+			case TAG_NOT_BEFORE:
+				m.NotBefore = t
+			case TAG_NOT_AFTER:
+				m.NotAfter = t
+			}
+		case TAG_USAGE:
+			if len(f.value) != 4 {
+				return Metadata{}, ErrInvalidMetadata
+			}
+			m.Usage = Usage(binary.BigEndian.Uint32(f.value))
+		case TAG_APP:
+			if len(f.value) < 1 || int(f.value[0]) == 0 || len(f.value) < 1+int(f.value[0]) {
+				return Metadata{}, ErrInvalidMetadata
+			}
+			name := string(f.value[1 : 1+f.value[0]])
+			if _, ok := m.Tags[name]; ok {
+				return Metadata{}, ErrInvalidMetadata
+			}
+			if m.Tags == nil {
+				m.Tags = make(map[string][]byte)
+			}
+			m.Tags[name] = f.value[1+f.value[0]:]
+		}
+	}
+	return m, nil
+}
+
+// Encode a time as 64-bit unix seconds.
+func encodeTime(t time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(t.Unix()))
+}
+
+// Decode a time written by encodeTime.
+func decodeTime(b []byte) (time.Time, bool) {
+	if len(b) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(binary.BigEndian.Uint64(b)), 0), true
+}
@@ -0,0 +1,114 @@
+// go-multikeypair/metadata_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Metadata survives an encode/decode round trip.
+func TestMetadata(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{
+		Label:   "release signing",
+		Created: time.Unix(1700000000, 0),
+		Usage:   USAGE_SIGN | USAGE_ENCRYPT,
+		Tags: map[string][]byte{
+			"owner": []byte("ops"),
+			"empty": {},
+		},
+	}
+
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeWithOptions(mk, DecodeOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := decoded.Metadata
+	if m.Label != kp.Metadata.Label || !m.Created.Equal(kp.Metadata.Created) || m.Usage != kp.Metadata.Usage {
+		t.Errorf("metadata mismatch: %+v", m)
+	}
+	if len(m.Tags) != 2 || !bytes.Equal(m.Tags["owner"], []byte("ops")) || len(m.Tags["empty"]) != 0 {
+		t.Errorf("tags mismatch: %v", m.Tags)
+	}
+	if !decoded.Equal(kp) {
+		t.Error("keys mismatch")
+	}
+
+	// Re-encoding is deterministic.
+	again, err := decoded.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, mk) {
+		t.Error("re-encoding changed the bytes")
+	}
+}
+
+// Metadata coexists with a checksum and the wide layout.
+func TestMetadataChecksumLarge(t *testing.T) {
+	kp := Keypair{
+		Code:     RSA,
+		Public:   bytes.Repeat([]byte{0x01}, MAX_V1_KEY_LENGTH+1),
+		Metadata: Metadata{Label: "big"},
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk, err = mk.WithChecksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeWithOptions(mk, DecodeOptions{Strict: true, RequireChecksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Metadata.Label != "big" {
+		t.Errorf("unexpected label: %q", decoded.Metadata.Label)
+	}
+}
+
+// Keys without metadata encode exactly as before.
+func TestMetadataEmpty(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Encode(kp.Private, kp.Public, kp.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mk, plain) {
+		t.Error("empty metadata changed the encoding")
+	}
+}
+
+// Repeated or malformed metadata fields are rejected.
+func TestMetadataInvalid(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, fields := range map[string][]extension{
+		"repeated label": {{TAG_LABEL, []byte("a")}, {TAG_LABEL, []byte("b")}},
+		"short usage":    {{TAG_USAGE, []byte{0x01}}},
+		"bad tag":        {{TAG_APP, []byte{0x05, 'a'}}},
+	} {
+		b, err := appendExtensions(mk, fields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decode(b); !errors.Is(err, ErrInvalidMetadata) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
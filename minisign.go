@@ -0,0 +1,402 @@
+// go-multikeypair/minisign.go
+//
+// Conversion between Ed25519 multikeypairs and minisign
+// (jedisct1.github.io/minisign) key files, and signing and verification
+// in minisign's signature format. Keys and signatures are base64 lines
+// following an untrusted comment line:
+//
+//	public key:  "Ed" || key id (64-bit LE) || public key (32 bytes)
+//	secret key:  "Ed" || kdf "Sc" or 0x0000 || "B2" || salt (32 bytes) ||
+//	             scrypt opslimit (64-bit LE) || scrypt memlimit (64-bit LE) ||
+//	             (key id || secret key (64 bytes) || checksum (32 bytes)) ^ scrypt
+//	signature:   "ED" || key id || Ed25519(BLAKE2b-512(message))
+//	             trusted comment: <comment>
+//	             Ed25519(signature || comment)
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	blake2b "golang.org/x/crypto/blake2b"
+	scrypt "golang.org/x/crypto/scrypt"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// minisign-specific errors this module exports.
+var (
+	ErrInvalidMinisignKey       = errors.New("input isn't valid minisign key")
+	ErrInvalidMinisignSignature = errors.New("input isn't valid minisign signature")
+	ErrMinisignPassword         = errors.New("minisign secret key checksum mismatch (wrong password?)")
+	ErrMinisignKeyID            = errors.New("minisign signature was made by a different key")
+)
+
+// Signature algorithms, stored little-endian so they read as ASCII.
+const (
+	MINISIGN_ED25519   = uint16(0x6445) // "Ed", Ed25519 over the message
+	MINISIGN_PREHASHED = uint16(0x4445) // "ED", Ed25519 over BLAKE2b-512(message)
+)
+
+// KDF and checksum algorithms, sizes and line prefixes of the file
+// formats.
+const (
+	minisignScrypt      = uint16(0x6353) // "Sc"
+	minisignBlake2b     = uint16(0x3242) // "B2"
+	minisignSecretSize  = 158
+	minisignKeynumSize  = 104
+	minisignCommentLine = "untrusted comment: "
+	minisignTrustedLine = "trusted comment: "
+)
+
+// scrypt cost parameters for newly encrypted secret keys, in libsodium's
+// terms. These are the "sensitive" limits minisign itself uses.
+var (
+	minisignOpsLimit = uint64(1 << 25)
+	minisignMemLimit = uint64(1 << 30)
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// MinisignKey is an Ed25519 keypair together with its minisign key id.
+type MinisignKey struct {
+	// Key id, shown by minisign as 16 upper-case hex digits.
+	ID uint64
+	// Ed25519 keypair. Keys parsed from public key files are public-only.
+	Key Keypair
+}
+
+// A parsed minisign signature file.
+type minisignSignature struct {
+	algorithm uint16
+	id        uint64
+	signature []byte
+	trusted   string
+	global    []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewMinisignKey wraps an Ed25519 Keypair for use with minisign, giving it
+// a fresh random key id.
+func NewMinisignKey(k Keypair) (MinisignKey, error) {
+	if k.Code != ED_25519 {
+		return MinisignKey{}, ErrUnsupportedCipher
+	}
+	var id [8]byte
+	if _, err := crypto_rand.Read(id[:]); err != nil {
+		return MinisignKey{}, err
+	}
+	return MinisignKey{ID: binary.LittleEndian.Uint64(id[:]), Key: k}, nil
+}
+
+// String returns the key id the way minisign displays it.
+func (m MinisignKey) String() string {
+	return fmt.Sprintf("%016X", m.ID)
+}
+
+// Sign produces a minisign signature file for message, using the
+// prehashed algorithm minisign defaults to. An empty trusted comment is
+// replaced by the current timestamp, as minisign does.
+func (m MinisignKey) Sign(message []byte, trustedComment string) ([]byte, error) {
+	if m.Key.Code != ED_25519 {
+		return nil, ErrUnsupportedCipher
+	}
+	if strings.ContainsAny(trustedComment, "\r\n") {
+		return nil, ErrInvalidMinisignSignature
+	}
+	if trustedComment == "" {
+		trustedComment = "timestamp:" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	digest := blake2b.Sum512(message)
+	signature, err := m.Key.Sign(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	global, err := m.Key.Sign(append(bytes.Clone(signature), trustedComment...))
+	if err != nil {
+		return nil, err
+	}
+	s := minisignSignature{
+		algorithm: MINISIGN_PREHASHED,
+		id:        m.ID,
+		signature: signature,
+		trusted:   trustedComment,
+		global:    global,
+	}
+	return s.encode("signature from minisign secret key"), nil
+}
+
+// Verify checks a minisign signature file over message, accepting both
+// the prehashed and legacy algorithms, and returns its (now
+// authenticated) trusted comment.
+func (m MinisignKey) Verify(message []byte, signature []byte) (string, error) {
+	if m.Key.Code != ED_25519 {
+		return "", ErrUnsupportedCipher
+	}
+	s, err := parseMinisignSignature(signature)
+	if err != nil {
+		return "", err
+	}
+	if s.id != m.ID {
+		return "", ErrMinisignKeyID
+	}
+	signed := message
+	if s.algorithm == MINISIGN_PREHASHED {
+		digest := blake2b.Sum512(message)
+		signed = digest[:]
+	}
+	if err := m.Key.Verify(signed, s.signature); err != nil {
+		return "", err
+	}
+	if err := m.Key.Verify(append(bytes.Clone(s.signature), s.trusted...), s.global); err != nil {
+		return "", err
+	}
+	return s.trusted, nil
+}
+
+//
+// ENCODE
+//
+
+// PublicKeyFile returns the contents of a minisign public key file
+// (minisign.pub).
+func (m MinisignKey) PublicKeyFile() ([]byte, error) {
+	if m.Key.Code != ED_25519 || len(m.Key.Public) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	raw := make([]byte, 0, 42)
+	raw = binary.LittleEndian.AppendUint16(raw, MINISIGN_ED25519)
+	raw = binary.LittleEndian.AppendUint64(raw, m.ID)
+	raw = append(raw, m.Key.Public...)
+	return minisignFile("minisign public key "+m.String(), raw), nil
+}
+
+// SecretKeyFile returns the contents of a minisign secret key file
+// (minisign.key), encrypted with password. An empty password writes an
+// unencrypted key, as "minisign -G -W" does.
+func (m MinisignKey) SecretKeyFile(password []byte) ([]byte, error) {
+	if m.Key.Code != ED_25519 || len(m.Key.Private) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	raw := make([]byte, minisignSecretSize)
+	binary.LittleEndian.PutUint16(raw[0:], MINISIGN_ED25519)
+	binary.LittleEndian.PutUint16(raw[4:], minisignBlake2b)
+	if _, err := crypto_rand.Read(raw[6:38]); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint64(raw[38:], minisignOpsLimit)
+	binary.LittleEndian.PutUint64(raw[46:], minisignMemLimit)
+
+	keynum := raw[54:]
+	binary.LittleEndian.PutUint64(keynum, m.ID)
+	copy(keynum[8:], m.Key.Private)
+	checksum := minisignChecksum(raw)
+	copy(keynum[72:], checksum[:])
+
+	comment := "minisign unencrypted secret key"
+	if len(password) != 0 {
+		binary.LittleEndian.PutUint16(raw[2:], minisignScrypt)
+		if err := minisignXOR(raw, password); err != nil {
+			return nil, err
+		}
+		comment = "minisign encrypted secret key"
+	}
+	return minisignFile(comment, raw), nil
+}
+
+// Encode a signature file.
+func (s minisignSignature) encode(untrustedComment string) []byte {
+	raw := make([]byte, 0, 10+len(s.signature))
+	raw = binary.LittleEndian.AppendUint16(raw, s.algorithm)
+	raw = binary.LittleEndian.AppendUint64(raw, s.id)
+	raw = append(raw, s.signature...)
+
+	out := minisignFile(untrustedComment, raw)
+	out = append(out, minisignTrustedLine...)
+	out = append(out, s.trusted...)
+	out = append(out, '\n')
+	out = append(out, base64.StdEncoding.EncodeToString(s.global)...)
+	return append(out, '\n')
+}
+
+// Format an untrusted comment line and a base64 line.
+func minisignFile(comment string, raw []byte) []byte {
+	out := []byte(minisignCommentLine + comment + "\n")
+	out = append(out, base64.StdEncoding.EncodeToString(raw)...)
+	return append(out, '\n')
+}
+
+// Checksum over the algorithm, key id and secret key of a secret key.
+func minisignChecksum(raw []byte) [32]byte {
+	message := make([]byte, 0, 74)
+	message = append(message, raw[0:2]...)
+	message = append(message, raw[54:126]...)
+	return blake2b.Sum256(message)
+}
+
+// Encrypt or decrypt the key id, secret key and checksum of a secret key
+// in place, using the scrypt parameters it carries.
+func minisignXOR(raw []byte, password []byte) error {
+	ops := binary.LittleEndian.Uint64(raw[38:])
+	mem := binary.LittleEndian.Uint64(raw[46:])
+	if ops > minisignOpsLimit || mem > minisignMemLimit {
+		return ErrInvalidMinisignKey
+	}
+	n, r, p := minisignScryptParams(ops, mem)
+	stream, err := scrypt.Key(password, raw[6:38], n, r, p, minisignKeynumSize)
+	if err != nil {
+		return ErrInvalidMinisignKey
+	}
+	subtle.XORBytes(raw[54:], raw[54:], stream)
+	return nil
+}
+
+// Convert libsodium's opslimit and memlimit into scrypt's N, r and p,
+// following crypto_pwhash_scryptsalsa208sha256's pickparams.
+func minisignScryptParams(ops uint64, mem uint64) (n int, r int, p int) {
+	ops = max(ops, 1<<15)
+	r = 8
+	if ops < mem/32 {
+		return 1 << minisignLogN(ops/(uint64(r)*4)), r, 1
+	}
+	logN := minisignLogN(mem / (uint64(r) * 128))
+	maxRP := min((ops/4)>>logN, 0x3fffffff)
+	return 1 << logN, r, int(maxRP) / r
+}
+
+// The smallest log2(N) with N greater than half of maxN.
+func minisignLogN(maxN uint64) int {
+	logN := 1
+	for logN < 63 && uint64(1)<<logN <= maxN/2 {
+		logN++
+	}
+	return logN
+}
+
+//
+// DECODE
+//
+
+// ParseMinisignPublicKey parses a minisign public key, either a whole
+// public key file or just its base64 line (as given to "minisign -P").
+func ParseMinisignPublicKey(b []byte) (MinisignKey, error) {
+	raw, err := minisignBase64(b)
+	if err != nil {
+		return MinisignKey{}, err
+	}
+	if len(raw) != 42 || binary.LittleEndian.Uint16(raw) != MINISIGN_ED25519 {
+		return MinisignKey{}, ErrInvalidMinisignKey
+	}
+	public := raw[10:]
+	return MinisignKey{
+		ID: binary.LittleEndian.Uint64(raw[2:]),
+		Key: Keypair{
+			Code:         ED_25519,
+			Name:         codeName(ED_25519),
+			Public:       public,
+			PublicLength: len(public),
+		},
+	}, nil
+}
+
+// ParseMinisignSecretKey parses a minisign secret key file, decrypting it
+// with password if it is encrypted.
+func ParseMinisignSecretKey(b []byte, password []byte) (MinisignKey, error) {
+	raw, err := minisignBase64(b)
+	if err != nil {
+		return MinisignKey{}, err
+	}
+	if len(raw) != minisignSecretSize ||
+		binary.LittleEndian.Uint16(raw[0:]) != MINISIGN_ED25519 ||
+		binary.LittleEndian.Uint16(raw[4:]) != minisignBlake2b {
+		return MinisignKey{}, ErrInvalidMinisignKey
+	}
+	switch binary.LittleEndian.Uint16(raw[2:]) {
+	case 0:
+	case minisignScrypt:
+		if err := minisignXOR(raw, password); err != nil {
+			return MinisignKey{}, err
+		}
+	default:
+		return MinisignKey{}, ErrInvalidMinisignKey
+	}
+	checksum := minisignChecksum(raw)
+	if subtle.ConstantTimeCompare(checksum[:], raw[126:]) != 1 {
+		return MinisignKey{}, ErrMinisignPassword
+	}
+
+	private := raw[62:126]
+	public := bytes.Clone(private[32:])
+	return MinisignKey{
+		ID: binary.LittleEndian.Uint64(raw[54:]),
+		Key: Keypair{
+			Code:          ED_25519,
+			Name:          codeName(ED_25519),
+			Private:       private,
+			PrivateLength: len(private),
+			Public:        public,
+			PublicLength:  len(public),
+		},
+	}, nil
+}
+
+// Parse a signature file: untrusted comment, signature, trusted comment,
+// global signature.
+func parseMinisignSignature(b []byte) (minisignSignature, error) {
+	lines := strings.Split(strings.TrimRight(string(b), "\r\n"), "\n")
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], minisignCommentLine) ||
+		!strings.HasPrefix(lines[2], minisignTrustedLine) {
+		return minisignSignature{}, ErrInvalidMinisignSignature
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 10+ed25519.SignatureSize {
+		return minisignSignature{}, ErrInvalidMinisignSignature
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return minisignSignature{}, ErrInvalidMinisignSignature
+	}
+	algorithm := binary.LittleEndian.Uint16(raw)
+	if algorithm != MINISIGN_ED25519 && algorithm != MINISIGN_PREHASHED {
+		return minisignSignature{}, ErrInvalidMinisignSignature
+	}
+	return minisignSignature{
+		algorithm: algorithm,
+		id:        binary.LittleEndian.Uint64(raw[2:]),
+		signature: raw[10:],
+		trusted:   strings.TrimSuffix(strings.TrimPrefix(lines[2], minisignTrustedLine), "\r"),
+		global:    global,
+	}, nil
+}
+
+// Decode the base64 line of a key file, or a bare base64 line.
+func minisignBase64(b []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(b))
+	if strings.HasPrefix(s, minisignCommentLine) {
+		_, line, ok := strings.Cut(s, "\n")
+		if !ok {
+			return nil, ErrInvalidMinisignKey
+		}
+		s = strings.TrimSpace(line)
+	}
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidMinisignKey
+	}
+	return raw, nil
+}
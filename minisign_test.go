@@ -0,0 +1,185 @@
+// go-multikeypair/minisign_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// A public key and signatures over testMinisignMessage made with the
+// Ed25519 key whose seed is 0x42 repeated, as produced by minisign.
+const (
+	testMinisignPublic = "untrusted comment: minisign public key E8C24A57B58AE826\n" +
+		"RWQm6Iq1V0rC6CFS+NGbeR0kRTJC4V8uq2y3z/p7al7TAJeWDgaYgdsS\n"
+	testMinisignPrehashed = "untrusted comment: signature from test key\n" +
+		"RUQm6Iq1V0rC6KWCvklAONBDaW7uMJFyzsj+2bV7ocoViCfA/DI2dKUC/ZcCzMwZ3p4npAQC1boINuavi8IaXjIQDcfuNgHFLQs=\n" +
+		"trusted comment: timestamp:1700000000\n" +
+		"9dRWno5/2sb4y4sA9kpteI0EakhacxS3x3UhJ0sVWlR+SYK85SZQ833KcslMNxaehToTobPonTX/H4R5q5ckBg==\n"
+	testMinisignLegacy = "untrusted comment: signature from test key\n" +
+		"RWQm6Iq1V0rC6ALczyvMUDQ1yq9jc9WZ6oiDlxoHCyRYOgvSSn22M0l4lOfcLJNUqLrnOQ+CttS5DuF3I9pU0+Lyaw5m94Hx5wI=\n" +
+		"trusted comment: timestamp:1700000000\n" +
+		"d/K1W/5sCQL8swywo4QLw6kyl9czI1/1UD5ka6vvbmq+sMnNf1S8asEMGZWA7jNZbRo4zcLhxKtKFS9wEyK0Dw==\n"
+	testMinisignMessage = "hello minisign\n"
+)
+
+// Use cheap scrypt parameters so that encrypted keys are quick to test.
+func cheapMinisign(t *testing.T) {
+	ops, mem := minisignOpsLimit, minisignMemLimit
+	minisignOpsLimit, minisignMemLimit = 1<<15, 1<<24
+	t.Cleanup(func() { minisignOpsLimit, minisignMemLimit = ops, mem })
+}
+
+// Construct the MinisignKey matching the test vectors.
+func testMinisignKey() MinisignKey {
+	private := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x42}, 32))
+	public := private.Public().(ed25519.PublicKey)
+	return MinisignKey{
+		ID: 0xE8C24A57B58AE826,
+		Key: Keypair{
+			Code:          ED_25519,
+			Name:          Codes[ED_25519],
+			Private:       private,
+			PrivateLength: len(private),
+			Public:        public,
+			PublicLength:  len(public),
+		},
+	}
+}
+
+// Public keys written by minisign parse, and are written back identically.
+func TestMinisignPublicKey(t *testing.T) {
+	key := testMinisignKey()
+
+	parsed, err := ParseMinisignPublicKey([]byte(testMinisignPublic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.ID != key.ID || !bytes.Equal(parsed.Key.Public, key.Key.Public) || len(parsed.Key.Private) != 0 {
+		t.Errorf("unexpected key %+v", parsed)
+	}
+
+	// The bare base64 line is accepted too.
+	line := strings.Split(testMinisignPublic, "\n")[1]
+	if bare, err := ParseMinisignPublicKey([]byte(line)); err != nil || bare.ID != key.ID {
+		t.Errorf("bare public key: %v", err)
+	}
+
+	file, err := key.PublicKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file) != testMinisignPublic {
+		t.Errorf("expected %q, got %q", testMinisignPublic, file)
+	}
+}
+
+// Signatures made by minisign verify, with either algorithm.
+func TestMinisignVerify(t *testing.T) {
+	key, err := ParseMinisignPublicKey([]byte(testMinisignPublic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sig := range []string{testMinisignPrehashed, testMinisignLegacy} {
+		comment, err := key.Verify([]byte(testMinisignMessage), []byte(sig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if comment != "timestamp:1700000000" {
+			t.Errorf("unexpected trusted comment %q", comment)
+		}
+	}
+
+	if _, err := key.Verify([]byte("goodbye minisign\n"), []byte(testMinisignPrehashed)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for wrong message, got %v", err)
+	}
+	tampered := strings.Replace(testMinisignPrehashed, "1700000000", "1800000000", 1)
+	if _, err := key.Verify([]byte(testMinisignMessage), []byte(tampered)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered comment, got %v", err)
+	}
+	key.ID++
+	if _, err := key.Verify([]byte(testMinisignMessage), []byte(testMinisignPrehashed)); !errors.Is(err, ErrMinisignKeyID) {
+		t.Errorf("expected ErrMinisignKeyID, got %v", err)
+	}
+}
+
+// Signatures we make match minisign's, since Ed25519 is deterministic.
+func TestMinisignSign(t *testing.T) {
+	key := testMinisignKey()
+	sig, err := key.Sign([]byte(testMinisignMessage), "timestamp:1700000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.SplitN(testMinisignPrehashed, "\n", 2)[1]
+	if got := strings.SplitN(string(sig), "\n", 2)[1]; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := key.Sign(nil, "two\nlines"); !errors.Is(err, ErrInvalidMinisignSignature) {
+		t.Errorf("expected ErrInvalidMinisignSignature, got %v", err)
+	}
+}
+
+// Secret keys round trip, encrypted or not, and the wrong password is
+// detected.
+func TestMinisignSecretKey(t *testing.T) {
+	cheapMinisign(t)
+
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewMinisignKey(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range [][]byte{nil, []byte("correct horse")} {
+		file, err := key.SecretKeyFile(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseMinisignSecretKey(file, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.ID != key.ID || !parsed.Key.Equal(kp) {
+			t.Errorf("secret key didn't round trip")
+		}
+	}
+
+	file, err := key.SecretKeyFile([]byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMinisignSecretKey(file, []byte("battery staple")); !errors.Is(err, ErrMinisignPassword) {
+		t.Errorf("expected ErrMinisignPassword, got %v", err)
+	}
+}
+
+// libsodium's cost limits map to the scrypt parameters minisign uses.
+func TestMinisignScryptParams(t *testing.T) {
+	if n, r, p := minisignScryptParams(1<<25, 1<<30); n != 1<<20 || r != 8 || p != 1 {
+		t.Errorf("unexpected sensitive parameters N=%d r=%d p=%d", n, r, p)
+	}
+	if n, r, p := minisignScryptParams(1<<15, 1<<24); n != 1<<10 || r != 8 || p != 1 {
+		t.Errorf("unexpected cheap parameters N=%d r=%d p=%d", n, r, p)
+	}
+}
+
+// Only Ed25519 keys can be used with minisign.
+func TestMinisignUnsupported(t *testing.T) {
+	kp, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMinisignKey(kp); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	if _, err := ParseMinisignPublicKey([]byte("not base64!")); !errors.Is(err, ErrInvalidMinisignKey) {
+		t.Errorf("expected ErrInvalidMinisignKey, got %v", err)
+	}
+}
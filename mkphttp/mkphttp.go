@@ -0,0 +1,360 @@
+// go-multikeypair/mkphttp/mkphttp.go
+//
+// An HTTP handler that serves a keyring as a small internal signing
+// service. Keys are addressed by their keystore identifier; private keys
+// never leave the service. Bodies are JSON, with binary values in
+// standard base64:
+//
+//	GET  /keys[?cipher=<name>&label=<text>&expired=<bool>]  {"keys": [<key info>, ...]}
+//	GET  /keys/{id}                                          <key info>
+//	POST /sign    {"id", "message"}               {"signature"}
+//	POST /verify  {"id", "message", "signature"}  {"valid"}
+//
+// where key info is {"id", "cipher", "label", "created", "notBefore",
+// "notAfter", "expired", "public"}, the public key being the public
+// half's JSON form (see Keypair.MarshalJSON). Expired keys don't sign.
+// Failures are reported as {"error": "..."} with a matching status.
+//
+// The handler does no authentication of its own. An Authorize hook sees
+// every request before it's served, along with the operation and key,
+// and may refuse it; pair it with a middleware that establishes who the
+// caller is, such as httpsig.Verifier.Middleware. Without a hook, keys
+// can be read but signing is refused with 403 Forbidden; AllowAll opts
+// out of that.
+
+package mkphttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// HTTP service errors this package exports.
+var (
+	ErrUnauthenticated = errors.New("request isn't authenticated")
+	ErrInvalidRequest  = errors.New("invalid request body")
+	ErrNoAuthorizer    = errors.New("signing needs an authorize hook")
+)
+
+// Operations passed to an Authorize hook.
+const (
+	OP_LIST   = "list"
+	OP_GET    = "get"
+	OP_SIGN   = "sign"
+	OP_VERIFY = "verify"
+)
+
+// Largest request body accepted, in bytes.
+const MAX_BODY_SIZE = 1 << 20
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Options configures a Handler.
+type Options struct {
+	// Authorize is called before every request with the operation, one
+	// of the OP_ constants, and the identifier of the key it concerns,
+	// empty for OP_LIST. A non-nil error refuses the request: with 401
+	// Unauthorized if it wraps ErrUnauthenticated, and 403 Forbidden
+	// otherwise. Nil refuses OP_SIGN and permits everything else; use
+	// AllowAll to permit every request.
+	Authorize func(r *http.Request, op string, id string) error
+}
+
+// Description of a stored key.
+type keyJSON struct {
+	ID        string               `json:"id"`
+	Cipher    string               `json:"cipher"`
+	Label     string               `json:"label,omitempty"`
+	Created   *time.Time           `json:"created,omitempty"`
+	NotBefore *time.Time           `json:"notBefore,omitempty"`
+	NotAfter  *time.Time           `json:"notAfter,omitempty"`
+	Expired   bool                 `json:"expired"`
+	Public    multikeypair.Keypair `json:"public"`
+}
+
+type signRequest struct {
+	ID      string `json:"id"`
+	Message []byte `json:"message"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type verifyRequest struct {
+	ID        string `json:"id"`
+	Message   []byte `json:"message"`
+	Signature []byte `json:"signature"`
+}
+
+type verifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// The handler's state.
+type service struct {
+	keys keystore.Keyring
+	opts Options
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Handler serves a keyring without an Authorize hook, so that its keys
+// can be listed and verify signatures but don't sign; see
+// HandlerWithOptions.
+func Handler(keys keystore.Keyring) http.Handler {
+	return HandlerWithOptions(keys, Options{})
+}
+
+// AllowAll is an Authorize hook that permits every request, for tests
+// and for handlers only reachable by trusted callers.
+func AllowAll(r *http.Request, op string, id string) error {
+	return nil
+}
+
+// HandlerWithOptions serves a keyring, as described above.
+func HandlerWithOptions(keys keystore.Keyring, opts Options) http.Handler {
+	s := &service{keys: keys, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys", s.list)
+	mux.HandleFunc("GET /keys/{id}", s.get)
+	mux.HandleFunc("POST /sign", s.sign)
+	mux.HandleFunc("POST /verify", s.verify)
+	return mux
+}
+
+// List the stored keys, optionally filtered by cipher, label and expiry.
+func (s *service) list(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, OP_LIST, "") {
+		return
+	}
+	var filter keystore.Filter
+	if name := r.URL.Query().Get("cipher"); name != "" {
+		code, err := multikeypair.CipherCode(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		filter.Code, filter.HasCode = code, true
+	}
+	filter.Label = r.URL.Query().Get("label")
+	if expired := r.URL.Query().Get("expired"); expired != "" {
+		b, err := strconv.ParseBool(expired)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		filter.Expired, filter.Unexpired = b, !b
+	}
+
+	infos, err := keystore.List(s.keys, filter)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	keys := make([]keyJSON, 0, len(infos))
+	for _, info := range infos {
+		key, err := s.describe(info.ID)
+		if err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		keys = append(keys, key)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Keys []keyJSON `json:"keys"`
+	}{keys})
+}
+
+// Describe one stored key.
+func (s *service) get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.authorize(w, r, OP_GET, id) {
+		return
+	}
+	key, err := s.describe(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// Sign a message with a stored key.
+func (s *service) sign(w http.ResponseWriter, r *http.Request) {
+	var req signRequest
+	if !readJSON(w, r, &req) || !s.authorize(w, r, OP_SIGN, req.ID) {
+		return
+	}
+	signature, err := signContext(r.Context(), s.keys, req.ID, req.Message)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, signResponse{signature})
+}
+
+// Verify a signature with a stored key. Signatures that don't verify
+// aren't an error, just invalid.
+func (s *service) verify(w http.ResponseWriter, r *http.Request) {
+	var req verifyRequest
+	if !readJSON(w, r, &req) || !s.authorize(w, r, OP_VERIFY, req.ID) {
+		return
+	}
+	public, err := s.public(req.ID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	err = public.Verify(req.Message, req.Signature)
+	writeJSON(w, http.StatusOK, verifyResponse{err == nil})
+}
+
+// Run the Authorize hook, or refuse to sign without one, writing the
+// refusal if there is one.
+func (s *service) authorize(w http.ResponseWriter, r *http.Request, op string, id string) bool {
+	var err error
+	switch {
+	case s.opts.Authorize != nil:
+		err = s.opts.Authorize(r, op, id)
+	case op == OP_SIGN:
+		err = ErrNoAuthorizer
+	}
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrUnauthenticated):
+		writeError(w, http.StatusUnauthorized, err)
+	default:
+		writeError(w, http.StatusForbidden, err)
+	}
+	return false
+}
+
+// Describe a stored key from its header.
+func (s *service) describe(id string) (keyJSON, error) {
+	m, err := keystore.Header(s.keys, id)
+	if err != nil {
+		return keyJSON{}, err
+	}
+	info, err := m.Inspect()
+	if err != nil {
+		return keyJSON{}, err
+	}
+	public, err := publicHalf(m)
+	if err != nil {
+		return keyJSON{}, err
+	}
+	key := keyJSON{
+		ID:        id,
+		Cipher:    info.Name,
+		Label:     info.Label,
+		Created:   optionalTime(info.Created),
+		NotBefore: optionalTime(info.NotBefore),
+		NotAfter:  optionalTime(info.NotAfter),
+		Expired:   info.Expired(time.Now()),
+		Public:    public,
+	}
+	return key, nil
+}
+
+// A time for JSON, omitted if zero.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Load the public half of a stored key.
+func (s *service) public(id string) (multikeypair.Keypair, error) {
+	m, err := s.keys.Get(id)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	defer m.Wipe()
+	return publicHalf(m)
+}
+
+// Copy out the public half of an encoded key, with its metadata, which
+// Verify consults.
+func publicHalf(m multikeypair.Multikeypair) (multikeypair.Keypair, error) {
+	kp, err := m.Decode()
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	public := bytes.Clone(kp.Public)
+	return multikeypair.Keypair{
+		Code:         kp.Code,
+		Name:         kp.Name,
+		Public:       public,
+		PublicLength: len(public),
+		Metadata:     kp.Metadata,
+	}, nil
+}
+
+// Sign with a stored key, passing the request context on to keyrings
+// that take one, such as an audited keyring's access hook.
+func signContext(ctx context.Context, keys keystore.Keyring, id string, message []byte) ([]byte, error) {
+	if k, ok := keys.(interface {
+		SignContext(context.Context, string, []byte) ([]byte, error)
+	}); ok {
+		return k.SignContext(ctx, id, message)
+	}
+	return keys.Sign(id, message)
+}
+
+// Decode a JSON request body, writing the error if it's malformed.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_BODY_SIZE))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, ErrInvalidRequest)
+		return false
+	}
+	return true
+}
+
+// Write a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Write an error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{err.Error()})
+}
+
+// The status for a keyring or signing error.
+func errorStatus(err error) int {
+	var de *multikeypair.DecodeError
+	switch {
+	case errors.Is(err, keystore.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, keystore.ErrInvalidID), errors.As(err, &de):
+		return http.StatusBadRequest
+	case errors.Is(err, multikeypair.ErrUsageNotPermitted), errors.Is(err, multikeypair.ErrPolicyViolation),
+		errors.Is(err, multikeypair.ErrKeyExpired), errors.Is(err, multikeypair.ErrKeyNotYetValid):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
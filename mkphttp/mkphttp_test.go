@@ -0,0 +1,214 @@
+// go-multikeypair/mkphttp/mkphttp_test.go
+
+package mkphttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+)
+
+// Store a labelled Ed25519 key in a fresh keyring.
+func keyring(t *testing.T) (*keystore.Memory, string) {
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata.Label = "signing"
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := keystore.NewMemory(0)
+	id, err := keys.Put(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys, id
+}
+
+// Make a request and decode its JSON response.
+func call(t *testing.T, h http.Handler, method string, path string, body any, out any) int {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, &buf))
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// Keys are listed and described, and sign messages that verify.
+func TestHandler(t *testing.T) {
+	keys, id := keyring(t)
+	h := HandlerWithOptions(keys, Options{Authorize: AllowAll})
+
+	var list struct {
+		Keys []keyJSON `json:"keys"`
+	}
+	if code := call(t, h, "GET", "/keys?cipher=ed25519", nil, &list); code != http.StatusOK {
+		t.Fatalf("list: status %d", code)
+	}
+	if len(list.Keys) != 1 || list.Keys[0].ID != id || list.Keys[0].Label != "signing" {
+		t.Errorf("unexpected keys %+v", list.Keys)
+	}
+	if call(t, h, "GET", "/keys?cipher=p256", nil, &list); len(list.Keys) != 0 {
+		t.Errorf("filter ignored: %+v", list.Keys)
+	}
+	if call(t, h, "GET", "/keys?cipher=identity", nil, &list); len(list.Keys) != 0 {
+		t.Errorf("identity filter ignored: %+v", list.Keys)
+	}
+
+	var key keyJSON
+	if code := call(t, h, "GET", "/keys/"+id, nil, &key); code != http.StatusOK {
+		t.Fatalf("get: status %d", code)
+	}
+	if key.Cipher != "ed25519" || len(key.Public.Public) == 0 || len(key.Public.Private) != 0 {
+		t.Errorf("unexpected key %+v", key)
+	}
+
+	var signed signResponse
+	if code := call(t, h, "POST", "/sign", signRequest{id, []byte("message")}, &signed); code != http.StatusOK {
+		t.Fatalf("sign: status %d", code)
+	}
+	if err := key.Public.Verify([]byte("message"), signed.Signature); err != nil {
+		t.Error(err)
+	}
+
+	var verified verifyResponse
+	call(t, h, "POST", "/verify", verifyRequest{id, []byte("message"), signed.Signature}, &verified)
+	if !verified.Valid {
+		t.Error("signature didn't verify")
+	}
+	call(t, h, "POST", "/verify", verifyRequest{id, []byte("other"), signed.Signature}, &verified)
+	if verified.Valid {
+		t.Error("signature verified for another message")
+	}
+}
+
+// Failures and refusals get matching statuses.
+func TestHandlerErrors(t *testing.T) {
+	keys, id := keyring(t)
+	h := HandlerWithOptions(keys, Options{
+		Authorize: func(r *http.Request, op string, _ string) error {
+			switch {
+			case r.Header.Get("Authorization") == "":
+				return ErrUnauthenticated
+			case op == OP_SIGN && r.Header.Get("Authorization") != "signer":
+				return errors.New("caller may not sign")
+			}
+			return nil
+		},
+	})
+
+	request := func(method string, path string, body string, auth string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	sign := `{"id": "` + id + `", "message": "bWVzc2FnZQ=="}`
+	for _, c := range []struct {
+		name   string
+		status int
+	}{
+		{"unauthenticated", request("POST", "/sign", sign, "")},
+		{"forbidden", request("POST", "/sign", sign, "reader")},
+		{"permitted", request("POST", "/sign", sign, "signer")},
+		{"unknown key", request("GET", "/keys/QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG", "", "reader")},
+		{"invalid id", request("GET", "/keys/0OIl", "", "reader")},
+		{"bad body", request("POST", "/verify", "{", "reader")},
+		{"unknown cipher", request("GET", "/keys?cipher=nope", "", "reader")},
+	} {
+		want := map[string]int{
+			"unauthenticated": http.StatusUnauthorized,
+			"forbidden":       http.StatusForbidden,
+			"permitted":       http.StatusOK,
+			"unknown key":     http.StatusNotFound,
+			"invalid id":      http.StatusBadRequest,
+			"bad body":        http.StatusBadRequest,
+			"unknown cipher":  http.StatusBadRequest,
+		}[c.name]
+		if c.status != want {
+			t.Errorf("%s: expected status %d, got %d", c.name, want, c.status)
+		}
+	}
+}
+
+// Validity windows are described, expired keys can be listed on their
+// own, and they don't sign.
+func TestHandlerExpired(t *testing.T) {
+	keys, current := keyring(t)
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter := time.Now().Add(-time.Hour).Truncate(time.Second)
+	kp.Metadata.NotAfter = notAfter
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := keys.Put(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := HandlerWithOptions(keys, Options{Authorize: AllowAll})
+
+	var key keyJSON
+	if code := call(t, h, "GET", "/keys/"+expired, nil, &key); code != http.StatusOK {
+		t.Fatalf("get: status %d", code)
+	}
+	if !key.Expired || key.NotAfter == nil || !key.NotAfter.Equal(notAfter) || key.NotBefore != nil {
+		t.Errorf("unexpected key %+v", key)
+	}
+
+	for query, want := range map[string]string{"true": expired, "false": current} {
+		var list struct {
+			Keys []keyJSON `json:"keys"`
+		}
+		call(t, h, "GET", "/keys?expired="+query, nil, &list)
+		if len(list.Keys) != 1 || list.Keys[0].ID != want {
+			t.Errorf("expired=%s: unexpected keys %+v", query, list.Keys)
+		}
+	}
+	if code := call(t, h, "GET", "/keys?expired=maybe", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("bad expired filter: status %d", code)
+	}
+	if code := call(t, h, "POST", "/sign", signRequest{expired, []byte("message")}, nil); code != http.StatusForbidden {
+		t.Errorf("sign with expired key: status %d", code)
+	}
+}
+
+// Without an Authorize hook, keys are described but don't sign.
+func TestHandlerNoAuthorizer(t *testing.T) {
+	keys, id := keyring(t)
+	h := Handler(keys)
+	if code := call(t, h, "GET", "/keys/"+id, nil, nil); code != http.StatusOK {
+		t.Errorf("get: status %d", code)
+	}
+	var refused errorResponse
+	if code := call(t, h, "POST", "/sign", signRequest{id, []byte("message")}, &refused); code != http.StatusForbidden {
+		t.Errorf("sign: status %d", code)
+	}
+	if refused.Error != ErrNoAuthorizer.Error() {
+		t.Errorf("unexpected error %q", refused.Error)
+	}
+}
@@ -0,0 +1,137 @@
+// go-multikeypair/mobile/mobile.go
+//
+// A flattened API for iOS and Android apps, bound with gomobile:
+//
+//	gomobile bind -target=ios,android github.com/proofzero/go-multikeypair/mobile
+//
+// gomobile only carries strings, byte slices, signed integers, booleans,
+// errors and pointers to exported structs across the language boundary,
+// so ciphers are named ("ed25519", "secp256k1", ...) rather than given
+// by code, and keypairs are opaque handles. Encoded multikeypairs are
+// plain bytes or base58 strings, and interchange with every other
+// implementation.
+
+package mobile
+
+import (
+	"bytes"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Keypair is a handle to a decoded keypair.
+type Keypair struct {
+	kp multikeypair.Keypair
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Generate creates a random keypair for a cipher, given by name.
+func Generate(cipher string) (*Keypair, error) {
+	code, err := multikeypair.CipherCode(cipher)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := multikeypair.Generate(code)
+	if err != nil {
+		return nil, err
+	}
+	return &Keypair{kp}, nil
+}
+
+// Encode packs raw key material for a cipher, given by name, into a
+// multikeypair. The private key may be empty.
+func Encode(private []byte, public []byte, cipher string) ([]byte, error) {
+	code, err := multikeypair.CipherCode(cipher)
+	if err != nil {
+		return nil, err
+	}
+	return multikeypair.Encode(private, public, code)
+}
+
+// Decode unpacks an encoded multikeypair.
+func Decode(data []byte) (*Keypair, error) {
+	kp, err := multikeypair.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Keypair{kp}, nil
+}
+
+// DecodeB58 unpacks a base58-encoded multikeypair.
+func DecodeB58(s string) (*Keypair, error) {
+	m, err := multikeypair.MultikeypairFromB58(s)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(m)
+}
+
+// Cipher returns the name of the keypair's cipher, e.g. "ed25519".
+func (k *Keypair) Cipher() string {
+	return k.kp.Name
+}
+
+// Label returns the label from the keypair's metadata, if any.
+func (k *Keypair) Label() string {
+	return k.kp.Metadata.Label
+}
+
+// PublicKey returns a copy of the raw public key.
+func (k *Keypair) PublicKey() []byte {
+	return bytes.Clone(k.kp.Public)
+}
+
+// HasPrivateKey reports whether the keypair includes its private key.
+func (k *Keypair) HasPrivateKey() bool {
+	return len(k.kp.Private) != 0
+}
+
+// PublicOnly returns a handle to the public half of the keypair,
+// metadata included, which is safe to share.
+func (k *Keypair) PublicOnly() *Keypair {
+	public := bytes.Clone(k.kp.Public)
+	return &Keypair{multikeypair.Keypair{
+		Code:         k.kp.Code,
+		Name:         k.kp.Name,
+		Public:       public,
+		PublicLength: len(public),
+		Metadata:     k.kp.Metadata,
+	}}
+}
+
+// Encode packs the keypair into a multikeypair.
+func (k *Keypair) Encode() ([]byte, error) {
+	return k.kp.Encode()
+}
+
+// EncodeB58 packs the keypair into a base58-encoded multikeypair.
+func (k *Keypair) EncodeB58() (string, error) {
+	m, err := k.kp.Encode()
+	if err != nil {
+		return "", err
+	}
+	return m.B58String(), nil
+}
+
+// Sign signs a message with the keypair's private key.
+func (k *Keypair) Sign(message []byte) ([]byte, error) {
+	return k.kp.Sign(message)
+}
+
+// Verify checks a signature over a message with the keypair's public
+// key, returning an error if it doesn't verify.
+func (k *Keypair) Verify(message []byte, signature []byte) error {
+	return k.kp.Verify(message, signature)
+}
+
+// Wipe zeroes the keypair's private key. The handle is unusable for
+// signing afterwards; apps should wipe keys once they're done with them
+// rather than wait for the garbage collector.
+func (k *Keypair) Wipe() {
+	k.kp.Wipe()
+}
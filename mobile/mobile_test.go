@@ -0,0 +1,89 @@
+// go-multikeypair/mobile/mobile_test.go
+
+package mobile
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Keys generated through the mobile API round trip through both
+// encodings and sign messages that verify.
+func TestKeypair(t *testing.T) {
+	for _, cipher := range []string{"ed25519", "secp256k1", "p256"} {
+		k, err := Generate(cipher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Cipher() != cipher || !k.HasPrivateKey() {
+			t.Errorf("%s: unexpected keypair %s", cipher, k.Cipher())
+		}
+
+		data, err := k.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := k.EncodeB58()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromB58, err := DecodeB58(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded.PublicKey(), k.PublicKey()) || !bytes.Equal(fromB58.PublicKey(), k.PublicKey()) {
+			t.Errorf("%s: public key changed in round trip", cipher)
+		}
+
+		message := []byte("hello")
+		signature, err := decoded.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public := k.PublicOnly()
+		if public.HasPrivateKey() {
+			t.Errorf("%s: public half has private key", cipher)
+		}
+		if err := public.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", cipher, err)
+		}
+		if err := public.Verify([]byte("other"), signature); err == nil {
+			t.Errorf("%s: signature verified for another message", cipher)
+		}
+
+		k.Wipe()
+		if _, err := k.Sign(message); err == nil {
+			t.Errorf("%s: wiped key signed", cipher)
+		}
+	}
+}
+
+// Raw key material is encoded under a named cipher, and unknown names
+// are refused.
+func TestEncode(t *testing.T) {
+	k, err := Generate("ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Encode(nil, k.PublicKey(), "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.HasPrivateKey() || !bytes.Equal(public.PublicKey(), k.PublicKey()) {
+		t.Error("unexpected decoded public key")
+	}
+	if _, err := Encode(nil, k.PublicKey(), "nope"); err == nil {
+		t.Error("expected unknown cipher to fail")
+	}
+	if _, err := Generate("nope"); err == nil {
+		t.Error("expected unknown cipher to fail")
+	}
+}
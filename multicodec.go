@@ -0,0 +1,103 @@
+// go-multikeypair/multicodec.go
+//
+// Cipher codes as entries of the multiformats multicodec table, for
+// projects that already identify keys with go-multicodec's enums. The
+// table has separate codes for public and private keys:
+//
+//	cipher      public           private
+//	ed25519     ed25519-pub      ed25519-priv
+//	x25519      x25519-pub       x25519-priv
+//	secp256k1   secp256k1-pub    secp256k1-priv
+//	p256        p256-pub         p256-priv
+//	rsa         rsa-pub          rsa-priv
+//	ml-kem-768  mlkem-768-pub    mlkem-768-priv
+//
+// Ciphers without multicodec entries (bip32, dsa, ml-dsa-65 and the
+// hybrid) have no mapping. Only the codes are mapped: key material stays
+// in the forms this module uses, e.g. an uncompressed P-256 public key.
+
+package multikeypair
+
+import (
+	"errors"
+
+	"github.com/multiformats/go-multicodec"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Multicodec-specific errors this module exports.
+var (
+	ErrUnknownMulticodec  = errors.New("multicodec doesn't identify a supported cipher")
+	ErrMulticodecMismatch = errors.New("multikeypair cipher doesn't match multicodec")
+)
+
+// Multicodec codes of each cipher's public and private keys.
+var multicodecs = map[uint64]struct{ public, private multicodec.Code }{
+	ED_25519:   {multicodec.Ed25519Pub, multicodec.Ed25519Priv},
+	X_25519:    {multicodec.X25519Pub, multicodec.X25519Priv},
+	SECP_256K1: {multicodec.Secp256k1Pub, multicodec.Secp256k1Priv},
+	P_256:      {multicodec.P256Pub, multicodec.P256Priv},
+	RSA:        {multicodec.RsaPub, multicodec.RsaPriv},
+	ML_KEM_768: {multicodec.Mlkem768Pub, multicodec.Mlkem768Priv},
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// PublicMulticodec returns the multicodec of a cipher's public keys.
+func PublicMulticodec(code uint64) (multicodec.Code, error) {
+	mc, ok := multicodecs[code]
+	if !ok {
+		return 0, ErrUnknownCode
+	}
+	return mc.public, nil
+}
+
+// PrivateMulticodec returns the multicodec of a cipher's private keys.
+func PrivateMulticodec(code uint64) (multicodec.Code, error) {
+	mc, ok := multicodecs[code]
+	if !ok {
+		return 0, ErrUnknownCode
+	}
+	return mc.private, nil
+}
+
+// CodeFromMulticodec returns the cipher code identified by the
+// multicodec of either its public or its private keys.
+func CodeFromMulticodec(mc multicodec.Code) (uint64, error) {
+	for code, m := range multicodecs {
+		if mc == m.public || mc == m.private {
+			return code, nil
+		}
+	}
+	return 0, ErrUnknownMulticodec
+}
+
+// EncodeMulticodec encodes a keypair into a Multikeypair, specifying the
+// keypair type using a multicodec instead of a cipher code.
+func EncodeMulticodec(private []byte, public []byte, mc multicodec.Code) (Multikeypair, error) {
+	code, err := CodeFromMulticodec(mc)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	return Encode(private, public, code)
+}
+
+// DecodeMulticodec decodes a Multikeypair, checking that its cipher is
+// the one a multicodec identifies.
+func DecodeMulticodec(m Multikeypair, mc multicodec.Code) (Keypair, error) {
+	code, err := CodeFromMulticodec(mc)
+	if err != nil {
+		return Keypair{}, err
+	}
+	k, err := m.Decode()
+	if err != nil {
+		return Keypair{}, err
+	}
+	if k.Code != code {
+		return Keypair{}, ErrMulticodecMismatch
+	}
+	return k, nil
+}
@@ -0,0 +1,63 @@
+// go-multikeypair/multicodec_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/multiformats/go-multicodec"
+)
+
+// Both multicodecs of each mapped cipher lead back to its code.
+func TestMulticodecRoundTrip(t *testing.T) {
+	for code := range multicodecs {
+		public, err := PublicMulticodec(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private, err := PrivateMulticodec(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mc := range []multicodec.Code{public, private} {
+			if got, err := CodeFromMulticodec(mc); err != nil || got != code {
+				t.Errorf("%s: got %#x, %v", mc, got, err)
+			}
+		}
+	}
+	if mc, _ := PublicMulticodec(ED_25519); mc.String() != "ed25519-pub" {
+		t.Errorf("unexpected ed25519 multicodec %s", mc)
+	}
+	if _, err := PublicMulticodec(ML_DSA_65); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("expected ErrUnknownCode, got %v", err)
+	}
+	if _, err := CodeFromMulticodec(multicodec.Sha2_256); !errors.Is(err, ErrUnknownMulticodec) {
+		t.Errorf("expected ErrUnknownMulticodec, got %v", err)
+	}
+}
+
+// Keypairs encoded by multicodec decode only as that multicodec's cipher.
+func TestEncodeDecodeMulticodec(t *testing.T) {
+	k, err := Generate(SECP_256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := EncodeMulticodec(k.Private, k.Public, multicodec.Secp256k1Priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeMulticodec(m, multicodec.Secp256k1Pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(k) {
+		t.Error("decoded keypair doesn't match")
+	}
+	if _, err := DecodeMulticodec(m, multicodec.Ed25519Pub); !errors.Is(err, ErrMulticodecMismatch) {
+		t.Errorf("expected ErrMulticodecMismatch, got %v", err)
+	}
+	if _, err := EncodeMulticodec(k.Private, k.Public, multicodec.Identity); !errors.Is(err, ErrUnknownMulticodec) {
+		t.Errorf("expected ErrUnknownMulticodec, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// go-multikeypair/multikeypairpb/convert.go
+//
+// Conversion between the multikeypair types and their generated protobuf
+// messages.
+
+//go:generate protoc -I.. --go_out=.. --go_opt=paths=source_relative multikeypairpb/multikeypair.proto
+//go:generate protoc -I.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative multikeypairpb/service.proto
+
+package multikeypairpb
+
+import (
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// KeypairToProto converts a Keypair into its protobuf message.
+func KeypairToProto(k multikeypair.Keypair) *Keypair {
+	return &Keypair{
+		Code:     k.Code,
+		Name:     k.Name,
+		Public:   k.Public,
+		Private:  k.Private,
+		Metadata: MetadataToProto(k.Metadata),
+	}
+}
+
+// KeypairFromProto converts a protobuf message into a Keypair, checking
+// that the cipher code is one we recognize. The name is taken from the
+// code rather than trusted from the message.
+func KeypairFromProto(p *Keypair) (multikeypair.Keypair, error) {
+	name, err := multikeypair.CipherName(p.GetCode())
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	return multikeypair.Keypair{
+		Code:          p.GetCode(),
+		Name:          name,
+		Public:        p.GetPublic(),
+		PublicLength:  len(p.GetPublic()),
+		Private:       p.GetPrivate(),
+		PrivateLength: len(p.GetPrivate()),
+		Metadata:      MetadataFromProto(p.GetMetadata()),
+	}, nil
+}
+
+// MetadataToProto converts Metadata into its protobuf message, or nil
+// if it's empty.
+func MetadataToProto(m multikeypair.Metadata) *Metadata {
+	if m.IsZero() {
+		return nil
+	}
+	return &Metadata{
+		Label:     m.Label,
+		Created:   unixOrZero(m.Created),
+		Usage:     uint32(m.Usage),
+		NotBefore: unixOrZero(m.NotBefore),
+		NotAfter:  unixOrZero(m.NotAfter),
+		Tags:      m.Tags,
+	}
+}
+
+// MetadataFromProto converts a protobuf message into Metadata. A nil
+// message is empty Metadata.
+func MetadataFromProto(p *Metadata) multikeypair.Metadata {
+	return multikeypair.Metadata{
+		Label:     p.GetLabel(),
+		Created:   timeOrZero(p.GetCreated()),
+		Usage:     multikeypair.Usage(p.GetUsage()),
+		NotBefore: timeOrZero(p.GetNotBefore()),
+		NotAfter:  timeOrZero(p.GetNotAfter()),
+		Tags:      p.GetTags(),
+	}
+}
+
+// MultikeypairToProto converts a Multikeypair into its protobuf message.
+func MultikeypairToProto(m multikeypair.Multikeypair) *Multikeypair {
+	return &Multikeypair{
+		Data: m,
+	}
+}
+
+// MultikeypairFromProto converts a protobuf message into a Multikeypair,
+// checking that it holds a valid encoding.
+func MultikeypairFromProto(p *Multikeypair) (multikeypair.Multikeypair, error) {
+	m := multikeypair.Multikeypair(p.GetData())
+	if _, err := m.Decode(); err != nil {
+		return multikeypair.Multikeypair{}, err
+	}
+	return m, nil
+}
+
+// InfoToProto converts the description of a Multikeypair into its
+// protobuf message. Unknown or unset times are zero.
+func InfoToProto(info multikeypair.Info) *Info {
+	return &Info{
+		Code:          info.Code,
+		Name:          info.Name,
+		Version:       info.Version,
+		PrivateLength: uint32(info.PrivateLength),
+		PublicLength:  uint32(info.PublicLength),
+		Size:          uint32(info.Size),
+		Label:         info.Label,
+		Created:       unixOrZero(info.Created),
+		Usage:         uint32(info.Usage),
+		NotBefore:     unixOrZero(info.NotBefore),
+		NotAfter:      unixOrZero(info.NotAfter),
+	}
+}
+
+// Unix seconds, with the zero time as zero.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// The time at unix seconds, with zero as the zero time.
+func timeOrZero(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,113 @@
+// go-multikeypair/multikeypairpb/convert_test.go
+
+package multikeypairpb
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"google.golang.org/protobuf/proto"
+)
+
+// Round trip a keypair and its encoding through protobuf wire format.
+func TestRoundTrip(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := multikeypair.Encode(private, public, multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := proto.Marshal(KeypairToProto(kp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pk Keypair
+	if err := proto.Unmarshal(b, &pk); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := KeypairFromProto(&pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Code != kp.Code || decoded.Name != kp.Name ||
+		!bytes.Equal(decoded.Public, kp.Public) || !bytes.Equal(decoded.Private, kp.Private) {
+		t.Error("keypair mismatch after protobuf round trip")
+	}
+
+	b, err = proto.Marshal(MultikeypairToProto(mk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pm Multikeypair
+	if err := proto.Unmarshal(b, &pm); err != nil {
+		t.Fatal(err)
+	}
+	m, err := MultikeypairFromProto(&pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, mk) {
+		t.Error("multikeypair mismatch after protobuf round trip")
+	}
+}
+
+// Unknown codes and invalid encodings are rejected.
+func TestFromProtoInvalid(t *testing.T) {
+	if _, err := KeypairFromProto(&Keypair{Code: 0xdead}); err != multikeypair.ErrUnknownCode {
+		t.Errorf("expected unknown code error, got: %v", err)
+	}
+	if _, err := MultikeypairFromProto(&Multikeypair{Data: []byte{0x01}}); err == nil {
+		t.Error("expected invalid multikeypair error")
+	}
+}
+
+// Metadata round trips through protobuf, usage restrictions included.
+func TestMetadataRoundTrip(t *testing.T) {
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata = multikeypair.Metadata{
+		Label:     "encryption",
+		Usage:     multikeypair.USAGE_ENCRYPT,
+		NotBefore: time.Unix(1700000000, 0),
+		Tags:      map[string][]byte{"app": {0x01}},
+	}
+
+	b, err := proto.Marshal(KeypairToProto(kp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pk Keypair
+	if err := proto.Unmarshal(b, &pk); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := KeypairFromProto(&pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := decoded.Metadata
+	if m.Label != "encryption" || m.Usage != multikeypair.USAGE_ENCRYPT || !m.NotBefore.Equal(kp.Metadata.NotBefore) ||
+		!m.Created.IsZero() || !m.NotAfter.IsZero() || !bytes.Equal(m.Tags["app"], []byte{0x01}) {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+	if _, err := decoded.Sign([]byte("message")); !errors.Is(err, multikeypair.ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+
+	if KeypairToProto(multikeypair.Keypair{}).GetMetadata() != nil {
+		t.Error("expected no metadata message for empty metadata")
+	}
+}
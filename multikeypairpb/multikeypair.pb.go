@@ -0,0 +1,320 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: multikeypairpb/multikeypair.proto
+
+package multikeypairpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Keypair is a public/private keypair unpacked into its fields.
+type Keypair struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cipher identification code.
+	Code uint64 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Human-readable cipher name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Raw public key bytes.
+	Public []byte `protobuf:"bytes,3,opt,name=public,proto3" json:"public,omitempty"`
+	// Raw private key bytes. Empty for public-only keys.
+	Private []byte `protobuf:"bytes,4,opt,name=private,proto3" json:"private,omitempty"`
+	// Metadata describing the key, if any.
+	Metadata      *Metadata `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Keypair) Reset() {
+	*x = Keypair{}
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Keypair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Keypair) ProtoMessage() {}
+
+func (x *Keypair) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Keypair.ProtoReflect.Descriptor instead.
+func (*Keypair) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_multikeypair_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Keypair) GetCode() uint64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Keypair) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Keypair) GetPublic() []byte {
+	if x != nil {
+		return x.Public
+	}
+	return nil
+}
+
+func (x *Keypair) GetPrivate() []byte {
+	if x != nil {
+		return x.Private
+	}
+	return nil
+}
+
+func (x *Keypair) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Metadata describes a keypair. Times are in unix seconds, with zero
+// meaning unset.
+type Metadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Human-readable label.
+	Label string `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	// When the key was created.
+	Created int64 `protobuf:"varint,2,opt,name=created,proto3" json:"created,omitempty"`
+	// Usage bits: the operations the key may be used for.
+	Usage uint32 `protobuf:"varint,3,opt,name=usage,proto3" json:"usage,omitempty"`
+	// Start of the key's validity window.
+	NotBefore int64 `protobuf:"varint,4,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// End of the key's validity window, inclusive.
+	NotAfter int64 `protobuf:"varint,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	// Application-defined tags.
+	Tags          map[string][]byte `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_multikeypair_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Metadata) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Metadata) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *Metadata) GetUsage() uint32 {
+	if x != nil {
+		return x.Usage
+	}
+	return 0
+}
+
+func (x *Metadata) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *Metadata) GetNotAfter() int64 {
+	if x != nil {
+		return x.NotAfter
+	}
+	return 0
+}
+
+func (x *Metadata) GetTags() map[string][]byte {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// Multikeypair is a keypair in its packed multikeypair encoding.
+type Multikeypair struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Encoded multikeypair bytes.
+	Data          []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Multikeypair) Reset() {
+	*x = Multikeypair{}
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Multikeypair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Multikeypair) ProtoMessage() {}
+
+func (x *Multikeypair) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_multikeypair_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Multikeypair.ProtoReflect.Descriptor instead.
+func (*Multikeypair) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_multikeypair_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Multikeypair) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_multikeypairpb_multikeypair_proto protoreflect.FileDescriptor
+
+const file_multikeypairpb_multikeypair_proto_rawDesc = "" +
+	"\n" +
+	"!multikeypairpb/multikeypair.proto\x12\x0fmultikeypair.v1\"\x9a\x01\n" +
+	"\aKeypair\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x04R\x04code\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06public\x18\x03 \x01(\fR\x06public\x12\x18\n" +
+	"\aprivate\x18\x04 \x01(\fR\aprivate\x125\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x19.multikeypair.v1.MetadataR\bmetadata\"\xfe\x01\n" +
+	"\bMetadata\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x18\n" +
+	"\acreated\x18\x02 \x01(\x03R\acreated\x12\x14\n" +
+	"\x05usage\x18\x03 \x01(\rR\x05usage\x12\x1d\n" +
+	"\n" +
+	"not_before\x18\x04 \x01(\x03R\tnotBefore\x12\x1b\n" +
+	"\tnot_after\x18\x05 \x01(\x03R\bnotAfter\x127\n" +
+	"\x04tags\x18\x06 \x03(\v2#.multikeypair.v1.Metadata.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\"\n" +
+	"\fMultikeypair\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04dataB5Z3github.com/proofzero/go-multikeypair/multikeypairpbb\x06proto3"
+
+var (
+	file_multikeypairpb_multikeypair_proto_rawDescOnce sync.Once
+	file_multikeypairpb_multikeypair_proto_rawDescData []byte
+)
+
+func file_multikeypairpb_multikeypair_proto_rawDescGZIP() []byte {
+	file_multikeypairpb_multikeypair_proto_rawDescOnce.Do(func() {
+		file_multikeypairpb_multikeypair_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_multikeypairpb_multikeypair_proto_rawDesc), len(file_multikeypairpb_multikeypair_proto_rawDesc)))
+	})
+	return file_multikeypairpb_multikeypair_proto_rawDescData
+}
+
+var file_multikeypairpb_multikeypair_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_multikeypairpb_multikeypair_proto_goTypes = []any{
+	(*Keypair)(nil),      // 0: multikeypair.v1.Keypair
+	(*Metadata)(nil),     // 1: multikeypair.v1.Metadata
+	(*Multikeypair)(nil), // 2: multikeypair.v1.Multikeypair
+	nil,                  // 3: multikeypair.v1.Metadata.TagsEntry
+}
+var file_multikeypairpb_multikeypair_proto_depIdxs = []int32{
+	1, // 0: multikeypair.v1.Keypair.metadata:type_name -> multikeypair.v1.Metadata
+	3, // 1: multikeypair.v1.Metadata.tags:type_name -> multikeypair.v1.Metadata.TagsEntry
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_multikeypairpb_multikeypair_proto_init() }
+func file_multikeypairpb_multikeypair_proto_init() {
+	if File_multikeypairpb_multikeypair_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_multikeypairpb_multikeypair_proto_rawDesc), len(file_multikeypairpb_multikeypair_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_multikeypairpb_multikeypair_proto_goTypes,
+		DependencyIndexes: file_multikeypairpb_multikeypair_proto_depIdxs,
+		MessageInfos:      file_multikeypairpb_multikeypair_proto_msgTypes,
+	}.Build()
+	File_multikeypairpb_multikeypair_proto = out.File
+	file_multikeypairpb_multikeypair_proto_goTypes = nil
+	file_multikeypairpb_multikeypair_proto_depIdxs = nil
+}
@@ -0,0 +1,637 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: multikeypairpb/service.proto
+
+package multikeypairpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GenerateRequest asks for a new key.
+type GenerateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cipher code of the key.
+	Code uint64 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Optional label stored in the key's metadata.
+	Label         string `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateRequest) Reset() {
+	*x = GenerateRequest{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateRequest) ProtoMessage() {}
+
+func (x *GenerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateRequest) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenerateRequest) GetCode() uint64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+// Key is a stored key.
+type Key struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keystore identifier of the key.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Public half of the key.
+	Public        *Keypair `protobuf:"bytes,2,opt,name=public,proto3" json:"public,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Key) Reset() {
+	*x = Key{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Key) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Key) ProtoMessage() {}
+
+func (x *Key) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Key.ProtoReflect.Descriptor instead.
+func (*Key) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Key) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Key) GetPublic() *Keypair {
+	if x != nil {
+		return x.Public
+	}
+	return nil
+}
+
+// SignRequest asks for a signature.
+type SignRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keystore identifier of the signing key.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Message to sign.
+	Message       []byte `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignRequest) Reset() {
+	*x = SignRequest{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRequest) ProtoMessage() {}
+
+func (x *SignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRequest.ProtoReflect.Descriptor instead.
+func (*SignRequest) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SignRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SignRequest) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+// SignResponse carries a signature.
+type SignResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Signature over the message.
+	Signature     []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignResponse) Reset() {
+	*x = SignResponse{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignResponse) ProtoMessage() {}
+
+func (x *SignResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignResponse.ProtoReflect.Descriptor instead.
+func (*SignResponse) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SignResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// DeriveRequest asks for a subkey of a stored key, as derived by
+// Keypair.DeriveSubkey.
+type DeriveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keystore identifier of the master key.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Purpose of the subkey, e.g. "signing".
+	Info []byte `protobuf:"bytes,2,opt,name=info,proto3" json:"info,omitempty"`
+	// Cipher code of the subkey.
+	Code          uint64 `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeriveRequest) Reset() {
+	*x = DeriveRequest{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeriveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeriveRequest) ProtoMessage() {}
+
+func (x *DeriveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeriveRequest.ProtoReflect.Descriptor instead.
+func (*DeriveRequest) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeriveRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeriveRequest) GetInfo() []byte {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *DeriveRequest) GetCode() uint64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// InspectRequest names the key to describe.
+type InspectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Key:
+	//
+	//	*InspectRequest_Id
+	//	*InspectRequest_Multikeypair
+	Key           isInspectRequest_Key `protobuf_oneof:"key"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InspectRequest) Reset() {
+	*x = InspectRequest{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InspectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InspectRequest) ProtoMessage() {}
+
+func (x *InspectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InspectRequest.ProtoReflect.Descriptor instead.
+func (*InspectRequest) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InspectRequest) GetKey() isInspectRequest_Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *InspectRequest) GetId() string {
+	if x != nil {
+		if x, ok := x.Key.(*InspectRequest_Id); ok {
+			return x.Id
+		}
+	}
+	return ""
+}
+
+func (x *InspectRequest) GetMultikeypair() *Multikeypair {
+	if x != nil {
+		if x, ok := x.Key.(*InspectRequest_Multikeypair); ok {
+			return x.Multikeypair
+		}
+	}
+	return nil
+}
+
+type isInspectRequest_Key interface {
+	isInspectRequest_Key()
+}
+
+type InspectRequest_Id struct {
+	// Keystore identifier of a stored key.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3,oneof"`
+}
+
+type InspectRequest_Multikeypair struct {
+	// An encoded multikeypair.
+	Multikeypair *Multikeypair `protobuf:"bytes,2,opt,name=multikeypair,proto3,oneof"`
+}
+
+func (*InspectRequest_Id) isInspectRequest_Key() {}
+
+func (*InspectRequest_Multikeypair) isInspectRequest_Key() {}
+
+// Info describes a multikeypair without its key material.
+type Info struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cipher identification code.
+	Code uint64 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Human-readable cipher name.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Wire format version.
+	Version uint64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Length in bytes of private key; zero for a public-only keypair.
+	PrivateLength uint32 `protobuf:"varint,4,opt,name=private_length,json=privateLength,proto3" json:"private_length,omitempty"`
+	// Length in bytes of public key.
+	PublicLength uint32 `protobuf:"varint,5,opt,name=public_length,json=publicLength,proto3" json:"public_length,omitempty"`
+	// Total encoded size in bytes.
+	Size uint32 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	// Label from the metadata, if any.
+	Label string `protobuf:"bytes,7,opt,name=label,proto3" json:"label,omitempty"`
+	// Creation time in unix seconds from the metadata; zero if unknown.
+	Created int64 `protobuf:"varint,8,opt,name=created,proto3" json:"created,omitempty"`
+	// Usage bits from the metadata.
+	Usage uint32 `protobuf:"varint,9,opt,name=usage,proto3" json:"usage,omitempty"`
+	// Start of the validity window in unix seconds from the metadata; zero
+	// if there is none.
+	NotBefore int64 `protobuf:"varint,10,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// End of the validity window in unix seconds from the metadata; zero if
+	// the key doesn't expire.
+	NotAfter      int64 `protobuf:"varint,11,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Info) Reset() {
+	*x = Info{}
+	mi := &file_multikeypairpb_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Info) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Info) ProtoMessage() {}
+
+func (x *Info) ProtoReflect() protoreflect.Message {
+	mi := &file_multikeypairpb_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Info.ProtoReflect.Descriptor instead.
+func (*Info) Descriptor() ([]byte, []int) {
+	return file_multikeypairpb_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Info) GetCode() uint64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Info) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Info) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Info) GetPrivateLength() uint32 {
+	if x != nil {
+		return x.PrivateLength
+	}
+	return 0
+}
+
+func (x *Info) GetPublicLength() uint32 {
+	if x != nil {
+		return x.PublicLength
+	}
+	return 0
+}
+
+func (x *Info) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Info) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Info) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *Info) GetUsage() uint32 {
+	if x != nil {
+		return x.Usage
+	}
+	return 0
+}
+
+func (x *Info) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *Info) GetNotAfter() int64 {
+	if x != nil {
+		return x.NotAfter
+	}
+	return 0
+}
+
+var File_multikeypairpb_service_proto protoreflect.FileDescriptor
+
+const file_multikeypairpb_service_proto_rawDesc = "" +
+	"\n" +
+	"\x1cmultikeypairpb/service.proto\x12\x0fmultikeypair.v1\x1a!multikeypairpb/multikeypair.proto\";\n" +
+	"\x0fGenerateRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x04R\x04code\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\"G\n" +
+	"\x03Key\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x06public\x18\x02 \x01(\v2\x18.multikeypair.v1.KeypairR\x06public\"7\n" +
+	"\vSignRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\fR\amessage\",\n" +
+	"\fSignResponse\x12\x1c\n" +
+	"\tsignature\x18\x01 \x01(\fR\tsignature\"G\n" +
+	"\rDeriveRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04info\x18\x02 \x01(\fR\x04info\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x04R\x04code\"n\n" +
+	"\x0eInspectRequest\x12\x10\n" +
+	"\x02id\x18\x01 \x01(\tH\x00R\x02id\x12C\n" +
+	"\fmultikeypair\x18\x02 \x01(\v2\x1d.multikeypair.v1.MultikeypairH\x00R\fmultikeypairB\x05\n" +
+	"\x03key\"\xaa\x02\n" +
+	"\x04Info\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x04R\x04code\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x12%\n" +
+	"\x0eprivate_length\x18\x04 \x01(\rR\rprivateLength\x12#\n" +
+	"\rpublic_length\x18\x05 \x01(\rR\fpublicLength\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\rR\x04size\x12\x14\n" +
+	"\x05label\x18\a \x01(\tR\x05label\x12\x18\n" +
+	"\acreated\x18\b \x01(\x03R\acreated\x12\x14\n" +
+	"\x05usage\x18\t \x01(\rR\x05usage\x12\x1d\n" +
+	"\n" +
+	"not_before\x18\n" +
+	" \x01(\x03R\tnotBefore\x12\x1b\n" +
+	"\tnot_after\x18\v \x01(\x03R\bnotAfter2\x98\x02\n" +
+	"\n" +
+	"KeyService\x12B\n" +
+	"\bGenerate\x12 .multikeypair.v1.GenerateRequest\x1a\x14.multikeypair.v1.Key\x12C\n" +
+	"\x04Sign\x12\x1c.multikeypair.v1.SignRequest\x1a\x1d.multikeypair.v1.SignResponse\x12>\n" +
+	"\x06Derive\x12\x1e.multikeypair.v1.DeriveRequest\x1a\x14.multikeypair.v1.Key\x12A\n" +
+	"\aInspect\x12\x1f.multikeypair.v1.InspectRequest\x1a\x15.multikeypair.v1.InfoB5Z3github.com/proofzero/go-multikeypair/multikeypairpbb\x06proto3"
+
+var (
+	file_multikeypairpb_service_proto_rawDescOnce sync.Once
+	file_multikeypairpb_service_proto_rawDescData []byte
+)
+
+func file_multikeypairpb_service_proto_rawDescGZIP() []byte {
+	file_multikeypairpb_service_proto_rawDescOnce.Do(func() {
+		file_multikeypairpb_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_multikeypairpb_service_proto_rawDesc), len(file_multikeypairpb_service_proto_rawDesc)))
+	})
+	return file_multikeypairpb_service_proto_rawDescData
+}
+
+var file_multikeypairpb_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_multikeypairpb_service_proto_goTypes = []any{
+	(*GenerateRequest)(nil), // 0: multikeypair.v1.GenerateRequest
+	(*Key)(nil),             // 1: multikeypair.v1.Key
+	(*SignRequest)(nil),     // 2: multikeypair.v1.SignRequest
+	(*SignResponse)(nil),    // 3: multikeypair.v1.SignResponse
+	(*DeriveRequest)(nil),   // 4: multikeypair.v1.DeriveRequest
+	(*InspectRequest)(nil),  // 5: multikeypair.v1.InspectRequest
+	(*Info)(nil),            // 6: multikeypair.v1.Info
+	(*Keypair)(nil),         // 7: multikeypair.v1.Keypair
+	(*Multikeypair)(nil),    // 8: multikeypair.v1.Multikeypair
+}
+var file_multikeypairpb_service_proto_depIdxs = []int32{
+	7, // 0: multikeypair.v1.Key.public:type_name -> multikeypair.v1.Keypair
+	8, // 1: multikeypair.v1.InspectRequest.multikeypair:type_name -> multikeypair.v1.Multikeypair
+	0, // 2: multikeypair.v1.KeyService.Generate:input_type -> multikeypair.v1.GenerateRequest
+	2, // 3: multikeypair.v1.KeyService.Sign:input_type -> multikeypair.v1.SignRequest
+	4, // 4: multikeypair.v1.KeyService.Derive:input_type -> multikeypair.v1.DeriveRequest
+	5, // 5: multikeypair.v1.KeyService.Inspect:input_type -> multikeypair.v1.InspectRequest
+	1, // 6: multikeypair.v1.KeyService.Generate:output_type -> multikeypair.v1.Key
+	3, // 7: multikeypair.v1.KeyService.Sign:output_type -> multikeypair.v1.SignResponse
+	1, // 8: multikeypair.v1.KeyService.Derive:output_type -> multikeypair.v1.Key
+	6, // 9: multikeypair.v1.KeyService.Inspect:output_type -> multikeypair.v1.Info
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_multikeypairpb_service_proto_init() }
+func file_multikeypairpb_service_proto_init() {
+	if File_multikeypairpb_service_proto != nil {
+		return
+	}
+	file_multikeypairpb_multikeypair_proto_init()
+	file_multikeypairpb_service_proto_msgTypes[5].OneofWrappers = []any{
+		(*InspectRequest_Id)(nil),
+		(*InspectRequest_Multikeypair)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_multikeypairpb_service_proto_rawDesc), len(file_multikeypairpb_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_multikeypairpb_service_proto_goTypes,
+		DependencyIndexes: file_multikeypairpb_service_proto_depIdxs,
+		MessageInfos:      file_multikeypairpb_service_proto_msgTypes,
+	}.Build()
+	File_multikeypairpb_service_proto = out.File
+	file_multikeypairpb_service_proto_goTypes = nil
+	file_multikeypairpb_service_proto_depIdxs = nil
+}
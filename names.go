@@ -0,0 +1,134 @@
+// go-multikeypair/names.go
+//
+// The registry of cipher codes and names. It starts out holding the
+// built-in ciphers; more can be registered, and ciphers can be given
+// aliases, e.g. "eddsa" for ed25519 or "secp256r1" for p256, but
+// nothing registered can be changed or removed. The registry is safe
+// for concurrent use.
+//
+// Names are matched without regard to case.
+
+package multikeypair
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Name-specific errors this module exports.
+var (
+	ErrUnknownName    = errors.New("unknown multikeypair cipher name")
+	ErrNameRegistered = errors.New("multikeypair cipher name already registered")
+	ErrCodeRegistered = errors.New("multikeypair code already registered")
+)
+
+// Registry
+// -----------------------------------------------------------------------------
+
+// Alternative names for the built-in ciphers, in lower case.
+var builtinAliases = map[string]uint64{
+	"eddsa":      ED_25519,
+	"curve25519": X_25519,
+	"secp256r1":  P_256,
+	"prime256v1": P_256,
+	"p-256":      P_256,
+	"k256":       SECP_256K1,
+	"mldsa65":    ML_DSA_65,
+	"mlkem768":   ML_KEM_768,
+}
+
+var (
+	ciphersMu sync.RWMutex
+	// Names of the registered ciphers, by code.
+	cipherNames = maps.Clone(builtinCiphers)
+	// Codes of the registered ciphers, by lower-case name or alias.
+	cipherCodes = registryCodes()
+)
+
+// Index the built-in ciphers by name and alias.
+func registryCodes() map[string]uint64 {
+	codes := maps.Clone(builtinAliases)
+	for code, name := range builtinCiphers {
+		codes[name] = code
+	}
+	return codes
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// CipherName returns the name of a registered cipher.
+func CipherName(code uint64) (string, error) {
+	ciphersMu.RLock()
+	defer ciphersMu.RUnlock()
+
+	name, ok := cipherNames[code]
+	if !ok {
+		return "", ErrUnknownCode
+	}
+	return name, nil
+}
+
+// CipherCode returns the code of a registered cipher, given its name or
+// an alias in any case.
+func CipherCode(name string) (uint64, error) {
+	ciphersMu.RLock()
+	defer ciphersMu.RUnlock()
+
+	code, ok := cipherCodes[strings.ToLower(name)]
+	if !ok {
+		return 0, ErrUnknownName
+	}
+	return code, nil
+}
+
+// RegisterCipher makes a cipher code known under a name, so that keys of
+// that cipher can be encoded and decoded. The module can't operate on
+// them (sign, encrypt, etc.) without support of its own. Codes and names
+// that are already registered cannot be replaced.
+func RegisterCipher(code uint64, name string) error {
+	name = strings.ToLower(name)
+
+	ciphersMu.Lock()
+	defer ciphersMu.Unlock()
+
+	if _, ok := cipherNames[code]; ok {
+		return ErrCodeRegistered
+	}
+	if _, ok := cipherCodes[name]; ok {
+		return ErrNameRegistered
+	}
+	cipherNames[code] = name
+	cipherCodes[name] = code
+	return nil
+}
+
+// RegisterAlias makes a cipher known by another name. Aliases are
+// matched without regard to case, and can't replace a cipher's name or
+// an existing alias.
+func RegisterAlias(alias string, code uint64) error {
+	alias = strings.ToLower(alias)
+
+	ciphersMu.Lock()
+	defer ciphersMu.Unlock()
+
+	if _, ok := cipherNames[code]; !ok {
+		return ErrUnknownCode
+	}
+	if _, ok := cipherCodes[alias]; ok {
+		return ErrNameRegistered
+	}
+	cipherCodes[alias] = code
+	return nil
+}
+
+// The name of a registered cipher, or "" if there's none.
+func codeName(code uint64) string {
+	name, _ := CipherName(code)
+	return name
+}
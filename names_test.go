@@ -0,0 +1,142 @@
+// go-multikeypair/names_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Every cipher's name leads back to its code.
+func TestNamesInverse(t *testing.T) {
+	for code, name := range Codes {
+		if Names[name] != code {
+			t.Errorf("%s: expected code %#x, got %#x", name, code, Names[name])
+		}
+	}
+	if len(Names) != len(Codes) {
+		t.Errorf("expected %d names, got %d", len(Codes), len(Names))
+	}
+}
+
+// Names and aliases are matched in any case, and unknown names are
+// refused rather than encoded as identity.
+func TestEncodeName(t *testing.T) {
+	for name, code := range map[string]uint64{
+		"rsa":       RSA,
+		"Ed25519":   ED_25519,
+		"EdDSA":     ED_25519,
+		"SECP256R1": P_256,
+	} {
+		m, err := EncodeName([]byte("private"), []byte("public"), name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		kp, err := m.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kp.Code != code {
+			t.Errorf("%s: expected code %#x, got %#x", name, code, kp.Code)
+		}
+	}
+	for _, name := range []string{"", "res", "ed448"} {
+		if _, err := EncodeName([]byte("private"), []byte("public"), name); !errors.Is(err, ErrUnknownName) {
+			t.Errorf("%q: expected ErrUnknownName, got %v", name, err)
+		}
+	}
+}
+
+// Registered aliases are matched in any case, and can't shadow names or
+// other aliases.
+func TestRegisterAlias(t *testing.T) {
+	if err := RegisterAlias("Test-Ed", ED_25519); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ciphersMu.Lock()
+		delete(cipherCodes, "test-ed")
+		ciphersMu.Unlock()
+	}()
+	if code, err := CipherCode("TEST-ED"); err != nil || code != ED_25519 {
+		t.Errorf("expected ed25519, got %#x, %v", code, err)
+	}
+
+	for _, alias := range []string{"test-ed", "X25519", "eddsa"} {
+		if err := RegisterAlias(alias, P_256); !errors.Is(err, ErrNameRegistered) {
+			t.Errorf("%s: expected ErrNameRegistered, got %v", alias, err)
+		}
+	}
+	if err := RegisterAlias("nothing", 0x1234); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("expected ErrUnknownCode, got %v", err)
+	}
+}
+
+// Registered ciphers can be encoded and decoded, but can't replace
+// existing ones.
+func TestRegisterCipher(t *testing.T) {
+	const code = uint64(0x7fff)
+	if err := RegisterCipher(code, "Test-Cipher"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ciphersMu.Lock()
+		delete(cipherNames, code)
+		delete(cipherCodes, "test-cipher")
+		ciphersMu.Unlock()
+	}()
+
+	m, err := EncodeName([]byte("private"), []byte("public"), "test-cipher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.Code != code || kp.Name != "test-cipher" {
+		t.Errorf("unexpected cipher %#x %q", kp.Code, kp.Name)
+	}
+	if _, ok := Codes[code]; ok {
+		t.Error("registered cipher leaked into deprecated Codes")
+	}
+
+	if err := RegisterCipher(code, "other"); !errors.Is(err, ErrCodeRegistered) {
+		t.Errorf("expected ErrCodeRegistered, got %v", err)
+	}
+	if err := RegisterCipher(code+1, "ED25519"); !errors.Is(err, ErrNameRegistered) {
+		t.Errorf("expected ErrNameRegistered, got %v", err)
+	}
+}
+
+// Changing the deprecated maps doesn't change the registry, and the
+// registry can be read while it's written to.
+func TestRegistryIsolated(t *testing.T) {
+	Codes[ED_25519] = "changed"
+	defer func() { Codes[ED_25519] = "ed25519" }()
+	if name, err := CipherName(ED_25519); err != nil || name != "ed25519" {
+		t.Errorf("expected ed25519, got %q, %v", name, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			RegisterAlias(fmt.Sprintf("race-%d", i), X_25519)
+		}
+	}()
+	defer func() {
+		ciphersMu.Lock()
+		for i := range 100 {
+			delete(cipherCodes, fmt.Sprintf("race-%d", i))
+		}
+		ciphersMu.Unlock()
+	}()
+	for range 100 {
+		if _, err := CipherCode("x25519"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,340 @@
+// go-multikeypair/openpgp/openpgp.go
+//
+// Conversion between multikeypairs and ASCII-armored OpenPGP keys, so
+// that multikeypairs can be used with GnuPG and other OpenPGP tools. It
+// lives in its own package so that the OpenPGP implementation is only
+// linked into programs that use it.
+//
+// Ed25519 keypairs become v4 EdDSA primary keys, usable for signing and
+// certification, with a Curve25519 encryption subkey derived from the
+// same secret (the clamped SHA-512 of the seed, as Ed25519 itself uses)
+// so that the key can also receive encrypted messages. RSA keypairs,
+// whose private and public halves are PKCS#1 DER, become RSA primary
+// keys. The key's creation time, which is part of its fingerprint, is
+// taken from the keypair's Created metadata.
+
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+
+	pgp "github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/ecdh"
+	pgp_ed25519 "github.com/ProtonMail/go-crypto/openpgp/ed25519"
+	"github.com/ProtonMail/go-crypto/openpgp/eddsa"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// OpenPGP-specific errors this package exports.
+var (
+	ErrInvalidKey      = errors.New("input isn't valid OpenPGP key")
+	ErrInvalidIdentity = errors.New("invalid OpenPGP user id")
+	ErrPassphrase      = errors.New("OpenPGP key decryption failed (wrong passphrase?)")
+)
+
+// Curve OIDs, as they appear in OpenPGP public key packets (RFC 9580,
+// section 9.2).
+var (
+	oidEd25519    = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+	oidCurve25519 = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0x97, 0x55, 0x01, 0x05, 0x01}
+)
+
+// The KDF parameters of the Curve25519 subkey: SHA-512 (10) and AES-256
+// (9), as the OpenPGP library chooses for keys it generates.
+var kdfParams = []byte{0x03, 0x01, 10, 9}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Export returns an ASCII-armored OpenPGP private key block for an
+// Ed25519 or RSA Keypair, with identity ("Name <email>", "Name (comment)
+// <email>" or just a name) as its user id. The output is deterministic
+// for a given keypair, identity and creation time.
+func Export(k multikeypair.Keypair, identity string) (string, error) {
+	name, comment, email, err := splitIdentity(identity)
+	if err != nil {
+		return "", err
+	}
+	created := k.Metadata.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	deterministic := false
+	config := &packet.Config{
+		DefaultHash:                           crypto.SHA256,
+		Time:                                  func() time.Time { return created },
+		NonDeterministicSignaturesViaNotation: &deterministic,
+	}
+
+	var primary *packet.PrivateKey
+	switch k.Code {
+	case multikeypair.ED_25519:
+		primary, err = ed25519PrivateKey(k, config.Now())
+	case multikeypair.RSA:
+		primary, err = rsaPrivateKey(k, config.Now())
+	default:
+		err = multikeypair.ErrUnsupportedCipher
+	}
+	if err != nil {
+		return "", err
+	}
+
+	e := &pgp.Entity{
+		PrimaryKey: &primary.PublicKey,
+		PrivateKey: primary,
+		Identities: make(map[string]*pgp.Identity),
+	}
+	if err := e.AddUserId(name, comment, email, config); err != nil {
+		return "", ErrInvalidIdentity
+	}
+	if k.Code == multikeypair.ED_25519 {
+		if err := addCurve25519Subkey(e, k, config); err != nil {
+			return "", err
+		}
+	}
+
+	var out bytes.Buffer
+	w, err := armor.Encode(&out, pgp.PrivateKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := e.SerializePrivateWithoutSigning(w, config); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+// Import reads the primary key of an ASCII-armored OpenPGP private key
+// block, decrypting it with passphrase if it is protected. Public key
+// blocks are accepted too, giving a public-only Keypair. The key's
+// primary user id and creation time are kept as its Label and Created
+// metadata.
+func Import(armored string, passphrase []byte) (multikeypair.Keypair, error) {
+	entities, err := pgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil || len(entities) != 1 {
+		return multikeypair.Keypair{}, ErrInvalidKey
+	}
+	e := entities[0]
+
+	var kp multikeypair.Keypair
+	if e.PrivateKey == nil {
+		kp, err = keypairFromPublic(e.PrimaryKey)
+	} else {
+		if e.PrivateKey.Encrypted {
+			if err := e.PrivateKey.Decrypt(passphrase); err != nil {
+				return multikeypair.Keypair{}, ErrPassphrase
+			}
+		}
+		kp, err = keypairFromPrivate(e.PrivateKey)
+	}
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	kp.Metadata.Created = e.PrimaryKey.CreationTime.UTC()
+	if id := e.PrimaryIdentity(); id != nil {
+		kp.Metadata.Label = id.Name
+	}
+	return kp, nil
+}
+
+// Build the EdDSA primary key of an Ed25519 keypair. The OpenPGP library
+// has no constructor for EdDSA keys from their material, so the public
+// key packet is encoded here and parsed back, and the seed set as its
+// secret.
+func ed25519PrivateKey(k multikeypair.Keypair, created time.Time) (*packet.PrivateKey, error) {
+	if len(k.Private) != ed25519.PrivateKeySize {
+		return nil, multikeypair.ErrInvalidPrivateKey
+	}
+	pk, err := readPublicKey(packet.PubKeyAlgoEdDSA, oidEd25519, k.Private[32:], nil, created)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := pk.PublicKey.(*eddsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	sk := eddsa.NewPrivateKey(*public)
+	sk.D = ed25519.PrivateKey(k.Private).Seed()
+	return packet.NewSignerPrivateKey(created, sk), nil
+}
+
+// Build the RSA primary key of an RSA keypair.
+func rsaPrivateKey(k multikeypair.Keypair, created time.Time) (*packet.PrivateKey, error) {
+	sk, err := x509.ParsePKCS1PrivateKey(k.Private)
+	if err != nil {
+		return nil, multikeypair.ErrInvalidPrivateKey
+	}
+	return packet.NewSignerPrivateKey(created, sk), nil
+}
+
+// Add the Curve25519 encryption subkey of an Ed25519 keypair to its
+// entity, bound by a signature from the primary key.
+func addCurve25519Subkey(e *pgp.Entity, k multikeypair.Keypair, config *packet.Config) error {
+	x, err := k.ToX25519()
+	if err != nil {
+		return err
+	}
+	created := config.Now()
+	pk, err := readPublicKey(packet.PubKeyAlgoECDH, oidCurve25519, x.Public, kdfParams, created)
+	if err != nil {
+		return err
+	}
+	public, ok := pk.PublicKey.(*ecdh.PublicKey)
+	if !ok {
+		return ErrInvalidKey
+	}
+	sk := ecdh.NewPrivateKey(*public)
+	sk.D = x.Private
+	sub := packet.NewDecrypterPrivateKey(created, sk)
+	sub.IsSubkey = true
+
+	var lifetime uint32
+	signer := e.PrimaryKey
+	sig := &packet.Signature{
+		Version:                   signer.Version,
+		SigType:                   packet.SigTypeSubkeyBinding,
+		PubKeyAlgo:                signer.PubKeyAlgo,
+		Hash:                      config.Hash(),
+		CreationTime:              created,
+		IssuerKeyId:               &signer.KeyId,
+		IssuerFingerprint:         signer.Fingerprint,
+		SigLifetimeSecs:           &lifetime,
+		KeyLifetimeSecs:           &lifetime,
+		FlagsValid:                true,
+		FlagEncryptStorage:        true,
+		FlagEncryptCommunications: true,
+	}
+	if err := sig.SignKey(&sub.PublicKey, e.PrivateKey, config); err != nil {
+		return err
+	}
+	e.Subkeys = append(e.Subkeys, pgp.Subkey{
+		PublicKey:  &sub.PublicKey,
+		PrivateKey: sub,
+		Sig:        sig,
+	})
+	return nil
+}
+
+// Parse a v4 public key packet for an elliptic curve key: the curve's
+// OID, the point with its 0x40 native-encoding prefix and, for ECDH
+// keys, the KDF parameters.
+func readPublicKey(algo packet.PublicKeyAlgorithm, oid, point, kdf []byte, created time.Time) (*packet.PublicKey, error) {
+	body := []byte{4, 0, 0, 0, 0, byte(algo), byte(len(oid))}
+	binary.BigEndian.PutUint32(body[1:5], uint32(created.Unix()))
+	body = append(body, oid...)
+	bits := 8*len(point) + 7
+	body = append(body, byte(bits>>8), byte(bits), 0x40)
+	body = append(body, point...)
+	body = append(body, kdf...)
+
+	// A new-format header for packet tag 6, with a one-octet length.
+	raw := append([]byte{0xc6, byte(len(body))}, body...)
+	p, err := packet.Read(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := p.(*packet.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return pk, nil
+}
+
+// Convert a decrypted OpenPGP primary key into a Keypair.
+func keypairFromPrivate(pk *packet.PrivateKey) (multikeypair.Keypair, error) {
+	var code uint64
+	var private, public []byte
+	switch sk := pk.PrivateKey.(type) {
+	case *eddsa.PrivateKey:
+		if sk.GetCurve().GetCurveName() != "ed25519" {
+			return multikeypair.Keypair{}, multikeypair.ErrUnsupportedCipher
+		}
+		key := ed25519.NewKeyFromSeed(sk.D)
+		code, private, public = multikeypair.ED_25519, key, key.Public().(ed25519.PublicKey)
+	case *pgp_ed25519.PrivateKey:
+		key := ed25519.NewKeyFromSeed(sk.Seed())
+		code, private, public = multikeypair.ED_25519, key, key.Public().(ed25519.PublicKey)
+	case *rsa.PrivateKey:
+		code = multikeypair.RSA
+		private = x509.MarshalPKCS1PrivateKey(sk)
+		public = x509.MarshalPKCS1PublicKey(&sk.PublicKey)
+	default:
+		return multikeypair.Keypair{}, multikeypair.ErrUnsupportedCipher
+	}
+	return multikeypair.Keypair{
+		Code:          code,
+		Name:          multikeypair.Codes[code],
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// Convert an OpenPGP primary public key into a public-only Keypair.
+func keypairFromPublic(pk *packet.PublicKey) (multikeypair.Keypair, error) {
+	var code uint64
+	var public []byte
+	switch key := pk.PublicKey.(type) {
+	case *eddsa.PublicKey:
+		if key.GetCurve().GetCurveName() != "ed25519" {
+			return multikeypair.Keypair{}, multikeypair.ErrUnsupportedCipher
+		}
+		code, public = multikeypair.ED_25519, key.X
+	case *pgp_ed25519.PublicKey:
+		code, public = multikeypair.ED_25519, key.Point
+	case *rsa.PublicKey:
+		code, public = multikeypair.RSA, x509.MarshalPKCS1PublicKey(key)
+	default:
+		return multikeypair.Keypair{}, multikeypair.ErrUnsupportedCipher
+	}
+	return multikeypair.Keypair{
+		Code:         code,
+		Name:         multikeypair.Codes[code],
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
+
+// Split a user id of the form "Name (comment) <email>" into its parts.
+// The comment and email are optional.
+func splitIdentity(identity string) (name, comment, email string, err error) {
+	name = strings.TrimSpace(identity)
+	if rest, ok := strings.CutSuffix(name, ">"); ok {
+		i := strings.LastIndex(rest, "<")
+		if i < 0 {
+			return "", "", "", ErrInvalidIdentity
+		}
+		name, email = strings.TrimSpace(rest[:i]), rest[i+1:]
+	}
+	if rest, ok := strings.CutSuffix(name, ")"); ok {
+		i := strings.LastIndex(rest, "(")
+		if i < 0 {
+			return "", "", "", ErrInvalidIdentity
+		}
+		name, comment = strings.TrimSpace(rest[:i]), rest[i+1:]
+	}
+	if name == "" && email == "" || strings.ContainsAny(name+comment+email, "()<>\x00") {
+		return "", "", "", ErrInvalidIdentity
+	}
+	return name, comment, email, nil
+}
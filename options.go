@@ -0,0 +1,106 @@
+// go-multikeypair/options.go
+//
+// Decoding with caller-imposed limits, for services that decode
+// multikeypairs supplied by untrusted parties and need to bound the work
+// and memory that takes.
+
+package multikeypair
+
+import (
+	"errors"
+
+	varint "github.com/multiformats/go-varint"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Decode option errors this module exports.
+var (
+	ErrTrailingBytes = errors.New("multikeypair has trailing bytes")
+)
+
+// Options
+// -----------------------------------------------------------------------------
+
+// DecodeOptions limits what Decode accepts, and says whether the result
+// may alias the encoding. The zero value applies only the module's
+// built-in limits, matching Decode.
+type DecodeOptions struct {
+	// MaxSize is the maximum total encoded size in bytes. It is checked
+	// before any parsing. Zero means no limit.
+	MaxSize int
+	// MaxKeyLength is the maximum length in bytes of either key. Zero
+	// means MAX_KEY_LENGTH.
+	MaxKeyLength int
+	// Strict rejects encodings with unused bytes after the cipher code,
+	// anything but known optional fields after the public key, cipher
+	// codes that aren't minimally encoded, and encodings that otherwise
+	// aren't canonical (see Canonical), such as the v2 layout for keys
+	// that would fit v1.
+	Strict bool
+	// RequireChecksum rejects encodings without a checksum. Checksums
+	// that are present are always verified.
+	RequireChecksum bool
+	// Copy copies the keys and application tags out of the encoding,
+	// in a single allocation for the keys, rather than aliasing it, so
+	// that the encoding's buffer can be reused.
+	Copy bool
+}
+
+// DecodeWithOptions unpacks a multikeypair into a Keypair struct, subject
+// to the given limits. The key slices in the result alias m unless
+// opts.Copy is set.
+func DecodeWithOptions(m Multikeypair, opts DecodeOptions) (Keypair, error) {
+	keypair, err := decodeKeypairWithOptions(m, opts)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return *keypair, nil
+}
+
+// DecodeIntoWithOptions is DecodeInto subject to the given limits. With
+// opts.Copy set, the keys are copied out of m rather than aliasing it.
+func DecodeIntoWithOptions(m Multikeypair, kp *Keypair, opts DecodeOptions) error {
+	return decodeInto(m, opts, kp)
+}
+
+// Check the fields read from an encoding buf against the options. rest
+// is whatever followed the public key inside the outer length prefix.
+func (o DecodeOptions) check(buf []byte, code []byte, private []byte, public []byte, rest []byte) error {
+	maxKey := o.MaxKeyLength
+	if maxKey <= 0 {
+		maxKey = MAX_KEY_LENGTH
+	}
+	if len(private) > maxKey {
+		return decodeError(FIELD_PRIVATE, offsetIn(buf, private), ErrTooLong)
+	}
+	if len(public) > maxKey {
+		return decodeError(FIELD_PUBLIC, offsetIn(buf, public), ErrTooLong)
+	}
+
+	if o.Strict {
+		// Only well-formed optional fields we know about may follow the
+		// public key.
+		fields, ok := splitExtensions(rest)
+		if !ok {
+			return decodeError(FIELD_OPTIONAL, offsetIn(buf, rest), ErrTrailingBytes)
+		}
+		for _, f := range fields {
+			if !knownExtension(f.tag) {
+				// Point at the tag, before the value's 16-bit length.
+				return decodeError(FIELD_OPTIONAL, offsetIn(buf, f.value)-3, ErrTrailingBytes)
+			}
+		}
+		// Unlike UnpackCode, FromUvarint rejects non-minimal varints.
+		_, n, err := varint.FromUvarint(code)
+		if err != nil {
+			return decodeError(FIELD_CODE, offsetIn(buf, code), ErrInvalidMultikeypair)
+		}
+		if n != len(code) {
+			return decodeError(FIELD_CODE, offsetIn(buf, code)+n, ErrTrailingBytes)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// go-multikeypair/options_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Build a v1 encoding by hand, with optional junk after the code and
+// after the public key.
+func encodeWithJunk(t *testing.T, code []byte, codeJunk []byte, tailJunk []byte) Multikeypair {
+	var b cryptobyte.Builder
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(code)
+			b.AddBytes(codeJunk)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte("private"))
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte("public"))
+		})
+		b.AddBytes(tailJunk)
+	})
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Multikeypair(out)
+}
+
+// Lenient decoding accepts junk that strict decoding rejects.
+func TestDecodeStrict(t *testing.T) {
+	strict := DecodeOptions{Strict: true}
+	for name, mk := range map[string]Multikeypair{
+		"trailing":     encodeWithJunk(t, PackCode(ED_25519), nil, []byte{0xff}),
+		"code junk":    encodeWithJunk(t, PackCode(ED_25519), []byte{0xff}, nil),
+		"long varint":  encodeWithJunk(t, []byte{0x91, 0x00}, nil, nil),
+		"strict clean": encodeWithJunk(t, PackCode(ED_25519), nil, nil),
+	} {
+		if _, err := Decode(mk); err != nil {
+			t.Errorf("%s: expected lenient decode to succeed: %s", name, err)
+		}
+		_, err := DecodeWithOptions(mk, strict)
+		if name == "strict clean" {
+			if err != nil {
+				t.Errorf("%s: expected strict decode to succeed: %s", name, err)
+			}
+		} else if err == nil {
+			t.Errorf("%s: expected strict decode to fail", name)
+		}
+	}
+}
+
+// Size limits are enforced.
+func TestDecodeLimits(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWithOptions(mk, DecodeOptions{MaxSize: len(mk)}); err != nil {
+		t.Errorf("expected decode at size limit to succeed: %s", err)
+	}
+	if _, err := DecodeWithOptions(mk, DecodeOptions{MaxSize: len(mk) - 1}); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected too long error, got: %v", err)
+	}
+	if _, err := DecodeWithOptions(mk, DecodeOptions{MaxKeyLength: 32}); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected too long key error, got: %v", err)
+	}
+}
+
+// With Copy set, decoded keys and tags don't alias the encoding, and the
+// limits still apply.
+func TestDecodeIntoCopy(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata.Tags = map[string][]byte{"app": []byte("v")}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Keypair
+	if err := DecodeIntoWithOptions(mk, &got, DecodeOptions{Copy: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(kp) {
+		t.Fatalf("expected %+v, got %+v", kp, got)
+	}
+	clear(mk)
+	if !bytes.Equal(got.Private, kp.Private) || !bytes.Equal(got.Public, kp.Public) ||
+		!bytes.Equal(got.Metadata.Tags["app"], []byte("v")) {
+		t.Error("copied keypair changed with the encoding")
+	}
+
+	public := bytes.Clone(got.Public)
+	_ = append(got.Private, 0xff)
+	if !bytes.Equal(got.Public, public) {
+		t.Error("appending to the private key overwrote the public key")
+	}
+
+	if err := DecodeIntoWithOptions(mk, &got, DecodeOptions{MaxSize: 1, Copy: true}); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected too long error, got: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+//go:build cgo
+
+// go-multikeypair/pkcs11/pkcs11_cgo.go
+//
+// The backend proper, which calls into a PKCS#11 module through
+// miekg/pkcs11 and so needs cgo.
+
+package pkcs11
+
+import (
+	"context"
+	"encoding/asn1"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Constants from PKCS#11 v3.0 that predate our binding.
+const (
+	CKK_EC_EDWARDS = uint(0x40)
+	CKM_EDDSA      = uint(0x1057)
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Module is the subset of the PKCS#11 API the backend uses; *p11.Ctx
+// satisfies it.
+type Module interface {
+	OpenSession(slotID uint, flags uint) (p11.SessionHandle, error)
+	CloseSession(sh p11.SessionHandle) error
+	Login(sh p11.SessionHandle, userType uint, pin string) error
+	FindObjectsInit(sh p11.SessionHandle, temp []*p11.Attribute) error
+	FindObjects(sh p11.SessionHandle, max int) ([]p11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh p11.SessionHandle) error
+	GetAttributeValue(sh p11.SessionHandle, o p11.ObjectHandle, a []*p11.Attribute) ([]*p11.Attribute, error)
+	SignInit(sh p11.SessionHandle, m []*p11.Mechanism, o p11.ObjectHandle) error
+	Sign(sh p11.SessionHandle, message []byte) ([]byte, error)
+}
+
+// Backend signs with keys held on a PKCS#11 token. Sessions are opened
+// and logged in on first use of a slot. PKCS#11 sessions can't be shared
+// between concurrent operations, so a Backend serializes its calls.
+type Backend struct {
+	module Module
+	pin    string
+	ctx    *p11.Ctx
+
+	mu       sync.Mutex
+	sessions map[uint]p11.SessionHandle
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// New returns a backend that uses an initialized module, logging in to
+// tokens with pin.
+func New(module Module, pin string) *Backend {
+	return &Backend{
+		module:   module,
+		pin:      pin,
+		sessions: make(map[uint]p11.SessionHandle),
+	}
+}
+
+// Open loads and initializes the PKCS#11 module at path, e.g. a vendor's
+// libcknfast.so or SoftHSM's libsofthsm2.so.
+func Open(path string, pin string) (*Backend, error) {
+	ctx := p11.New(path)
+	if ctx == nil {
+		return nil, ErrModuleLoad
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+	b := New(ctx, pin)
+	b.ctx = ctx
+	return b, nil
+}
+
+// Close closes any open sessions and, for a backend created by Open,
+// finalizes and unloads the module.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	for slot, sh := range b.sessions {
+		if e := b.module.CloseSession(sh); e != nil && err == nil {
+			err = e
+		}
+		delete(b.sessions, slot)
+	}
+	if b.ctx != nil {
+		if e := b.ctx.Finalize(); e != nil && err == nil {
+			err = e
+		}
+		b.ctx.Destroy()
+		b.ctx = nil
+	}
+	return err
+}
+
+// PublicKey reads the public key object named by a PKCS#11 URI.
+func (b *Backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	o, err := parseResource(resource)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sh, err := b.session(o.Slot)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	handle, err := b.find(sh, o, p11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	attrs, err := b.module.GetAttributeValue(sh, handle, []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	public, err := ecPoint(attrs[0].Value)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	m, err := multikeypair.Encode(nil, public, multikeypair.ED_25519)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	return multikeypair.Decode(m)
+}
+
+// Sign signs message on the token with the private key object named by
+// a PKCS#11 URI.
+func (b *Backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	o, err := parseResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sh, err := b.session(o.Slot)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := b.find(sh, o, p11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, err
+	}
+	mechanism := []*p11.Mechanism{p11.NewMechanism(CKM_EDDSA, nil)}
+	if err := b.module.SignInit(sh, mechanism, handle); err != nil {
+		return nil, err
+	}
+	return b.module.Sign(sh, message)
+}
+
+// Return the logged-in session for a slot, opening one if needed.
+func (b *Backend) session(slot uint) (p11.SessionHandle, error) {
+	if sh, ok := b.sessions[slot]; ok {
+		return sh, nil
+	}
+	sh, err := b.module.OpenSession(slot, p11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return 0, err
+	}
+	if b.pin != "" {
+		err := b.module.Login(sh, p11.CKU_USER, b.pin)
+		if err != nil && err != p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN) {
+			b.module.CloseSession(sh)
+			return 0, err
+		}
+	}
+	b.sessions[slot] = sh
+	return sh, nil
+}
+
+// Find the single Ed25519 key object of a class matching an Object.
+func (b *Backend) find(sh p11.SessionHandle, o Object, class uint) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, CKK_EC_EDWARDS),
+	}
+	if o.Label != "" {
+		template = append(template, p11.NewAttribute(p11.CKA_LABEL, o.Label))
+	}
+	if o.ID != nil {
+		template = append(template, p11.NewAttribute(p11.CKA_ID, o.ID))
+	}
+
+	if err := b.module.FindObjectsInit(sh, template); err != nil {
+		return 0, err
+	}
+	handles, _, err := b.module.FindObjects(sh, 2)
+	if e := b.module.FindObjectsFinal(sh); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch len(handles) {
+	case 0:
+		return 0, ErrObjectNotFound
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, ErrAmbiguous
+	}
+}
+
+// Unwrap an Ed25519 CKA_EC_POINT. The standard form is a DER OCTET
+// STRING, but some tokens store the raw point.
+func ecPoint(value []byte) ([]byte, error) {
+	if len(value) == 32 {
+		return value, nil
+	}
+	var point []byte
+	rest, err := asn1.Unmarshal(value, &point)
+	if err != nil || len(rest) != 0 || len(point) != 32 {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	return point, nil
+}
@@ -0,0 +1,152 @@
+//go:build cgo
+
+// go-multikeypair/pkcs11/pkcs11_cgo_test.go
+
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"encoding/asn1"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// A Module holding one Ed25519 key pair in memory: handle 1 is the
+// public key object and handle 2 the private key object.
+type fakeModule struct {
+	private  ed25519.PrivateKey
+	label    string
+	id       []byte
+	template []*p11.Attribute
+	signing  p11.ObjectHandle
+	logins   int
+}
+
+func (m *fakeModule) OpenSession(slotID uint, flags uint) (p11.SessionHandle, error) {
+	return p11.SessionHandle(slotID + 1), nil
+}
+
+func (m *fakeModule) CloseSession(sh p11.SessionHandle) error { return nil }
+
+func (m *fakeModule) Login(sh p11.SessionHandle, userType uint, pin string) error {
+	m.logins++
+	if pin != "1234" {
+		return p11.Error(p11.CKR_PIN_INCORRECT)
+	}
+	return nil
+}
+
+func (m *fakeModule) FindObjectsInit(sh p11.SessionHandle, temp []*p11.Attribute) error {
+	m.template = temp
+	return nil
+}
+
+func (m *fakeModule) FindObjects(sh p11.SessionHandle, max int) ([]p11.ObjectHandle, bool, error) {
+	var class []byte
+	for _, a := range m.template {
+		switch a.Type {
+		case p11.CKA_CLASS:
+			class = a.Value
+		case p11.CKA_LABEL:
+			if string(a.Value) != m.label {
+				return nil, false, nil
+			}
+		case p11.CKA_ID:
+			if !bytes.Equal(a.Value, m.id) {
+				return nil, false, nil
+			}
+		}
+	}
+	if bytes.Equal(class, p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PUBLIC_KEY).Value) {
+		return []p11.ObjectHandle{1}, false, nil
+	}
+	return []p11.ObjectHandle{2}, false, nil
+}
+
+func (m *fakeModule) FindObjectsFinal(sh p11.SessionHandle) error { return nil }
+
+func (m *fakeModule) GetAttributeValue(sh p11.SessionHandle, o p11.ObjectHandle, a []*p11.Attribute) ([]*p11.Attribute, error) {
+	point, err := asn1.Marshal([]byte(m.private.Public().(ed25519.PublicKey)))
+	if err != nil {
+		return nil, err
+	}
+	return []*p11.Attribute{p11.NewAttribute(p11.CKA_EC_POINT, point)}, nil
+}
+
+func (m *fakeModule) SignInit(sh p11.SessionHandle, mech []*p11.Mechanism, o p11.ObjectHandle) error {
+	if len(mech) != 1 || mech[0].Mechanism != CKM_EDDSA {
+		return p11.Error(p11.CKR_MECHANISM_INVALID)
+	}
+	m.signing = o
+	return nil
+}
+
+func (m *fakeModule) Sign(sh p11.SessionHandle, message []byte) ([]byte, error) {
+	if m.signing != 2 {
+		return nil, p11.Error(p11.CKR_KEY_TYPE_INCONSISTENT)
+	}
+	return ed25519.Sign(m.private, message), nil
+}
+
+func newFake(t *testing.T) *fakeModule {
+	_, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeModule{private: private, label: "release key", id: []byte{0x01, 0xff}}
+}
+
+// Keys on the token sign through a RemoteKeypair.
+func TestBackendSign(t *testing.T) {
+	module := newFake(t)
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, New(module, "1234")); err != nil {
+		t.Fatal(err)
+	}
+
+	reference := Reference(Object{Slot: 3, Label: module.label, ID: module.id})
+	ctx := context.Background()
+	remote, err := multikeypair.NewRemoteKeypair(ctx, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello")
+	for i := 0; i < 2; i++ {
+		signature, err := remote.SignContext(ctx, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Verify(message, signature); err != nil {
+			t.Error(err)
+		}
+	}
+	if module.logins != 1 {
+		t.Errorf("expected 1 login, got %d", module.logins)
+	}
+
+	// The encoding carries the object reference and public key.
+	s, err := remote.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := multikeypair.RemoteKeypairFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Reference != reference || !decoded.Key.Equal(remote.Key) {
+		t.Error("decoded remote keypair doesn't match")
+	}
+}
+
+// Objects that don't match the URI aren't found.
+func TestBackendNotFound(t *testing.T) {
+	b := New(newFake(t), "1234")
+	_, err := b.PublicKey(context.Background(), "slot-id=0;object=other")
+	if err != ErrObjectNotFound {
+		t.Errorf("expected ErrObjectNotFound, got %v", err)
+	}
+}
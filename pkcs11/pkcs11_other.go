@@ -0,0 +1,37 @@
+//go:build !cgo
+
+// go-multikeypair/pkcs11/pkcs11_other.go
+//
+// Loading a PKCS#11 module needs cgo.
+
+package pkcs11
+
+import (
+	"context"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Backend signs with keys held on a PKCS#11 token. Without cgo there is
+// no way to load a module, so none can be created.
+type Backend struct{}
+
+// Open fails with ErrUnsupported, since loading a module needs cgo.
+func Open(path string, pin string) (*Backend, error) {
+	return nil, ErrUnsupported
+}
+
+// Close does nothing.
+func (b *Backend) Close() error {
+	return nil
+}
+
+// PublicKey fails with ErrUnsupported.
+func (b *Backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	return multikeypair.Keypair{}, ErrUnsupported
+}
+
+// Sign fails with ErrUnsupported.
+func (b *Backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
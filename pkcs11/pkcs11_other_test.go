@@ -0,0 +1,22 @@
+//go:build !cgo
+
+// go-multikeypair/pkcs11/pkcs11_other_test.go
+
+package pkcs11
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Without cgo, modules can't be loaded and the backend refuses to work.
+func TestUnsupported(t *testing.T) {
+	if _, err := Open("libsofthsm2.so", "1234"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	var b Backend
+	if _, err := b.Sign(context.Background(), "slot-id=0;object=x", []byte("hello")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
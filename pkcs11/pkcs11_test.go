@@ -0,0 +1,37 @@
+// go-multikeypair/pkcs11/pkcs11_test.go
+
+package pkcs11
+
+import (
+	"bytes"
+	"testing"
+)
+
+// References round trip through RFC 7512 URIs.
+func TestReference(t *testing.T) {
+	o := Object{Slot: 7, Label: "my key;1", ID: []byte{0x00, 'a', 0xfe}}
+	reference := Reference(o)
+	if reference != "pkcs11:slot-id=7;object=my%20key%3B1;id=%00a%FE" {
+		t.Errorf("unexpected reference %q", reference)
+	}
+	parsed, err := ParseReference(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Slot != o.Slot || parsed.Label != o.Label || !bytes.Equal(parsed.ID, o.ID) {
+		t.Errorf("expected %+v, got %+v", o, parsed)
+	}
+
+	for _, bad := range []string{
+		"pkcs11:object=x",
+		"pkcs11:slot-id=0",
+		"pkcs11:slot-id=0;object=x?pin-value=1234",
+		"pkcs11:slot-id=0;object=x;object=y",
+		"pkcs11:slot-id=0;serial=1;object=x",
+		"other:slot-id=0;object=x",
+	} {
+		if _, err := ParseReference(bad); err != ErrInvalidURI {
+			t.Errorf("%q: expected ErrInvalidURI, got %v", bad, err)
+		}
+	}
+}
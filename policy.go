@@ -0,0 +1,107 @@
+// go-multikeypair/policy.go
+//
+// A process-wide policy restricting the ciphers the module will work
+// with, for deployments that must rule some out, e.g. FIPS-constrained
+// services, or production systems that should never see identity keys.
+// Once a policy is set, every operation that checks a cipher code
+// refuses ciphers it forbids: encoding, decoding (Decode, Inspect, and
+// friends), generation, signing and verification. Refusals wrap
+// ErrPolicyViolation in a PolicyError naming the cipher.
+
+package multikeypair
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Policy-specific errors this module exports.
+var (
+	ErrPolicyViolation = errors.New("cipher not permitted by policy")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Policy lists the ciphers that may be used. The zero value permits
+// every cipher.
+type Policy struct {
+	// Allow lists the permitted cipher codes. Empty permits every cipher
+	// that isn't denied.
+	Allow []uint64
+	// Deny lists forbidden cipher codes. It takes precedence over Allow.
+	Deny []uint64
+}
+
+// PolicyError reports a cipher forbidden by the policy.
+type PolicyError struct {
+	// Code of the forbidden cipher.
+	Code uint64
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   *Policy
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Error names the forbidden cipher.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("multikeypair: %s (0x%x): %v", codeName(e.Code), e.Code, ErrPolicyViolation)
+}
+
+// Unwrap returns ErrPolicyViolation.
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// FIPSPolicy returns a policy permitting only the ciphers approved by
+// FIPS 186-5, FIPS 203 and FIPS 204: Ed25519, P-256, RSA, ML-DSA-65 and
+// ML-KEM-768. It says nothing about whether the implementations in use
+// are validated.
+func FIPSPolicy() Policy {
+	return Policy{Allow: []uint64{ED_25519, P_256, RSA, ML_DSA_65, ML_KEM_768}}
+}
+
+// Check returns a PolicyError if the policy forbids a cipher.
+func (p Policy) Check(code uint64) error {
+	if slices.Contains(p.Deny, code) || (len(p.Allow) != 0 && !slices.Contains(p.Allow, code)) {
+		return &PolicyError{Code: code}
+	}
+	return nil
+}
+
+// SetPolicy installs a process-wide cipher policy, replacing any
+// previous one. The policy is copied, so later changes to it have no
+// effect until it is set again.
+func SetPolicy(p Policy) {
+	p.Allow, p.Deny = slices.Clone(p.Allow), slices.Clone(p.Deny)
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	policy = &p
+}
+
+// ClearPolicy removes the process-wide cipher policy, permitting every
+// cipher again.
+func ClearPolicy() {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	policy = nil
+}
+
+// Check a cipher code against the process-wide policy, if there is one.
+func checkPolicy(code uint64) error {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	if policy == nil {
+		return nil
+	}
+	return policy.Check(code)
+}
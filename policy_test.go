@@ -0,0 +1,71 @@
+// go-multikeypair/policy_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"testing"
+)
+
+// A policy forbids ciphers for generation, encoding and decoding alike,
+// and clearing it permits them again.
+func TestPolicy(t *testing.T) {
+	k, err := Generate(SECP_256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk, err := k.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetPolicy(FIPSPolicy())
+	defer ClearPolicy()
+
+	_, err = Generate(SECP_256K1)
+	var pe *PolicyError
+	if !errors.Is(err, ErrPolicyViolation) || !errors.As(err, &pe) || pe.Code != SECP_256K1 {
+		t.Errorf("generate: unexpected error %v", err)
+	}
+	if got := pe.Error(); got != "multikeypair: secp256k1 (0x99): cipher not permitted by policy" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if _, err := k.Encode(); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("encode: expected ErrPolicyViolation, got %v", err)
+	}
+	_, err = mk.Decode()
+	var de *DecodeError
+	if !errors.Is(err, ErrPolicyViolation) || !errors.As(err, &de) || de.Field != FIELD_CODE {
+		t.Errorf("decode: unexpected error %v", err)
+	}
+	if _, err := Generate(ED_25519); err != nil {
+		t.Errorf("permitted cipher refused: %v", err)
+	}
+
+	ClearPolicy()
+	if _, err := mk.Decode(); err != nil {
+		t.Errorf("cleared policy still applies: %v", err)
+	}
+}
+
+// Denied ciphers are refused even when allowed, and the policy is
+// copied when set.
+func TestPolicyDeny(t *testing.T) {
+	p := Policy{Allow: []uint64{ED_25519, IDENTITY}, Deny: []uint64{IDENTITY}}
+	if err := p.Check(IDENTITY); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("expected ErrPolicyViolation, got %v", err)
+	}
+	if err := p.Check(ED_25519); err != nil {
+		t.Error(err)
+	}
+	if err := (Policy{}).Check(DSA); err != nil {
+		t.Errorf("zero policy refused a cipher: %v", err)
+	}
+
+	SetPolicy(p)
+	defer ClearPolicy()
+	p.Allow[0] = X_25519
+	if _, err := Generate(ED_25519); err != nil {
+		t.Errorf("policy wasn't copied: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// go-multikeypair/possession.go
+//
+// Proof of possession: a service that's been shown a public key sends a
+// fresh random challenge, and the client proves it controls the private
+// key by signing it. The signature covers the key itself as well as the
+// challenge, under a label of its own, so that it can't be mistaken for
+// (or replayed as) an ordinary signature or a proof for another key:
+//
+//	"multikeypair possession v1" || 0x00 || uvarint(code) ||
+//	uvarint(public key length) || public key || challenge
+//
+// Only ciphers that can sign can prove possession this way.
+
+package multikeypair
+
+import (
+	crypto_rand "crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Possession-specific errors this module exports.
+var (
+	ErrInvalidChallenge = errors.New("challenge is too short")
+)
+
+// Length of the challenges NewChallenge makes.
+const CHALLENGE_LENGTH = 32
+
+// Shortest challenge accepted, so that challenges can't be guessed.
+const MIN_CHALLENGE_LENGTH = 16
+
+// Label separating possession proofs from other signatures.
+const possessionLabel = "multikeypair possession v1\x00"
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// NewChallenge returns a fresh random challenge for a proof of
+// possession. Challenges must be used once only.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, CHALLENGE_LENGTH)
+	if _, err := crypto_rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// ProvePossession signs a challenge to prove possession of the private
+// key. Keys whose usage doesn't include USAGE_AUTHENTICATE, or that are
+// outside their validity window, are refused.
+func (k Keypair) ProvePossession(challenge []byte) ([]byte, error) {
+	if err := k.checkUsage(USAGE_AUTHENTICATE); err != nil {
+		return nil, err
+	}
+	if err := k.checkValid(); err != nil {
+		return nil, err
+	}
+	if len(challenge) < MIN_CHALLENGE_LENGTH {
+		return nil, ErrInvalidChallenge
+	}
+	s, err := lookupScheme(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	if s.sign == nil {
+		return nil, ErrUnsupportedCipher
+	}
+	if len(k.Private) == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	private, release := k.privateKey()
+	defer release()
+	return s.sign(private, possessionMessage(k, challenge))
+}
+
+// VerifyPossession checks a proof made by ProvePossession that the
+// holder of public's private key signed challenge. It's up to the caller
+// to check that the challenge is one it issued and hasn't seen before.
+func VerifyPossession(public Keypair, challenge []byte, proof []byte) error {
+	if err := public.checkUsage(USAGE_AUTHENTICATE); err != nil {
+		return err
+	}
+	if len(challenge) < MIN_CHALLENGE_LENGTH {
+		return ErrInvalidChallenge
+	}
+	s, err := lookupScheme(public.Code)
+	if err != nil {
+		return err
+	}
+	if s.verify == nil {
+		return ErrUnsupportedCipher
+	}
+	return s.verify(public.Public, possessionMessage(public, challenge), proof)
+}
+
+// The message signed to prove possession of a key.
+func possessionMessage(k Keypair, challenge []byte) []byte {
+	message := binary.AppendUvarint([]byte(possessionLabel), k.Code)
+	message = binary.AppendUvarint(message, uint64(len(k.Public)))
+	message = append(message, k.Public...)
+	return append(message, challenge...)
+}
@@ -0,0 +1,109 @@
+// go-multikeypair/possession_test.go
+
+package multikeypair
+
+import (
+	"errors"
+	"testing"
+)
+
+// Proofs of possession verify against the public key and the challenge
+// they were made for, and against nothing else.
+func TestProvePossession(t *testing.T) {
+	for _, code := range []uint64{ED_25519, P_256, SECP_256K1, ML_DSA_65, ED_25519_ML_DSA_65} {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		challenge, err := NewChallenge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := k.ProvePossession(challenge)
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		if err := VerifyPossession(k.publicOnly(), challenge, proof); err != nil {
+			t.Errorf("%s: %v", k.Name, err)
+		}
+
+		other, err := NewChallenge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyPossession(k.publicOnly(), other, proof); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: other challenge: %v", k.Name, err)
+		}
+		impostor, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyPossession(impostor.publicOnly(), challenge, proof); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: other key: %v", k.Name, err)
+		}
+
+		// A proof isn't a signature over the challenge, nor the reverse.
+		if err := k.Verify(challenge, proof); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: proof verified as signature: %v", k.Name, err)
+		}
+		signature, err := k.Sign(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyPossession(k, challenge, signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: signature verified as proof: %v", k.Name, err)
+		}
+	}
+}
+
+// Short challenges, ciphers that can't sign and keys not usable for
+// authentication are refused.
+func TestProvePossessionRefused(t *testing.T) {
+	k, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.ProvePossession(make([]byte, MIN_CHALLENGE_LENGTH-1)); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("expected ErrInvalidChallenge, got %v", err)
+	}
+	if err := VerifyPossession(k, nil, nil); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("expected ErrInvalidChallenge, got %v", err)
+	}
+
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x25519, err := Generate(X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x25519.ProvePossession(challenge); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	if _, err := k.publicOnly().ProvePossession(challenge); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+
+	k.Metadata.Usage = USAGE_SIGN
+	if _, err := k.ProvePossession(challenge); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
+
+// Keys in locked memory prove possession like any other.
+func TestProvePossessionLocked(t *testing.T) {
+	k := generateLocked(t)
+	defer k.Wipe()
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := k.ProvePossession(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPossession(k.publicOnly(), challenge, proof); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,86 @@
+// go-multikeypair/qr/qr.go
+//
+// QR code rendering of multikeypairs for air-gapped transfer and paper
+// backups. The QR code holds the base58 string form, so anything that
+// scans it can hand the text straight to MultikeypairFromB58.
+
+package qr
+
+import (
+	"errors"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// QR-specific errors this package exports.
+var (
+	ErrTooLarge = errors.New("multikeypair too large for a qr code")
+)
+
+// DEFAULT_SIZE is the width and height in pixels of rendered PNG images.
+const DEFAULT_SIZE = 512
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// PNG renders a Multikeypair, including its private key, as a QR code PNG
+// image of size by size pixels. A size of zero uses DEFAULT_SIZE.
+func PNG(m multikeypair.Multikeypair, size int) ([]byte, error) {
+	q, err := newCode(m)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		size = DEFAULT_SIZE
+	}
+	return q.PNG(size)
+}
+
+// Terminal renders a Multikeypair, including its private key, as a block
+// of Unicode characters suitable for printing to a terminal.
+func Terminal(m multikeypair.Multikeypair) (string, error) {
+	q, err := newCode(m)
+	if err != nil {
+		return "", err
+	}
+	return q.ToSmallString(false), nil
+}
+
+// PublicPNG renders the public half of a Keypair as a QR code PNG image.
+func PublicPNG(k multikeypair.Keypair, size int) ([]byte, error) {
+	public, err := publicOnly(k)
+	if err != nil {
+		return nil, err
+	}
+	return PNG(public, size)
+}
+
+// PublicTerminal renders the public half of a Keypair for a terminal.
+func PublicTerminal(k multikeypair.Keypair) (string, error) {
+	public, err := publicOnly(k)
+	if err != nil {
+		return "", err
+	}
+	return Terminal(public)
+}
+
+// Build a QR code holding the base58 form of a Multikeypair.
+func newCode(m multikeypair.Multikeypair) (*qrcode.QRCode, error) {
+	if _, err := m.Decode(); err != nil {
+		return nil, err
+	}
+	q, err := qrcode.New(m.B58String(), qrcode.Medium)
+	if err != nil {
+		return nil, ErrTooLarge
+	}
+	return q, nil
+}
+
+// Encode just the public half of a Keypair.
+func publicOnly(k multikeypair.Keypair) (multikeypair.Multikeypair, error) {
+	return multikeypair.Encode(nil, k.Public, k.Code)
+}
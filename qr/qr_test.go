@@ -0,0 +1,82 @@
+// go-multikeypair/qr/qr_test.go
+
+package qr
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"strings"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Generate a fresh ed25519 Keypair for testing.
+func generate(t *testing.T) multikeypair.Keypair {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := multikeypair.Encode(private, public, multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// Keys render as PNG images and terminal blocks.
+func TestRender(t *testing.T) {
+	kp := generate(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	png, err := PNG(mk, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("not a png image")
+	}
+
+	full, err := Terminal(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := PublicTerminal(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full == "" || public == "" || !strings.Contains(public, "\n") {
+		t.Error("expected terminal output")
+	}
+	// The public-only code holds less data, so it is no larger.
+	if len(public) > len(full) {
+		t.Error("public-only code larger than full code")
+	}
+
+	if _, err := PublicPNG(kp, 128); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Invalid and oversized input is rejected.
+func TestRenderInvalid(t *testing.T) {
+	if _, err := Terminal(multikeypair.Multikeypair{0x01}); err == nil {
+		t.Error("expected invalid multikeypair to be rejected")
+	}
+
+	big, err := multikeypair.Encode(nil, make([]byte, 4096), multikeypair.RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PNG(big, 0); err != ErrTooLarge {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
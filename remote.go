@@ -0,0 +1,352 @@
+// go-multikeypair/remote.go
+//
+// Keypairs whose private half lives in a remote backend such as a cloud
+// KMS (AWS KMS, GCP Cloud KMS, Azure Key Vault) or a remote signer. A
+// RemoteKeypair holds a reference to the key plus a cached copy of its
+// public key, satisfies crypto.Signer, and encodes as a public-only
+// multikeypair with the reference in an extension field:
+//
+//	<scheme>:<resource>
+//
+// e.g. "awskms:arn:aws:kms:us-east-1:111122223333:key/1234abcd-..." or
+// "gcpkms:projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1".
+// Backends are registered under their scheme.
+
+package multikeypair
+
+import (
+	"context"
+	"crypto"
+	"crypto/mldsa"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Remote-specific errors this module exports.
+var (
+	ErrInvalidReference     = errors.New("invalid remote key reference")
+	ErrMissingReference     = errors.New("multikeypair has no remote key reference")
+	ErrUnknownRemoteBackend = errors.New("no backend registered for remote key scheme")
+	ErrRemoteRegistered     = errors.New("remote key scheme already registered")
+	ErrRemoteKeyMismatch    = errors.New("remote key doesn't match cached public key")
+	ErrPrehashedMessage     = errors.New("remote keys sign whole messages, not digests")
+	ErrUnsupportedDigest    = errors.New("remote backend can't sign this digest")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// RemoteBackend performs operations on keys held by a remote service.
+// The resource is the part of a reference after the scheme, e.g. a key
+// ARN or resource name.
+type RemoteBackend interface {
+	// PublicKey fetches the public half of a remote key.
+	PublicKey(ctx context.Context, resource string) (Keypair, error)
+	// Sign asks the backend to sign message with a remote key. The
+	// signature must be the one Keypair.Verify accepts for the key's
+	// cipher.
+	Sign(ctx context.Context, resource string, message []byte) ([]byte, error)
+}
+
+// RemoteDigestSigner is implemented by backends that can also sign a
+// digest the caller has computed, which is how crypto.Signer callers
+// such as crypto/x509 and crypto/tls ask for P-256 and RSA signatures.
+// The signature is ASN.1 DER for P-256 keys and PKCS #1 v1.5 for RSA
+// keys; backends refuse hashes they can't sign with
+// ErrUnsupportedDigest.
+type RemoteDigestSigner interface {
+	SignDigest(ctx context.Context, resource string, digest []byte, hash crypto.Hash) ([]byte, error)
+}
+
+// RemoteKeypair is a keypair whose private half never leaves a remote
+// backend.
+type RemoteKeypair struct {
+	// Reference to the key, of the form <scheme>:<resource>.
+	Reference string
+	// Cached public half of the key, including any metadata.
+	Key Keypair
+
+	backend RemoteBackend
+}
+
+var (
+	remotesMu sync.RWMutex
+	remotes   = map[string]RemoteBackend{}
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// RegisterRemoteBackend makes a backend available for references with
+// the given scheme. Schemes that are already registered cannot be
+// replaced.
+func RegisterRemoteBackend(scheme string, backend RemoteBackend) error {
+	if scheme == "" || strings.Contains(scheme, ":") {
+		return ErrInvalidReference
+	}
+
+	remotesMu.Lock()
+	defer remotesMu.Unlock()
+
+	if _, ok := remotes[scheme]; ok {
+		return ErrRemoteRegistered
+	}
+	remotes[scheme] = backend
+	return nil
+}
+
+// Look up the backend registered for a reference, returning it along
+// with the reference's resource.
+func lookupRemote(reference string) (RemoteBackend, string, error) {
+	scheme, resource, ok := strings.Cut(reference, ":")
+	if !ok || scheme == "" || resource == "" {
+		return nil, "", ErrInvalidReference
+	}
+
+	remotesMu.RLock()
+	defer remotesMu.RUnlock()
+
+	backend, ok := remotes[scheme]
+	if !ok {
+		return nil, "", ErrUnknownRemoteBackend
+	}
+	return backend, resource, nil
+}
+
+// NewRemoteKeypair fetches the public key for reference from its
+// registered backend.
+func NewRemoteKeypair(ctx context.Context, reference string) (RemoteKeypair, error) {
+	backend, resource, err := lookupRemote(reference)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	public, err := backend.PublicKey(ctx, resource)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	if err := validCode(public.Code); err != nil {
+		return RemoteKeypair{}, err
+	}
+	cached := public.publicOnly()
+	cached.Metadata = public.Metadata
+	return RemoteKeypair{
+		Reference: reference,
+		Key:       cached,
+		backend:   backend,
+	}, nil
+}
+
+// Refresh refetches the public key from the backend and checks that it
+// still matches the cached one, so that a key swapped out behind a
+// reference is noticed.
+func (r RemoteKeypair) Refresh(ctx context.Context) error {
+	backend, resource, err := r.resolve()
+	if err != nil {
+		return err
+	}
+	public, err := backend.PublicKey(ctx, resource)
+	if err != nil {
+		return err
+	}
+	if !public.publicOnly().Equal(r.Key.publicOnly()) {
+		return ErrRemoteKeyMismatch
+	}
+	return nil
+}
+
+// SignContext asks the backend to sign message. Keys whose cached usage
+// doesn't include USAGE_SIGN, or whose cached validity window doesn't
+// include now, are refused without a round trip.
+func (r RemoteKeypair) SignContext(ctx context.Context, message []byte) ([]byte, error) {
+	backend, resource, err := r.signer()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Sign(ctx, resource, message)
+}
+
+// SignDigestContext asks the backend to sign a digest made with hash.
+// Only P-256 and RSA keys in backends that implement RemoteDigestSigner
+// can; others are refused with ErrPrehashedMessage. Usage and validity
+// are checked as for SignContext.
+func (r RemoteKeypair) SignDigestContext(ctx context.Context, digest []byte, hash crypto.Hash) ([]byte, error) {
+	if r.Key.Code != P_256 && r.Key.Code != RSA {
+		return nil, ErrPrehashedMessage
+	}
+	if len(digest) != hash.Size() {
+		return nil, ErrUnsupportedDigest
+	}
+	backend, resource, err := r.signer()
+	if err != nil {
+		return nil, err
+	}
+	digestSigner, ok := backend.(RemoteDigestSigner)
+	if !ok {
+		return nil, ErrPrehashedMessage
+	}
+	return digestSigner.SignDigest(ctx, resource, digest, hash)
+}
+
+// Check that the cached key may sign now, and resolve its backend.
+func (r RemoteKeypair) signer() (RemoteBackend, string, error) {
+	if err := r.Key.checkUsage(USAGE_SIGN); err != nil {
+		return nil, "", err
+	}
+	if err := r.Key.checkValid(); err != nil {
+		return nil, "", err
+	}
+	return r.resolve()
+}
+
+// Verify checks a signature using the cached public key.
+func (r RemoteKeypair) Verify(message []byte, signature []byte) error {
+	return r.Key.Verify(message, signature)
+}
+
+// Public implements crypto.Signer. The key is the standard library type
+// for the cipher: ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey or
+// *mldsa.PublicKey, and the raw public key bytes for any other.
+func (r RemoteKeypair) Public() crypto.PublicKey {
+	if r.Key.Code == ML_DSA_65 {
+		if pk, err := mldsa.NewPublicKey(mldsa.MLDSA65(), r.Key.Public); err == nil {
+			return pk
+		}
+	}
+	if pk, err := r.Key.cryptoPublicKey(); err == nil {
+		return pk
+	}
+	return r.Key.Public
+}
+
+// Sign implements crypto.Signer; rand is unused since the backend
+// supplies its own randomness. Without a hash in opts, message is a
+// whole message, signed as by SignContext. With one, as crypto/x509 and
+// crypto/tls pass, it is a digest, signed as by SignDigestContext;
+// RSA-PSS isn't supported.
+func (r RemoteKeypair) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() == 0 {
+		return r.SignContext(context.Background(), message)
+	}
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, ErrUnsupportedDigest
+	}
+	return r.SignDigestContext(context.Background(), message, opts.HashFunc())
+}
+
+// Resolve the backend for a RemoteKeypair, preferring the one it was
+// created with over the registry.
+func (r RemoteKeypair) resolve() (RemoteBackend, string, error) {
+	if r.backend == nil {
+		return lookupRemote(r.Reference)
+	}
+	_, resource, ok := strings.Cut(r.Reference, ":")
+	if !ok || resource == "" {
+		return nil, "", ErrInvalidReference
+	}
+	return r.backend, resource, nil
+}
+
+//
+// ENCODE
+//
+
+// Encode packs a RemoteKeypair into a public-only multikeypair carrying
+// the reference in a TAG_REFERENCE field.
+func (r RemoteKeypair) Encode() (Multikeypair, error) {
+	if _, _, ok := strings.Cut(r.Reference, ":"); !ok {
+		return Multikeypair{}, ErrInvalidReference
+	}
+	public := r.Key.publicOnly()
+	public.Metadata = r.Key.Metadata
+	m, err := public.Encode()
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	b, err := appendExtensions(m, extension{TAG_REFERENCE, []byte(r.Reference)})
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	return Multikeypair(b), nil
+}
+
+// B58String generates a base58-encoded version of a RemoteKeypair.
+func (r RemoteKeypair) B58String() (string, error) {
+	m, err := r.Encode()
+	if err != nil {
+		return "", err
+	}
+	return m.B58String(), nil
+}
+
+//
+// DECODE
+//
+
+// DecodeRemoteKeypair unpacks a multikeypair written by
+// RemoteKeypair.Encode. The backend for its reference must be
+// registered; the cached public key is used as-is, so call Refresh to
+// check it against the backend.
+func DecodeRemoteKeypair(m Multikeypair) (RemoteKeypair, error) {
+	kp, err := decodeKeypair(m)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	if len(kp.Private) != 0 {
+		return RemoteKeypair{}, ErrInvalidReference
+	}
+	_, _, _, rest, err := splitKeypair(m)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	reference, err := findReference(rest)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	backend, _, err := lookupRemote(reference)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	return RemoteKeypair{
+		Reference: reference,
+		Key:       *kp,
+		backend:   backend,
+	}, nil
+}
+
+// RemoteKeypairFromB58 parses a base58-encoded RemoteKeypair.
+func RemoteKeypairFromB58(s string) (RemoteKeypair, error) {
+	m, err := MultikeypairFromB58(s)
+	if err != nil {
+		return RemoteKeypair{}, err
+	}
+	return DecodeRemoteKeypair(m)
+}
+
+// Find the single TAG_REFERENCE field among the extension fields.
+func findReference(rest []byte) (string, error) {
+	fields, ok := splitExtensions(rest)
+	if !ok {
+		return "", ErrInvalidReference
+	}
+	var reference []byte
+	found := false
+	for _, f := range fields {
+		if f.tag != TAG_REFERENCE {
+			continue
+		}
+		if found {
+			return "", ErrInvalidReference
+		}
+		reference, found = f.value, true
+	}
+	if !found {
+		return "", ErrMissingReference
+	}
+	return string(reference), nil
+}
@@ -0,0 +1,244 @@
+// go-multikeypair/remote_test.go
+
+package multikeypair
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+)
+
+// A RemoteBackend that signs with keys held in memory.
+type memoryBackend struct {
+	keys map[string]Keypair
+}
+
+func (b *memoryBackend) PublicKey(ctx context.Context, resource string) (Keypair, error) {
+	kp, ok := b.keys[resource]
+	if !ok {
+		return Keypair{}, ErrInvalidReference
+	}
+	return kp, nil
+}
+
+func (b *memoryBackend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	kp, ok := b.keys[resource]
+	if !ok {
+		return nil, ErrInvalidReference
+	}
+	return kp.Sign(message)
+}
+
+func (b *memoryBackend) SignDigest(ctx context.Context, resource string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	kp, ok := b.keys[resource]
+	if !ok {
+		return nil, ErrInvalidReference
+	}
+	sk, err := kp.cryptoPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(crypto_rand.Reader, digest, hash)
+}
+
+// Register a memory backend under a scheme unique to the test.
+func registerMemory(t *testing.T, keys map[string]Keypair) (*memoryBackend, string) {
+	backend := &memoryBackend{keys: keys}
+	scheme := "test-" + t.Name()
+	if err := RegisterRemoteBackend(scheme, backend); err != nil {
+		t.Fatal(err)
+	}
+	return backend, scheme
+}
+
+// A RemoteKeypair signs through its backend and satisfies crypto.Signer.
+func TestRemoteKeypairSign(t *testing.T) {
+	kp := generateEd25519(t)
+	_, scheme := registerMemory(t, map[string]Keypair{"key/1": kp})
+
+	remote, err := NewRemoteKeypair(context.Background(), scheme+":key/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remote.Key.Private) != 0 {
+		t.Error("cached key holds private material")
+	}
+
+	var signer crypto.Signer = remote
+	message := []byte("hello")
+	signature, err := signer.Sign(nil, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, ok := signer.Public().(ed25519.PublicKey)
+	if !ok || !ed25519.Verify(public, message, signature) {
+		t.Error("signature doesn't verify with crypto.Signer public key")
+	}
+	if err := remote.Verify(message, signature); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := signer.Sign(nil, message, crypto.SHA256); err != ErrPrehashedMessage {
+		t.Errorf("expected ErrPrehashedMessage, got %v", err)
+	}
+}
+
+// P-256 and RSA remote keys sign digests for standard library callers
+// such as x509, and their public keys are the standard library types.
+func TestRemoteKeypairSignDigest(t *testing.T) {
+	p256, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := Generate(RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, scheme := registerMemory(t, map[string]Keypair{"p256": p256, "rsa": rsaKey})
+
+	for _, resource := range []string{"p256", "rsa"} {
+		remote, err := NewRemoteKeypair(context.Background(), scheme+":"+resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: resource}}
+		der, err := x509.CreateCertificateRequest(crypto_rand.Reader, template, remote)
+		if err != nil {
+			t.Fatalf("%s: %v", resource, err)
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := csr.CheckSignature(); err != nil {
+			t.Errorf("%s: %v", resource, err)
+		}
+
+		message := []byte("hello")
+		signature, err := remote.Sign(nil, message, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", resource, err)
+		}
+	}
+
+	remote, err := NewRemoteKeypair(context.Background(), scheme+":rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := make([]byte, 32)
+	if _, err := remote.Sign(nil, digest, &rsa.PSSOptions{Hash: crypto.SHA256}); err != ErrUnsupportedDigest {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+	if _, err := remote.Sign(nil, digest[:20], crypto.SHA256); err != ErrUnsupportedDigest {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+}
+
+// A RemoteKeypair survives an encode/decode round trip.
+func TestRemoteKeypairEncode(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{Label: "release"}
+	_, scheme := registerMemory(t, map[string]Keypair{"arn:aws:kms:key/1": kp})
+	reference := scheme + ":arn:aws:kms:key/1"
+
+	remote, err := NewRemoteKeypair(context.Background(), reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := remote.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := RemoteKeypairFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Reference != reference {
+		t.Errorf("expected reference %q, got %q", reference, decoded.Reference)
+	}
+	if !decoded.Key.Equal(remote.Key) || decoded.Key.Metadata.Label != "release" {
+		t.Error("cached public key doesn't match")
+	}
+	if err := decoded.Refresh(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	// The encoding is an ordinary public-only multikeypair too.
+	m, err := remote.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeWithOptions(m, DecodeOptions{Strict: true}); err != nil {
+		t.Error(err)
+	}
+}
+
+// Decoding fails without a reference or a registered backend.
+func TestDecodeRemoteKeypairErrors(t *testing.T) {
+	kp := generateEd25519(t)
+	m, err := kp.publicOnly().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeRemoteKeypair(m); err != ErrMissingReference {
+		t.Errorf("expected ErrMissingReference, got %v", err)
+	}
+
+	remote := RemoteKeypair{Reference: "nowhere:key", Key: kp.publicOnly()}
+	m, err = remote.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeRemoteKeypair(m); err != ErrUnknownRemoteBackend {
+		t.Errorf("expected ErrUnknownRemoteBackend, got %v", err)
+	}
+}
+
+// Refresh notices a key swapped out behind a reference.
+func TestRemoteKeypairRefresh(t *testing.T) {
+	backend, scheme := registerMemory(t, map[string]Keypair{"key": generateEd25519(t)})
+
+	remote, err := NewRemoteKeypair(context.Background(), scheme+":key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.keys["key"] = generateEd25519(t)
+	if err := remote.Refresh(context.Background()); err != ErrRemoteKeyMismatch {
+		t.Errorf("expected ErrRemoteKeyMismatch, got %v", err)
+	}
+}
+
+// Cached usage restrictions are enforced before contacting the backend.
+func TestRemoteKeypairUsage(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata.Usage = USAGE_ENCRYPT
+	_, scheme := registerMemory(t, map[string]Keypair{"key": kp})
+
+	remote, err := NewRemoteKeypair(context.Background(), scheme+":key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.SignContext(context.Background(), []byte("x")); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+}
+
+// Schemes can only be registered once.
+func TestRegisterRemoteBackend(t *testing.T) {
+	_, scheme := registerMemory(t, nil)
+	if err := RegisterRemoteBackend(scheme, &memoryBackend{}); err != ErrRemoteRegistered {
+		t.Errorf("expected ErrRemoteRegistered, got %v", err)
+	}
+	if err := RegisterRemoteBackend("bad:scheme", &memoryBackend{}); err != ErrInvalidReference {
+		t.Errorf("expected ErrInvalidReference, got %v", err)
+	}
+}
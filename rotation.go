@@ -0,0 +1,230 @@
+// go-multikeypair/rotation.go
+//
+// Key rotation for long-lived identities. When a key is replaced, the
+// outgoing key signs a Rotation statement naming its successor; anyone
+// who trusts the original key can then walk the chain of statements to
+// find the current one.
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Rotation-specific errors this module exports.
+var (
+	ErrInvalidRotation = errors.New("input isn't valid rotation")
+	ErrBrokenRotation  = errors.New("rotation chain is broken")
+)
+
+// Domain separation prefix for rotation signatures.
+const rotationDomain = "multikeypair rotation v1\x00"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Rotation is a statement by one key that it has been replaced by another.
+type Rotation struct {
+	// Public key being retired.
+	Previous Keypair
+	// Public key replacing it.
+	Next Keypair
+	// Signature by Previous over the encoded rotation.
+	Signature []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Rotate generates a new Keypair of the given cipher to replace old, and
+// a Rotation signed by old attesting to the change.
+func Rotate(old Keypair, newCode uint64) (Keypair, Rotation, error) {
+	next, err := Generate(newCode)
+	if err != nil {
+		return Keypair{}, Rotation{}, err
+	}
+	r, err := NewRotation(old, next)
+	if err != nil {
+		return Keypair{}, Rotation{}, err
+	}
+	return next, r, nil
+}
+
+// NewRotation has old attest that it is replaced by next, for callers
+// that generate the new key themselves. Only the public half of next is
+// recorded.
+func NewRotation(old Keypair, next Keypair) (Rotation, error) {
+	r := Rotation{
+		Previous: old.publicOnly(),
+		Next:     next.publicOnly(),
+	}
+	payload, err := r.payload()
+	if err != nil {
+		return Rotation{}, err
+	}
+	r.Signature, err = old.Sign(payload)
+	if err != nil {
+		return Rotation{}, err
+	}
+	return r, nil
+}
+
+// Verify checks the signature on a Rotation.
+func (r Rotation) Verify() error {
+	payload, err := r.payload()
+	if err != nil {
+		return err
+	}
+	return r.Previous.Verify(payload, r.Signature)
+}
+
+// VerifyRotations walks a chain of rotations starting at root, where each
+// rotation retires the key introduced by the one before it, and returns
+// the current (public) key. An empty chain returns root itself.
+func VerifyRotations(root Keypair, chain []Rotation) (Keypair, error) {
+	current := root.publicOnly()
+	for _, r := range chain {
+		if r.Previous.Code != current.Code || !bytes.Equal(r.Previous.Public, current.Public) {
+			return Keypair{}, ErrBrokenRotation
+		}
+		if err := r.Verify(); err != nil {
+			return Keypair{}, err
+		}
+		current = r.Next
+	}
+	return current, nil
+}
+
+// Strip the private key from a Keypair.
+func (k Keypair) publicOnly() Keypair {
+	return Keypair{
+		Code:         k.Code,
+		Name:         k.Name,
+		Public:       k.Public,
+		PublicLength: k.PublicLength,
+	}
+}
+
+//
+// ENCODE
+//
+
+// The bytes signed by the outgoing key: the rotation without its
+// signature, behind a domain separation prefix.
+func (r Rotation) payload() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes([]byte(rotationDomain))
+	if err := r.addFields(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes()
+}
+
+// Add the signed fields of a rotation to a builder.
+func (r Rotation) addFields(b *cryptobyte.Builder) error {
+	previous, err := Encode(nil, r.Previous.Public, r.Previous.Code)
+	if err != nil {
+		return err
+	}
+	next, err := Encode(nil, r.Next.Public, r.Next.Code)
+	if err != nil {
+		return err
+	}
+
+	b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(previous)
+	})
+	b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(next)
+	})
+	return nil
+}
+
+// Encode packs a Rotation into bytes with the following form:
+//
+//	[length] (32-bit length prefix)
+//	  [previous length]<previous public multikeypair> (32-bit length prefix)
+//	  [next length]<next public multikeypair> (32-bit length prefix)
+//	  [signature length]<signature> (16-bit length prefix)
+func (r Rotation) Encode() ([]byte, error) {
+	var b cryptobyte.Builder
+	var err error
+
+	b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+		err = r.addFields(b)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(r.Signature)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes()
+}
+
+//
+// DECODE
+//
+
+// DecodeRotation unpacks an encoded Rotation.
+func DecodeRotation(buf []byte) (Rotation, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !readUint32LengthPrefixed(&input, &values) || !input.Empty() {
+		return Rotation{}, ErrInvalidRotation
+	}
+
+	var previous, next, signature cryptobyte.String
+	if !readUint32LengthPrefixed(&values, &previous) ||
+		!readUint32LengthPrefixed(&values, &next) ||
+		!values.ReadUint16LengthPrefixed(&signature) ||
+		!values.Empty() {
+		return Rotation{}, ErrInvalidRotation
+	}
+
+	previousKp, err := Decode(Multikeypair(previous))
+	if err != nil {
+		return Rotation{}, err
+	}
+	nextKp, err := Decode(Multikeypair(next))
+	if err != nil {
+		return Rotation{}, err
+	}
+
+	return Rotation{
+		Previous:  previousKp.publicOnly(),
+		Next:      nextKp.publicOnly(),
+		Signature: signature,
+	}, nil
+}
+
+//
+// Base-58
+//
+
+// B58String generates a base58-encoded version of a Rotation.
+func (r Rotation) B58String() (string, error) {
+	b, err := r.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(b), nil
+}
+
+// RotationFromB58 parses a base58-encoded Rotation.
+func RotationFromB58(s string) (Rotation, error) {
+	b, err := b58.Decode(s)
+	if err != nil {
+		return Rotation{}, ErrInvalidRotation
+	}
+	return DecodeRotation(b)
+}
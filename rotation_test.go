@@ -0,0 +1,79 @@
+// go-multikeypair/rotation_test.go
+
+package multikeypair
+
+import (
+	"testing"
+)
+
+// A chain of rotations leads from the original key to the current one.
+func TestRotations(t *testing.T) {
+	root := generateEd25519(t)
+
+	second, r1, err := Rotate(root, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, r2, err := Rotate(second, ED_25519_ML_DSA_65)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotations survive encoding.
+	s, err := r2.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2, err = RotationFromB58(s); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := VerifyRotations(root, []Rotation{r1, r2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Code != third.Code || string(current.Public) != string(third.Public) {
+		t.Error("chain doesn't lead to the current key")
+	}
+	if len(current.Private) != 0 {
+		t.Error("rotation leaked a private key")
+	}
+
+	if current, err := VerifyRotations(root, nil); err != nil || !current.Equal(root.publicOnly()) {
+		t.Errorf("empty chain: %v", err)
+	}
+}
+
+// Out-of-order, forged, and tampered rotations are rejected.
+func TestRotationsInvalid(t *testing.T) {
+	root := generateEd25519(t)
+	second, r1, err := Rotate(root, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, r2, err := Rotate(second, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyRotations(root, []Rotation{r2, r1}); err != ErrBrokenRotation {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Someone else claims root has rotated to their key.
+	attacker := generateEd25519(t)
+	forged, err := NewRotation(attacker, attacker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Previous = root.publicOnly()
+	if _, err := VerifyRotations(root, []Rotation{forged}); err != ErrInvalidSignature {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tampered := r1
+	tampered.Next = attacker.publicOnly()
+	if err := tampered.Verify(); err != ErrInvalidSignature {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
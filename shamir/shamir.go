@@ -0,0 +1,274 @@
+// go-multikeypair/shamir/shamir.go
+//
+// Shamir secret sharing of the private half of a Keypair, for k-of-n
+// backups. Each byte of the private key is shared independently over
+// GF(2^8). Every share also records the cipher and public key, so any k
+// shares are enough to rebuild the whole Keypair.
+
+package shamir
+
+import (
+	"bytes"
+	crypto_rand "crypto/rand"
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Sharing-specific errors this package exports.
+var (
+	ErrInvalidShare   = errors.New("input isn't valid share")
+	ErrThreshold      = errors.New("threshold must be between 2 and share count, at most 255")
+	ErrNoPrivateKey   = errors.New("keypair has no private key to share")
+	ErrTooFewShares   = errors.New("not enough shares to meet threshold")
+	ErrShareMismatch  = errors.New("shares belong to different keys")
+	ErrDuplicateShare = errors.New("duplicate share index")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Share is one of the n pieces a private key is split into.
+type Share struct {
+	// Cipher type code of the shared keypair.
+	Code uint64
+	// Raw public key of the shared keypair.
+	Public []byte
+	// Number of shares needed to recover the private key.
+	Threshold int
+	// Evaluation point of this share, from 1 to 255.
+	Index int
+	// Share of the private key; as long as the private key.
+	Value []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Split divides the private key of kp into n shares, any k of which
+// recover it. Fewer than k shares reveal nothing about the private key.
+func Split(kp multikeypair.Keypair, n int, k int) ([]Share, error) {
+	if k < 2 || k > n || n > 255 {
+		return nil, ErrThreshold
+	}
+	if len(kp.Private) == 0 {
+		return nil, ErrNoPrivateKey
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{
+			Code:      kp.Code,
+			Public:    bytes.Clone(kp.Public),
+			Threshold: k,
+			Index:     i + 1,
+			Value:     make([]byte, len(kp.Private)),
+		}
+	}
+
+	// One random polynomial of degree k-1 per byte, with the secret
+	// byte as its constant term.
+	coefficients := make([]byte, k)
+	defer clear(coefficients)
+	for j, secret := range kp.Private {
+		if _, err := crypto_rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		coefficients[0] = secret
+		for i := range shares {
+			shares[i].Value[j] = evaluate(coefficients, byte(shares[i].Index))
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine recovers a Keypair from at least threshold shares of it. Only
+// the first threshold shares are used.
+func Combine(shares []Share) (multikeypair.Keypair, error) {
+	if len(shares) == 0 {
+		return multikeypair.Keypair{}, ErrTooFewShares
+	}
+	first := shares[0]
+	if first.Threshold < 2 || len(shares) < first.Threshold {
+		return multikeypair.Keypair{}, ErrTooFewShares
+	}
+	shares = shares[:first.Threshold]
+
+	seen := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if s.Code != first.Code ||
+			s.Threshold != first.Threshold ||
+			len(s.Value) != len(first.Value) ||
+			!bytes.Equal(s.Public, first.Public) {
+			return multikeypair.Keypair{}, ErrShareMismatch
+		}
+		if s.Index < 1 || s.Index > 255 {
+			return multikeypair.Keypair{}, ErrInvalidShare
+		}
+		if seen[s.Index] {
+			return multikeypair.Keypair{}, ErrDuplicateShare
+		}
+		seen[s.Index] = true
+	}
+
+	// Lagrange interpolation at zero.
+	private := make([]byte, len(first.Value))
+	for i, s := range shares {
+		xi := byte(s.Index)
+		basis := byte(1)
+		for j, o := range shares {
+			if i == j {
+				continue
+			}
+			xj := byte(o.Index)
+			basis = mul(basis, mul(xj, inverse(xj^xi)))
+		}
+		for b := range private {
+			private[b] ^= mul(s.Value[b], basis)
+		}
+	}
+
+	mk, err := multikeypair.Encode(private, first.Public, first.Code)
+	clear(private)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	return mk.Decode()
+}
+
+//
+// GF(2^8)
+//
+
+// Evaluate a polynomial at x using Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// Multiply in GF(2^8) with the AES polynomial, without data-dependent
+// branches.
+func mul(a byte, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		carry := -(a >> 7)
+		a = a<<1 ^ carry&0x1b
+		b >>= 1
+	}
+	return p
+}
+
+// Invert a non-zero element: a^254 = a^-1.
+func inverse(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 7; i++ {
+		a = mul(a, a)
+		result = mul(result, a)
+	}
+	return result
+}
+
+//
+// ENCODE
+//
+
+// Encode packs a Share into bytes with the following form:
+//
+//	[length] (24-bit length prefix)
+//	  [code length]<code> (16-bit length prefix, uvarint code)
+//	  <threshold> (8-bit)
+//	  <index> (8-bit)
+//	  [public key length]<public key> (16-bit length prefix)
+//	  [value length]<value> (16-bit length prefix)
+func (s Share) Encode() ([]byte, error) {
+	if s.Threshold < 2 || s.Threshold > 255 || s.Index < 1 || s.Index > 255 {
+		return nil, ErrInvalidShare
+	}
+
+	var b cryptobyte.Builder
+
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(multikeypair.PackCode(s.Code))
+		})
+		b.AddUint8(uint8(s.Threshold))
+		b.AddUint8(uint8(s.Index))
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(s.Public)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(s.Value)
+		})
+	})
+
+	return b.Bytes()
+}
+
+// B58String generates a base58-encoded version of a Share.
+func (s Share) B58String() (string, error) {
+	b, err := s.Encode()
+	if err != nil {
+		return "", err
+	}
+	return b58.Encode(b), nil
+}
+
+//
+// DECODE
+//
+
+// Decode unpacks an encoded Share.
+func Decode(buf []byte) (Share, error) {
+	input := cryptobyte.String(buf)
+
+	var values cryptobyte.String
+	if !input.ReadUint24LengthPrefixed(&values) || !input.Empty() {
+		return Share{}, ErrInvalidShare
+	}
+
+	var code, public, value cryptobyte.String
+	var threshold, index uint8
+	if !values.ReadUint16LengthPrefixed(&code) ||
+		!values.ReadUint8(&threshold) ||
+		!values.ReadUint8(&index) ||
+		!values.ReadUint16LengthPrefixed(&public) ||
+		!values.ReadUint16LengthPrefixed(&value) ||
+		!values.Empty() {
+		return Share{}, ErrInvalidShare
+	}
+	if threshold < 2 || index < 1 {
+		return Share{}, ErrInvalidShare
+	}
+
+	numCode, err := multikeypair.UnpackCode(code)
+	if err != nil {
+		return Share{}, ErrInvalidShare
+	}
+
+	return Share{
+		Code:      numCode,
+		Public:    public,
+		Threshold: int(threshold),
+		Index:     int(index),
+		Value:     value,
+	}, nil
+}
+
+// ShareFromB58 parses a base58-encoded Share.
+func ShareFromB58(s string) (Share, error) {
+	b, err := b58.Decode(s)
+	if err != nil {
+		return Share{}, ErrInvalidShare
+	}
+	return Decode(b)
+}
@@ -0,0 +1,139 @@
+// go-multikeypair/shamir/shamir_test.go
+
+package shamir
+
+import (
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Generate a fresh ed25519 Keypair for testing.
+func generate(t *testing.T) multikeypair.Keypair {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := multikeypair.Encode(private, public, multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// Any k of n shares recover the keypair.
+func TestSplitCombine(t *testing.T) {
+	kp := generate(t)
+	shares, err := Split(kp, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, got %d", len(shares))
+	}
+
+	for _, pick := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var subset []Share
+		for _, i := range pick {
+			subset = append(subset, shares[i])
+		}
+		recovered, err := Combine(subset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !recovered.Equal(kp) {
+			t.Errorf("shares %v recovered the wrong key", pick)
+		}
+	}
+}
+
+// Fewer than k shares don't recover the key.
+func TestCombineTooFew(t *testing.T) {
+	shares, err := Split(generate(t), 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine(shares[:2]); err != ErrTooFewShares {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Combine([]Share{shares[0], shares[0], shares[1]}); err != ErrDuplicateShare {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Shares of different keys can't be mixed.
+func TestCombineMismatch(t *testing.T) {
+	a, err := Split(generate(t), 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Split(generate(t), 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine([]Share{a[0], b[1]}); err != ErrShareMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Shares survive encoding.
+func TestShareEncoding(t *testing.T) {
+	kp := generate(t)
+	shares, err := Split(kp, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []Share
+	for _, s := range shares[1:] {
+		str, err := s.B58String()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ShareFromB58(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, d)
+	}
+	recovered, err := Combine(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recovered.Equal(kp) {
+		t.Error("recovered the wrong key")
+	}
+
+	if _, err := Decode([]byte{0x00, 0x00, 0x01, 0x00}); err != ErrInvalidShare {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Bad parameters are rejected.
+func TestSplitInvalid(t *testing.T) {
+	kp := generate(t)
+	for _, nk := range [][2]int{{3, 1}, {2, 3}, {256, 2}} {
+		if _, err := Split(kp, nk[0], nk[1]); err != ErrThreshold {
+			t.Errorf("n=%d k=%d: unexpected error: %v", nk[0], nk[1], err)
+		}
+	}
+	public := multikeypair.Keypair{Code: kp.Code, Public: kp.Public}
+	if _, err := Split(public, 3, 2); err != ErrNoPrivateKey {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Field arithmetic: every non-zero element has an inverse.
+func TestInverse(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if mul(byte(a), inverse(byte(a))) != 1 {
+			t.Fatalf("bad inverse for %d", a)
+		}
+	}
+}
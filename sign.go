@@ -0,0 +1,518 @@
+// go-multikeypair/sign.go
+//
+// Signing and verification using the key material held in a Keypair.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/mlkem"
+	crypto_rand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1_ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Signing-specific errors this module exports.
+var (
+	ErrUnsupportedCipher = errors.New("operation not supported for cipher")
+	ErrInvalidPrivateKey = errors.New("invalid private key for cipher")
+	ErrInvalidPublicKey  = errors.New("invalid public key for cipher")
+	ErrInvalidSignature  = errors.New("signature verification failed")
+)
+
+// Schemes
+// -----------------------------------------------------------------------------
+
+// scheme collects the operations a cipher supports. Operations a cipher
+// doesn't support are left nil.
+type scheme struct {
+	// Sign a message with a private key.
+	sign func(private []byte, message []byte) ([]byte, error)
+	// Verify a signature over a message with a public key.
+	verify func(public []byte, message []byte, signature []byte) error
+	// Generate a fresh random keypair.
+	generate func() (private []byte, public []byte, err error)
+	// Derive a keypair from seed material, returning errSeedRejected if
+	// the material doesn't make a valid key.
+	fromSeed func(material []byte) (private []byte, public []byte, err error)
+	// Size of the seed material fromSeed takes.
+	seedSize int
+	// Derive the public key from a private key.
+	public func(private []byte) ([]byte, error)
+	// Encrypt a message to a public key.
+	encrypt func(public []byte, plaintext []byte) ([]byte, error)
+	// Decrypt a message with a private key.
+	decrypt func(private []byte, ciphertext []byte) ([]byte, error)
+}
+
+// Ciphers that we know how to operate on.
+var schemes = map[uint64]scheme{
+	ED_25519: {
+		sign:     ed25519Sign,
+		verify:   ed25519Verify,
+		generate: ed25519Generate,
+		fromSeed: ed25519FromSeed,
+		seedSize: ed25519.SeedSize,
+		public:   ed25519PublicFromPrivate,
+	},
+	ML_DSA_65: {
+		sign:     mldsa65Sign,
+		verify:   mldsa65Verify,
+		generate: mldsa65Generate,
+		fromSeed: mldsa65FromSeed,
+		seedSize: mldsa.PrivateKeySize,
+		public:   mldsa65PublicFromPrivate,
+	},
+	P_256: {
+		sign:     p256Sign,
+		verify:   p256Verify,
+		generate: p256Generate,
+		fromSeed: p256FromSeed,
+		seedSize: 32,
+		public:   p256PublicFromPrivate,
+		encrypt:  p256Encrypt,
+		decrypt:  p256Decrypt,
+	},
+	X_25519: {
+		generate: x25519Generate,
+		fromSeed: x25519FromSeed,
+		seedSize: 32,
+		public:   x25519PublicFromPrivate,
+		encrypt:  x25519Encrypt,
+		decrypt:  x25519Decrypt,
+	},
+	SECP_256K1: {
+		sign:     secp256k1Sign,
+		verify:   secp256k1Verify,
+		generate: secp256k1Generate,
+		fromSeed: secp256k1FromSeed,
+		seedSize: secp256k1.PrivKeyBytesLen,
+		public:   secp256k1PublicFromPrivate,
+		encrypt:  secp256k1Encrypt,
+		decrypt:  secp256k1Decrypt,
+	},
+	ML_KEM_768: {
+		generate: mlkem768Generate,
+		fromSeed: mlkem768FromSeed,
+		seedSize: mlkem.SeedSize,
+		public:   mlkem768PublicFromPrivate,
+	},
+	RSA: {
+		sign:     rsaSign,
+		verify:   rsaVerify,
+		generate: rsaGenerate,
+		public:   rsaPublicFromPrivate,
+	},
+}
+
+// Look up the operations supported for a cipher code.
+func lookupScheme(code uint64) (scheme, error) {
+	if err := validCode(code); err != nil {
+		return scheme{}, err
+	}
+	s, ok := schemes[code]
+	if !ok {
+		return scheme{}, ErrUnsupportedCipher
+	}
+	return s, nil
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Sign produces a signature over message using the private key. Keys
+// whose usage doesn't include USAGE_SIGN, or that are outside their
+// validity window, are refused.
+func (k Keypair) Sign(message []byte) ([]byte, error) {
+	if err := k.checkUsage(USAGE_SIGN); err != nil {
+		return nil, err
+	}
+	if err := k.checkValid(); err != nil {
+		return nil, err
+	}
+	s, err := lookupScheme(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	if s.sign == nil {
+		return nil, ErrUnsupportedCipher
+	}
+	private, release := k.privateKey()
+	defer release()
+	return s.sign(private, message)
+}
+
+// Verify checks a signature over message using the public key. Keys
+// whose usage doesn't include USAGE_SIGN can't have made a legitimate
+// signature, so they are refused too.
+func (k Keypair) Verify(message []byte, signature []byte) error {
+	if err := k.checkUsage(USAGE_SIGN); err != nil {
+		return err
+	}
+	s, err := lookupScheme(k.Code)
+	if err != nil {
+		return err
+	}
+	if s.verify == nil {
+		return ErrUnsupportedCipher
+	}
+	return s.verify(k.Public, message, signature)
+}
+
+//
+// ED25519
+//
+
+func ed25519Sign(private []byte, message []byte) ([]byte, error) {
+	if len(private) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	return ed25519.Sign(ed25519.PrivateKey(private), message), nil
+}
+
+func ed25519Verify(public []byte, message []byte, signature []byte) error {
+	if len(public) != ed25519.PublicKeySize {
+		return ErrInvalidPublicKey
+	}
+	if !ed25519.Verify(ed25519.PublicKey(public), message, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func ed25519Generate() ([]byte, []byte, error) {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private, public, nil
+}
+
+func ed25519FromSeed(material []byte) ([]byte, []byte, error) {
+	private := ed25519.NewKeyFromSeed(material)
+	return private, private.Public().(ed25519.PublicKey), nil
+}
+
+// The private key holds the public key after the seed; it must be the
+// one the seed gives.
+func ed25519PublicFromPrivate(private []byte) ([]byte, error) {
+	if len(private) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	public := ed25519.NewKeyFromSeed(private[:ed25519.SeedSize]).Public().(ed25519.PublicKey)
+	if !bytes.Equal(public, private[ed25519.SeedSize:]) {
+		return nil, ErrInvalidPrivateKey
+	}
+	return public, nil
+}
+
+//
+// ML-DSA-65
+//
+
+// The private key is the 32-byte FIPS 204 seed; the public key is the
+// encoded public key.
+
+func mldsa65Sign(private []byte, message []byte) ([]byte, error) {
+	sk, err := mldsa.NewPrivateKey(mldsa.MLDSA65(), private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk.Sign(nil, message, nil)
+}
+
+func mldsa65Verify(public []byte, message []byte, signature []byte) error {
+	pk, err := mldsa.NewPublicKey(mldsa.MLDSA65(), public)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	if err := mldsa.Verify(pk, message, signature, nil); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mldsa65Generate() ([]byte, []byte, error) {
+	sk, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Bytes(), sk.PublicKey().Bytes(), nil
+}
+
+func mldsa65FromSeed(material []byte) ([]byte, []byte, error) {
+	sk, err := mldsa.NewPrivateKey(mldsa.MLDSA65(), material)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Bytes(), sk.PublicKey().Bytes(), nil
+}
+
+func mldsa65PublicFromPrivate(private []byte) ([]byte, error) {
+	sk, err := mldsa.NewPrivateKey(mldsa.MLDSA65(), private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk.PublicKey().Bytes(), nil
+}
+
+//
+// P-256
+//
+
+// ECDSA over NIST P-256 with SHA-256. The private key is the 32-byte
+// scalar, the public key the 65-byte uncompressed point, and signatures
+// are ASN.1 DER, as produced by crypto.Signer implementations.
+
+func p256Sign(private []byte, message []byte) ([]byte, error) {
+	sk, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	digest := sha256.Sum256(message)
+	return ecdsa.SignASN1(crypto_rand.Reader, sk, digest[:])
+}
+
+func p256Verify(public []byte, message []byte, signature []byte) error {
+	pk, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), public)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(pk, digest[:], signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func p256Generate() ([]byte, []byte, error) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	private, err := sk.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	public, err := sk.PublicKey.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return private, public, nil
+}
+
+func p256FromSeed(material []byte) ([]byte, []byte, error) {
+	sk, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), material)
+	if err != nil {
+		return nil, nil, errSeedRejected
+	}
+	public, err := sk.PublicKey.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return material, public, nil
+}
+
+func p256PublicFromPrivate(private []byte) ([]byte, error) {
+	sk, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk.PublicKey.Bytes()
+}
+
+//
+// X25519
+//
+
+// X25519 keys agree on secrets rather than sign. The private key is the
+// 32-byte scalar and the public key the 32-byte u-coordinate.
+
+func x25519Generate() ([]byte, []byte, error) {
+	sk, err := ecdh.X25519().GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Bytes(), sk.PublicKey().Bytes(), nil
+}
+
+func x25519FromSeed(material []byte) ([]byte, []byte, error) {
+	sk, err := ecdh.X25519().NewPrivateKey(material)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Bytes(), sk.PublicKey().Bytes(), nil
+}
+
+func x25519PublicFromPrivate(private []byte) ([]byte, error) {
+	sk, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk.PublicKey().Bytes(), nil
+}
+
+//
+// ML-KEM-768
+//
+
+// ML-KEM keys encapsulate secrets rather than sign. The private key is
+// the 64-byte FIPS 203 seed; the public key is the encapsulation key.
+
+func mlkem768Generate() ([]byte, []byte, error) {
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk.Bytes(), dk.EncapsulationKey().Bytes(), nil
+}
+
+func mlkem768FromSeed(material []byte) ([]byte, []byte, error) {
+	dk, err := mlkem.NewDecapsulationKey768(material)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk.Bytes(), dk.EncapsulationKey().Bytes(), nil
+}
+
+func mlkem768PublicFromPrivate(private []byte) ([]byte, error) {
+	dk, err := mlkem.NewDecapsulationKey768(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return dk.EncapsulationKey().Bytes(), nil
+}
+
+//
+// RSA
+//
+
+// RSASSA-PKCS1-v1_5 with SHA-256. Both keys are PKCS#1 DER.
+
+// Size of generated RSA keys.
+const rsaBits = 2048
+
+func rsaSign(private []byte, message []byte) ([]byte, error) {
+	sk, err := x509.ParsePKCS1PrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	digest := sha256.Sum256(message)
+	return rsa.SignPKCS1v15(nil, sk, crypto.SHA256, digest[:])
+}
+
+func rsaVerify(public []byte, message []byte, signature []byte) error {
+	pk, err := x509.ParsePKCS1PublicKey(public)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	digest := sha256.Sum256(message)
+	if err := rsa.VerifyPKCS1v15(pk, crypto.SHA256, digest[:], signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func rsaGenerate() ([]byte, []byte, error) {
+	sk, err := rsa.GenerateKey(crypto_rand.Reader, rsaBits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x509.MarshalPKCS1PrivateKey(sk), x509.MarshalPKCS1PublicKey(&sk.PublicKey), nil
+}
+
+func rsaPublicFromPrivate(private []byte) ([]byte, error) {
+	sk, err := x509.ParsePKCS1PrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return x509.MarshalPKCS1PublicKey(&sk.PublicKey), nil
+}
+
+//
+// SECP256K1
+//
+
+// ECDSA over secp256k1 with SHA-256, as Bitcoin uses. The private key is
+// the 32-byte scalar, the public key the 33-byte compressed point, and
+// signatures are ASN.1 DER with a low S value. Signing is deterministic
+// (RFC 6979).
+
+func secp256k1Sign(private []byte, message []byte) ([]byte, error) {
+	sk, err := secp256k1PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(message)
+	return secp256k1_ecdsa.Sign(sk, digest[:]).Serialize(), nil
+}
+
+func secp256k1Verify(public []byte, message []byte, signature []byte) error {
+	if len(public) != secp256k1.PubKeyBytesLenCompressed {
+		return ErrInvalidPublicKey
+	}
+	pk, err := secp256k1.ParsePubKey(public)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	sig, err := secp256k1_ecdsa.ParseDERSignature(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(message)
+	if !sig.Verify(digest[:], pk) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func secp256k1Generate() ([]byte, []byte, error) {
+	sk, err := secp256k1.GeneratePrivateKeyFromRand(crypto_rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.Serialize(), sk.PubKey().SerializeCompressed(), nil
+}
+
+func secp256k1FromSeed(material []byte) ([]byte, []byte, error) {
+	sk, err := secp256k1PrivateKey(material)
+	if err != nil {
+		return nil, nil, errSeedRejected
+	}
+	return sk.Serialize(), sk.PubKey().SerializeCompressed(), nil
+}
+
+func secp256k1PublicFromPrivate(private []byte) ([]byte, error) {
+	sk, err := secp256k1PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return sk.PubKey().SerializeCompressed(), nil
+}
+
+// Parse a 32-byte secp256k1 private scalar, refusing zero and
+// out-of-range values.
+func secp256k1PrivateKey(private []byte) (*secp256k1.PrivateKey, error) {
+	if len(private) != secp256k1.PrivKeyBytesLen {
+		return nil, ErrInvalidPrivateKey
+	}
+	sk := secp256k1.PrivKeyFromBytes(private)
+	if sk.Key.IsZero() || !bytes.Equal(sk.Serialize(), private) {
+		return nil, ErrInvalidPrivateKey
+	}
+	return sk, nil
+}
@@ -0,0 +1,152 @@
+// go-multikeypair/sign_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+// Generate a fresh ed25519 Keypair for testing.
+func generateEd25519(t *testing.T) Keypair {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		t.Fatal("can't generate key")
+	}
+	mk, err := Encode(private, public, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := Decode(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// Sign and verify a message with an ed25519 keypair.
+func TestSignVerify(t *testing.T) {
+	kp := generateEd25519(t)
+	message := []byte("Wn3Sf5Ke/3:PA:Tm{KCf59Wg6j%/g*#d")
+
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kp.Verify(message, sig); err != nil {
+		t.Errorf("expected signature to verify: %s", err)
+	}
+	if err := kp.Verify(message[1:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+}
+
+// Sign and verify a message with a P-256 keypair; signatures are ASN.1
+// DER, as crypto/ecdsa expects.
+func TestSignVerifyP256(t *testing.T) {
+	kp, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("Wn3Sf5Ke/3:PA:Tm{KCf59Wg6j%/g*#d")
+
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), kp.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(pk, digest[:], sig) {
+		t.Error("expected signature to verify with crypto/ecdsa")
+	}
+	if err := kp.Verify(message[1:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+}
+
+// Sign and verify a message with a generated RSA keypair.
+func TestSignVerifyRSA(t *testing.T) {
+	kp, err := Generate(RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("Wn3Sf5Ke/3:PA:Tm{KCf59Wg6j%/g*#d")
+
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != rsaBits/8 {
+		t.Errorf("expected %d-byte signature, got %d", rsaBits/8, len(sig))
+	}
+	if err := kp.Verify(message, sig); err != nil {
+		t.Errorf("expected signature to verify: %s", err)
+	}
+	if err := kp.Verify(message[1:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+}
+
+// Ciphers without signing support are rejected.
+func TestSignUnsupported(t *testing.T) {
+	kp := Keypair{Code: IDENTITY, Private: []byte("private"), Public: []byte("public")}
+	if _, err := kp.Sign([]byte("message")); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Fatalf("expected unsupported cipher error, got: %v", err)
+	}
+}
+
+// Malformed key material is rejected rather than causing a panic.
+func TestSignInvalidKey(t *testing.T) {
+	kp := Keypair{Code: ED_25519, Private: []byte("short"), Public: []byte("short")}
+	if _, err := kp.Sign([]byte("message")); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected invalid private key error, got: %v", err)
+	}
+	if err := kp.Verify([]byte("message"), nil); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Errorf("expected invalid public key error, got: %v", err)
+	}
+}
+
+// Sign and verify a message with a secp256k1 keypair; signing is
+// deterministic and out-of-range scalars are refused.
+func TestSignVerifySecp256k1(t *testing.T) {
+	kp, err := Generate(SECP_256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kp.Public) != 33 {
+		t.Fatalf("expected compressed public key, got %d bytes", len(kp.Public))
+	}
+	message := []byte("Wn3Sf5Ke/3:PA:Tm{KCf59Wg6j%/g*#d")
+
+	sig, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, again) {
+		t.Error("expected deterministic signatures")
+	}
+	if err := kp.Verify(message, sig); err != nil {
+		t.Errorf("expected signature to verify: %s", err)
+	}
+	if err := kp.Verify(message[1:], sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got: %v", err)
+	}
+
+	kp.Private = bytes.Repeat([]byte{0xff}, 32)
+	if _, err := kp.Sign(message); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// go-multikeypair/signature.go
+//
+// Conversion of ECDSA signatures between the ASN.1 DER form Sign
+// produces and Verify accepts, and the fixed-size form, the 32-byte
+// big-endian R and S concatenated, that JOSE, COSE and HTTP message
+// signatures use. Signatures of other ciphers have a single form and are
+// passed through unchanged.
+
+package multikeypair
+
+import (
+	"encoding/asn1"
+	"math/big"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// RawSignature converts a signature made with the keypair to its
+// fixed-size form.
+func (k Keypair) RawSignature(signature []byte) ([]byte, error) {
+	if k.Code != P_256 && k.Code != SECP_256K1 {
+		return signature, nil
+	}
+	r, s := new(big.Int), new(big.Int)
+	input := cryptobyte.String(signature)
+	var inner cryptobyte.String
+	if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(r) || !inner.ReadASN1Integer(s) || !inner.Empty() ||
+		r.Sign() < 0 || s.Sign() < 0 || r.BitLen() > 256 || s.BitLen() > 256 {
+		return nil, ErrInvalidSignature
+	}
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	s.FillBytes(raw[32:])
+	return raw, nil
+}
+
+// DERSignature converts a fixed-size signature to the form Verify
+// accepts. secp256k1 signatures are normalized to low S, since Verify
+// refuses high-S ones.
+func (k Keypair) DERSignature(raw []byte) ([]byte, error) {
+	if k.Code != P_256 && k.Code != SECP_256K1 {
+		return raw, nil
+	}
+	if len(raw) != 64 {
+		return nil, ErrInvalidSignature
+	}
+	r := new(big.Int).SetBytes(raw[:32])
+	s := new(big.Int).SetBytes(raw[32:])
+	if k.Code == SECP_256K1 {
+		n := secp256k1.S256().N
+		if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+			s.Sub(n, s)
+		}
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
@@ -0,0 +1,53 @@
+// go-multikeypair/signature_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// ECDSA signatures convert to 64 bytes and back, still verifying.
+func TestRawSignature(t *testing.T) {
+	for _, code := range []uint64{P_256, SECP_256K1} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := kp.Sign([]byte("message"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := kp.RawSignature(signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != 64 {
+			t.Fatalf("%s: raw signature is %d bytes", kp.Name, len(raw))
+		}
+		der, err := kp.DERSignature(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kp.Verify([]byte("message"), der); err != nil {
+			t.Errorf("%s: %v", kp.Name, err)
+		}
+		if _, err := kp.DERSignature(raw[1:]); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: short signature: %v", kp.Name, err)
+		}
+	}
+}
+
+// Signatures of other ciphers pass through unchanged.
+func TestRawSignaturePassthrough(t *testing.T) {
+	kp := generateEd25519(t)
+	signature, err := kp.Sign([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := kp.RawSignature(signature)
+	if err != nil || !bytes.Equal(raw, signature) {
+		t.Fatal("signature changed")
+	}
+}
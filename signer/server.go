@@ -0,0 +1,111 @@
+// go-multikeypair/signer/server.go
+//
+// The signer side of the protocol: answering requests from a keyring.
+// The signer does no authentication of its own, so whoever can reach its
+// socket can sign with its keys; listen on a Unix socket only its
+// clients may open, or behind an authenticating transport.
+
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Serve accepts connections on l and answers requests on each from
+// keys, until accepting fails, e.g. because l was closed. It returns the
+// error from Accept.
+func Serve(l net.Listener, keys keystore.Keyring) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			ServeConn(conn, keys)
+		}()
+	}
+}
+
+// ServeConn answers requests on a single connection until it is closed,
+// which it reports as a nil error, or a frame can't be read. Requests
+// that are framed but malformed are answered with STATUS_FAILED.
+func ServeConn(conn net.Conn, keys keystore.Keyring) error {
+	for {
+		request, err := readFrame(conn)
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeFrame(conn, answer(keys, request)); err != nil {
+			return err
+		}
+	}
+}
+
+// Answer a request with a response body.
+func answer(keys keystore.Keyring, request []byte) []byte {
+	op, id, message, err := decodeRequest(request)
+	if err != nil {
+		return failure(err)
+	}
+	var payload []byte
+	switch op {
+	case OP_PUBLIC_KEY:
+		payload, err = publicKey(keys, id)
+	case OP_SIGN:
+		payload, err = keys.Sign(id, message)
+	default:
+		err = ErrInvalidFrame
+	}
+	if err != nil {
+		return failure(err)
+	}
+	return append([]byte{STATUS_OK}, payload...)
+}
+
+// Encode the public half of a stored key, with its metadata.
+func publicKey(keys keystore.Keyring, id string) ([]byte, error) {
+	m, err := keys.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Wipe()
+	kp, err := m.Decode()
+	if err != nil {
+		return nil, err
+	}
+	defer kp.Wipe()
+	public := bytes.Clone(kp.Public)
+	return multikeypair.Keypair{
+		Code:         kp.Code,
+		Name:         kp.Name,
+		Public:       public,
+		PublicLength: len(public),
+		Metadata:     kp.Metadata,
+	}.Encode()
+}
+
+// The response body for a failed request.
+func failure(err error) []byte {
+	status := byte(STATUS_FAILED)
+	switch {
+	case errors.Is(err, keystore.ErrNotFound), errors.Is(err, keystore.ErrInvalidID):
+		status = STATUS_NOT_FOUND
+	case errors.Is(err, multikeypair.ErrUsageNotPermitted), errors.Is(err, multikeypair.ErrPolicyViolation),
+		errors.Is(err, multikeypair.ErrKeyExpired), errors.Is(err, multikeypair.ErrKeyNotYetValid):
+		status = STATUS_REFUSED
+	}
+	return append([]byte{status}, err.Error()...)
+}
@@ -0,0 +1,68 @@
+// go-multikeypair/signer/server_test.go
+
+package signer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+)
+
+// Malformed requests are answered with STATUS_FAILED; oversized frames
+// end the connection.
+func TestServeConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() {
+		done <- ServeConn(server, keystore.NewMemory(0))
+	}()
+
+	for _, request := range [][]byte{
+		{},
+		{OP_SIGN},
+		{OP_SIGN, 0x00, 0x05, 'k'},
+		{0x7f, 0x00, 0x01, 'k'},
+	} {
+		if err := writeFrame(client, request); err != nil {
+			t.Fatal(err)
+		}
+		response, err := readFrame(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response) == 0 || response[0] != STATUS_FAILED {
+			t.Errorf("request %x: unexpected response %q", request, response)
+		}
+	}
+
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], MAX_FRAME_SIZE+1)
+	if _, err := client.Write(prefix[:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+// Keys that may not be used, or not at this time, are refused rather
+// than reported as failures.
+func TestFailureStatus(t *testing.T) {
+	for err, want := range map[error]byte{
+		keystore.ErrNotFound:              STATUS_NOT_FOUND,
+		multikeypair.ErrUsageNotPermitted: STATUS_REFUSED,
+		multikeypair.ErrKeyExpired:        STATUS_REFUSED,
+		multikeypair.ErrKeyNotYetValid:    STATUS_REFUSED,
+		multikeypair.ErrInvalidPrivateKey: STATUS_FAILED,
+	} {
+		if got := failure(fmt.Errorf("wrapped: %w", err))[0]; got != want {
+			t.Errorf("%v: expected status %d, got %d", err, want, got)
+		}
+	}
+}
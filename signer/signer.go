@@ -0,0 +1,259 @@
+// go-multikeypair/signer/signer.go
+//
+// A minimal protocol for signing with keys held by a separate, hardened
+// process, and a multikeypair.RemoteBackend that speaks it. The signer
+// process serves a keyring (see Serve); clients reach it over a Unix or
+// TCP socket and address keys by their keystore identifier, so private
+// keys never leave the signer:
+//
+//	client := signer.New("unix", "/run/mkp/signer.sock")
+//	multikeypair.RegisterRemoteBackend(signer.SCHEME, client)
+//	remote, err := multikeypair.NewRemoteKeypair(ctx, "mkpsigner:"+id)
+//
+// Requests and responses are frames with a 32-bit big-endian length
+// prefix, exchanged one at a time over a connection:
+//
+//	request:  <op> (8-bit) [resource]<resource> (16-bit length prefix) <message>
+//	response: <status> (8-bit) <payload>
+//
+// OP_PUBLIC_KEY has no message and answers with the key's public half as
+// a multikeypair, metadata included; OP_SIGN answers with the signature
+// over the message. A response with any status other than STATUS_OK
+// carries a human-readable error message as its payload.
+
+package signer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Remote signer errors this package exports.
+var (
+	ErrInvalidFrame  = errors.New("invalid remote signer frame")
+	ErrFrameTooLarge = errors.New("remote signer frame too large")
+	ErrNotFound      = errors.New("remote signer has no such key")
+	ErrRefused       = errors.New("remote signer refused request")
+	ErrSignerFailed  = errors.New("remote signer failed")
+)
+
+// SCHEME is the conventional reference scheme for remote signer keys.
+const SCHEME = "mkpsigner"
+
+// Largest frame either side accepts, in bytes, excluding its length
+// prefix.
+const MAX_FRAME_SIZE = 1 << 20
+
+// Request operations.
+const (
+	OP_PUBLIC_KEY = 0x01
+	OP_SIGN       = 0x02
+)
+
+// Response statuses.
+const (
+	STATUS_OK        = 0x00
+	STATUS_NOT_FOUND = 0x01
+	STATUS_REFUSED   = 0x02
+	STATUS_FAILED    = 0x03
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Client signs with keys held by a remote signer. It keeps a single
+// connection, dialled on first use and redialled after any failure;
+// concurrent requests take turns on it.
+type Client struct {
+	network string
+	address string
+	dialer  net.Dialer
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// New returns a client for the signer listening at address on network,
+// e.g. "unix" or "tcp". Nothing is dialled until the first request.
+func New(network string, address string) *Client {
+	return &Client{network: network, address: address}
+}
+
+// Close closes the client's connection, if it has one. The client
+// redials if it is used again.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// PublicKey fetches the public half of a key from the signer.
+func (c *Client) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	payload, err := c.call(ctx, OP_PUBLIC_KEY, resource, nil)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(payload)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	if len(kp.Private) != 0 {
+		kp.Wipe()
+		return multikeypair.Keypair{}, ErrInvalidFrame
+	}
+	return kp, nil
+}
+
+// Sign asks the signer to sign message with a key.
+func (c *Client) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	return c.call(ctx, OP_SIGN, resource, message)
+}
+
+// Send a request and wait for its response, returning the payload of a
+// successful one.
+func (c *Client) call(ctx context.Context, op uint8, resource string, message []byte) ([]byte, error) {
+	request, err := encodeRequest(op, resource, message)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := c.dialer.DialContext(ctx, c.network, c.address)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	response, err := c.roundTrip(ctx, request)
+	if err != nil {
+		// The connection may be left mid-frame, so start afresh.
+		c.conn.Close()
+		c.conn = nil
+		// Deadlines only ever come from the context, whose own timer
+		// may not have fired yet.
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if len(response) == 0 {
+		return nil, ErrInvalidFrame
+	}
+	status, payload := response[0], response[1:]
+	switch status {
+	case STATUS_OK:
+		return payload, nil
+	case STATUS_NOT_FOUND:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, payload)
+	case STATUS_REFUSED:
+		return nil, fmt.Errorf("%w: %s", ErrRefused, payload)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSignerFailed, payload)
+}
+
+// Write a request frame and read the response frame, giving up when the
+// context is done.
+func (c *Client) roundTrip(ctx context.Context, request []byte) ([]byte, error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	conn := c.conn
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	if err := writeFrame(conn, request); err != nil {
+		return nil, err
+	}
+	return readFrame(conn)
+}
+
+//
+// FRAMES
+//
+
+// Encode a request body.
+func encodeRequest(op uint8, resource string, message []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8(op)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(resource))
+	})
+	b.AddBytes(message)
+	body, err := b.Bytes()
+	if err != nil || len(resource) == 0 {
+		return nil, multikeypair.ErrInvalidReference
+	}
+	if len(body) > MAX_FRAME_SIZE {
+		return nil, ErrFrameTooLarge
+	}
+	return body, nil
+}
+
+// Decode a request body.
+func decodeRequest(body []byte) (op uint8, resource string, message []byte, err error) {
+	input := cryptobyte.String(body)
+	var r cryptobyte.String
+	if !input.ReadUint8(&op) || !input.ReadUint16LengthPrefixed(&r) || len(r) == 0 {
+		return 0, "", nil, ErrInvalidFrame
+	}
+	return op, string(r), input, nil
+}
+
+// Write a frame with its length prefix.
+func writeFrame(w io.Writer, body []byte) error {
+	if len(body) > MAX_FRAME_SIZE {
+		return ErrFrameTooLarge
+	}
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(body)), uint32(len(body)))
+	_, err := w.Write(append(frame, body...))
+	return err
+}
+
+// Read a frame, refusing any larger than MAX_FRAME_SIZE.
+func readFrame(r io.Reader) ([]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(prefix[:])
+	if n > MAX_FRAME_SIZE {
+		return nil, ErrFrameTooLarge
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,152 @@
+// go-multikeypair/signer/signer_test.go
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/keystore"
+)
+
+// The client registered under SCHEME, pointed at each test's signer;
+// schemes can't be registered twice.
+var testClient = New("unix", "")
+
+// Start a signer serving a keyring with a single Ed25519 key of the
+// given usage, returning the key's identifier and the socket's path.
+func startSigner(t *testing.T, usage multikeypair.Usage) (string, string) {
+	kp, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp.Metadata.Usage = usage
+	m, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := keystore.NewMemory(0)
+	id, err := keys.Put(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go Serve(l, keys)
+	t.Cleanup(func() {
+		l.Close()
+		keys.Close()
+	})
+	return id, path
+}
+
+// A RemoteKeypair backed by the signer is a working crypto.Signer.
+func TestRemoteKeypair(t *testing.T) {
+	id, path := startSigner(t, multikeypair.USAGE_SIGN)
+	testClient.Close()
+	testClient.address = path
+	err := multikeypair.RegisterRemoteBackend(SCHEME, testClient)
+	if err != nil && !errors.Is(err, multikeypair.ErrRemoteRegistered) {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	remote, err := multikeypair.NewRemoteKeypair(ctx, SCHEME+":"+id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Key.Code != multikeypair.ED_25519 || remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+		t.Errorf("unexpected cached key %+v", remote.Key)
+	}
+
+	var signer crypto.Signer = remote
+	message := []byte("hello")
+	signature, err := signer.Sign(crypto_rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(signer.Public().(ed25519.PublicKey), message, signature) {
+		t.Error("signature didn't verify")
+	}
+	if err := remote.Refresh(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+// Signer errors come back as the matching client errors.
+func TestClientErrors(t *testing.T) {
+	id, path := startSigner(t, multikeypair.USAGE_ENCRYPT)
+	client := New("unix", path)
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, err := client.Sign(ctx, id, []byte("hello")); !errors.Is(err, ErrRefused) {
+		t.Errorf("expected ErrRefused, got %v", err)
+	}
+	if _, err := client.PublicKey(ctx, "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := client.Sign(ctx, "", nil); !errors.Is(err, multikeypair.ErrInvalidReference) {
+		t.Errorf("expected ErrInvalidReference, got %v", err)
+	}
+	if _, err := client.Sign(ctx, id, make([]byte, MAX_FRAME_SIZE)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+	if _, err := client.PublicKey(ctx, id); err != nil {
+		t.Errorf("connection unusable after errors: %v", err)
+	}
+}
+
+// A signer that never answers is given up on when the context is done,
+// and the client redials afterwards.
+func TestClientContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	client := New("unix", path)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.PublicKey(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	(<-accepted).Close()
+
+	go serveOnce(t, l)
+	if _, err := client.PublicKey(context.Background(), "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after redialling, got %v", err)
+	}
+}
+
+// Serve the next connection on l from an empty keyring.
+func serveOnce(t *testing.T, l net.Listener) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	ServeConn(conn, keystore.NewMemory(0))
+}
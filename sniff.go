@@ -0,0 +1,62 @@
+// go-multikeypair/sniff.go
+//
+// Cheap classification of arbitrary input, so that routers and storage
+// layers can dispatch on format without attempting every full decode.
+
+package multikeypair
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Kind is the format an input was recognized as.
+type Kind int
+
+// Formats that TypeOf recognizes.
+const (
+	KindUnknown Kind = iota
+	KindMultikeypair
+)
+
+// String returns a human-readable name for the Kind.
+func (k Kind) String() string {
+	switch k {
+	case KindMultikeypair:
+		return "multikeypair"
+	default:
+		return "unknown"
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// IsMultikeypair reports whether b is a well-formed Multikeypair with a
+// cipher code we recognize. Key material isn't copied or validated.
+func IsMultikeypair(b []byte) bool {
+	_, err := Multikeypair(b).Code()
+	return err == nil
+}
+
+// TypeOf classifies b, also returning the cipher code when b is a
+// Multikeypair. Unrecognized input is reported as KindUnknown with a zero
+// code rather than as an error.
+func TypeOf(b []byte) (Kind, uint64) {
+	code, err := Multikeypair(b).Code()
+	if err != nil {
+		return KindUnknown, 0
+	}
+	return KindMultikeypair, code
+}
+
+// TypeOfString classifies a string holding a base58 or multibase encoded
+// input, as accepted by ParseMultikeypair.
+func TypeOfString(s string) (Kind, uint64) {
+	if s == "" {
+		return KindUnknown, 0
+	}
+	b, _, err := decodeMultibase(s)
+	if err != nil {
+		return KindUnknown, 0
+	}
+	return TypeOf(b)
+}
@@ -0,0 +1,51 @@
+// go-multikeypair/sniff_test.go
+
+package multikeypair
+
+import (
+	"testing"
+)
+
+// Encoded keypairs are recognized in binary and string form.
+func TestTypeOf(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsMultikeypair(mk) {
+		t.Error("multikeypair not recognized")
+	}
+	if kind, code := TypeOf(mk); kind != KindMultikeypair || code != ED_25519 {
+		t.Errorf("TypeOf = %v, %x", kind, code)
+	}
+	if kind, code := TypeOfString(mk.B58String()); kind != KindMultikeypair || code != ED_25519 {
+		t.Errorf("TypeOfString = %v, %x", kind, code)
+	}
+	if kind, _ := TypeOfString("z" + mk.B58String()); kind != KindMultikeypair {
+		t.Errorf("multibase not recognized: %v", kind)
+	}
+}
+
+// Anything else is reported as unknown.
+func TestTypeOfUnknown(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		{0x00},
+		[]byte("hello, world"),
+		{0x00, 0x00, 0x07, 0x00, 0x01, 0xff, 0x00, 0x00, 0x00, 0x00},
+	}
+	for _, in := range inputs {
+		if IsMultikeypair(in) {
+			t.Errorf("%x recognized as multikeypair", in)
+		}
+		if kind, code := TypeOf(in); kind != KindUnknown || code != 0 {
+			t.Errorf("TypeOf(%x) = %v, %x", in, kind, code)
+		}
+	}
+	for _, s := range []string{"", "not base58!", "f00"} {
+		if kind, _ := TypeOfString(s); kind != KindUnknown {
+			t.Errorf("TypeOfString(%q) = %v", s, kind)
+		}
+	}
+}
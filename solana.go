@@ -0,0 +1,107 @@
+// go-multikeypair/solana.go
+//
+// Conversion between Ed25519 multikeypairs and Solana CLI key files
+// (id.json, as written by solana-keygen), and Solana addresses. A key
+// file is a JSON array of the 64 bytes of the Ed25519 private key, the
+// seed followed by the public key:
+//
+//	[174,47,154,...,91,17]
+//
+// An address is the base58 encoding of the 32-byte public key.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+
+	b58 "github.com/mr-tron/base58/base58"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Solana-specific errors this module exports.
+var (
+	ErrInvalidSolanaKey     = errors.New("input isn't valid Solana key file")
+	ErrInvalidSolanaAddress = errors.New("input isn't valid Solana address")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// SolanaKeyFile returns the Solana CLI key file (id.json) for an Ed25519
+// Keypair.
+func (k Keypair) SolanaKeyFile() ([]byte, error) {
+	if k.Code != ED_25519 {
+		return nil, ErrUnsupportedCipher
+	}
+	if len(k.Private) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+	// Marshalling a []byte would give a base64 string, not an array.
+	array := make([]int, len(k.Private))
+	for i, b := range k.Private {
+		array[i] = int(b)
+	}
+	return json.Marshal(array)
+}
+
+// SolanaAddress returns the base58 Solana address of an Ed25519 Keypair.
+// Only the public key is needed.
+func (k Keypair) SolanaAddress() (string, error) {
+	if k.Code != ED_25519 {
+		return "", ErrUnsupportedCipher
+	}
+	if len(k.Public) != ed25519.PublicKeySize {
+		return "", ErrInvalidPublicKey
+	}
+	return b58.Encode(k.Public), nil
+}
+
+// KeypairFromSolanaKeyFile parses a Solana CLI key file into an Ed25519
+// Keypair, checking that its public half matches its seed.
+func KeypairFromSolanaKeyFile(b []byte) (Keypair, error) {
+	var array []int
+	if err := json.Unmarshal(b, &array); err != nil || len(array) != ed25519.PrivateKeySize {
+		return Keypair{}, ErrInvalidSolanaKey
+	}
+	raw := make([]byte, len(array))
+	for i, v := range array {
+		if v < 0 || v > 0xff {
+			return Keypair{}, ErrInvalidSolanaKey
+		}
+		raw[i] = byte(v)
+	}
+
+	private := ed25519.NewKeyFromSeed(raw[:ed25519.SeedSize])
+	public := private.Public().(ed25519.PublicKey)
+	if !bytes.Equal(public, raw[ed25519.SeedSize:]) {
+		return Keypair{}, ErrInvalidSolanaKey
+	}
+	return Keypair{
+		Code:          ED_25519,
+		Name:          codeName(ED_25519),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// KeypairFromSolanaAddress parses a base58 Solana address into a
+// public-only Ed25519 Keypair.
+func KeypairFromSolanaAddress(s string) (Keypair, error) {
+	public, err := b58.Decode(s)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return Keypair{}, ErrInvalidSolanaAddress
+	}
+	return Keypair{
+		Code:         ED_25519,
+		Name:         codeName(ED_25519),
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
@@ -0,0 +1,89 @@
+// go-multikeypair/solana_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The key file and address of the Ed25519 key whose seed is 0x42
+// repeated, in the form solana-keygen writes them.
+const (
+	testSolanaKeyFile = "[66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66,66," +
+		"33,82,248,209,155,121,29,36,69,50,66,225,95,46,171,108,183,207,250,123,106,94,211,0,151,150,14,6,152,129,219,18]"
+	testSolanaAddress = "3F5qRPtKg8GhGNnbd3qCj6nVJxWsGxq7pvH84okYLAqf"
+)
+
+// Key files parse into Ed25519 keypairs, and are written back identically.
+func TestSolanaKeyFile(t *testing.T) {
+	kp, err := KeypairFromSolanaKeyFile([]byte(testSolanaKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.Code != ED_25519 || !bytes.Equal(kp.Private[:32], bytes.Repeat([]byte{0x42}, 32)) {
+		t.Errorf("unexpected keypair %+v", kp)
+	}
+
+	file, err := kp.SolanaKeyFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file) != testSolanaKeyFile {
+		t.Errorf("expected %s, got %s", testSolanaKeyFile, file)
+	}
+
+	address, err := kp.SolanaAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != testSolanaAddress {
+		t.Errorf("expected %s, got %s", testSolanaAddress, address)
+	}
+}
+
+// Addresses parse into public-only keypairs.
+func TestSolanaAddress(t *testing.T) {
+	kp, err := KeypairFromSolanaAddress(testSolanaAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kp.Private) != 0 || kp.PublicLength != 32 {
+		t.Errorf("unexpected keypair %+v", kp)
+	}
+	address, err := kp.SolanaAddress()
+	if err != nil || address != testSolanaAddress {
+		t.Errorf("address didn't round trip: %v", err)
+	}
+
+	for _, s := range []string{"", "0OIl", "3F5qRPtKg8GhGNnbd3qCj6nVJxWsGxq7pvH84okYLA"} {
+		if _, err := KeypairFromSolanaAddress(s); !errors.Is(err, ErrInvalidSolanaAddress) {
+			t.Errorf("%q: expected ErrInvalidSolanaAddress, got %v", s, err)
+		}
+	}
+}
+
+// Malformed key files, and those whose public half doesn't match their
+// seed, are refused.
+func TestSolanaKeyFileInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"[1,2,3]",
+		strings.Replace(testSolanaKeyFile, "[66,", "[256,", 1),
+		strings.Replace(testSolanaKeyFile, ",18]", ",19]", 1),
+	} {
+		if _, err := KeypairFromSolanaKeyFile([]byte(s)); !errors.Is(err, ErrInvalidSolanaKey) {
+			t.Errorf("%q: expected ErrInvalidSolanaKey, got %v", s, err)
+		}
+	}
+
+	kp, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.SolanaKeyFile(); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+}
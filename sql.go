@@ -0,0 +1,77 @@
+// go-multikeypair/sql.go
+//
+// database/sql support. A Multikeypair is stored as its raw encoding in a
+// binary column (bytea, BLOB); wrap it as a B58Multikeypair to store it as
+// base58 in a text column instead. Either type scans from both forms.
+
+package multikeypair
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// SQL-specific errors this module exports.
+var (
+	ErrUnsupportedScan = errors.New("can't scan multikeypair from column type")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// B58Multikeypair is a Multikeypair that is stored in a database as a
+// base58 string.
+type B58Multikeypair Multikeypair
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Value implements driver.Valuer. An empty Multikeypair is stored as NULL.
+func (m Multikeypair) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m.MarshalBinary()
+}
+
+// Scan implements sql.Scanner, accepting NULL, the raw encoding, or a
+// base58 string. The scanned value is copied.
+func (m *Multikeypair) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		return m.UnmarshalText([]byte(v))
+	case []byte:
+		// Drivers hand back text columns as []byte too; anything that
+		// isn't a valid raw encoding is tried as base58.
+		if err := m.UnmarshalBinary(v); err == nil {
+			return nil
+		}
+		return m.UnmarshalText(v)
+	default:
+		return ErrUnsupportedScan
+	}
+}
+
+// Value implements driver.Valuer. An empty Multikeypair is stored as NULL.
+func (m B58Multikeypair) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	text, err := Multikeypair(m).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan implements sql.Scanner, accepting the same input as
+// Multikeypair.Scan.
+func (m *B58Multikeypair) Scan(src interface{}) error {
+	return (*Multikeypair)(m).Scan(src)
+}
@@ -0,0 +1,64 @@
+// go-multikeypair/sql_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Values written by either type can be scanned back by either type.
+func TestSQLRoundTrip(t *testing.T) {
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binary, err := mk.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := binary.([]byte); !ok {
+		t.Fatalf("expected binary value, got %T", binary)
+	}
+	text, err := B58Multikeypair(mk).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != mk.B58String() {
+		t.Fatalf("expected base58 value, got %v", text)
+	}
+
+	for _, src := range []interface{}{binary, text, []byte(text.(string))} {
+		var scanned Multikeypair
+		if err := scanned.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(scanned, mk) {
+			t.Errorf("multikeypair mismatch after scanning %T", src)
+		}
+		var scanned58 B58Multikeypair
+		if err := scanned58.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(scanned58, mk) {
+			t.Errorf("multikeypair mismatch after scanning %T as base58", src)
+		}
+	}
+}
+
+// NULL maps to an empty Multikeypair and back.
+func TestSQLNull(t *testing.T) {
+	var mk Multikeypair
+	v, err := mk.Value()
+	if err != nil || v != nil {
+		t.Fatalf("expected NULL, got %v (%v)", v, err)
+	}
+	mk = Multikeypair{0x01}
+	if err := mk.Scan(nil); err != nil || mk != nil {
+		t.Fatalf("expected empty multikeypair, got %v (%v)", mk, err)
+	}
+	if err := mk.Scan(42); err != ErrUnsupportedScan {
+		t.Fatalf("expected unsupported scan error, got: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// go-multikeypair/stdlib.go
+//
+// Conversion between Keypairs and the standard library's key types, for
+// the formats and protocols (cosign, CSRs, JOSE) built on crypto/x509
+// and crypto.Signer. Ed25519, P-256 and RSA keys have standard library
+// forms; RSA keypairs hold PKCS#1 DER.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Convert a Keypair's private key into its standard library form.
+func (k Keypair) cryptoPrivateKey() (crypto.Signer, error) {
+	switch k.Code {
+	case ED_25519:
+		if len(k.Private) != ed25519.PrivateKeySize {
+			return nil, ErrInvalidPrivateKey
+		}
+		if k.locked != nil {
+			// The signer outlives the call, so the copy can't be wiped.
+			return ed25519.PrivateKey(bytes.Clone(k.Private)), nil
+		}
+		return ed25519.PrivateKey(k.Private), nil
+	case P_256:
+		sk, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), k.Private)
+		if err != nil {
+			return nil, ErrInvalidPrivateKey
+		}
+		return sk, nil
+	case RSA:
+		sk, err := x509.ParsePKCS1PrivateKey(k.Private)
+		if err != nil {
+			return nil, ErrInvalidPrivateKey
+		}
+		return sk, nil
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// Convert a Keypair's public key into its standard library form.
+func (k Keypair) cryptoPublicKey() (crypto.PublicKey, error) {
+	switch k.Code {
+	case ED_25519:
+		if len(k.Public) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+		return ed25519.PublicKey(k.Public), nil
+	case P_256:
+		pk, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), k.Public)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		return pk, nil
+	case RSA:
+		pk, err := x509.ParsePKCS1PublicKey(k.Public)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		return pk, nil
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+// Build a Keypair from a standard library private key.
+func keypairFromCryptoPrivateKey(sk crypto.PrivateKey) (Keypair, error) {
+	var code uint64
+	var private, public []byte
+	switch sk := sk.(type) {
+	case ed25519.PrivateKey:
+		code, private, public = ED_25519, sk, sk.Public().(ed25519.PublicKey)
+	case *ecdsa.PrivateKey:
+		if sk.Curve != elliptic.P256() {
+			return Keypair{}, ErrUnsupportedCipher
+		}
+		var err error
+		if private, err = sk.Bytes(); err != nil {
+			return Keypair{}, ErrInvalidPrivateKey
+		}
+		if public, err = sk.PublicKey.Bytes(); err != nil {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+		code = P_256
+	case *rsa.PrivateKey:
+		code = RSA
+		private = x509.MarshalPKCS1PrivateKey(sk)
+		public = x509.MarshalPKCS1PublicKey(&sk.PublicKey)
+	default:
+		return Keypair{}, ErrUnsupportedCipher
+	}
+	return Keypair{
+		Code:          code,
+		Name:          codeName(code),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// Build a public-only Keypair from a standard library public key.
+func keypairFromCryptoPublicKey(pk crypto.PublicKey) (Keypair, error) {
+	var code uint64
+	var public []byte
+	switch pk := pk.(type) {
+	case ed25519.PublicKey:
+		code, public = ED_25519, pk
+	case *ecdsa.PublicKey:
+		if pk.Curve != elliptic.P256() {
+			return Keypair{}, ErrUnsupportedCipher
+		}
+		var err error
+		if public, err = pk.Bytes(); err != nil {
+			return Keypair{}, ErrInvalidPublicKey
+		}
+		code = P_256
+	case *rsa.PublicKey:
+		code, public = RSA, x509.MarshalPKCS1PublicKey(pk)
+	default:
+		return Keypair{}, ErrUnsupportedCipher
+	}
+	return Keypair{
+		Code:         code,
+		Name:         codeName(code),
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
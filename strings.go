@@ -0,0 +1,76 @@
+// go-multikeypair/strings.go
+//
+// Hex and base64url string forms of multikeypairs, for JSON APIs and URLs
+// where base58 isn't expected.
+
+package multikeypair
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+//
+// Hex
+//
+
+// HexString generates a lowercase hex-encoded version of a Multikeypair.
+func (m Multikeypair) HexString() string {
+	return hex.EncodeToString(m)
+}
+
+// MultikeypairFromHex parses a hex-encoded Multikeypair. Either case is
+// accepted.
+func MultikeypairFromHex(s string) (Multikeypair, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return Multikeypair{}, ErrInvalidMultikeypair
+	}
+	return castKeypair(b)
+}
+
+// KeypairFromHex parses a hex-encoded Multikeypair into a Keypair.
+func KeypairFromHex(s string) (Keypair, error) {
+	mk, err := MultikeypairFromHex(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return mk.Decode()
+}
+
+//
+// Base64url
+//
+
+// Base64URLString generates an unpadded base64url-encoded version of a
+// Multikeypair, safe for use in URLs and file names.
+func (m Multikeypair) Base64URLString() string {
+	return base64.RawURLEncoding.EncodeToString(m)
+}
+
+// MultikeypairFromBase64URL parses a base64url-encoded Multikeypair.
+// Padding is optional.
+func MultikeypairFromBase64URL(s string) (Multikeypair, error) {
+	enc := base64.RawURLEncoding
+	if len(s)%4 == 0 && len(s) > 0 && s[len(s)-1] == '=' {
+		enc = base64.URLEncoding
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return Multikeypair{}, ErrInvalidMultikeypair
+	}
+	return castKeypair(b)
+}
+
+// KeypairFromBase64URL parses a base64url-encoded Multikeypair into a
+// Keypair.
+func KeypairFromBase64URL(s string) (Keypair, error) {
+	mk, err := MultikeypairFromBase64URL(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return mk.Decode()
+}
@@ -0,0 +1,61 @@
+// go-multikeypair/strings_test.go
+
+package multikeypair
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// Hex encodings round trip in either case.
+func TestHex(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := mk.HexString()
+	for _, in := range []string{s, strings.ToUpper(s)} {
+		decoded, err := KeypairFromHex(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(kp) {
+			t.Error("decoded keypair doesn't match")
+		}
+	}
+
+	if _, err := MultikeypairFromHex("zz"); err != ErrInvalidMultikeypair {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Base64url encodings round trip with or without padding.
+func TestBase64URL(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := mk.Base64URLString()
+	if strings.ContainsAny(s, "+/=") {
+		t.Errorf("not URL safe: %s", s)
+	}
+	padded := base64.URLEncoding.EncodeToString(mk)
+	for _, in := range []string{s, padded} {
+		decoded, err := KeypairFromBase64URL(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(kp) {
+			t.Error("decoded keypair doesn't match")
+		}
+	}
+
+	if _, err := MultikeypairFromBase64URL("a+b/"); err != ErrInvalidMultikeypair {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+// go-multikeypair/subkey.go
+//
+// Purpose-specific subkeys derived from a master keypair, so that one
+// stored secret can back separate keys for, say, signing and encryption
+// without those keys being related in any usable way. The subkey's seed
+// is derived from the master private key with HKDF-SHA256:
+//
+//	HKDF(ikm = master private key, salt = "multikeypair subkey v1",
+//	     info = uvarint(master code) || uvarint(subkey code) || info, L = 32)
+//
+// and the subkey generated from that seed with GenerateFromSeed. The
+// same master, info and code always give the same subkey.
+
+package multikeypair
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+)
+
+// Salt for subkey derivation.
+const subkeySalt = "multikeypair subkey v1"
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// DeriveSubkey derives the subkey of a cipher code for a purpose named
+// by info, e.g. "signing" or "encryption". The master keypair must hold
+// its private key, permit USAGE_DERIVE and be within its validity
+// window; the subkey's cipher needn't match the master's.
+func (k Keypair) DeriveSubkey(info []byte, code uint64) (Keypair, error) {
+	if len(k.Private) == 0 {
+		return Keypair{}, ErrInvalidPrivateKey
+	}
+	if err := k.checkUsage(USAGE_DERIVE); err != nil {
+		return Keypair{}, err
+	}
+	if err := k.checkValid(); err != nil {
+		return Keypair{}, err
+	}
+	if err := validCode(code); err != nil {
+		return Keypair{}, err
+	}
+	label := append(PackCode(k.Code), PackCode(code)...)
+	seed, err := hkdf.Key(sha256.New, k.Private, []byte(subkeySalt), string(append(label, info...)), MIN_SEED_LENGTH)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return GenerateFromSeed(code, seed)
+}
@@ -0,0 +1,96 @@
+// go-multikeypair/subkey_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Subkeys are deterministic, and differ by purpose, cipher and master.
+func TestDeriveSubkey(t *testing.T) {
+	master := generateEd25519(t)
+
+	signing, err := master.DeriveSubkey([]byte("signing"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := master.DeriveSubkey([]byte("signing"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signing.Equal(again) {
+		t.Error("derivation isn't deterministic")
+	}
+	if signing.Equal(master) {
+		t.Error("subkey is the master key")
+	}
+
+	encryption, err := master.DeriveSubkey([]byte("encryption"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(signing.Public, encryption.Public) {
+		t.Error("purposes gave the same subkey")
+	}
+
+	other, err := generateEd25519(t).DeriveSubkey([]byte("signing"), ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(signing.Public, other.Public) {
+		t.Error("masters gave the same subkey")
+	}
+}
+
+// Subkeys of other ciphers work as keys of that cipher.
+func TestDeriveSubkeyCiphers(t *testing.T) {
+	master := generateEd25519(t)
+	for _, code := range []uint64{X_25519, P_256, SECP_256K1, ML_KEM_768} {
+		sub, err := master.DeriveSubkey([]byte("purpose"), code)
+		if err != nil {
+			t.Fatalf("%x: %v", code, err)
+		}
+		if sub.Code != code {
+			t.Errorf("%x: got code %x", code, sub.Code)
+		}
+	}
+
+	sub, err := master.DeriveSubkey([]byte("encryption"), X_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := sub.Encrypt([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := sub.Decrypt(ciphertext)
+	if err != nil || string(plaintext) != "message" {
+		t.Fatalf("decrypt: %q, %v", plaintext, err)
+	}
+}
+
+// A public-only master can't derive subkeys.
+func TestDeriveSubkeyPublicOnly(t *testing.T) {
+	master := generateEd25519(t).publicOnly()
+	if _, err := master.DeriveSubkey([]byte("signing"), ED_25519); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// Masters must permit derivation and be within their validity window.
+func TestDeriveSubkeyRestricted(t *testing.T) {
+	master := generateEd25519(t)
+	master.Metadata.Usage = USAGE_SIGN
+	if _, err := master.DeriveSubkey([]byte("signing"), ED_25519); !errors.Is(err, ErrUsageNotPermitted) {
+		t.Errorf("expected ErrUsageNotPermitted, got %v", err)
+	}
+
+	master.Metadata.Usage = USAGE_SIGN | USAGE_DERIVE
+	master.Metadata.NotAfter = time.Now().Add(-time.Hour)
+	if _, err := master.DeriveSubkey([]byte("signing"), ED_25519); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("expected ErrKeyExpired, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// go-multikeypair/tpm/device.go
+//
+// A Device for a TPM reached through a go-tpm transport, e.g.
+//
+//	t, err := linuxtpm.Open("/dev/tpmrm0")
+//	device := tpm.Open(t)
+//
+// Keys are created under the TCG reference ECC P-256 storage root key,
+// which the TPM derives from its owner seed on demand, so nothing needs
+// to be provisioned beforehand.
+
+package tpm
+
+import (
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// A TPM reached through a transport.
+type device struct {
+	t transport.TPM
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Open returns a Device that talks to a TPM through t. The caller keeps
+// ownership of t and closes it when done.
+func Open(t transport.TPM) Device {
+	return &device{t: t}
+}
+
+func (d *device) PCRPolicy(pcrs []int) ([]byte, error) {
+	session, closeSession, err := tpm2.PolicySession(d.t, tpm2.TPMAlgSHA256, 16, tpm2.Trial())
+	if err != nil {
+		return nil, err
+	}
+	defer closeSession()
+
+	// An empty PCR digest makes the TPM use the PCRs' current values.
+	if _, err := (tpm2.PolicyPCR{
+		PolicySession: session.Handle(),
+		Pcrs:          pcrSelection(pcrs),
+	}).Execute(d.t); err != nil {
+		return nil, err
+	}
+	rsp, err := (tpm2.PolicyGetDigest{PolicySession: session.Handle()}).Execute(d.t)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.PolicyDigest.Buffer, nil
+}
+
+func (d *device) Create(template tpm2.TPMTPublic) (tpm2.TPM2BPublic, tpm2.TPM2BPrivate, error) {
+	srk, flush, err := d.storageRootKey()
+	if err != nil {
+		return tpm2.TPM2BPublic{}, tpm2.TPM2BPrivate{}, err
+	}
+	defer flush()
+
+	rsp, err := (tpm2.Create{
+		ParentHandle: srk,
+		InPublic:     tpm2.New2B(template),
+	}).Execute(d.t)
+	if err != nil {
+		return tpm2.TPM2BPublic{}, tpm2.TPM2BPrivate{}, err
+	}
+	return rsp.OutPublic, rsp.OutPrivate, nil
+}
+
+func (d *device) Sign(public tpm2.TPM2BPublic, private tpm2.TPM2BPrivate, pcrs []int, digest []byte) (*tpm2.TPMSSignatureECC, error) {
+	srk, flush, err := d.storageRootKey()
+	if err != nil {
+		return nil, err
+	}
+	defer flush()
+
+	loaded, err := (tpm2.Load{
+		ParentHandle: srk,
+		InPrivate:    private,
+		InPublic:     public,
+	}).Execute(d.t)
+	if err != nil {
+		return nil, err
+	}
+	defer (tpm2.FlushContext{FlushHandle: loaded.ObjectHandle}).Execute(d.t)
+
+	auth := tpm2.PasswordAuth(nil)
+	if len(pcrs) > 0 {
+		auth = tpm2.Policy(tpm2.TPMAlgSHA256, 16, func(t transport.TPM, session tpm2.TPMISHPolicy, _ tpm2.TPM2BNonce) error {
+			_, err := (tpm2.PolicyPCR{
+				PolicySession: session,
+				Pcrs:          pcrSelection(pcrs),
+			}).Execute(t)
+			return err
+		})
+	}
+
+	rsp, err := (tpm2.Sign{
+		KeyHandle: tpm2.AuthHandle{
+			Handle: loaded.ObjectHandle,
+			Name:   loaded.Name,
+			Auth:   auth,
+		},
+		Digest: tpm2.TPM2BDigest{Buffer: digest},
+		InScheme: tpm2.TPMTSigScheme{
+			Scheme: tpm2.TPMAlgECDSA,
+			Details: tpm2.NewTPMUSigScheme(tpm2.TPMAlgECDSA, &tpm2.TPMSSchemeHash{
+				HashAlg: tpm2.TPMAlgSHA256,
+			}),
+		},
+		Validation: tpm2.TPMTTKHashCheck{Tag: tpm2.TPMSTHashCheck},
+	}).Execute(d.t)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.Signature.Signature.ECDSA()
+}
+
+// Create the storage root key, returning its handle and a function that
+// flushes it.
+func (d *device) storageRootKey() (tpm2.NamedHandle, func(), error) {
+	rsp, err := (tpm2.CreatePrimary{
+		PrimaryHandle: tpm2.TPMRHOwner,
+		InPublic:      tpm2.New2B(tpm2.ECCSRKTemplate),
+	}).Execute(d.t)
+	if err != nil {
+		return tpm2.NamedHandle{}, nil, err
+	}
+	flush := func() {
+		(tpm2.FlushContext{FlushHandle: rsp.ObjectHandle}).Execute(d.t)
+	}
+	return tpm2.NamedHandle{Handle: rsp.ObjectHandle, Name: rsp.Name}, flush, nil
+}
+
+// Select PCRs in the SHA-256 bank.
+func pcrSelection(pcrs []int) tpm2.TPMLPCRSelection {
+	indices := make([]uint, len(pcrs))
+	for i, pcr := range pcrs {
+		indices[i] = uint(pcr)
+	}
+	return tpm2.TPMLPCRSelection{
+		PCRSelections: []tpm2.TPMSPCRSelection{{
+			Hash:      tpm2.TPMAlgSHA256,
+			PCRSelect: tpm2.PCClientCompatible.PCRs(indices...),
+		}},
+	}
+}
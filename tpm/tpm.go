@@ -0,0 +1,320 @@
+// go-multikeypair/tpm/tpm.go
+//
+// P-256 signing keys created under a TPM 2.0 storage root key. The TPM
+// hands back the key's public area and its private part sealed to the
+// TPM; both travel in the RemoteKeypair reference, so the key can be
+// stored anywhere but only used on the TPM that created it:
+//
+//	tpm:<base64url key blob>
+//
+// where the blob has the form:
+//
+//	[public area length]<TPM2B_PUBLIC contents> (16-bit length prefix)
+//	[private blob length]<TPM2B_PRIVATE contents> (16-bit length prefix)
+//	[pcr count]<pcr indices> (8-bit count, 8 bits each)
+//
+// Keys may be bound to the SHA-256 PCR bank: they then only sign while
+// the selected PCRs hold the values they had at creation. The package
+// registers its backend under SCHEME; a device must be attached before
+// keys can sign.
+
+package tpm
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-tpm/tpm2"
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// TPM-specific errors this package exports.
+var (
+	ErrInvalidReference = errors.New("invalid tpm key reference")
+	ErrInvalidPCR       = errors.New("invalid pcr index")
+	ErrNoDevice         = errors.New("no tpm attached")
+)
+
+// SCHEME is the reference scheme for TPM keys.
+const SCHEME = "tpm"
+
+// PCRs are numbered 0 to MAX_PCR.
+const MAX_PCR = 23
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Device is a TPM. Open returns one for a TPM reached through a go-tpm
+// transport; tests and other implementations can supply their own.
+type Device interface {
+	// PCRPolicy returns the policy digest binding a key to the current
+	// values of the given SHA-256 bank PCRs.
+	PCRPolicy(pcrs []int) ([]byte, error)
+	// Create creates a key from template under the storage root key.
+	Create(template tpm2.TPMTPublic) (tpm2.TPM2BPublic, tpm2.TPM2BPrivate, error)
+	// Sign loads a key and signs a SHA-256 digest with it, satisfying
+	// its PCR policy if pcrs isn't empty.
+	Sign(public tpm2.TPM2BPublic, private tpm2.TPM2BPrivate, pcrs []int, digest []byte) (*tpm2.TPMSSignatureECC, error)
+}
+
+// Key is a key created by a TPM.
+type Key struct {
+	// Marshalled TPM2B_PUBLIC contents: the key's public area.
+	Public []byte
+	// Marshalled TPM2B_PRIVATE contents: the private key sealed to the
+	// TPM's storage root key.
+	Private []byte
+	// SHA-256 bank PCRs the key is bound to, if any.
+	PCRs []int
+}
+
+// The backend registered under SCHEME, which signs with the attached
+// device.
+type backend struct {
+	mu     sync.RWMutex
+	device Device
+}
+
+var attached = &backend{}
+
+func init() {
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, attached); err != nil {
+		panic(err)
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Attach makes a device available for signing with the keys it created.
+// A machine has a single TPM, so attaching replaces any earlier device.
+func Attach(device Device) {
+	attached.mu.Lock()
+	defer attached.mu.Unlock()
+	attached.device = device
+}
+
+// GenerateOnTPM creates a P-256 signing key on device, attaches the
+// device, and returns a RemoteKeypair for the key. If pcrs is non-empty
+// the key is bound to their current values. The key is restricted to
+// multikeypair.USAGE_SIGN.
+func GenerateOnTPM(ctx context.Context, device Device, pcrs []int) (multikeypair.RemoteKeypair, error) {
+	pcrs, err := normalizePCRs(pcrs)
+	if err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+
+	template := keyTemplate()
+	if len(pcrs) > 0 {
+		policy, err := device.PCRPolicy(pcrs)
+		if err != nil {
+			return multikeypair.RemoteKeypair{}, err
+		}
+		template.AuthPolicy = tpm2.TPM2BDigest{Buffer: policy}
+		template.ObjectAttributes.UserWithAuth = false
+	}
+
+	public, private, err := device.Create(template)
+	if err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	reference, err := Reference(Key{Public: public.Bytes(), Private: private.Buffer, PCRs: pcrs})
+	if err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+
+	Attach(device)
+	return multikeypair.NewRemoteKeypair(ctx, reference)
+}
+
+// PublicKey reads the public key out of a key's public area; no device
+// is needed.
+func (b *backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	k, err := parseResource(resource)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	point, err := publicPoint(k.Public)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	m, err := multikeypair.Encode(nil, point, multikeypair.P_256)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	return kp, nil
+}
+
+// Sign signs the SHA-256 digest of message on the attached device. The
+// TPM's (r, s) signature is re-encoded as ASN.1 DER, the form P-256
+// multikeypair signatures take.
+func (b *backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	k, err := parseResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	device := b.device
+	b.mu.RUnlock()
+	if device == nil {
+		return nil, ErrNoDevice
+	}
+
+	digest := sha256.Sum256(message)
+	public := tpm2.BytesAs2B[tpm2.TPMTPublic](k.Public)
+	private := tpm2.TPM2BPrivate{Buffer: k.Private}
+	sig, err := device.Sign(public, private, k.PCRs, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(sig.SignatureR.Buffer),
+		new(big.Int).SetBytes(sig.SignatureS.Buffer),
+	})
+}
+
+// The template for a non-restricted P-256 ECDSA signing key.
+func keyTemplate() tpm2.TPMTPublic {
+	return tpm2.TPMTPublic{
+		Type:    tpm2.TPMAlgECC,
+		NameAlg: tpm2.TPMAlgSHA256,
+		ObjectAttributes: tpm2.TPMAObject{
+			FixedTPM:            true,
+			FixedParent:         true,
+			SensitiveDataOrigin: true,
+			UserWithAuth:        true,
+			SignEncrypt:         true,
+		},
+		Parameters: tpm2.NewTPMUPublicParms(tpm2.TPMAlgECC, &tpm2.TPMSECCParms{
+			Symmetric: tpm2.TPMTSymDefObject{Algorithm: tpm2.TPMAlgNull},
+			Scheme: tpm2.TPMTECCScheme{
+				Scheme: tpm2.TPMAlgECDSA,
+				Details: tpm2.NewTPMUAsymScheme(tpm2.TPMAlgECDSA, &tpm2.TPMSSigSchemeECDSA{
+					HashAlg: tpm2.TPMAlgSHA256,
+				}),
+			},
+			CurveID: tpm2.TPMECCNistP256,
+			KDF:     tpm2.TPMTKDFScheme{Scheme: tpm2.TPMAlgNull},
+		}),
+	}
+}
+
+// Extract the uncompressed P-256 point from a marshalled public area.
+func publicPoint(public []byte) ([]byte, error) {
+	area, err := tpm2.Unmarshal[tpm2.TPMTPublic](public)
+	if err != nil || area.Type != tpm2.TPMAlgECC {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	params, err := area.Parameters.ECCDetail()
+	if err != nil || params.CurveID != tpm2.TPMECCNistP256 {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	unique, err := area.Unique.ECC()
+	if err != nil || len(unique.X.Buffer) > 32 || len(unique.Y.Buffer) > 32 {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+
+	point := make([]byte, 65)
+	point[0] = 4
+	copy(point[33-len(unique.X.Buffer):33], unique.X.Buffer)
+	copy(point[65-len(unique.Y.Buffer):], unique.Y.Buffer)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	return point, nil
+}
+
+// Sort and deduplicate PCR indices, checking their range.
+func normalizePCRs(pcrs []int) ([]int, error) {
+	out := slices.Clone(pcrs)
+	for _, pcr := range out {
+		if pcr < 0 || pcr > MAX_PCR {
+			return nil, ErrInvalidPCR
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+//
+// REFERENCE
+//
+
+// Reference returns the RemoteKeypair reference for a key.
+func Reference(k Key) (string, error) {
+	pcrs, err := normalizePCRs(k.PCRs)
+	if err != nil {
+		return "", err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(k.Public)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(k.Private)
+	})
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, pcr := range pcrs {
+			b.AddUint8(uint8(pcr))
+		}
+	})
+	blob, err := b.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return SCHEME + ":" + base64.RawURLEncoding.EncodeToString(blob), nil
+}
+
+// ParseReference parses a reference written by Reference.
+func ParseReference(reference string) (Key, error) {
+	resource, ok := strings.CutPrefix(reference, SCHEME+":")
+	if !ok {
+		return Key{}, ErrInvalidReference
+	}
+	return parseResource(resource)
+}
+
+// Parse the part of a reference after the scheme.
+func parseResource(resource string) (Key, error) {
+	blob, err := base64.RawURLEncoding.DecodeString(resource)
+	if err != nil {
+		return Key{}, ErrInvalidReference
+	}
+
+	input := cryptobyte.String(blob)
+	var public, private, pcrs cryptobyte.String
+	if !input.ReadUint16LengthPrefixed(&public) ||
+		!input.ReadUint16LengthPrefixed(&private) ||
+		!input.ReadUint8LengthPrefixed(&pcrs) ||
+		!input.Empty() {
+		return Key{}, ErrInvalidReference
+	}
+
+	k := Key{Public: public, Private: private}
+	for _, pcr := range pcrs {
+		if int(pcr) > MAX_PCR || (len(k.PCRs) > 0 && int(pcr) <= k.PCRs[len(k.PCRs)-1]) {
+			return Key{}, ErrInvalidReference
+		}
+		k.PCRs = append(k.PCRs, int(pcr))
+	}
+	return k, nil
+}
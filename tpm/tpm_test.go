@@ -0,0 +1,183 @@
+// go-multikeypair/tpm/tpm_test.go
+
+package tpm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// A Device that keeps keys in memory, "sealing" a key by remembering it
+// under a random handle, and models PCR policies by comparing the bound
+// PCRs against a current set.
+type fakeDevice struct {
+	keys    map[string]*ecdsa.PrivateKey
+	pcrs    map[int]byte
+	created tpm2.TPMTPublic
+}
+
+func newFake() *fakeDevice {
+	return &fakeDevice{keys: make(map[string]*ecdsa.PrivateKey), pcrs: make(map[int]byte)}
+}
+
+func (d *fakeDevice) policy(pcrs []int) []byte {
+	policy := []byte("pcr")
+	for _, pcr := range pcrs {
+		policy = append(policy, byte(pcr), d.pcrs[pcr])
+	}
+	return policy
+}
+
+func (d *fakeDevice) PCRPolicy(pcrs []int) ([]byte, error) {
+	return d.policy(pcrs), nil
+}
+
+func (d *fakeDevice) Create(template tpm2.TPMTPublic) (tpm2.TPM2BPublic, tpm2.TPM2BPrivate, error) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		return tpm2.TPM2BPublic{}, tpm2.TPM2BPrivate{}, err
+	}
+	point, err := sk.PublicKey.Bytes()
+	if err != nil {
+		return tpm2.TPM2BPublic{}, tpm2.TPM2BPrivate{}, err
+	}
+	handle := make([]byte, 16)
+	crypto_rand.Read(handle)
+	d.keys[string(handle)] = sk
+	d.created = template
+
+	template.Unique = tpm2.NewTPMUPublicID(tpm2.TPMAlgECC, &tpm2.TPMSECCPoint{
+		X: tpm2.TPM2BECCParameter{Buffer: point[1:33]},
+		Y: tpm2.TPM2BECCParameter{Buffer: point[33:]},
+	})
+	return tpm2.New2B(template), tpm2.TPM2BPrivate{Buffer: handle}, nil
+}
+
+func (d *fakeDevice) Sign(public tpm2.TPM2BPublic, private tpm2.TPM2BPrivate, pcrs []int, digest []byte) (*tpm2.TPMSSignatureECC, error) {
+	sk, ok := d.keys[string(private.Buffer)]
+	if !ok {
+		return nil, errors.New("key not sealed by this tpm")
+	}
+	area, err := public.Contents()
+	if err != nil {
+		return nil, err
+	}
+	if len(pcrs) > 0 && !bytes.Equal(area.AuthPolicy.Buffer, d.policy(pcrs)) {
+		return nil, errors.New("policy check failed")
+	}
+	r, s, err := ecdsa.Sign(crypto_rand.Reader, sk, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &tpm2.TPMSSignatureECC{
+		Hash:       tpm2.TPMAlgSHA256,
+		SignatureR: tpm2.TPM2BECCParameter{Buffer: r.Bytes()},
+		SignatureS: tpm2.TPM2BECCParameter{Buffer: s.Bytes()},
+	}, nil
+}
+
+// Keys created on the TPM sign there and verify as ordinary P-256 keys.
+func TestGenerateOnTPM(t *testing.T) {
+	device := newFake()
+	ctx := context.Background()
+
+	remote, err := GenerateOnTPM(ctx, device, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Key.Code != multikeypair.P_256 || remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+		t.Errorf("unexpected cached key %+v", remote.Key)
+	}
+	if !device.created.ObjectAttributes.UserWithAuth || len(device.created.AuthPolicy.Buffer) != 0 {
+		t.Error("unbound key created with a policy")
+	}
+
+	message := []byte("hello")
+	signature, err := remote.SignContext(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Verify(message, signature); err != nil {
+		t.Error(err)
+	}
+
+	// The sealed key survives an encode/decode round trip.
+	s, err := remote.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := multikeypair.RemoteKeypairFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.SignContext(ctx, message); err != nil {
+		t.Error(err)
+	}
+}
+
+// Keys bound to PCRs stop signing when the PCRs change.
+func TestGenerateOnTPMPolicy(t *testing.T) {
+	device := newFake()
+	device.pcrs[7] = 1
+	ctx := context.Background()
+
+	remote, err := GenerateOnTPM(ctx, device, []int{7, 0, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.created.ObjectAttributes.UserWithAuth {
+		t.Error("policy-bound key usable with password auth")
+	}
+	k, err := ParseReference(remote.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(k.PCRs, []int{0, 7}) {
+		t.Errorf("expected pcrs [0 7], got %v", k.PCRs)
+	}
+
+	if _, err := remote.SignContext(ctx, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	device.pcrs[7] = 2
+	if _, err := remote.SignContext(ctx, []byte("x")); err == nil {
+		t.Error("expected signing to fail after pcr change")
+	}
+
+	if _, err := GenerateOnTPM(ctx, device, []int{24}); err != ErrInvalidPCR {
+		t.Errorf("expected ErrInvalidPCR, got %v", err)
+	}
+}
+
+// Malformed references are refused.
+func TestParseReference(t *testing.T) {
+	for _, bad := range []string{
+		"tpm:!!",
+		"tpm:AAA",
+		"pkcs11:AAAAAAA",
+	} {
+		if _, err := ParseReference(bad); err != ErrInvalidReference {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", bad, err)
+		}
+	}
+
+	// PCR lists must be sorted and in range.
+	k := Key{Public: []byte{1}, Private: []byte{2}, PCRs: []int{3}}
+	reference, err := Reference(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseReference(reference)
+	if err != nil || !slices.Equal(parsed.PCRs, k.PCRs) {
+		t.Errorf("expected %v, got %v (%v)", k.PCRs, parsed.PCRs, err)
+	}
+}
@@ -0,0 +1,117 @@
+// go-multikeypair/ucan/did.go
+//
+// did:key identifiers (W3C CCG did:key method) for multikeypair public
+// keys, which UCANs use to name their issuer and audience:
+//
+//	did:key:z<base58btc(uvarint(multicodec) || public key)>
+//
+// with the public key in the form its multicodec specifies:
+//
+//	ed25519    0xed    32 bytes
+//	x25519     0xec    32 bytes
+//	secp256k1  0xe7    compressed point
+//	p256       0x1200  compressed point
+//	rsa        0x1205  PKCS#1 DER
+
+package ucan
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/multiformats/go-multicodec"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// DID-specific errors this package exports.
+var (
+	ErrInvalidDID = errors.New("input isn't a valid did:key")
+)
+
+// Prefix of a did:key with a base58btc multibase key.
+const didKeyPrefix = "did:key:z"
+
+// Multicodec codes of the public key types did:key supports, by cipher.
+var didCodecs = map[uint64]multicodec.Code{
+	multikeypair.ED_25519:   multicodec.Ed25519Pub,
+	multikeypair.X_25519:    multicodec.X25519Pub,
+	multikeypair.SECP_256K1: multicodec.Secp256k1Pub,
+	multikeypair.P_256:      multicodec.P256Pub,
+	multikeypair.RSA:        multicodec.RsaPub,
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// DIDKey returns the did:key identifying a keypair's public key.
+func DIDKey(k multikeypair.Keypair) (string, error) {
+	codec, ok := didCodecs[k.Code]
+	if !ok {
+		return "", multikeypair.ErrUnsupportedCipher
+	}
+	public := k.Public
+	if k.Code == multikeypair.P_256 {
+		if _, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), k.Public); err != nil {
+			return "", multikeypair.ErrInvalidPublicKey
+		}
+		public = append([]byte{0x02 | k.Public[64]&1}, k.Public[1:33]...)
+	}
+	buf := binary.AppendUvarint(nil, uint64(codec))
+	return didKeyPrefix + base58.Encode(append(buf, public...)), nil
+}
+
+// KeypairFromDIDKey returns the public-only keypair a did:key
+// identifies.
+func KeypairFromDIDKey(did string) (multikeypair.Keypair, error) {
+	encoded, ok := strings.CutPrefix(did, didKeyPrefix)
+	if !ok {
+		return multikeypair.Keypair{}, ErrInvalidDID
+	}
+	buf, err := base58.Decode(encoded)
+	if err != nil {
+		return multikeypair.Keypair{}, ErrInvalidDID
+	}
+	codec, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return multikeypair.Keypair{}, ErrInvalidDID
+	}
+	public := buf[n:]
+
+	var code uint64
+	for c, mc := range didCodecs {
+		if uint64(mc) == codec {
+			code = c
+		}
+	}
+	switch code {
+	case 0:
+		return multikeypair.Keypair{}, multikeypair.ErrUnsupportedCipher
+	case multikeypair.P_256:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), public)
+		if x == nil {
+			return multikeypair.Keypair{}, multikeypair.ErrInvalidPublicKey
+		}
+		public = make([]byte, 65)
+		public[0] = 0x04
+		x.FillBytes(public[1:33])
+		y.FillBytes(public[33:])
+	}
+	name, err := multikeypair.CipherName(code)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+
+	return multikeypair.Keypair{
+		Code:         code,
+		Name:         name,
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
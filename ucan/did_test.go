@@ -0,0 +1,61 @@
+// go-multikeypair/ucan/did_test.go
+
+package ucan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// The Ed25519 example of the did:key specification.
+func TestDIDKeyVector(t *testing.T) {
+	public, _ := base58.Decode("4zvwRjXUKGfvwnParsHAS3HuSVzV5cA4McphgmoCtajS")
+	k := multikeypair.Keypair{Code: multikeypair.ED_25519, Public: public}
+
+	did, err := DIDKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"; did != want {
+		t.Fatalf("got %s, want %s", did, want)
+	}
+}
+
+// Public keys of each supported cipher round trip through did:key.
+func TestDIDKey(t *testing.T) {
+	for _, code := range []uint64{
+		multikeypair.ED_25519,
+		multikeypair.X_25519,
+		multikeypair.SECP_256K1,
+		multikeypair.P_256,
+		multikeypair.RSA,
+	} {
+		k, err := multikeypair.Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		did, err := DIDKey(k)
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		decoded, err := KeypairFromDIDKey(did)
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		if decoded.Code != k.Code || string(decoded.Public) != string(k.Public) || decoded.Private != nil {
+			t.Errorf("%s: round trip changed the key", k.Name)
+		}
+	}
+}
+
+// Malformed identifiers are rejected.
+func TestDIDKeyInvalid(t *testing.T) {
+	for _, did := range []string{"", "did:web:example.com", "did:key:z0OIl", "did:key:z"} {
+		if _, err := KeypairFromDIDKey(did); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("%q: got %v", did, err)
+		}
+	}
+}
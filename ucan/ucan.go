@@ -0,0 +1,351 @@
+// go-multikeypair/ucan/ucan.go
+//
+// User Controlled Authorization Networks (UCAN 0.10): JWTs in which an
+// issuer key, named by its did:key, delegates capabilities to an
+// audience key. The payload is:
+//
+//	{
+//	  "ucv": "0.10.0",
+//	  "iss": "did:key:...",           issuer
+//	  "aud": "did:key:...",           audience
+//	  "nbf": 1700000000,              optional start of validity
+//	  "exp": 1700003600,              end of validity, or null
+//	  "nnc": "...",                   optional nonce
+//	  "cap": {resource: {ability: [caveat, ...]}},
+//	  "prf": ["bafkrei...", ...]      CIDs of the proof UCANs
+//	}
+//
+// A UCAN with no proofs claims authority of its own; one with proofs
+// delegates (some of) what its proofs grant to its issuer. Delegating
+// may only attenuate: each capability must be covered by a proof whose
+// audience is the issuer, and a proof's validity bounds those it
+// supports. Proofs are referenced by the CIDv1 (raw, SHA-256) of their
+// token.
+//
+// Validate checks a whole chain, from the token presented back to
+// proofs issued by a trusted root key.
+
+package ucan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	"github.com/proofzero/go-multikeypair/jose"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// UCAN-specific errors this package exports.
+var (
+	ErrInvalidUCAN  = errors.New("input isn't a valid UCAN")
+	ErrMissingProof = errors.New("UCAN proof not available")
+	ErrBrokenChain  = errors.New("UCAN proof wasn't issued to UCAN issuer")
+	ErrEscalation   = errors.New("UCAN grants capability its proofs don't")
+	ErrUntrusted    = errors.New("UCAN chain doesn't start at trusted root")
+	ErrNotYetValid  = errors.New("UCAN not yet valid")
+	ErrExpired      = errors.New("UCAN expired")
+)
+
+// Version of the UCAN specification implemented.
+const VERSION = "0.10.0"
+
+// Wildcard ability, covering every ability on a resource.
+const ANY = "*"
+
+// Longest chain of proofs Validate follows.
+const maxDepth = 32
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Capabilities maps resources (URIs) to the abilities granted on them,
+// e.g. "store/put", each with a list of caveats. A single empty caveat
+// ({}) places no restriction.
+type Capabilities map[string]map[string][]map[string]any
+
+// UCAN is a decoded token.
+type UCAN struct {
+	// Public key of the issuer.
+	Issuer multikeypair.Keypair
+	// Public key of the audience.
+	Audience multikeypair.Keypair
+	// Capabilities delegated to the audience.
+	Capabilities Capabilities
+	// Start of the validity window. The zero value means no start.
+	NotBefore time.Time
+	// End of the validity window. The zero value means no expiry.
+	Expiry time.Time
+	// Nonce, if any.
+	Nonce string
+	// CIDs of the proofs.
+	Proofs []string
+}
+
+// The JSON payload.
+type payload struct {
+	Version      string       `json:"ucv"`
+	Issuer       string       `json:"iss"`
+	Audience     string       `json:"aud"`
+	NotBefore    int64        `json:"nbf,omitempty"`
+	Expiry       *int64       `json:"exp"`
+	Nonce        string       `json:"nnc,omitempty"`
+	Capabilities Capabilities `json:"cap"`
+	Proofs       []string     `json:"prf"`
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Issue signs a UCAN from issuer to audience granting capabilities until
+// expiry (zero for none). With proofs, the UCAN delegates: every
+// capability must be covered by a proof issued to the issuer, and the
+// proofs' tokens are referenced by CID.
+func Issue(
+	issuer multikeypair.Keypair,
+	audience multikeypair.Keypair,
+	capabilities Capabilities,
+	expiry time.Time,
+	proofs ...string,
+) (string, error) {
+	iss, err := DIDKey(issuer)
+	if err != nil {
+		return "", err
+	}
+	aud, err := DIDKey(audience)
+	if err != nil {
+		return "", err
+	}
+
+	p := payload{
+		Version:      VERSION,
+		Issuer:       iss,
+		Audience:     aud,
+		Capabilities: capabilities,
+		Proofs:       []string{},
+	}
+	if !expiry.IsZero() {
+		exp := expiry.Unix()
+		p.Expiry = &exp
+	}
+
+	var parents []UCAN
+	for _, token := range proofs {
+		proof, err := Parse(token)
+		if err != nil {
+			return "", err
+		}
+		parents = append(parents, proof)
+		p.Proofs = append(p.Proofs, CID(token))
+	}
+	u := UCAN{Issuer: issuer, Capabilities: capabilities, Expiry: expiry}
+	if len(parents) > 0 {
+		if err := attenuates(u, parents); err != nil {
+			return "", err
+		}
+	}
+	return jose.SignJWT(issuer, p)
+}
+
+// Parse decodes a UCAN and checks its signature against the issuer's
+// did:key. It doesn't check the validity window or proofs.
+func Parse(token string) (UCAN, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return UCAN{}, ErrInvalidUCAN
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return UCAN{}, ErrInvalidUCAN
+	}
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil || p.Capabilities == nil {
+		return UCAN{}, ErrInvalidUCAN
+	}
+	issuer, err := KeypairFromDIDKey(p.Issuer)
+	if err != nil {
+		return UCAN{}, err
+	}
+	audience, err := KeypairFromDIDKey(p.Audience)
+	if err != nil {
+		return UCAN{}, err
+	}
+	if _, err := jose.Verify(token, issuer); err != nil {
+		return UCAN{}, err
+	}
+
+	u := UCAN{
+		Issuer:       issuer,
+		Audience:     audience,
+		Capabilities: p.Capabilities,
+		Nonce:        p.Nonce,
+		Proofs:       p.Proofs,
+	}
+	if p.NotBefore != 0 {
+		u.NotBefore = time.Unix(p.NotBefore, 0)
+	}
+	if p.Expiry != nil {
+		u.Expiry = time.Unix(*p.Expiry, 0)
+	}
+	return u, nil
+}
+
+// Validate checks a UCAN and its chain of proofs at the given time,
+// returning the decoded UCAN. Proofs are looked up by CID among the
+// tokens given; every chain must end in UCANs issued by root, which
+// are trusted for any capability.
+func Validate(token string, root multikeypair.Keypair, proofs []string, at time.Time) (UCAN, error) {
+	byCID := make(map[string]string, len(proofs))
+	for _, proof := range proofs {
+		byCID[CID(proof)] = proof
+	}
+	return validate(token, root, byCID, at, 0)
+}
+
+// Validate a UCAN and, recursively, its proofs.
+func validate(token string, root multikeypair.Keypair, proofs map[string]string, at time.Time, depth int) (UCAN, error) {
+	if depth > maxDepth {
+		return UCAN{}, ErrInvalidUCAN
+	}
+	u, err := Parse(token)
+	if err != nil {
+		return UCAN{}, err
+	}
+	if !u.NotBefore.IsZero() && at.Before(u.NotBefore) {
+		return UCAN{}, ErrNotYetValid
+	}
+	if !u.Expiry.IsZero() && !at.Before(u.Expiry) {
+		return UCAN{}, ErrExpired
+	}
+
+	if len(u.Proofs) == 0 {
+		if !samePublic(u.Issuer, root) {
+			return UCAN{}, ErrUntrusted
+		}
+		return u, nil
+	}
+	var parents []UCAN
+	for _, cid := range u.Proofs {
+		proof, ok := proofs[cid]
+		if !ok {
+			return UCAN{}, ErrMissingProof
+		}
+		parent, err := validate(proof, root, proofs, at, depth+1)
+		if err != nil {
+			return UCAN{}, err
+		}
+		parents = append(parents, parent)
+	}
+	if err := attenuates(u, parents); err != nil {
+		return UCAN{}, err
+	}
+	return u, nil
+}
+
+// Check that a UCAN grants no more than its proofs give its issuer, and
+// expires no later than they do.
+func attenuates(u UCAN, proofs []UCAN) error {
+	for _, proof := range proofs {
+		if !samePublic(proof.Audience, u.Issuer) {
+			return ErrBrokenChain
+		}
+		if !proof.Expiry.IsZero() && (u.Expiry.IsZero() || u.Expiry.Unix() > proof.Expiry.Unix()) {
+			return ErrEscalation
+		}
+	}
+	for resource, abilities := range u.Capabilities {
+		for ability, caveats := range abilities {
+			if !coveredBy(proofs, resource, ability, caveats) {
+				return ErrEscalation
+			}
+		}
+	}
+	return nil
+}
+
+// Check whether a capability is covered by any of a set of proofs.
+func coveredBy(proofs []UCAN, resource string, ability string, caveats []map[string]any) bool {
+	for _, proof := range proofs {
+		for held, heldCaveats := range proof.Capabilities[resource] {
+			if !abilityCovers(held, ability) {
+				continue
+			}
+			if caveatsCover(heldCaveats, caveats) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check whether a held ability covers another: "*" covers everything,
+// and "store/*" everything beneath "store/".
+func abilityCovers(held string, ability string) bool {
+	if held == ANY || held == ability {
+		return true
+	}
+	return strings.HasSuffix(held, "/*") && strings.HasPrefix(ability, held[:len(held)-1])
+}
+
+// Check whether held caveats cover delegated ones: an unrestricted
+// (empty) held caveat covers any, and otherwise each delegated caveat
+// must be one that's held.
+func caveatsCover(held []map[string]any, caveats []map[string]any) bool {
+	for _, h := range held {
+		if len(h) == 0 {
+			return true
+		}
+	}
+	if len(caveats) == 0 {
+		return false
+	}
+	for _, c := range caveats {
+		found := false
+		for _, h := range held {
+			if reflect.DeepEqual(normalize(h), normalize(c)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Round trip a caveat through JSON, so that caveats built in Go compare
+// equal to decoded ones.
+func normalize(caveat map[string]any) any {
+	b, err := json.Marshal(caveat)
+	if err != nil {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// Check whether two Keypairs have the same public key.
+func samePublic(a multikeypair.Keypair, b multikeypair.Keypair) bool {
+	return a.Code == b.Code && bytes.Equal(a.Public, b.Public)
+}
+
+// CID returns the CIDv1 by which a UCAN token is referenced as a proof:
+// raw codec, SHA-256 multihash, base32 multibase.
+func CID(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	cid := append([]byte{0x01, 0x55, 0x12, 0x20}, digest[:]...)
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(cid))
+}
@@ -0,0 +1,131 @@
+// go-multikeypair/ucan/ucan_test.go
+
+package ucan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+func generate(t *testing.T) multikeypair.Keypair {
+	t.Helper()
+	k, err := multikeypair.Generate(multikeypair.ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// Storage capabilities on a bucket, with the given abilities.
+func storage(abilities ...string) Capabilities {
+	caps := Capabilities{"storage://photos": {}}
+	for _, a := range abilities {
+		caps["storage://photos"][a] = []map[string]any{{}}
+	}
+	return caps
+}
+
+// Delegate from root to child to grandchild, attenuating as we go.
+func TestValidateChain(t *testing.T) {
+	root, child, grandchild := generate(t), generate(t), generate(t)
+	now := time.Now()
+
+	first, err := Issue(root, child, storage("store/*"), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Issue(child, grandchild, storage("store/get"), now.Add(time.Minute), first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := Validate(second, root, []string{first}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Audience.Code != grandchild.Code || string(u.Audience.Public) != string(grandchild.Public) {
+		t.Error("wrong audience")
+	}
+	if len(u.Proofs) != 1 || u.Proofs[0] != CID(first) {
+		t.Errorf("proofs %v", u.Proofs)
+	}
+
+	if _, err := Validate(second, root, nil, now); !errors.Is(err, ErrMissingProof) {
+		t.Errorf("missing proof: %v", err)
+	}
+	if _, err := Validate(second, child, []string{first}, now); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("wrong root: %v", err)
+	}
+	if _, err := Validate(second, root, []string{first}, now.Add(2*time.Minute)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired: %v", err)
+	}
+}
+
+// Delegations can't widen what the proofs grant, extend their expiry, or
+// use proofs issued to someone else.
+func TestIssueEscalation(t *testing.T) {
+	root, child, other := generate(t), generate(t), generate(t)
+	expiry := time.Now().Add(time.Hour)
+
+	first, err := Issue(root, child, storage("store/get"), expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Issue(child, other, storage("store/put"), expiry, first); !errors.Is(err, ErrEscalation) {
+		t.Errorf("wider ability: %v", err)
+	}
+	if _, err := Issue(child, other, storage("store/get"), expiry.Add(time.Hour), first); !errors.Is(err, ErrEscalation) {
+		t.Errorf("later expiry: %v", err)
+	}
+	if _, err := Issue(other, child, storage("store/get"), expiry, first); !errors.Is(err, ErrBrokenChain) {
+		t.Errorf("wrong issuer: %v", err)
+	}
+}
+
+// Caveats can be added but not removed when delegating.
+func TestCaveats(t *testing.T) {
+	root, child, other := generate(t), generate(t), generate(t)
+	expiry := time.Now().Add(time.Hour)
+	restricted := Capabilities{"storage://photos": {"store/get": {{"path": "/public"}}}}
+
+	first, err := Issue(root, child, storage("store/get"), expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Issue(child, other, restricted, expiry, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Issue(other, root, storage("store/get"), expiry, second); !errors.Is(err, ErrEscalation) {
+		t.Errorf("dropped caveat: %v", err)
+	}
+	if _, err := Issue(other, root, restricted, expiry, second); err != nil {
+		t.Errorf("kept caveat: %v", err)
+	}
+}
+
+// A token whose payload is altered fails to parse.
+func TestParseTampered(t *testing.T) {
+	root, child := generate(t), generate(t)
+	token, err := Issue(root, child, storage("store/get"), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := token[:len(token)-2] + "AA"
+	if tampered == token {
+		tampered = token[:len(token)-2] + "BA"
+	}
+	if _, err := Parse(tampered); err == nil {
+		t.Error("tampered token parsed")
+	}
+	u, err := Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.Expiry.IsZero() {
+		t.Error("unexpected expiry")
+	}
+}
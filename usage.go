@@ -0,0 +1,81 @@
+// go-multikeypair/usage.go
+//
+// Key usage flags, recorded in a keypair's metadata and enforced by the
+// operations in this module, so that e.g. an encryption-only key can't
+// accidentally be used for signing.
+
+package multikeypair
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Usage-specific errors this module exports.
+var (
+	ErrUsageNotPermitted = errors.New("operation not permitted by key usage")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Usage is a set of operations a key may be used for. The zero value
+// places no restriction on the key.
+type Usage uint32
+
+// Operations a key may be restricted to.
+const (
+	USAGE_SIGN         = Usage(1 << 0)
+	USAGE_ENCRYPT      = Usage(1 << 1)
+	USAGE_DERIVE       = Usage(1 << 2)
+	USAGE_AUTHENTICATE = Usage(1 << 3)
+)
+
+// Names of the usage flags, in bit order.
+var usageNames = []struct {
+	usage Usage
+	name  string
+}{
+	{USAGE_SIGN, "sign"},
+	{USAGE_ENCRYPT, "encrypt"},
+	{USAGE_DERIVE, "derive"},
+	{USAGE_AUTHENTICATE, "authenticate"},
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Allows reports whether every operation in op is permitted. An empty
+// Usage permits everything.
+func (u Usage) Allows(op Usage) bool {
+	return u == 0 || u&op == op
+}
+
+// String lists the permitted operations, e.g. "sign|authenticate".
+func (u Usage) String() string {
+	if u == 0 {
+		return "any"
+	}
+	var names []string
+	for _, n := range usageNames {
+		if u&n.usage != 0 {
+			names = append(names, n.name)
+			u &^= n.usage
+		}
+	}
+	if u != 0 {
+		names = append(names, "unknown")
+	}
+	return strings.Join(names, "|")
+}
+
+// Check that the Keypair's usage permits an operation.
+func (k Keypair) checkUsage(op Usage) error {
+	if !k.Metadata.Usage.Allows(op) {
+		return ErrUsageNotPermitted
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// go-multikeypair/usage_test.go
+
+package multikeypair
+
+import (
+	"testing"
+)
+
+// Keys restricted to other uses can't sign or verify.
+func TestUsageEnforced(t *testing.T) {
+	kp := generateEd25519(t)
+	sig, err := kp.Sign([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kp.Metadata.Usage = USAGE_ENCRYPT
+	if _, err := kp.Sign([]byte("message")); err != ErrUsageNotPermitted {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := kp.Verify([]byte("message"), sig); err != ErrUsageNotPermitted {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Usage survives encoding, so the restriction does too.
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.Sign([]byte("message")); err != ErrUsageNotPermitted {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	kp.Metadata.Usage = USAGE_SIGN | USAGE_AUTHENTICATE
+	if _, err := kp.Sign([]byte("message")); err != nil {
+		t.Error(err)
+	}
+}
+
+// Usage sets report what they allow.
+func TestUsageAllows(t *testing.T) {
+	if !Usage(0).Allows(USAGE_SIGN | USAGE_DERIVE) {
+		t.Error("empty usage should allow everything")
+	}
+	u := USAGE_SIGN | USAGE_AUTHENTICATE
+	if !u.Allows(USAGE_SIGN) || u.Allows(USAGE_SIGN|USAGE_ENCRYPT) {
+		t.Error("unexpected Allows result")
+	}
+	if s := u.String(); s != "sign|authenticate" {
+		t.Errorf("unexpected string: %s", s)
+	}
+	if s := Usage(0).String(); s != "any" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
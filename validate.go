@@ -0,0 +1,82 @@
+// go-multikeypair/validate.go
+//
+// Consistency checking of key material. Encoding never interprets the
+// keys it packs, so a Keypair whose public key doesn't belong to its
+// private key encodes without complaint and only fails later, when a
+// signature doesn't verify. Validate catches that up front by deriving
+// the public key from the private key and comparing.
+//
+// The same derivation recovers the public key when only the private key
+// is at hand, as when importing keys from tools that store just that.
+// Private keys are in the forms the rest of the module uses:
+//
+//	ed25519     64-byte seed || public key (a 32-byte seed is also
+//	            accepted by KeypairFromPrivate)
+//	ml-dsa-65   32-byte FIPS 204 seed
+//	ml-kem-768  64-byte FIPS 203 seed
+//	x25519      32-byte scalar
+//	p256        32-byte scalar
+//	secp256k1   32-byte scalar
+//	rsa         PKCS#1 DER
+
+package multikeypair
+
+import (
+	"crypto/ed25519"
+	"crypto/subtle"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Validation-specific errors this module exports.
+var (
+	ErrKeyMismatch = errors.New("public key doesn't match private key")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Validate checks that the public key is the one that belongs to the
+// private key. Keypairs without a private key have nothing to check
+// against and are accepted.
+func (k Keypair) Validate() error {
+	if len(k.Private) == 0 {
+		return nil
+	}
+	public, err := PublicFromPrivate(k.Code, k.Private)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(public, k.Public) != 1 {
+		return ErrKeyMismatch
+	}
+	return nil
+}
+
+// PublicFromPrivate derives the public key that belongs to a private key
+// of the given cipher.
+func PublicFromPrivate(code uint64, private []byte) ([]byte, error) {
+	s, err := lookupScheme(code)
+	if err != nil {
+		return nil, err
+	}
+	if s.public == nil {
+		return nil, ErrUnsupportedCipher
+	}
+	return s.public(private)
+}
+
+// KeypairFromPrivate builds a Keypair from a private key alone, deriving
+// its public key.
+func KeypairFromPrivate(code uint64, private []byte) (Keypair, error) {
+	if code == ED_25519 && len(private) == ed25519.SeedSize {
+		private = ed25519.NewKeyFromSeed(private)
+	}
+	public, err := PublicFromPrivate(code, private)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return newKeypair(code, private, public), nil
+}
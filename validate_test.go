@@ -0,0 +1,102 @@
+// go-multikeypair/validate_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+// Generated keypairs validate, and swapping in another keypair's public
+// key is caught.
+func TestValidate(t *testing.T) {
+	for code := range schemes {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := k.Validate(); err != nil {
+			t.Errorf("%s: %v", k.Name, err)
+		}
+		if err := k.publicOnly().Validate(); err != nil {
+			t.Errorf("%s: public only: %v", k.Name, err)
+		}
+
+		other, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Public = other.Public
+		if err := k.Validate(); !errors.Is(err, ErrKeyMismatch) {
+			t.Errorf("%s: expected ErrKeyMismatch, got %v", k.Name, err)
+		}
+	}
+}
+
+// Malformed private keys and ciphers without key derivation are refused.
+func TestValidateRefused(t *testing.T) {
+	k, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An Ed25519 private key carries its public key, which must match
+	// the seed too.
+	k.Private = append([]byte{}, k.Private...)
+	k.Private[63] ^= 0x01
+	if err := k.Validate(); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+
+	p256 := Keypair{Code: P_256, Private: make([]byte, 32), Public: make([]byte, 65)}
+	if err := p256.Validate(); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+
+	dsa := Keypair{Code: DSA, Private: []byte("private"), Public: []byte("public")}
+	if err := dsa.Validate(); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+}
+
+// Keypairs rebuilt from their private keys alone match the originals.
+func TestKeypairFromPrivate(t *testing.T) {
+	for code := range schemes {
+		k, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := PublicFromPrivate(code, k.Private)
+		if err != nil {
+			t.Fatalf("%s: %v", k.Name, err)
+		}
+		if !bytes.Equal(public, k.Public) {
+			t.Errorf("%s: public key doesn't match", k.Name)
+		}
+		rebuilt, err := KeypairFromPrivate(code, k.Private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rebuilt.Equal(k) {
+			t.Errorf("%s: rebuilt keypair doesn't match", k.Name)
+		}
+	}
+
+	// A bare Ed25519 seed is expanded.
+	seed := bytes.Repeat([]byte{0x42}, ed25519.SeedSize)
+	k, err := KeypairFromPrivate(ED_25519, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k.Private, ed25519.NewKeyFromSeed(seed)) || k.PublicLength != ed25519.PublicKeySize {
+		t.Errorf("unexpected keypair %+v", k)
+	}
+
+	if _, err := PublicFromPrivate(DSA, []byte("private")); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	if _, err := KeypairFromPrivate(SECP_256K1, make([]byte, 32)); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// go-multikeypair/validity.go
+//
+// Validity windows for keypairs, recorded in their metadata, so that key
+// lifecycle policies don't need bookkeeping outside the key itself.
+// Signing with a key outside its window is refused; verifying isn't,
+// since signatures made while the key was valid stay good.
+
+package multikeypair
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Validity-specific errors this module exports.
+var (
+	ErrKeyNotYetValid = errors.New("key not yet valid")
+	ErrKeyExpired     = errors.New("key expired")
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Valid checks that at falls within the Keypair's validity window. Keys
+// without a window are always valid.
+func (k Keypair) Valid(at time.Time) error {
+	return validAt(k.Metadata.NotBefore, k.Metadata.NotAfter, at)
+}
+
+// Expired reports whether the Keypair's validity window ended before at.
+func (k Keypair) Expired(at time.Time) bool {
+	return k.Valid(at) == ErrKeyExpired
+}
+
+// Valid checks that at falls within the described key's validity window.
+func (i Info) Valid(at time.Time) error {
+	return validAt(i.NotBefore, i.NotAfter, at)
+}
+
+// Expired reports whether the described key's validity window ended
+// before at.
+func (i Info) Expired(at time.Time) bool {
+	return i.Valid(at) == ErrKeyExpired
+}
+
+// Check that the Keypair is within its validity window now.
+func (k Keypair) checkValid() error {
+	return k.Valid(time.Now())
+}
+
+// Check at against a validity window, either end of which may be zero.
+func validAt(notBefore time.Time, notAfter time.Time, at time.Time) error {
+	if !notBefore.IsZero() && at.Before(notBefore) {
+		return ErrKeyNotYetValid
+	}
+	if !notAfter.IsZero() && at.After(notAfter) {
+		return ErrKeyExpired
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// go-multikeypair/validity_test.go
+
+package multikeypair
+
+import (
+	"testing"
+	"time"
+)
+
+// Keys are valid only within their window, which survives encoding.
+func TestValid(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	end := start.Add(24 * time.Hour)
+
+	kp := generateEd25519(t)
+	if err := kp.Valid(start); err != nil {
+		t.Errorf("key without window: %v", err)
+	}
+
+	kp.Metadata.NotBefore = start
+	kp.Metadata.NotAfter = end
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeWithOptions(mk, DecodeOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for at, want := range map[time.Time]error{
+		start.Add(-time.Second): ErrKeyNotYetValid,
+		start:                   nil,
+		end:                     nil,
+		end.Add(time.Second):    ErrKeyExpired,
+	} {
+		if err := decoded.Valid(at); err != want {
+			t.Errorf("%v: got %v, want %v", at, err, want)
+		}
+	}
+	if !decoded.Expired(end.Add(time.Second)) || decoded.Expired(start.Add(-time.Second)) {
+		t.Error("unexpected Expired result")
+	}
+}
+
+// Keys outside their window don't sign, but their signatures verify.
+func TestSignValidity(t *testing.T) {
+	kp := generateEd25519(t)
+	message := []byte("message")
+	signature, err := kp.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kp.Metadata.NotAfter = time.Now().Add(-time.Hour)
+	if _, err := kp.Sign(message); err != ErrKeyExpired {
+		t.Errorf("expired key: got %v", err)
+	}
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.ProvePossession(challenge); err != ErrKeyExpired {
+		t.Errorf("expired key proving possession: got %v", err)
+	}
+	if err := kp.Verify(message, signature); err != nil {
+		t.Errorf("expired key verifying: %v", err)
+	}
+
+	kp.Metadata = Metadata{NotBefore: time.Now().Add(time.Hour)}
+	if _, err := kp.Sign(message); err != ErrKeyNotYetValid {
+		t.Errorf("future key: got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// go-multikeypair/version.go
+//
+// Explicit control over the wire format version, and migration of
+// existing multikeypairs to the current version. Encode picks the
+// smallest layout that fits; these functions are for callers that need a
+// particular one, e.g. to attach features that only v2 supports.
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+// CURRENT_VERSION is the version Migrate upgrades to.
+const CURRENT_VERSION = V2
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Version returns the wire format version of a Multikeypair. Only the
+// header is read; the rest of the encoding isn't validated.
+func (m Multikeypair) Version() (uint64, error) {
+	if !bytes.HasPrefix(m, versionEscape) {
+		if len(m) < len(versionEscape) {
+			return 0, decodeError(FIELD_LENGTH, 0, ErrInvalidMultikeypair)
+		}
+		return V1, nil
+	}
+	version, n := binary.Uvarint(m[len(versionEscape):])
+	if n <= 0 {
+		return 0, decodeError(FIELD_VERSION, len(versionEscape), ErrInvalidMultikeypair)
+	}
+	if version != V2 {
+		return 0, decodeError(FIELD_VERSION, len(versionEscape), ErrUnknownVersion)
+	}
+	return version, nil
+}
+
+// EncodeVersion encodes a keypair into a Multikeypair using a specific
+// wire format version. Keys too long for the requested version are
+// rejected with ErrTooLong.
+func EncodeVersion(private []byte, public []byte, code uint64, version uint64) (Multikeypair, error) {
+	if err := validCode(code); err != nil {
+		return Multikeypair{}, err
+	}
+	if len(private) > MAX_KEY_LENGTH || len(public) > MAX_KEY_LENGTH {
+		return Multikeypair{}, ErrTooLong
+	}
+
+	switch version {
+	case V1:
+		if len(private) > MAX_V1_KEY_LENGTH || len(public) > MAX_V1_KEY_LENGTH {
+			return Multikeypair{}, ErrTooLong
+		}
+		return Multikeypair(encodeKeypairV1(private, public, code)), nil
+	case V2:
+		return Multikeypair(encodeKeypairV2(private, public, code)), nil
+	}
+	return Multikeypair{}, ErrUnknownVersion
+}
+
+// Migrate re-encodes a Multikeypair of any supported version using
+// CURRENT_VERSION, keeping its metadata and recomputing its checksum if
+// it had one. The result never aliases m. Its optional fields are in
+// canonical order, but keys that fit the v1 layout aren't canonical in
+// v2, so strict decoding refuses them until Canonical returns them to
+// v1.
+func Migrate(m Multikeypair) (Multikeypair, error) {
+	kp, err := Decode(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	// The private key is taken from the encoding rather than kp, which
+	// leaves out a wrapped one.
+	_, private, _, rest, _ := splitKeypair(m)
+	out, err := EncodeVersion(private, kp.Public, kp.Code, CURRENT_VERSION)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+
+	fields, ok := splitExtensions(rest)
+	if !ok {
+		return out, nil
+	}
+	var kept []extension
+	checksummed := false
+	for _, f := range fields {
+		if f.tag == TAG_CHECKSUM {
+			checksummed = true
+			continue
+		}
+		kept = append(kept, f)
+	}
+	slices.SortStableFunc(kept, compareExtensions)
+	if len(kept) > 0 {
+		b, err := appendExtensions(out, kept...)
+		if err != nil {
+			return Multikeypair{}, err
+		}
+		out = Multikeypair(b)
+	}
+	if checksummed {
+		return out.WithChecksum()
+	}
+	return out, nil
+}
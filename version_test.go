@@ -0,0 +1,158 @@
+// go-multikeypair/version_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Small keypairs encode as v1 and migrate to v2 without changing content.
+func TestMigrate(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := mk.Version(); err != nil || v != V1 {
+		t.Fatalf("Version = %d, %v", v, err)
+	}
+
+	migrated, err := Migrate(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := migrated.Version(); err != nil || v != V2 {
+		t.Fatalf("migrated Version = %d, %v", v, err)
+	}
+
+	decoded, err := Decode(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(kp) {
+		t.Error("migrated keypair doesn't match original")
+	}
+
+	// Migrating again is a no-op.
+	again, err := Migrate(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, migrated) {
+		t.Error("migrating v2 changed the encoding")
+	}
+}
+
+// EncodeVersion honours the requested version.
+func TestEncodeVersion(t *testing.T) {
+	kp := generateEd25519(t)
+
+	v2, err := EncodeVersion(kp.Private, kp.Public, kp.Code, V2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := v2.Version(); v != V2 {
+		t.Errorf("expected v2, got %d", v)
+	}
+	if _, err := DecodeWithOptions(v2, DecodeOptions{Strict: true}); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("strict decoding accepted v2 for a key that fits v1: %v", err)
+	}
+
+	long := bytes.Repeat([]byte{0x01}, MAX_V1_KEY_LENGTH+1)
+	if _, err := EncodeVersion(nil, long, RSA, V1); !errors.Is(err, ErrTooLong) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := EncodeVersion(kp.Private, kp.Public, kp.Code, 3); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Unknown versions are reported as such.
+func TestVersionUnknown(t *testing.T) {
+	m := Multikeypair{0x00, 0x00, 0x00, 0x03}
+	if _, err := m.Version(); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Decode(m); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Migration keeps metadata and checksums, and Canonical returns the
+// result to the strictly decodable v1 layout.
+func TestMigrateMetadata(t *testing.T) {
+	kp := generateEd25519(t)
+	kp.Metadata = Metadata{Label: "kept"}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mk, err = mk.WithChecksum(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := Migrate(mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeWithOptions(migrated, DecodeOptions{RequireChecksum: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Metadata.Label != "kept" {
+		t.Errorf("unexpected label: %q", decoded.Metadata.Label)
+	}
+
+	strict := DecodeOptions{Strict: true, RequireChecksum: true}
+	if _, err := DecodeWithOptions(migrated, strict); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("strict decoding accepted the v2 encoding: %v", err)
+	}
+	canonical, err := migrated.Canonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(canonical, mk) {
+		t.Error("canonical encoding differs from the original")
+	}
+	if _, err := DecodeWithOptions(canonical, strict); err != nil {
+		t.Error(err)
+	}
+}
+
+// Migration keeps a wrapped private key, which still unwraps.
+func TestMigrateWrapped(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x42}, 32)
+	mk, err := generateEd25519(t).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := mk.Wrap(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := Migrate(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated.IsWrapped() {
+		t.Fatal("migrated key isn't wrapped")
+	}
+	unwrapped, err := migrated.Unwrap(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := unwrapped.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kp.Equal(want) {
+		t.Error("wrapped key didn't survive migration")
+	}
+}
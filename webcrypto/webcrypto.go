@@ -0,0 +1,181 @@
+// go-multikeypair/webcrypto/webcrypto.go
+//
+// Non-extractable keys created with the WebCrypto API (SubtleCrypto)
+// when running as WebAssembly in a browser or another JavaScript host.
+// The private key is a CryptoKey that the host never reveals, not even
+// to the page; the backend holds it under a random name, and that name
+// is the RemoteKeypair reference:
+//
+//	webcrypto:<32 hex digits>
+//
+// Ed25519 and P-256 keys are supported. Keys live only as long as the
+// WebAssembly instance that created them. Outside GOOS=js GOARCH=wasm,
+// or where the host has no SubtleCrypto, every operation fails with
+// ErrUnsupported.
+
+package webcrypto
+
+import (
+	"context"
+	crypto_rand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+	cryptobyte "golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// WebCrypto-specific errors this package exports.
+var (
+	ErrUnsupported       = errors.New("webcrypto not available on this platform")
+	ErrUnsupportedCipher = errors.New("cipher not supported by webcrypto")
+	ErrInvalidReference  = errors.New("invalid webcrypto key reference")
+	ErrInvalidSignature  = errors.New("invalid webcrypto signature")
+)
+
+// SCHEME is the reference scheme for WebCrypto keys.
+const SCHEME = "webcrypto"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// The operations the host provides on keys identified by name. Public
+// keys are in WebCrypto's "raw" format, which for P-256 is the 65-byte
+// uncompressed point, and P-256 signatures are the 64-byte
+// concatenation of r and s over the SHA-256 digest of the message.
+type platformWebCrypto interface {
+	generate(ctx context.Context, name string, code uint64) error
+	publicKey(ctx context.Context, name string) (code uint64, public []byte, err error)
+	sign(ctx context.Context, name string, message []byte) (code uint64, signature []byte, err error)
+	remove(name string) error
+}
+
+// The backend registered under SCHEME.
+type backend struct{}
+
+func init() {
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, backend{}); err != nil {
+		panic(err)
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Generate creates a new non-extractable key with WebCrypto and returns
+// a RemoteKeypair for it. The code must be multikeypair.ED_25519 or
+// multikeypair.P_256; the key is restricted to multikeypair.USAGE_SIGN.
+func Generate(ctx context.Context, code uint64) (multikeypair.RemoteKeypair, error) {
+	if code != multikeypair.ED_25519 && code != multikeypair.P_256 {
+		return multikeypair.RemoteKeypair{}, ErrUnsupportedCipher
+	}
+	if platform == nil {
+		return multikeypair.RemoteKeypair{}, ErrUnsupported
+	}
+	random := make([]byte, 16)
+	if _, err := crypto_rand.Read(random); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	name := hex.EncodeToString(random)
+	if err := platform.generate(ctx, name, code); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	return multikeypair.NewRemoteKeypair(ctx, SCHEME+":"+name)
+}
+
+// Delete forgets a WebCrypto key. Once the host collects the CryptoKey,
+// nothing can sign with it again.
+func Delete(r multikeypair.RemoteKeypair) error {
+	name, err := parseReference(r.Reference)
+	if err != nil {
+		return err
+	}
+	if platform == nil {
+		return ErrUnsupported
+	}
+	return platform.remove(name)
+}
+
+// PublicKey exports the public half of a WebCrypto key.
+func (backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	if !validName(resource) {
+		return multikeypair.Keypair{}, ErrInvalidReference
+	}
+	if platform == nil {
+		return multikeypair.Keypair{}, ErrUnsupported
+	}
+	code, public, err := platform.publicKey(ctx, resource)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	m, err := multikeypair.Encode(nil, public, code)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	return kp, nil
+}
+
+// Sign signs message with a WebCrypto key. P-256 signatures are
+// converted to the ASN.1 DER form Keypair.Verify accepts.
+func (backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	if !validName(resource) {
+		return nil, ErrInvalidReference
+	}
+	if platform == nil {
+		return nil, ErrUnsupported
+	}
+	code, signature, err := platform.sign(ctx, resource, message)
+	if err != nil {
+		return nil, err
+	}
+	if code == multikeypair.P_256 {
+		return p1363ToASN1(signature)
+	}
+	return signature, nil
+}
+
+// Convert an ECDSA signature from the fixed-width r || s form WebCrypto
+// produces to ASN.1 DER.
+func p1363ToASN1(signature []byte) ([]byte, error) {
+	if len(signature) == 0 || len(signature)%2 != 0 {
+		return nil, ErrInvalidSignature
+	}
+	half := len(signature) / 2
+	r := new(big.Int).SetBytes(signature[:half])
+	s := new(big.Int).SetBytes(signature[half:])
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.Bytes()
+}
+
+// Extract the key name from a reference.
+func parseReference(reference string) (string, error) {
+	name, ok := strings.CutPrefix(reference, SCHEME+":")
+	if !ok || !validName(name) {
+		return "", ErrInvalidReference
+	}
+	return name, nil
+}
+
+// Report whether a name is one this package could have created.
+func validName(name string) bool {
+	if len(name) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil && strings.ToLower(name) == name
+}
@@ -0,0 +1,157 @@
+//go:build js && wasm
+
+// go-multikeypair/webcrypto/webcrypto_js.go
+//
+// WebCrypto access through syscall/js. Key pairs are generated with
+// crypto.subtle.generateKey as non-extractable CryptoKeyPairs and kept
+// in a map by name; SubtleCrypto's promises are awaited by parking the
+// calling goroutine, which lets the JavaScript event loop run.
+
+package webcrypto
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// The JavaScript host's SubtleCrypto, with the key pairs it holds.
+type jsWebCrypto struct {
+	subtle js.Value
+
+	mu   sync.Mutex
+	keys map[string]jsKey
+}
+
+// A CryptoKeyPair and its cipher.
+type jsKey struct {
+	code uint64
+	pair js.Value
+}
+
+var platform = newJSWebCrypto()
+
+// Find the host's SubtleCrypto, if it has one.
+func newJSWebCrypto() platformWebCrypto {
+	crypto := js.Global().Get("crypto")
+	if crypto.Type() != js.TypeObject {
+		return nil
+	}
+	subtle := crypto.Get("subtle")
+	if subtle.Type() != js.TypeObject {
+		return nil
+	}
+	return &jsWebCrypto{subtle: subtle, keys: make(map[string]jsKey)}
+}
+
+func (w *jsWebCrypto) generate(ctx context.Context, name string, code uint64) error {
+	algorithm := map[string]any{"name": "Ed25519"}
+	if code == multikeypair.P_256 {
+		algorithm = map[string]any{"name": "ECDSA", "namedCurve": "P-256"}
+	}
+	usages := []any{"sign", "verify"}
+	pair, err := await(ctx, w.subtle.Call("generateKey", algorithm, false, usages))
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keys[name] = jsKey{code, pair}
+	return nil
+}
+
+func (w *jsWebCrypto) publicKey(ctx context.Context, name string) (uint64, []byte, error) {
+	key, err := w.key(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	raw, err := await(ctx, w.subtle.Call("exportKey", "raw", key.pair.Get("publicKey")))
+	if err != nil {
+		return 0, nil, err
+	}
+	return key.code, bytesFromJS(raw), nil
+}
+
+func (w *jsWebCrypto) sign(ctx context.Context, name string, message []byte) (uint64, []byte, error) {
+	key, err := w.key(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	algorithm := map[string]any{"name": "Ed25519"}
+	if key.code == multikeypair.P_256 {
+		algorithm = map[string]any{"name": "ECDSA", "hash": "SHA-256"}
+	}
+	data := js.Global().Get("Uint8Array").New(len(message))
+	js.CopyBytesToJS(data, message)
+	signature, err := await(ctx, w.subtle.Call("sign", algorithm, key.pair.Get("privateKey"), data))
+	if err != nil {
+		return 0, nil, err
+	}
+	return key.code, bytesFromJS(signature), nil
+}
+
+func (w *jsWebCrypto) remove(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.keys, name)
+	return nil
+}
+
+// Look up a key pair by name.
+func (w *jsWebCrypto) key(name string) (jsKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key, ok := w.keys[name]
+	if !ok {
+		return jsKey{}, errors.New("webcrypto key not found")
+	}
+	return key, nil
+}
+
+// Wait for a promise to settle, or the context to be done. SubtleCrypto
+// operations can't be cancelled, so one abandoned this way still runs
+// to completion.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	type settled struct {
+		value js.Value
+		err   error
+	}
+	done := make(chan settled, 1)
+	resolve := js.FuncOf(func(this js.Value, args []js.Value) any {
+		done <- settled{value: args[0]}
+		return nil
+	})
+	reject := js.FuncOf(func(this js.Value, args []js.Value) any {
+		done <- settled{err: js.Error{Value: args[0]}}
+		return nil
+	})
+	release := func() {
+		resolve.Release()
+		reject.Release()
+	}
+	promise.Call("then", resolve, reject)
+
+	select {
+	case s := <-done:
+		release()
+		return s.value, s.err
+	case <-ctx.Done():
+		// The callbacks must outlive the promise.
+		go func() {
+			<-done
+			release()
+		}()
+		return js.Value{}, ctx.Err()
+	}
+}
+
+// Copy the contents of an ArrayBuffer.
+func bytesFromJS(buffer js.Value) []byte {
+	view := js.Global().Get("Uint8Array").New(buffer)
+	b := make([]byte, view.Get("length").Int())
+	js.CopyBytesToGo(b, view)
+	return b
+}
@@ -0,0 +1,9 @@
+//go:build !(js && wasm)
+
+// go-multikeypair/webcrypto/webcrypto_other.go
+//
+// There is no WebCrypto outside a JavaScript host.
+
+package webcrypto
+
+var platform platformWebCrypto
@@ -0,0 +1,166 @@
+// go-multikeypair/webcrypto/webcrypto_test.go
+
+package webcrypto
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// A host that keeps keys in memory and signs the way WebCrypto does.
+type fakeWebCrypto struct {
+	keys map[string]any
+}
+
+func (w *fakeWebCrypto) generate(ctx context.Context, name string, code uint64) error {
+	if code == multikeypair.P_256 {
+		sk, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+		w.keys[name] = sk
+		return err
+	}
+	_, sk, err := ed25519.GenerateKey(crypto_rand.Reader)
+	w.keys[name] = sk
+	return err
+}
+
+func (w *fakeWebCrypto) publicKey(ctx context.Context, name string) (uint64, []byte, error) {
+	switch sk := w.keys[name].(type) {
+	case *ecdsa.PrivateKey:
+		public, err := sk.PublicKey.Bytes()
+		return multikeypair.P_256, public, err
+	case ed25519.PrivateKey:
+		return multikeypair.ED_25519, sk.Public().(ed25519.PublicKey), nil
+	}
+	return 0, nil, errors.New("webcrypto key not found")
+}
+
+func (w *fakeWebCrypto) sign(ctx context.Context, name string, message []byte) (uint64, []byte, error) {
+	switch sk := w.keys[name].(type) {
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(message)
+		r, s, err := ecdsa.Sign(crypto_rand.Reader, sk, digest[:])
+		if err != nil {
+			return 0, nil, err
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return multikeypair.P_256, signature, nil
+	case ed25519.PrivateKey:
+		return multikeypair.ED_25519, ed25519.Sign(sk, message), nil
+	}
+	return 0, nil, errors.New("webcrypto key not found")
+}
+
+func (w *fakeWebCrypto) remove(name string) error {
+	delete(w.keys, name)
+	return nil
+}
+
+// Substitute a fake host for the duration of a test.
+func useFake(t *testing.T) *fakeWebCrypto {
+	fake := &fakeWebCrypto{keys: make(map[string]any)}
+	saved := platform
+	platform = fake
+	t.Cleanup(func() { platform = saved })
+	return fake
+}
+
+// Generate, sign with, refresh and delete a key of each cipher.
+func testGenerate(t *testing.T) {
+	ctx := context.Background()
+	for _, code := range []uint64{multikeypair.ED_25519, multikeypair.P_256} {
+		remote, err := Generate(ctx, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if remote.Key.Code != code || remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+			t.Errorf("unexpected cached key %+v", remote.Key)
+		}
+
+		message := []byte("hello")
+		signature, err := remote.SignContext(ctx, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Verify(message, signature); err != nil {
+			t.Errorf("%s: %v", remote.Key.Name, err)
+		}
+
+		s, err := remote.B58String()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := multikeypair.RemoteKeypairFromB58(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.Refresh(ctx); err != nil {
+			t.Error(err)
+		}
+
+		if err := Delete(decoded); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decoded.SignContext(ctx, message); err == nil {
+			t.Error("expected deleted key to fail signing")
+		}
+	}
+}
+
+// WebCrypto keys sign on the host and verify as ordinary keys.
+func TestGenerate(t *testing.T) {
+	useFake(t)
+	testGenerate(t)
+}
+
+// The same holds for the host's own SubtleCrypto, where there is one.
+func TestGenerateHost(t *testing.T) {
+	if platform == nil {
+		t.Skip("no webcrypto on this platform")
+	}
+	testGenerate(t)
+}
+
+// Without WebCrypto every operation reports ErrUnsupported, and other
+// ciphers are refused everywhere.
+func TestUnsupported(t *testing.T) {
+	saved := platform
+	platform = nil
+	defer func() { platform = saved }()
+
+	ctx := context.Background()
+	if _, err := Generate(ctx, multikeypair.ED_25519); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := Generate(ctx, multikeypair.SECP_256K1); err != ErrUnsupportedCipher {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+}
+
+// Only names this package could have created are accepted.
+func TestParseReference(t *testing.T) {
+	if _, err := parseReference(SCHEME + ":0123456789abcdef0123456789abcdef"); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []string{
+		SCHEME + ":0123",
+		SCHEME + ":0123456789ABCDEF0123456789ABCDEF",
+		"secure-enclave:0123456789abcdef0123456789abcdef",
+	} {
+		if _, err := parseReference(bad); err != ErrInvalidReference {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", bad, err)
+		}
+	}
+	if _, err := p1363ToASN1(make([]byte, 63)); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// go-multikeypair/wipe.go
+//
+// Zeroizing private key material once it's no longer needed. See the
+// Keypair documentation for which values share memory.
+
+package multikeypair
+
+// Wipe overwrites the private key bytes with zeros and drops them from
+// the Keypair. Because a decoded Keypair aliases its Multikeypair, this
+// also wipes the private key inside the Multikeypair it came from. A key
+// in locked memory is released; see LockPrivate.
+func (k *Keypair) Wipe() {
+	if k.locked != nil {
+		k.locked.destroy()
+		k.locked = nil
+	} else {
+		clear(k.Private)
+	}
+	k.Private = nil
+	k.PrivateLength = 0
+}
+
+// Wipe overwrites the private key bytes inside the encoding with zeros,
+// in place. The public key and structure are left intact, so the
+// Multikeypair still decodes (to an all-zero private key). A Multikeypair
+// that doesn't decode is zeroed entirely, since we can't tell where its
+// private key is.
+func (m Multikeypair) Wipe() {
+	kp, err := decodeKeypair(m)
+	if err != nil {
+		clear(m)
+		return
+	}
+	clear(kp.Private)
+}
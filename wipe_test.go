@@ -0,0 +1,62 @@
+// go-multikeypair/wipe_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Wiping a Multikeypair zeroes its private key but leaves the public key
+// readable.
+func TestMultikeypairWipe(t *testing.T) {
+	kp := generateEd25519(t)
+	public := append([]byte(nil), kp.Public...)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mk.Wipe()
+
+	wiped, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wiped.Private, make([]byte, len(kp.Private))) {
+		t.Error("expected private key to be zeroed")
+	}
+	if !bytes.Equal(wiped.Public, public) {
+		t.Error("expected public key to survive wipe")
+	}
+}
+
+// Wiping a decoded Keypair also wipes the Multikeypair it aliases.
+func TestKeypairWipe(t *testing.T) {
+	kp := generateEd25519(t)
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := decoded.Private
+
+	decoded.Wipe()
+
+	if decoded.Private != nil || decoded.PrivateLength != 0 {
+		t.Error("expected private key to be dropped")
+	}
+	if !bytes.Equal(private, make([]byte, len(private))) {
+		t.Error("expected private key bytes to be zeroed")
+	}
+	again, err := mk.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Private, make([]byte, len(private))) {
+		t.Error("expected aliased multikeypair to be wiped")
+	}
+}
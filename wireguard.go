@@ -0,0 +1,119 @@
+// go-multikeypair/wireguard.go
+//
+// WireGuard and Noise static keys from X25519 multikeypairs, so the
+// identity key a service already holds can configure its tunnels.
+// WireGuard keys are the 32-byte X25519 keys in standard base64, as
+// `wg genkey` and `wg pubkey` print them and wg-quick configurations
+// hold them:
+//
+//	[Interface]
+//	PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+//
+// Ed25519 keypairs are accepted too, converted to X25519 as for Seal.
+
+package multikeypair
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// WireGuard-specific errors this module exports.
+var (
+	ErrInvalidWireGuardKey = errors.New("input isn't a valid WireGuard key")
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// NoiseKey is a static Diffie-Hellman keypair for the Noise protocol
+// framework's 25519 DH functions. Its fields match those of
+// github.com/flynn/noise's DHKey, so it converts to one directly.
+type NoiseKey struct {
+	Private []byte
+	Public  []byte
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// WireGuardPrivateKey returns the private key in WireGuard's format.
+func (k Keypair) WireGuardPrivateKey() (string, error) {
+	private, err := x25519Private(k)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(private), nil
+}
+
+// WireGuardPublicKey returns the public key in WireGuard's format.
+func (k Keypair) WireGuardPublicKey() (string, error) {
+	public, err := x25519Public(k)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(public), nil
+}
+
+// NoiseStaticKey returns the keypair as a Noise static key.
+func (k Keypair) NoiseStaticKey() (NoiseKey, error) {
+	private, err := x25519Private(k)
+	if err != nil {
+		return NoiseKey{}, err
+	}
+	public, err := x25519Public(k)
+	if err != nil {
+		return NoiseKey{}, err
+	}
+	return NoiseKey{Private: private, Public: public}, nil
+}
+
+// KeypairFromWireGuardPrivateKey builds an X25519 Keypair from a
+// WireGuard private key, deriving its public key.
+func KeypairFromWireGuardPrivateKey(s string) (Keypair, error) {
+	private, err := decodeWireGuardKey(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	sk, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return Keypair{}, ErrInvalidWireGuardKey
+	}
+	public := sk.PublicKey().Bytes()
+	return Keypair{
+		Code:          X_25519,
+		Name:          codeName(X_25519),
+		Private:       private,
+		PrivateLength: len(private),
+		Public:        public,
+		PublicLength:  len(public),
+	}, nil
+}
+
+// KeypairFromWireGuardPublicKey builds a public-only X25519 Keypair from
+// a WireGuard public key.
+func KeypairFromWireGuardPublicKey(s string) (Keypair, error) {
+	public, err := decodeWireGuardKey(s)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return Keypair{
+		Code:         X_25519,
+		Name:         codeName(X_25519),
+		Public:       public,
+		PublicLength: len(public),
+	}, nil
+}
+
+// Decode a base64 WireGuard key.
+func decodeWireGuardKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidWireGuardKey
+	}
+	return key, nil
+}
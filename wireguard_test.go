@@ -0,0 +1,78 @@
+// go-multikeypair/wireguard_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// The key of the WireGuard quick start derives its published public key.
+func TestWireGuardVector(t *testing.T) {
+	kp, err := KeypairFromWireGuardPrivateKey("yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := kp.WireGuardPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public != "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8ykw=" {
+		t.Fatalf("got public key %s", public)
+	}
+}
+
+// X25519 and Ed25519 keypairs round trip through WireGuard keys, and
+// their Noise keys agree.
+func TestWireGuard(t *testing.T) {
+	for _, code := range []uint64{X_25519, ED_25519} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private, err := kp.WireGuardPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := kp.WireGuardPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromPrivate, err := KeypairFromWireGuardPrivateKey(private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromPublic, err := KeypairFromWireGuardPublicKey(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fromPrivate.Public, fromPublic.Public) {
+			t.Errorf("%s: public keys disagree", kp.Name)
+		}
+
+		noise, err := kp.NoiseStaticKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(noise.Private, fromPrivate.Private) || !bytes.Equal(noise.Public, fromPublic.Public) {
+			t.Errorf("%s: Noise key disagrees", kp.Name)
+		}
+	}
+}
+
+// Malformed keys and unsupported ciphers are rejected.
+func TestWireGuardInvalid(t *testing.T) {
+	for _, s := range []string{"", "not base64", "AAAA"} {
+		if _, err := KeypairFromWireGuardPublicKey(s); !errors.Is(err, ErrInvalidWireGuardKey) {
+			t.Errorf("%q: got %v", s, err)
+		}
+	}
+	kp, err := Generate(P_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kp.WireGuardPrivateKey(); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// go-multikeypair/wrap.go
+//
+// Wrapping the private key of a multikeypair under a symmetric key
+// encryption key (KEK), e.g. one held in a KMS, with AES key wrap. The
+// result is still a multikeypair: the cipher code, public key and
+// metadata stay readable, the private key field holds the wrapped key,
+// and a TAG_WRAPPED field records the algorithm:
+//
+//	WRAP_KW   (0x01)  RFC 3394 AES key wrap, for keys that are a multiple
+//	                  of 8 bytes and at least 16 bytes long
+//	WRAP_KWP  (0x02)  RFC 5649 AES key wrap with padding, for other keys
+//
+// Decoding a wrapped multikeypair gives a public-only Keypair, so the
+// wrapped bytes can't be mistaken for a usable private key.
+
+package multikeypair
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// Key wrap errors this module exports.
+var (
+	ErrInvalidKEK   = errors.New("key encryption key must be 16, 24 or 32 bytes")
+	ErrUnwrapFailed = errors.New("key unwrap failed (wrong key encryption key?)")
+	ErrWrapped      = errors.New("multikeypair private key is already wrapped")
+	ErrNotWrapped   = errors.New("multikeypair private key isn't wrapped")
+)
+
+// Key wrap algorithms recorded in a TAG_WRAPPED field.
+const (
+	WRAP_KW  = byte(0x01)
+	WRAP_KWP = byte(0x02)
+)
+
+// Initial values of RFC 3394 and RFC 5649.
+var (
+	kwIV  = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	kwpIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+)
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Wrap returns a copy of the Multikeypair with its private key wrapped
+// under kek, an AES-128, AES-192 or AES-256 key. Metadata is kept, and a
+// checksum is recomputed if there was one.
+func (m Multikeypair) Wrap(kek []byte) (Multikeypair, error) {
+	if m.IsWrapped() {
+		return Multikeypair{}, ErrWrapped
+	}
+	_, private, _, _, err := splitKeypair(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	if len(private) == 0 {
+		return Multikeypair{}, ErrInvalidPrivateKey
+	}
+	block, err := newKEK(kek)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+
+	algorithm := WRAP_KWP
+	var wrapped []byte
+	if len(private)%8 == 0 && len(private) >= 16 {
+		algorithm = WRAP_KW
+		wrapped = kwWrap(block, kwIV, private)
+	} else {
+		wrapped = kwpWrap(block, private)
+	}
+	return rewrap(m, wrapped, &extension{TAG_WRAPPED, []byte{algorithm}})
+}
+
+// Unwrap returns a copy of a wrapped Multikeypair with its private key
+// unwrapped using kek.
+func (m Multikeypair) Unwrap(kek []byte) (Multikeypair, error) {
+	_, wrapped, _, rest, err := splitKeypair(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	algorithm, ok := findWrapped(rest)
+	if !ok {
+		return Multikeypair{}, ErrNotWrapped
+	}
+	block, err := newKEK(kek)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+
+	var private []byte
+	switch algorithm {
+	case WRAP_KW:
+		private, err = kwUnwrap(block, kwIV, wrapped)
+	case WRAP_KWP:
+		private, err = kwpUnwrap(block, wrapped)
+	default:
+		return Multikeypair{}, ErrInvalidMultikeypair
+	}
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	return rewrap(m, private, nil)
+}
+
+// IsWrapped reports whether the Multikeypair's private key is wrapped.
+func (m Multikeypair) IsWrapped() bool {
+	_, _, _, rest, err := splitKeypair(m)
+	if err != nil {
+		return false
+	}
+	_, ok := findWrapped(rest)
+	return ok
+}
+
+// Re-encode m with a new private key, keeping its optional fields except
+// any TAG_WRAPPED, adding wrapped if given, and recomputing the checksum
+// if it had one.
+func rewrap(m Multikeypair, private []byte, wrapped *extension) (Multikeypair, error) {
+	code, _, public, rest, err := splitKeypair(m)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	numCode, err := UnpackCode(code)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+	out, err := Encode(private, public, numCode)
+	if err != nil {
+		return Multikeypair{}, err
+	}
+
+	var kept []extension
+	checksummed := false
+	if fields, ok := splitExtensions(rest); ok {
+		for _, f := range fields {
+			switch f.tag {
+			case TAG_CHECKSUM:
+				checksummed = true
+			case TAG_WRAPPED:
+			default:
+				kept = append(kept, f)
+			}
+		}
+	}
+	if wrapped != nil {
+		kept = append(kept, *wrapped)
+	}
+	if len(kept) > 0 {
+		b, err := appendExtensions(out, kept...)
+		if err != nil {
+			return Multikeypair{}, err
+		}
+		out = Multikeypair(b)
+	}
+	if checksummed {
+		return out.WithChecksum()
+	}
+	return out, nil
+}
+
+// Find the wrapping algorithm among the extension fields.
+func findWrapped(rest []byte) (byte, bool) {
+	fields, ok := splitExtensions(rest)
+	if !ok {
+		return 0, false
+	}
+	return findWrappedField(fields)
+}
+
+// Find the algorithm of a wrapped private key among optional fields.
+func findWrappedField(fields []extension) (byte, bool) {
+	for _, f := range fields {
+		if f.tag == TAG_WRAPPED && len(f.value) == 1 {
+			return f.value[0], true
+		}
+	}
+	return 0, false
+}
+
+// Set up the AES cipher for a KEK.
+func newKEK(kek []byte) (cipher.Block, error) {
+	switch len(kek) {
+	case 16, 24, 32:
+		return aes.NewCipher(kek)
+	}
+	return nil, ErrInvalidKEK
+}
+
+//
+// RFC 3394
+//
+
+// Wrap plaintext, a multiple of 8 bytes, with initial value iv.
+func kwWrap(block cipher.Block, iv []byte, plaintext []byte) []byte {
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	a, r := out[:8], out[8:]
+	copy(a, iv)
+	copy(r, plaintext)
+
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b[:8], a)
+			copy(b[8:], r[i*8:i*8+8])
+			block.Encrypt(b[:], b[:])
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:], b[8:])
+		}
+	}
+	return out
+}
+
+// Unwrap ciphertext, returning the plaintext after checking the initial
+// value against iv. A nil iv skips the check, returning the recovered
+// initial value as the first 8 bytes of the result.
+func kwUnwrap(block cipher.Block, iv []byte, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext)%8 != 0 || len(ciphertext) < 24 {
+		return nil, ErrUnwrapFailed
+	}
+	n := len(ciphertext)/8 - 1
+	out := bytes.Clone(ciphertext)
+	a, r := out[:8], out[8:]
+
+	var b [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:i*8+8])
+			block.Decrypt(b[:], b[:])
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+		}
+	}
+	if iv == nil {
+		return out, nil
+	}
+	if subtle.ConstantTimeCompare(a, iv) != 1 {
+		return nil, ErrUnwrapFailed
+	}
+	return r, nil
+}
+
+//
+// RFC 5649
+//
+
+// Wrap plaintext of any non-zero length.
+func kwpWrap(block cipher.Block, plaintext []byte) []byte {
+	iv := binary.BigEndian.AppendUint32(bytes.Clone(kwpIV), uint32(len(plaintext)))
+	padded := make([]byte, (len(plaintext)+7)/8*8)
+	copy(padded, plaintext)
+	if len(padded) == 8 {
+		out := append(iv, padded...)
+		block.Encrypt(out, out)
+		return out
+	}
+	return kwWrap(block, iv, padded)
+}
+
+// Unwrap ciphertext, checking the initial value and padding.
+func kwpUnwrap(block cipher.Block, ciphertext []byte) ([]byte, error) {
+	var out []byte
+	switch {
+	case len(ciphertext) == 16:
+		out = make([]byte, 16)
+		block.Decrypt(out, ciphertext)
+	case len(ciphertext) > 16:
+		var err error
+		if out, err = kwUnwrap(block, nil, ciphertext); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnwrapFailed
+	}
+
+	iv, padded := out[:8], out[8:]
+	length := int(binary.BigEndian.Uint32(iv[4:]))
+	if subtle.ConstantTimeCompare(iv[:4], kwpIV) != 1 ||
+		length > len(padded) || length <= len(padded)-8 {
+		return nil, ErrUnwrapFailed
+	}
+	for _, b := range padded[length:] {
+		if b != 0 {
+			return nil, ErrUnwrapFailed
+		}
+	}
+	return padded[:length], nil
+}
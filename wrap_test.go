@@ -0,0 +1,120 @@
+// go-multikeypair/wrap_test.go
+
+package multikeypair
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Test vectors from RFC 3394 section 4 and RFC 5649 section 6.
+func TestKeyWrapVectors(t *testing.T) {
+	for _, v := range []struct {
+		kek, plaintext, ciphertext string
+		padded                     bool
+	}{
+		{"000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5", false},
+		{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f", "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21", false},
+		{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a", true},
+		{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f", true},
+	} {
+		kek, _ := hex.DecodeString(v.kek)
+		plaintext, _ := hex.DecodeString(v.plaintext)
+		block, err := newKEK(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wrapped, unwrapped []byte
+		if v.padded {
+			wrapped = kwpWrap(block, plaintext)
+			unwrapped, err = kwpUnwrap(block, wrapped)
+		} else {
+			wrapped = kwWrap(block, kwIV, plaintext)
+			unwrapped, err = kwUnwrap(block, kwIV, wrapped)
+		}
+		if got := hex.EncodeToString(wrapped); got != v.ciphertext {
+			t.Errorf("expected %s, got %s", v.ciphertext, got)
+		}
+		if err != nil || !bytes.Equal(unwrapped, plaintext) {
+			t.Errorf("%s didn't unwrap: %v", v.ciphertext, err)
+		}
+	}
+}
+
+// Wrapped multikeypairs keep their public key and metadata readable,
+// hide the private key, and unwrap to the original.
+func TestWrapUnwrap(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x42}, 32)
+	for _, code := range []uint64{ED_25519, P_256, ML_DSA_65} {
+		kp, err := Generate(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp.Metadata.Label = "wrapped"
+		mk, err := kp.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mk, err = mk.WithChecksum()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wrapped, err := mk.Wrap(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !wrapped.IsWrapped() || !wrapped.HasChecksum() {
+			t.Errorf("%s: expected wrapped multikeypair with checksum", Codes[code])
+		}
+		decoded, err := wrapped.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.Private) != 0 || !bytes.Equal(decoded.Public, kp.Public) || decoded.Metadata.Label != "wrapped" {
+			t.Errorf("%s: unexpected wrapped keypair %+v", Codes[code], decoded)
+		}
+		if _, err := wrapped.Wrap(kek); !errors.Is(err, ErrWrapped) {
+			t.Errorf("%s: expected ErrWrapped, got %v", Codes[code], err)
+		}
+
+		if _, err := wrapped.Unwrap(bytes.Repeat([]byte{0x43}, 32)); !errors.Is(err, ErrUnwrapFailed) {
+			t.Errorf("%s: expected ErrUnwrapFailed, got %v", Codes[code], err)
+		}
+		unwrapped, err := wrapped.Unwrap(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unwrapped, mk) {
+			t.Errorf("%s: multikeypair didn't round trip", Codes[code])
+		}
+	}
+}
+
+// Bad KEKs, unwrapped multikeypairs and public-only keys are refused.
+func TestWrapRefused(t *testing.T) {
+	kp, err := Generate(ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk, err := kp.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mk.Wrap(make([]byte, 20)); !errors.Is(err, ErrInvalidKEK) {
+		t.Errorf("expected ErrInvalidKEK, got %v", err)
+	}
+	if _, err := mk.Unwrap(make([]byte, 16)); !errors.Is(err, ErrNotWrapped) {
+		t.Errorf("expected ErrNotWrapped, got %v", err)
+	}
+	public, err := Encode(nil, kp.Public, ED_25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := public.Wrap(make([]byte, 16)); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+}
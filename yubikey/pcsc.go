@@ -0,0 +1,171 @@
+//go:build pcsc
+
+// go-multikeypair/yubikey/pcsc.go
+//
+// A Card for YubiKeys reached through PC/SC. Building it needs the
+// platform's smart card library (libpcsclite on Linux), so it is only
+// included with the pcsc build tag.
+
+package yubikey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+
+	"github.com/go-piv/piv-go/piv"
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Options configure access to a YubiKey.
+type Options struct {
+	// ManagementKey authorizes key generation. The zero value means the
+	// factory default key.
+	ManagementKey *[24]byte
+	// PIN is called when the device needs the PIN.
+	PIN func() (string, error)
+	// Touch is called before an operation that needs a touch, e.g. to
+	// tell the user to tap their key.
+	Touch func()
+}
+
+// A YubiKey opened through PC/SC.
+type yubiKey struct {
+	yk   *piv.YubiKey
+	opts Options
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Cards lists the names of the smart card readers present.
+func Cards() ([]string, error) {
+	return piv.Cards()
+}
+
+// Open connects to the YubiKey in the named reader. Call Close on the
+// result when done with it.
+func Open(name string, opts Options) (Card, error) {
+	yk, err := piv.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &yubiKey{yk: yk, opts: opts}, nil
+}
+
+// Close releases the connection to the device.
+func (y *yubiKey) Close() error {
+	return y.yk.Close()
+}
+
+func (y *yubiKey) Serial() (uint32, error) {
+	return y.yk.Serial()
+}
+
+func (y *yubiKey) GenerateKey(slot Slot, policy Policy) (ed25519.PublicKey, error) {
+	s, err := pivSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	key := piv.DefaultManagementKey
+	if y.opts.ManagementKey != nil {
+		key = *y.opts.ManagementKey
+	}
+	public, err := y.yk.GenerateKey(key, s, piv.Key{
+		Algorithm:   piv.AlgorithmEd25519,
+		PINPolicy:   pivPINPolicy(policy.PIN),
+		TouchPolicy: pivTouchPolicy(policy.Touch),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ed, ok := public.(ed25519.PublicKey)
+	if !ok {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	return ed, nil
+}
+
+func (y *yubiKey) PublicKey(slot Slot) (ed25519.PublicKey, error) {
+	s, err := pivSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := y.yk.Attest(s)
+	if err != nil {
+		return nil, err
+	}
+	ed, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, multikeypair.ErrInvalidPublicKey
+	}
+	return ed, nil
+}
+
+func (y *yubiKey) Sign(slot Slot, policy Policy, message []byte) ([]byte, error) {
+	s, err := pivSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	public, err := y.PublicKey(slot)
+	if err != nil {
+		return nil, err
+	}
+	private, err := y.yk.PrivateKey(s, public, piv.KeyAuth{
+		PINPrompt: y.opts.PIN,
+		PINPolicy: pivPINPolicy(policy.PIN),
+	})
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, multikeypair.ErrUnsupportedCipher
+	}
+	if policy.Touch != TOUCH_POLICY_NEVER && y.opts.Touch != nil {
+		y.opts.Touch()
+	}
+	return signer.Sign(crypto_rand.Reader, message, crypto.Hash(0))
+}
+
+// Convert our types to piv-go's.
+
+func pivSlot(slot Slot) (piv.Slot, error) {
+	switch slot {
+	case SLOT_AUTHENTICATION:
+		return piv.SlotAuthentication, nil
+	case SLOT_SIGNATURE:
+		return piv.SlotSignature, nil
+	case SLOT_KEY_MANAGEMENT:
+		return piv.SlotKeyManagement, nil
+	case SLOT_CARD_AUTHENTICATION:
+		return piv.SlotCardAuthentication, nil
+	default:
+		return piv.Slot{}, ErrInvalidSlot
+	}
+}
+
+func pivPINPolicy(p PINPolicy) piv.PINPolicy {
+	switch p {
+	case PIN_POLICY_NEVER:
+		return piv.PINPolicyNever
+	case PIN_POLICY_ONCE:
+		return piv.PINPolicyOnce
+	default:
+		return piv.PINPolicyAlways
+	}
+}
+
+func pivTouchPolicy(t TouchPolicy) piv.TouchPolicy {
+	switch t {
+	case TOUCH_POLICY_NEVER:
+		return piv.TouchPolicyNever
+	case TOUCH_POLICY_CACHED:
+		return piv.TouchPolicyCached
+	default:
+		return piv.TouchPolicyAlways
+	}
+}
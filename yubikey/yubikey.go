@@ -0,0 +1,310 @@
+// go-multikeypair/yubikey/yubikey.go
+//
+// Keys generated in and used from YubiKey PIV slots. The private key
+// never leaves the device: GenerateOnPIV returns a RemoteKeypair whose
+// reference names the card and slot, e.g.
+//
+//	piv:serial=12345678;slot=9c;pin=always;touch=cached
+//
+// and signatures are made on the device, subject to the PIN and touch
+// policies the key was generated with. The package registers its backend
+// under SCHEME; cards must be attached before keys on them can sign.
+
+package yubikey
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// Errors
+// -----------------------------------------------------------------------------
+
+// PIV-specific errors this package exports.
+var (
+	ErrInvalidReference = errors.New("invalid piv key reference")
+	ErrInvalidSlot      = errors.New("invalid piv slot")
+	ErrInvalidPolicy    = errors.New("invalid piv pin or touch policy")
+	ErrCardNotAttached  = errors.New("no attached yubikey with that serial")
+)
+
+// SCHEME is the reference scheme for keys in PIV slots.
+const SCHEME = "piv"
+
+// Types
+// -----------------------------------------------------------------------------
+
+// Slot is a PIV key slot.
+type Slot byte
+
+// The PIV slots keys can be generated in.
+const (
+	SLOT_AUTHENTICATION      = Slot(0x9a)
+	SLOT_SIGNATURE           = Slot(0x9c)
+	SLOT_KEY_MANAGEMENT      = Slot(0x9d)
+	SLOT_CARD_AUTHENTICATION = Slot(0x9e)
+)
+
+// PINPolicy controls when the device asks for the PIN before using a key.
+type PINPolicy byte
+
+// PIN policies.
+const (
+	PIN_POLICY_NEVER  = PINPolicy(1)
+	PIN_POLICY_ONCE   = PINPolicy(2)
+	PIN_POLICY_ALWAYS = PINPolicy(3)
+)
+
+// TouchPolicy controls when the device requires a touch before using a
+// key.
+type TouchPolicy byte
+
+// Touch policies. TOUCH_POLICY_CACHED requires a touch at most every 15
+// seconds.
+const (
+	TOUCH_POLICY_NEVER  = TouchPolicy(1)
+	TOUCH_POLICY_ALWAYS = TouchPolicy(2)
+	TOUCH_POLICY_CACHED = TouchPolicy(3)
+)
+
+// Policy is the PIN and touch policy of a key, fixed when it is generated.
+type Policy struct {
+	PIN   PINPolicy
+	Touch TouchPolicy
+}
+
+// DEFAULT_POLICY asks for the PIN once per session and a touch for every
+// signature.
+var DEFAULT_POLICY = Policy{PIN: PIN_POLICY_ONCE, Touch: TOUCH_POLICY_ALWAYS}
+
+// Card is a PIV device. Open returns one for a YubiKey when built with
+// the pcsc tag; tests and other devices can supply their own.
+type Card interface {
+	// Serial returns the device serial number.
+	Serial() (uint32, error)
+	// GenerateKey generates an Ed25519 key in a slot, replacing any key
+	// already there.
+	GenerateKey(slot Slot, policy Policy) (ed25519.PublicKey, error)
+	// PublicKey returns the public key in a slot.
+	PublicKey(slot Slot) (ed25519.PublicKey, error)
+	// Sign signs message with the key in a slot, prompting for the PIN
+	// or a touch as the policy requires.
+	Sign(slot Slot, policy Policy, message []byte) ([]byte, error)
+}
+
+// Key names a key on a card.
+type Key struct {
+	Serial uint32
+	Slot   Slot
+	Policy Policy
+}
+
+// The backend registered under SCHEME, which signs with attached cards.
+type backend struct {
+	mu    sync.RWMutex
+	cards map[uint32]Card
+}
+
+var cards = &backend{cards: make(map[uint32]Card)}
+
+func init() {
+	if err := multikeypair.RegisterRemoteBackend(SCHEME, cards); err != nil {
+		panic(err)
+	}
+}
+
+// Implementation
+// -----------------------------------------------------------------------------
+
+// Attach makes a card available for signing with the keys on it, e.g.
+// ones decoded from a RemoteKeypair encoding.
+func Attach(card Card) error {
+	serial, err := card.Serial()
+	if err != nil {
+		return err
+	}
+	cards.mu.Lock()
+	defer cards.mu.Unlock()
+	cards.cards[serial] = card
+	return nil
+}
+
+// Detach forgets an attached card.
+func Detach(serial uint32) {
+	cards.mu.Lock()
+	defer cards.mu.Unlock()
+	delete(cards.cards, serial)
+}
+
+// GenerateOnPIV generates an Ed25519 key in a slot of card, attaches the
+// card, and returns a RemoteKeypair for the key. The key is restricted
+// to multikeypair.USAGE_SIGN.
+func GenerateOnPIV(ctx context.Context, card Card, slot Slot, policy Policy) (multikeypair.RemoteKeypair, error) {
+	if !slot.valid() {
+		return multikeypair.RemoteKeypair{}, ErrInvalidSlot
+	}
+	if !policy.valid() {
+		return multikeypair.RemoteKeypair{}, ErrInvalidPolicy
+	}
+	serial, err := card.Serial()
+	if err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	if _, err := card.GenerateKey(slot, policy); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	if err := Attach(card); err != nil {
+		return multikeypair.RemoteKeypair{}, err
+	}
+	return multikeypair.NewRemoteKeypair(ctx, Reference(Key{Serial: serial, Slot: slot, Policy: policy}))
+}
+
+// PublicKey reads the public key in a slot of an attached card.
+func (b *backend) PublicKey(ctx context.Context, resource string) (multikeypair.Keypair, error) {
+	card, key, err := b.lookup(resource)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	public, err := card.PublicKey(key.Slot)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	m, err := multikeypair.Encode(nil, public, multikeypair.ED_25519)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp, err := multikeypair.Decode(m)
+	if err != nil {
+		return multikeypair.Keypair{}, err
+	}
+	kp.Metadata.Usage = multikeypair.USAGE_SIGN
+	return kp, nil
+}
+
+// Sign signs on an attached card.
+func (b *backend) Sign(ctx context.Context, resource string, message []byte) ([]byte, error) {
+	card, key, err := b.lookup(resource)
+	if err != nil {
+		return nil, err
+	}
+	return card.Sign(key.Slot, key.Policy, message)
+}
+
+// Find the attached card holding a key.
+func (b *backend) lookup(resource string) (Card, Key, error) {
+	key, err := parseResource(resource)
+	if err != nil {
+		return nil, Key{}, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	card, ok := b.cards[key.Serial]
+	if !ok {
+		return nil, Key{}, ErrCardNotAttached
+	}
+	return card, key, nil
+}
+
+func (s Slot) valid() bool {
+	switch s {
+	case SLOT_AUTHENTICATION, SLOT_SIGNATURE, SLOT_KEY_MANAGEMENT, SLOT_CARD_AUTHENTICATION:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p Policy) valid() bool {
+	return p.PIN >= PIN_POLICY_NEVER && p.PIN <= PIN_POLICY_ALWAYS &&
+		p.Touch >= TOUCH_POLICY_NEVER && p.Touch <= TOUCH_POLICY_CACHED
+}
+
+//
+// REFERENCE
+//
+
+var pinPolicyNames = map[PINPolicy]string{
+	PIN_POLICY_NEVER:  "never",
+	PIN_POLICY_ONCE:   "once",
+	PIN_POLICY_ALWAYS: "always",
+}
+
+var touchPolicyNames = map[TouchPolicy]string{
+	TOUCH_POLICY_NEVER:  "never",
+	TOUCH_POLICY_ALWAYS: "always",
+	TOUCH_POLICY_CACHED: "cached",
+}
+
+// Reference returns the RemoteKeypair reference for a key.
+func Reference(k Key) string {
+	return fmt.Sprintf("%s:serial=%d;slot=%02x;pin=%s;touch=%s",
+		SCHEME, k.Serial, byte(k.Slot), pinPolicyNames[k.Policy.PIN], touchPolicyNames[k.Policy.Touch])
+}
+
+// ParseReference parses a reference written by Reference.
+func ParseReference(reference string) (Key, error) {
+	resource, ok := strings.CutPrefix(reference, SCHEME+":")
+	if !ok {
+		return Key{}, ErrInvalidReference
+	}
+	return parseResource(resource)
+}
+
+// Parse the part of a reference after the scheme.
+func parseResource(resource string) (Key, error) {
+	var k Key
+	seen := make(map[string]bool)
+	for _, attr := range strings.Split(resource, ";") {
+		name, value, ok := strings.Cut(attr, "=")
+		if !ok || seen[name] {
+			return Key{}, ErrInvalidReference
+		}
+		seen[name] = true
+		switch name {
+		case "serial":
+			serial, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return Key{}, ErrInvalidReference
+			}
+			k.Serial = uint32(serial)
+		case "slot":
+			slot, err := strconv.ParseUint(value, 16, 8)
+			if err != nil || !Slot(slot).valid() {
+				return Key{}, ErrInvalidReference
+			}
+			k.Slot = Slot(slot)
+		case "pin":
+			if k.Policy.PIN, ok = lookupName(pinPolicyNames, value); !ok {
+				return Key{}, ErrInvalidReference
+			}
+		case "touch":
+			if k.Policy.Touch, ok = lookupName(touchPolicyNames, value); !ok {
+				return Key{}, ErrInvalidReference
+			}
+		default:
+			return Key{}, ErrInvalidReference
+		}
+	}
+	if len(seen) != 4 {
+		return Key{}, ErrInvalidReference
+	}
+	return k, nil
+}
+
+// Find the key for a name in a policy name table.
+func lookupName[P comparable](names map[P]string, name string) (P, bool) {
+	for p, n := range names {
+		if n == name {
+			return p, true
+		}
+	}
+	var zero P
+	return zero, false
+}
@@ -0,0 +1,153 @@
+// go-multikeypair/yubikey/yubikey_test.go
+
+package yubikey
+
+import (
+	"context"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"errors"
+	"testing"
+
+	multikeypair "github.com/proofzero/go-multikeypair"
+)
+
+// A Card that keeps its slots in memory and counts PIN prompts.
+type fakeCard struct {
+	serial  uint32
+	slots   map[Slot]ed25519.PrivateKey
+	prompts int
+}
+
+func (c *fakeCard) Serial() (uint32, error) { return c.serial, nil }
+
+func (c *fakeCard) GenerateKey(slot Slot, policy Policy) (ed25519.PublicKey, error) {
+	public, private, err := ed25519.GenerateKey(crypto_rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	c.slots[slot] = private
+	return public, nil
+}
+
+func (c *fakeCard) PublicKey(slot Slot) (ed25519.PublicKey, error) {
+	private, ok := c.slots[slot]
+	if !ok {
+		return nil, errors.New("empty slot")
+	}
+	return private.Public().(ed25519.PublicKey), nil
+}
+
+func (c *fakeCard) Sign(slot Slot, policy Policy, message []byte) ([]byte, error) {
+	private, ok := c.slots[slot]
+	if !ok {
+		return nil, errors.New("empty slot")
+	}
+	if policy.PIN == PIN_POLICY_ALWAYS {
+		c.prompts++
+	}
+	return ed25519.Sign(private, message), nil
+}
+
+func newFake(serial uint32) *fakeCard {
+	return &fakeCard{serial: serial, slots: make(map[Slot]ed25519.PrivateKey)}
+}
+
+// Keys generated on a card sign on the card.
+func TestGenerateOnPIV(t *testing.T) {
+	card := newFake(1001)
+	policy := Policy{PIN: PIN_POLICY_ALWAYS, Touch: TOUCH_POLICY_CACHED}
+	ctx := context.Background()
+
+	remote, err := GenerateOnPIV(ctx, card, SLOT_SIGNATURE, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Reference != "piv:serial=1001;slot=9c;pin=always;touch=cached" {
+		t.Errorf("unexpected reference %q", remote.Reference)
+	}
+	if remote.Key.Metadata.Usage != multikeypair.USAGE_SIGN {
+		t.Error("expected key restricted to signing")
+	}
+
+	message := []byte("hello")
+	signature, err := remote.SignContext(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Verify(message, signature); err != nil {
+		t.Error(err)
+	}
+	if card.prompts != 1 {
+		t.Errorf("expected PIN policy passed to card, got %d prompts", card.prompts)
+	}
+}
+
+// Decoded keys sign only once their card is attached.
+func TestAttach(t *testing.T) {
+	card := newFake(1002)
+	ctx := context.Background()
+	remote, err := GenerateOnPIV(ctx, card, SLOT_AUTHENTICATION, DEFAULT_POLICY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := remote.B58String()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Detach(1002)
+	decoded, err := multikeypair.RemoteKeypairFromB58(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.SignContext(ctx, []byte("x")); err != ErrCardNotAttached {
+		t.Errorf("expected ErrCardNotAttached, got %v", err)
+	}
+
+	if err := Attach(card); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.SignContext(ctx, []byte("x")); err != nil {
+		t.Error(err)
+	}
+}
+
+// Invalid slots and policies are refused before touching the card.
+func TestGenerateOnPIVInvalid(t *testing.T) {
+	card := newFake(1003)
+	ctx := context.Background()
+	if _, err := GenerateOnPIV(ctx, card, Slot(0x80), DEFAULT_POLICY); err != ErrInvalidSlot {
+		t.Errorf("expected ErrInvalidSlot, got %v", err)
+	}
+	if _, err := GenerateOnPIV(ctx, card, SLOT_SIGNATURE, Policy{}); err != ErrInvalidPolicy {
+		t.Errorf("expected ErrInvalidPolicy, got %v", err)
+	}
+	if len(card.slots) != 0 {
+		t.Error("card was modified")
+	}
+}
+
+// References round trip, and malformed ones are refused.
+func TestReference(t *testing.T) {
+	k := Key{Serial: 42, Slot: SLOT_CARD_AUTHENTICATION, Policy: Policy{PIN: PIN_POLICY_NEVER, Touch: TOUCH_POLICY_NEVER}}
+	parsed, err := ParseReference(Reference(k))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != k {
+		t.Errorf("expected %+v, got %+v", k, parsed)
+	}
+
+	for _, bad := range []string{
+		"piv:serial=42;slot=9c;pin=once",
+		"piv:serial=42;slot=80;pin=once;touch=never",
+		"piv:serial=42;slot=9c;pin=sometimes;touch=never",
+		"piv:serial=42;slot=9c;pin=once;touch=never;slot=9a",
+		"pkcs11:serial=42;slot=9c;pin=once;touch=never",
+	} {
+		if _, err := ParseReference(bad); err != ErrInvalidReference {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", bad, err)
+		}
+	}
+}